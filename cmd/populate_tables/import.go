@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"sql_generator/internal/config"
+	"sql_generator/internal/datasource"
+	"sql_generator/internal/importer"
+	"sql_generator/internal/storage"
+)
+
+// runImport reads tables from the catalog of a live database and creates or
+// updates them in the store:
+//
+//	populate_tables import -driver mysql -dsn 'user:pass@tcp(localhost:3306)/shop' [-schema shop] [-tables users,orders]
+//	populate_tables import -datasource local
+func runImport(ctx context.Context, store storage.Store, cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	driver := flags.String("driver", "", "database driver: mysql, postgres or sqlite")
+	dsn := flags.String("dsn", "", "connection string of the database to import")
+	name := flags.String("datasource", "", "name of a configured SANDBOX_DATASOURCES entry, instead of -driver and -dsn")
+	schema := flags.String("schema", "", "MySQL database or Postgres schema to import (default: the connection's database, or public)")
+	tableList := flags.String("tables", "", "comma-separated tables to import (default: all tables and views)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	source := config.DatasourceConfig{Name: "import", Driver: *driver, DSN: *dsn}
+	if *name != "" {
+		found := false
+		for _, dc := range cfg.Sandbox.Datasources {
+			if dc.Name == *name {
+				source, found = dc, true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: %s", datasource.ErrUnknownDatasource, *name)
+		}
+	} else if *driver == "" || *dsn == "" {
+		return fmt.Errorf("either -datasource or both -driver and -dsn are required")
+	}
+
+	ds, err := datasource.Open(source)
+	if err != nil {
+		return err
+	}
+	defer ds.Close()
+
+	opts := importer.Options{Schema: *schema}
+	for _, table := range strings.Split(*tableList, ",") {
+		if table = strings.TrimSpace(table); table != "" {
+			opts.Tables = append(opts.Tables, table)
+		}
+	}
+
+	fmt.Printf("Reading tables from %s datasource %s...\n", ds.Driver, ds.Name)
+	tables, err := importer.Import(ctx, ds.DB(), ds.Driver, opts)
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		fmt.Printf("- %s (%d columns, %d relationships)\n", table.Name, len(table.Columns), len(table.Relationships))
+	}
+
+	summary, err := importer.Save(ctx, store, tables)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nCreated %d tables, updated %d tables\n", len(summary.Created), len(summary.Updated))
+	for _, failure := range summary.Failed {
+		fmt.Printf("Failed to save table %s: %s\n", failure.Table, failure.Error)
+	}
+	if len(summary.Failed) > 0 {
+		return fmt.Errorf("%d tables could not be saved", len(summary.Failed))
+	}
+	return nil
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
-	"awesomeProject2/internal/config"
-	"awesomeProject2/internal/models"
-	"awesomeProject2/internal/storage"
+	"context"
+	"sql_generator/internal/config"
+	"sql_generator/internal/ddl"
+	"sql_generator/internal/dialect"
+	"sql_generator/internal/models"
+	"sql_generator/internal/storage"
 
 	"encoding/json"
 	"fmt"
@@ -11,7 +14,6 @@ import (
 	"log"
 	"os"
 	"os/signal"
-	"regexp"
 	"strings"
 	"syscall"
 	"time"
@@ -33,19 +35,17 @@ func main() {
 	}
 	defer mysqlStore.DB.Close()
 
-	// Handle graceful shutdown
-	// ctx, cancel := context.WithCancel(context.Background())
-	// defer cancel()
+	// Cancel in-flight database calls on interrupt
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	// Set up signal handling for graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-
-	// go func() {
-	// 	<-sigChan
-	// 	fmt.Println("\nReceived interrupt signal, shutting down...")
-	// 	cancel()
-	// }()
+	// "import" reads the tables from a live database instead of a file
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImport(ctx, mysqlStore, cfg, os.Args[2:]); err != nil {
+			log.Fatalf("Failed to import tables: %v", err)
+		}
+		return
+	}
 
 	// Check if a file path is provided as command line argument
 	var tables []*models.Table
@@ -84,7 +84,10 @@ func main() {
 		}
 		table.UpdatedAt = time.Now()
 
-		sql := generateTableSQL(table)
+		sql, err := ddl.Generate(table, dialect.MySQL)
+		if err != nil {
+			log.Fatalf("Failed to generate SQL for table '%s': %v", table.Name, err)
+		}
 		fmt.Printf("SQL for table '%s':\n%s\n\n", table.Name, sql)
 	}
 
@@ -106,7 +109,7 @@ func main() {
 		}
 		table.UpdatedAt = time.Now()
 
-		err := mysqlStore.CreateTable(table)
+		err := mysqlStore.CreateTable(ctx, table)
 		if err != nil {
 			log.Printf("Failed to create table %s: %v", table.Name, err)
 			continue
@@ -119,7 +122,7 @@ func main() {
 
 	// Verify tables were inserted
 	fmt.Println("\nVerifying tables...")
-	allTables, err := mysqlStore.ListTables(20, 0)
+	allTables, err := mysqlStore.ListTables(ctx, 20, 0)
 	if err != nil {
 		log.Fatalf("Failed to list tables: %v", err)
 	}
@@ -132,61 +135,6 @@ func main() {
 	fmt.Println("\nDatabase population completed successfully!")
 }
 
-// generateTableSQL generates SQL CREATE TABLE statement for a given table model
-func generateTableSQL(table *models.Table) string {
-	var sqlBuilder strings.Builder
-
-	// Start CREATE TABLE statement
-	sqlBuilder.WriteString(fmt.Sprintf("CREATE TABLE IF NOT EXISTS `%s` (\n", table.Name))
-
-	// Add columns
-	primaryKeys := []string{}
-	for i, column := range table.Columns {
-		// Add column definition
-		sqlBuilder.WriteString(fmt.Sprintf("  `%s` %s", column.Name, column.Type))
-
-		// Add NOT NULL constraint if required
-		if column.IsRequired {
-			sqlBuilder.WriteString(" NOT NULL")
-		}
-
-		// Add comment if exists
-		if column.Description != "" {
-			sqlBuilder.WriteString(fmt.Sprintf(" COMMENT '%s'", column.Description))
-		}
-
-		// Check if it's a primary key
-		if column.IsPrimary {
-			primaryKeys = append(primaryKeys, column.Name)
-		}
-
-		// Add comma if not the last column
-		if i < len(table.Columns)-1 {
-			sqlBuilder.WriteString(",\n")
-		}
-	}
-
-	// Add primary key constraint if exists
-	if len(primaryKeys) > 0 {
-		sqlBuilder.WriteString(",\n")
-		if len(primaryKeys) == 1 {
-			sqlBuilder.WriteString(fmt.Sprintf("  PRIMARY KEY (`%s`)", primaryKeys[0]))
-		} else {
-			sqlBuilder.WriteString(fmt.Sprintf("  PRIMARY KEY (%s)", strings.Join(primaryKeys, "`, `")))
-		}
-	}
-
-	// Close CREATE TABLE statement
-	sqlBuilder.WriteString("\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;")
-
-	// Add table comment
-	if table.Description != "" {
-		sqlBuilder.WriteString(fmt.Sprintf("\nALTER TABLE `%s` COMMENT='%s';", table.Name, table.Description))
-	}
-
-	return sqlBuilder.String()
-}
-
 // readTablesFromFile reads table structures from a JSON file
 func readTablesFromFile(filePath string) ([]*models.Table, error) {
 	// Read the file
@@ -217,7 +165,8 @@ func readTablesFromFile(filePath string) ([]*models.Table, error) {
 	return tables, nil
 }
 
-// readTablesFromSQLFile reads table structures from a SQL file
+// readTablesFromSQLFile reads table structures from the CREATE TABLE
+// statements of a MySQL, Postgres or Hive DDL file
 func readTablesFromSQLFile(filePath string) ([]*models.Table, error) {
 	// Read the file
 	data, err := ioutil.ReadFile(filePath)
@@ -225,158 +174,18 @@ func readTablesFromSQLFile(filePath string) ([]*models.Table, error) {
 		return nil, fmt.Errorf("failed to read file %s: %w", filePath, err)
 	}
 
-	// Parse SQL data to extract table structures
-	content := string(data)
-
-	// Find all CREATE TABLE statements
-	createTableRegex := regexp.MustCompile(`(?is)CREATE TABLE(?: IF NOT EXISTS)?\s+[` + "`" + `"]?(\w+)[` + "`" + `"]?\s*\((.*?)(?:\)|,\s*PRIMARY KEY\s*\([^)]*\))`)
-	matches := createTableRegex.FindAllStringSubmatch(content, -1)
+	statements, err := ddl.Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DDL in %s: %w", filePath, err)
+	}
 
-	var tables []*models.Table
+	tables := ddl.Tables(statements)
 	now := time.Now()
-
-	for _, match := range matches {
-		if len(match) < 3 {
-			continue
-		}
-
-		tableName := match[1]
-		columnsPart := match[2]
-
-		table := &models.Table{
-			ID:        uuid.New().String(),
-			Name:      tableName,
-			Columns:   []models.Column{},
-			CreatedAt: now,
-			UpdatedAt: now,
-		}
-
-		// Parse columns
-		columnLines := splitColumns(columnsPart)
-		for _, line := range columnLines {
-			line = strings.TrimSpace(line)
-			if line == "" {
-				continue
-			}
-
-			// Skip constraints
-			if strings.HasPrefix(strings.ToUpper(line), "PRIMARY KEY") ||
-				strings.HasPrefix(strings.ToUpper(line), "FOREIGN KEY") ||
-				strings.HasPrefix(strings.ToUpper(line), "CONSTRAINT") ||
-				strings.HasPrefix(strings.ToUpper(line), "UNIQUE") ||
-				strings.HasPrefix(strings.ToUpper(line), "KEY") {
-				continue
-			}
-
-			// Parse column definition
-			column := parseColumnDefinition(line)
-			if column != nil {
-				table.Columns = append(table.Columns, *column)
-			}
-		}
-
-		// Try to extract table description from comments
-		descriptionRegex := regexp.MustCompile(`(?i)COMMENT\s*=\s*['"](.*?)['"]`)
-		descriptionMatches := descriptionRegex.FindStringSubmatch(content)
-		if len(descriptionMatches) > 1 {
-			table.Description = descriptionMatches[1]
-		}
-
-		tables = append(tables, table)
+	for _, table := range tables {
+		table.ID = uuid.New().String()
+		table.CreatedAt = now
+		table.UpdatedAt = now
 	}
 
 	return tables, nil
 }
-
-// parseColumnDefinition parses a column definition line and returns a Column struct
-func parseColumnDefinition(line string) *models.Column {
-	trimmedLine := strings.TrimSpace(line)
-	if trimmedLine == "" {
-		return nil
-	}
-
-	// Remove trailing comma
-	trimmedLine = strings.TrimRight(trimmedLine, ",")
-
-	// Use regex to parse column definition
-	// Matches: column_name type [constraints]
-	columnRegex := regexp.MustCompile(`(?i)^\s*[` + "`" + `"]?(\w+)[` + "`" + `"]?\s+(\w+(?:\([^)]*\))?)\s*(.*)$`)
-	matches := columnRegex.FindStringSubmatch(trimmedLine)
-	if len(matches) < 3 {
-		return nil
-	}
-
-	name := matches[1]
-	typePart := strings.ToUpper(matches[2])
-	constraints := matches[3]
-
-	// Extract column description from comments
-	var description string
-	commentRegex := regexp.MustCompile(`(?i)COMMENT\s*['"](.*?)['"]`)
-	commentMatches := commentRegex.FindStringSubmatch(trimmedLine)
-	if len(commentMatches) > 1 {
-		description = commentMatches[1]
-	}
-
-	column := &models.Column{
-		Name:        name,
-		Type:        typePart,
-		Description: description,
-		IsRequired:  !strings.Contains(strings.ToUpper(constraints), "NULL") && !strings.Contains(strings.ToUpper(constraints), "DEFAULT"),
-	}
-
-	// Check if it's a primary key
-	if strings.Contains(strings.ToUpper(constraints), "PRIMARY KEY") {
-		column.IsPrimary = true
-	}
-
-	return column
-}
-
-// splitColumns splits the columns part of a CREATE TABLE statement into individual column definitions
-func splitColumns(columnsPart string) []string {
-	var columns []string
-	var currentColumn strings.Builder
-	var inParentheses int
-	var inQuotes bool
-	var quoteChar rune
-
-	for _, r := range columnsPart {
-		switch r {
-		case '"', '`':
-			if !inQuotes {
-				inQuotes = true
-				quoteChar = r
-			} else if quoteChar == r {
-				inQuotes = false
-			}
-			currentColumn.WriteRune(r)
-		case '(':
-			if !inQuotes {
-				inParentheses++
-			}
-			currentColumn.WriteRune(r)
-		case ')':
-			if !inQuotes {
-				inParentheses--
-			}
-			currentColumn.WriteRune(r)
-		case ',':
-			if !inQuotes && inParentheses == 0 {
-				columns = append(columns, currentColumn.String())
-				currentColumn.Reset()
-			} else {
-				currentColumn.WriteRune(r)
-			}
-		default:
-			currentColumn.WriteRune(r)
-		}
-	}
-
-	// Add the last column if it exists
-	if currentColumn.Len() > 0 {
-		columns = append(columns, currentColumn.String())
-	}
-
-	return columns
-}
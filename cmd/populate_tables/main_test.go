@@ -0,0 +1,145 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sql_generator/internal/models"
+)
+
+const relationshipDDL = `
+CREATE TABLE IF NOT EXISTS users (
+    id BIGINT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL
+);
+
+CREATE TABLE orders (
+    id BIGINT PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    amount DECIMAL(10, 2),
+    CONSTRAINT fk_orders_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE ` + "`user_profiles`" + ` (
+    ` + "`user_id`" + ` BIGINT PRIMARY KEY REFERENCES users(id),
+    bio TEXT
+);
+`
+
+func TestReadTablesFromSQLFileRelationships(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tables.sql")
+	if err := os.WriteFile(path, []byte(relationshipDDL), 0o644); err != nil {
+		t.Fatalf("failed to write DDL: %v", err)
+	}
+
+	tables, err := readTablesFromSQLFile(path)
+	if err != nil {
+		t.Fatalf("readTablesFromSQLFile failed: %v", err)
+	}
+	if len(tables) != 3 {
+		t.Fatalf("expected 3 tables, got %d", len(tables))
+	}
+
+	users, orders, profiles := tables[0], tables[1], tables[2]
+	if len(users.Relationships) != 0 {
+		t.Errorf("unexpected users relationships: %+v", users.Relationships)
+	}
+
+	if len(orders.Columns) != 3 || orders.Columns[2].Type != "DECIMAL(10, 2)" {
+		t.Errorf("unexpected orders columns: %+v", orders.Columns)
+	}
+	want := models.Relationship{Columns: []string{"user_id"}, ToTable: "users", ToColumns: []string{"id"}, Cardinality: models.ManyToOne}
+	if len(orders.Relationships) != 1 || !sameRelationship(orders.Relationships[0], want) {
+		t.Errorf("unexpected orders relationships: %+v", orders.Relationships)
+	}
+
+	want.Cardinality = models.OneToOne
+	if len(profiles.Relationships) != 1 || !sameRelationship(profiles.Relationships[0], want) {
+		t.Errorf("unexpected user_profiles relationships: %+v", profiles.Relationships)
+	}
+}
+
+func sameRelationship(a, b models.Relationship) bool {
+	return a.ToTable == b.ToTable && a.Cardinality == b.Cardinality &&
+		len(a.Columns) == 1 && len(a.ToColumns) == 1 &&
+		a.Columns[0] == b.Columns[0] && a.ToColumns[0] == b.ToColumns[0]
+}
+
+const hiveDDL = `
+CREATE EXTERNAL TABLE IF NOT EXISTS dw.user_events (
+    user_id BIGINT COMMENT '用户ID',
+    tags ARRAY<STRING>,
+    attributes MAP<STRING, INT> COMMENT 'key, value; pairs',
+    address STRUCT<street:STRING, city:STRING, geo:STRUCT<lat:DOUBLE, lng:DOUBLE>>
+)
+COMMENT '用户行为 (LOCATION-free comment)'
+PARTITIONED BY (dt STRING COMMENT '日期', hour INT)
+CLUSTERED BY (user_id) SORTED BY (user_id ASC) INTO 32 BUCKETS
+ROW FORMAT DELIMITED FIELDS TERMINATED BY ','
+STORED AS ORC
+LOCATION 'hdfs://warehouse/dw/user_events'
+TBLPROPERTIES ('orc.compress'='SNAPPY');
+
+CREATE TABLE daily_totals (
+    dt STRING,
+    total DECIMAL(18,2)
+)
+STORED AS INPUTFORMAT 'org.apache.hadoop.hive.ql.io.parquet.MapredParquetInputFormat'
+OUTPUTFORMAT 'org.apache.hadoop.hive.ql.io.parquet.MapredParquetOutputFormat';
+
+CREATE TABLE accounts (id BIGINT) ENGINE=InnoDB COMMENT='账户表';
+`
+
+func TestReadTablesFromSQLFileHive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hive.sql")
+	if err := os.WriteFile(path, []byte(hiveDDL), 0o644); err != nil {
+		t.Fatalf("failed to write DDL: %v", err)
+	}
+
+	tables, err := readTablesFromSQLFile(path)
+	if err != nil {
+		t.Fatalf("readTablesFromSQLFile failed: %v", err)
+	}
+	if len(tables) != 3 {
+		t.Fatalf("expected 3 tables, got %d", len(tables))
+	}
+
+	events := tables[0]
+	if events.Name != "user_events" || events.TableType != models.TableTypeExternal || events.Description != "用户行为 (LOCATION-free comment)" {
+		t.Errorf("unexpected table metadata: %+v", events)
+	}
+	wantTypes := []string{"BIGINT", "ARRAY<STRING>", "MAP<STRING, INT>", "STRUCT<street:STRING, city:STRING, geo:STRUCT<lat:DOUBLE, lng:DOUBLE>>"}
+	if len(events.Columns) != len(wantTypes) {
+		t.Fatalf("unexpected columns: %+v", events.Columns)
+	}
+	for i, want := range wantTypes {
+		if events.Columns[i].Type != want {
+			t.Errorf("column %s: expected type %q, got %q", events.Columns[i].Name, want, events.Columns[i].Type)
+		}
+	}
+	if events.Columns[0].Description != "用户ID" || events.Columns[2].Description != "key, value; pairs" {
+		t.Errorf("unexpected column comments: %+v", events.Columns)
+	}
+
+	if len(events.PartitionKeys) != 2 || events.PartitionKeys[0].Name != "dt" || events.PartitionKeys[0].Description != "日期" || events.PartitionKeys[1].Type != "INT" {
+		t.Errorf("unexpected partition keys: %+v", events.PartitionKeys)
+	}
+	if b := events.Bucketing; b == nil || b.Buckets != 32 || len(b.Columns) != 1 || b.Columns[0] != "user_id" || len(b.SortedBy) != 1 || b.SortedBy[0] != "user_id" {
+		t.Errorf("unexpected bucketing: %+v", events.Bucketing)
+	}
+	if events.StorageFormat != "ORC" || events.Location != "hdfs://warehouse/dw/user_events" {
+		t.Errorf("unexpected storage: %q %q", events.StorageFormat, events.Location)
+	}
+
+	totals := tables[1]
+	if totals.TableType != models.TableTypeManaged || totals.StorageFormat != "PARQUET" || totals.Description != "" {
+		t.Errorf("unexpected daily_totals metadata: %+v", totals)
+	}
+
+	// MySQL tables keep their own comment and get no Hive metadata
+	accounts := tables[2]
+	if accounts.Description != "账户表" || accounts.TableType != "" || accounts.StorageFormat != "" {
+		t.Errorf("unexpected accounts metadata: %+v", accounts)
+	}
+}
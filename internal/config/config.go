@@ -4,16 +4,21 @@ import (
 	"github.com/joho/godotenv"
 	"os"
 	"strconv"
+	"strings"
 )
 
 // Config holds the application configuration
 type Config struct {
-	Server    ServerConfig
-	Mongo     MongoConfig
-	MySQL     MySQLConfig  // 添加MySQL配置
-	LLM       LLMConfig
-	Embedding EmbeddingConfig
-	VectorDB  VectorDBConfig
+	Server      ServerConfig
+	Mongo       MongoConfig
+	MySQL       MySQLConfig // 添加MySQL配置
+	LLM         LLMConfig
+	Embedding   EmbeddingConfig
+	VectorDB    VectorDBConfig
+	Sandbox     SandboxConfig
+	Policy      PolicyConfig
+	Sensitivity SensitivityConfig
+	Prompt      PromptConfig
 }
 
 // ServerConfig holds the HTTP server configuration
@@ -41,6 +46,14 @@ type LLMConfig struct {
 	Model     string
 	MaxTokens int
 	Temp      float64
+	// ContextWindow 模型的上下文窗口大小（token数），0表示按模型名称推断；提示词预算为上下文窗口减去MaxTokens
+	ContextWindow int
+	// MaxRepairAttempts 生成的SQL未通过解析或表结构校验时，把问题反馈给模型重新生成的最大轮数，0表示不修正
+	MaxRepairAttempts int
+	// FewShotExamples 提示词中加入的与问题最相似的已确认正确的历史查询数量，0表示不加入示例
+	FewShotExamples int
+	// Dialect 请求未指定方言时默认生成的SQL方言：hive、mysql、postgres、spark、presto/trino、clickhouse
+	Dialect string
 }
 
 // EmbeddingConfig holds the embedding service configuration
@@ -51,7 +64,7 @@ type EmbeddingConfig struct {
 	Provider string
 	// Hugging Face特定配置
 	HFEndpoint string
-	HFModel   string
+	HFModel    string
 	// Qwen特定配置
 	QwenModel string
 }
@@ -61,6 +74,61 @@ type VectorDBConfig struct {
 	APIKey      string
 	IndexName   string
 	Environment string
+	// Provider指定向量存储实现：memory（本地内存+快照）或pinecone
+	Provider string
+	// Metric指定相似度度量方式：cosine或dot
+	Metric string
+	// SnapshotPath为内存/HNSW向量存储的本地快照文件路径，为空则不持久化
+	SnapshotPath string
+	// HNSW索引参数
+	HNSWM              int
+	HNSWEfConstruction int
+	HNSWEfSearch       int
+}
+
+// PolicyConfig holds the statement policy generated SQL must satisfy before it is saved or returned
+type PolicyConfig struct {
+	// AllowedStatements 允许生成的语句类别：query、dml、ddl、dcl、other
+	AllowedStatements []string
+	// AllowDDLOptIn 是否允许请求通过allow_ddl额外生成DDL语句
+	AllowDDLOptIn bool
+	// MaxStatements 单次生成允许的最大语句数，0表示不限制
+	MaxStatements int
+}
+
+// SensitivityConfig holds how columns tagged as sensitive are handled for each caller role
+type SensitivityConfig struct {
+	// DefaultRole 请求的角色，信任X-User-Role请求头时为请求未指定角色时使用的角色
+	DefaultRole string
+	// TrustRoleHeader 是否使用X-User-Role请求头中的角色，仅应在可信的认证层设置该请求头时开启
+	TrustRoleHeader bool
+	// Rules 各角色对每种敏感标签的处理方式：allow、mask、exclude、refuse
+	Rules map[string]map[string]string
+}
+
+// PromptConfig holds where prompt templates are loaded from besides the store
+type PromptConfig struct {
+	// TemplateDir 提示词模板文件目录，目录中的<name>.tmpl文件作为名为name的模板加载，为空则不加载
+	TemplateDir string
+}
+
+// SandboxConfig holds the datasources that generated queries can be executed against
+type SandboxConfig struct {
+	Datasources []DatasourceConfig
+	// Default 执行请求未指定数据源时使用的数据源名称，为空则使用第一个数据源
+	Default string
+	// MaxRows 单次执行返回的最大行数
+	MaxRows int
+	// Timeout 单次执行的语句超时时间（秒）
+	Timeout int
+}
+
+// DatasourceConfig holds the connection settings of one datasource
+type DatasourceConfig struct {
+	Name string
+	// Driver 数据库驱动：mysql、postgres或sqlite
+	Driver string
+	DSN    string
 }
 
 // Load loads configuration from environment variables
@@ -83,23 +151,52 @@ func Load() (*Config, error) {
 			Database: getEnv("MYSQL_DATABASE", "sqlbot"),
 		},
 		LLM: LLMConfig{
-			APIKey:    getEnv("LLM_API_KEY", ""),
-			Model:     getEnv("LLM_MODEL", "gpt-3.5-turbo"),
-			MaxTokens: getEnvAsInt("LLM_MAX_TOKENS", 2000),
-			Temp:      getEnvAsFloat("LLM_TEMPERATURE", 0.3),
+			APIKey:            getEnv("LLM_API_KEY", ""),
+			Model:             getEnv("LLM_MODEL", "gpt-3.5-turbo"),
+			MaxTokens:         getEnvAsInt("LLM_MAX_TOKENS", 2000),
+			Temp:              getEnvAsFloat("LLM_TEMPERATURE", 0.3),
+			ContextWindow:     getEnvAsInt("LLM_CONTEXT_WINDOW", 0),
+			MaxRepairAttempts: getEnvAsInt("LLM_MAX_REPAIR_ATTEMPTS", 2),
+			FewShotExamples:   getEnvAsInt("LLM_FEW_SHOT_EXAMPLES", 3),
+			Dialect:           getEnv("LLM_SQL_DIALECT", "hive"),
 		},
 		Embedding: EmbeddingConfig{
-			APIKey:    getEnv("EMBEDDING_API_KEY", ""),
-			Model:     getEnv("EMBEDDING_MODEL", "text-embedding-v1"),
-			Provider:  getEnv("EMBEDDING_PROVIDER", "qwen"), // 默认使用阿里云千问
+			APIKey:     getEnv("EMBEDDING_API_KEY", ""),
+			Model:      getEnv("EMBEDDING_MODEL", "text-embedding-v1"),
+			Provider:   getEnv("EMBEDDING_PROVIDER", "qwen"), // 默认使用阿里云千问
 			HFEndpoint: getEnv("HF_ENDPOINT", "https://api-inference.huggingface.co/models/"),
-			HFModel:   getEnv("HF_MODEL", "sentence-transformers/all-MiniLM-L6-v2"),
-			QwenModel: getEnv("QWEN_MODEL", "text-embedding-v1"),
+			HFModel:    getEnv("HF_MODEL", "sentence-transformers/all-MiniLM-L6-v2"),
+			QwenModel:  getEnv("QWEN_MODEL", "text-embedding-v1"),
 		},
 		VectorDB: VectorDBConfig{
-			APIKey:      getEnv("VECTOR_DB_API_KEY", ""),
-			IndexName:   getEnv("VECTOR_DB_INDEX_NAME", "sqlbot-tables"),
-			Environment: getEnv("VECTOR_DB_ENVIRONMENT", "us-west1-gcp"),
+			APIKey:             getEnv("VECTOR_DB_API_KEY", ""),
+			IndexName:          getEnv("VECTOR_DB_INDEX_NAME", "sqlbot-tables"),
+			Environment:        getEnv("VECTOR_DB_ENVIRONMENT", "us-west1-gcp"),
+			Provider:           getEnv("VECTOR_DB_PROVIDER", "memory"),
+			Metric:             getEnv("VECTOR_DB_METRIC", "cosine"),
+			SnapshotPath:       getEnv("VECTOR_DB_SNAPSHOT_PATH", defaultSnapshotPath(getEnv("VECTOR_DB_PROVIDER", "memory"))),
+			HNSWM:              getEnvAsInt("VECTOR_DB_HNSW_M", 16),
+			HNSWEfConstruction: getEnvAsInt("VECTOR_DB_HNSW_EF_CONSTRUCTION", 200),
+			HNSWEfSearch:       getEnvAsInt("VECTOR_DB_HNSW_EF_SEARCH", 64),
+		},
+		Policy: PolicyConfig{
+			AllowedStatements: getEnvAsList("SQL_POLICY_ALLOWED_STATEMENTS", "query"),
+			AllowDDLOptIn:     getEnvAsBool("SQL_POLICY_ALLOW_DDL_OPT_IN", true),
+			MaxStatements:     getEnvAsInt("SQL_POLICY_MAX_STATEMENTS", 1),
+		},
+		Sensitivity: SensitivityConfig{
+			DefaultRole:     getEnv("SENSITIVITY_DEFAULT_ROLE", "analyst"),
+			TrustRoleHeader: getEnvAsBool("SENSITIVITY_TRUST_ROLE_HEADER", false),
+			Rules:           getSensitivityRules("SENSITIVITY_RULES", "analyst:pii=mask,financial=refuse,secret=exclude"),
+		},
+		Prompt: PromptConfig{
+			TemplateDir: getEnv("PROMPT_TEMPLATE_DIR", ""),
+		},
+		Sandbox: SandboxConfig{
+			Datasources: getDatasources("SANDBOX_DATASOURCES"),
+			Default:     getEnv("SANDBOX_DEFAULT_DATASOURCE", ""),
+			MaxRows:     getEnvAsInt("SANDBOX_MAX_ROWS", 1000),
+			Timeout:     getEnvAsInt("SANDBOX_TIMEOUT", 30),
 		},
 	}
 
@@ -130,4 +227,70 @@ func getEnvAsFloat(key string, defaultValue float64) float64 {
 		}
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+// defaultSnapshotPath keeps the JSON snapshot of the memory store and the gob
+// snapshot of the hnsw store in separate files, so that switching providers
+// does not load one as the other
+func defaultSnapshotPath(provider string) string {
+	if provider == "hnsw" {
+		return "data/vectors.hnsw"
+	}
+	return "data/vectors.json"
+}
+
+// getEnvAsList splits a comma-separated value, dropping empty items
+func getEnvAsList(key, defaultValue string) []string {
+	var items []string
+	for _, item := range strings.Split(getEnv(key, defaultValue), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getDatasources reads a comma-separated list of datasource names from key
+// and the SANDBOX_<NAME>_DRIVER and SANDBOX_<NAME>_DSN settings of each
+func getDatasources(key string) []DatasourceConfig {
+	var datasources []DatasourceConfig
+	for _, name := range getEnvAsList(key, "") {
+		prefix := "SANDBOX_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		datasources = append(datasources, DatasourceConfig{
+			Name:   name,
+			Driver: getEnv(prefix+"DRIVER", ""),
+			DSN:    getEnv(prefix+"DSN", ""),
+		})
+	}
+	return datasources
+}
+
+// getSensitivityRules parses role rules written as
+// "role:tag=action,tag=action;role:tag=action"
+func getSensitivityRules(key, defaultValue string) map[string]map[string]string {
+	rules := make(map[string]map[string]string)
+	for _, entry := range strings.Split(getEnv(key, defaultValue), ";") {
+		role, actions, _ := strings.Cut(entry, ":")
+		role = strings.TrimSpace(role)
+		if role == "" {
+			continue
+		}
+		rules[role] = make(map[string]string)
+		for _, item := range strings.Split(actions, ",") {
+			tag, action, _ := strings.Cut(item, "=")
+			if tag = strings.TrimSpace(tag); tag != "" {
+				rules[role][tag] = strings.TrimSpace(action)
+			}
+		}
+	}
+	return rules
+}
@@ -0,0 +1,264 @@
+// Package datasource runs generated queries read-only against configured
+// sandbox databases.
+package datasource
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+
+	"sql_generator/internal/config"
+)
+
+var (
+	// ErrNoDatasources is returned when no datasource is configured
+	ErrNoDatasources = errors.New("no datasources configured")
+	// ErrUnknownDatasource is returned for a name that is not registered
+	ErrUnknownDatasource = errors.New("unknown datasource")
+	// ErrTimeout is returned when a query exceeds its statement timeout
+	ErrTimeout = errors.New("query timed out")
+)
+
+// driverNames maps the configured driver names to registered database/sql drivers
+var driverNames = map[string]string{
+	"mysql":      "mysql",
+	"postgres":   "postgres",
+	"postgresql": "postgres",
+	"sqlite":     "sqlite",
+	"sqlite3":    "sqlite",
+}
+
+// Options limit a single execution
+type Options struct {
+	// MaxRows is the maximum number of rows returned, 0 for no limit
+	MaxRows int
+	// Timeout is the statement timeout, 0 for none
+	Timeout time.Duration
+}
+
+// Result holds the rows returned by a query
+type Result struct {
+	Columns []string        `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+	// Truncated is set when rows beyond MaxRows were discarded
+	Truncated bool `json:"truncated"`
+}
+
+// Datasource is a database that queries can be executed against
+type Datasource struct {
+	Name   string
+	Driver string
+	db     *sql.DB
+}
+
+// New wraps an open database. Driver is the database/sql driver name.
+func New(name, driver string, db *sql.DB) *Datasource {
+	return &Datasource{Name: name, Driver: driver, db: db}
+}
+
+// Open connects to a datasource described by cfg
+func Open(cfg config.DatasourceConfig) (*Datasource, error) {
+	driver, ok := driverNames[strings.ToLower(cfg.Driver)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported driver %q for datasource %s", cfg.Driver, cfg.Name)
+	}
+
+	db, err := sql.Open(driver, cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open datasource %s: %w", cfg.Name, err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to datasource %s: %w", cfg.Name, err)
+	}
+
+	return New(cfg.Name, driver, db), nil
+}
+
+// Execute runs query in a read-only transaction that is always rolled back,
+// and returns at most opts.MaxRows rows
+func (d *Datasource) Execute(ctx context.Context, query string, opts Options) (*Result, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	result, err := d.execute(ctx, query, opts.MaxRows)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w after %s", ErrTimeout, opts.Timeout)
+	}
+	return result, err
+}
+
+func (d *Datasource) execute(ctx context.Context, query string, maxRows int) (*Result, error) {
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	// SQLite accepts but ignores read-only transactions, so the connection
+	// itself is switched to read-only for the duration of the query
+	if d.Driver == "sqlite" {
+		if _, err := conn.ExecContext(ctx, "PRAGMA query_only = ON"); err != nil {
+			return nil, fmt.Errorf("failed to enable read-only mode: %w", err)
+		}
+		defer conn.ExecContext(context.Background(), "PRAGMA query_only = OFF")
+	}
+
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin read-only transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns: %w", err)
+	}
+
+	result := &Result{Columns: columns, Rows: [][]interface{}{}}
+	for rows.Next() {
+		if maxRows > 0 && len(result.Rows) == maxRows {
+			result.Truncated = true
+			break
+		}
+
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		for i, value := range values {
+			// Drivers return text columns as bytes
+			if b, ok := value.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	return result, nil
+}
+
+// DB returns the underlying database, e.g. for reading its catalog
+func (d *Datasource) DB() *sql.DB {
+	return d.db
+}
+
+// Close closes the underlying database
+func (d *Datasource) Close() error {
+	return d.db.Close()
+}
+
+// Registry holds the configured datasources and the limits applied to every execution
+type Registry struct {
+	sources     map[string]*Datasource
+	defaultName string
+	limits      Options
+}
+
+// NewRegistry creates an empty registry. defaultName is used for lookups
+// without a name; when empty the first registered datasource is used.
+func NewRegistry(defaultName string, limits Options) *Registry {
+	return &Registry{sources: make(map[string]*Datasource), defaultName: defaultName, limits: limits}
+}
+
+// OpenRegistry connects to every datasource in cfg
+func OpenRegistry(cfg config.SandboxConfig) (*Registry, error) {
+	registry := NewRegistry(cfg.Default, Options{
+		MaxRows: cfg.MaxRows,
+		Timeout: time.Duration(cfg.Timeout) * time.Second,
+	})
+	for _, dsCfg := range cfg.Datasources {
+		ds, err := Open(dsCfg)
+		if err != nil {
+			registry.Close()
+			return nil, err
+		}
+		registry.Register(ds)
+	}
+
+	if cfg.Default != "" && len(cfg.Datasources) > 0 {
+		if _, err := registry.Get(cfg.Default); err != nil {
+			registry.Close()
+			return nil, fmt.Errorf("invalid default datasource: %w", err)
+		}
+	}
+	return registry, nil
+}
+
+// Register adds a datasource, replacing one with the same name
+func (r *Registry) Register(ds *Datasource) {
+	if r.defaultName == "" {
+		r.defaultName = ds.Name
+	}
+	r.sources[ds.Name] = ds
+}
+
+// Get returns the named datasource, or the default one when name is empty
+func (r *Registry) Get(name string) (*Datasource, error) {
+	if len(r.sources) == 0 {
+		return nil, ErrNoDatasources
+	}
+	if name == "" {
+		name = r.defaultName
+	}
+	ds, ok := r.sources[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDatasource, name)
+	}
+	return ds, nil
+}
+
+// Options returns the configured limits, lowered to maxRows when it is
+// positive and below the configured row limit
+func (r *Registry) Options(maxRows int) Options {
+	opts := r.limits
+	if maxRows > 0 && (opts.MaxRows <= 0 || maxRows < opts.MaxRows) {
+		opts.MaxRows = maxRows
+	}
+	return opts
+}
+
+// Names returns the registered datasource names in sorted order
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.sources))
+	for name := range r.sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Close closes every datasource
+func (r *Registry) Close() error {
+	var firstErr error
+	for _, ds := range r.sources {
+		if err := ds.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
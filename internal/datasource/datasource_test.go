@@ -0,0 +1,112 @@
+package datasource
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newTestDatasource creates a SQLite datasource with a users table of three rows
+func newTestDatasource(t *testing.T) *Datasource {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "sandbox.db"))
+	if err != nil {
+		t.Fatalf("Failed to open SQLite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	for _, stmt := range []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)",
+		"INSERT INTO users (id, name) VALUES (1, 'alice'), (2, 'bob'), (3, 'carol')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to prepare fixture: %v", err)
+		}
+	}
+	return New("local", "sqlite", db)
+}
+
+func TestDatasource_Execute(t *testing.T) {
+	ds := newTestDatasource(t)
+
+	result, err := ds.Execute(context.Background(), "SELECT id, name FROM users ORDER BY id", Options{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !reflect.DeepEqual(result.Columns, []string{"id", "name"}) {
+		t.Errorf("Unexpected columns: %v", result.Columns)
+	}
+	want := [][]interface{}{{int64(1), "alice"}, {int64(2), "bob"}, {int64(3), "carol"}}
+	if !reflect.DeepEqual(result.Rows, want) || result.Truncated {
+		t.Errorf("Unexpected rows %v (truncated %v)", result.Rows, result.Truncated)
+	}
+
+	result, err = ds.Execute(context.Background(), "SELECT name FROM users ORDER BY id", Options{MaxRows: 2})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if len(result.Rows) != 2 || !result.Truncated {
+		t.Errorf("Expected 2 rows and truncation, got %v (truncated %v)", result.Rows, result.Truncated)
+	}
+
+	result, err = ds.Execute(context.Background(), "SELECT name FROM users WHERE id > 10", Options{})
+	if err != nil || result.Rows == nil || len(result.Rows) != 0 {
+		t.Errorf("Expected an empty result, got %v, %v", result, err)
+	}
+}
+
+func TestDatasource_ReadOnly(t *testing.T) {
+	ds := newTestDatasource(t)
+
+	if _, err := ds.Execute(context.Background(), "DELETE FROM users", Options{}); err == nil {
+		t.Error("Expected the write to be rejected")
+	}
+
+	result, err := ds.Execute(context.Background(), "SELECT count(*) FROM users", Options{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.Rows[0][0] != int64(3) {
+		t.Errorf("Expected the rows to be kept, got %v", result.Rows)
+	}
+}
+
+func TestDatasource_Timeout(t *testing.T) {
+	ds := newTestDatasource(t)
+
+	endless := "WITH RECURSIVE n(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM n) SELECT count(*) FROM n"
+	_, err := ds.Execute(context.Background(), endless, Options{Timeout: 50 * time.Millisecond})
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("Expected ErrTimeout, got %v", err)
+	}
+
+	// The connection is usable again afterwards
+	if _, err := ds.Execute(context.Background(), "SELECT 1", Options{}); err != nil {
+		t.Errorf("Execute after timeout failed: %v", err)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry("", Options{MaxRows: 100})
+	if _, err := registry.Get(""); !errors.Is(err, ErrNoDatasources) {
+		t.Errorf("Expected ErrNoDatasources, got %v", err)
+	}
+
+	registry.Register(newTestDatasource(t))
+	if ds, err := registry.Get(""); err != nil || ds.Name != "local" {
+		t.Errorf("Expected the first datasource as default, got %v, %v", ds, err)
+	}
+	if _, err := registry.Get("warehouse"); !errors.Is(err, ErrUnknownDatasource) {
+		t.Errorf("Expected ErrUnknownDatasource, got %v", err)
+	}
+
+	if got := registry.Options(10).MaxRows; got != 10 {
+		t.Errorf("Expected the requested row limit, got %d", got)
+	}
+	if got := registry.Options(1000).MaxRows; got != 100 {
+		t.Errorf("Expected the configured row limit, got %d", got)
+	}
+}
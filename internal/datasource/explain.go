@@ -0,0 +1,282 @@
+package datasource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"sql_generator/internal/models"
+)
+
+// ErrExplainUnsupported is returned for drivers without a plan parser
+var ErrExplainUnsupported = errors.New("EXPLAIN is not supported for this datasource")
+
+// planParser turns the rows of an EXPLAIN statement into a plan
+type planParser struct {
+	prefix string
+	parse  func(result *Result) *models.QueryPlan
+}
+
+var planParsers = map[string]planParser{
+	"mysql":    {prefix: "EXPLAIN ", parse: parseMySQLPlan},
+	"postgres": {prefix: "EXPLAIN (FORMAT JSON) ", parse: parsePostgresPlan},
+	"sqlite":   {prefix: "EXPLAIN QUERY PLAN ", parse: parseSQLitePlan},
+}
+
+// Explain runs EXPLAIN for query and parses the plan. The plan warnings
+// cover full table scans and sorts or groupings through temporary tables.
+func (d *Datasource) Explain(ctx context.Context, query string, timeout time.Duration) (*models.QueryPlan, error) {
+	parser, ok := planParsers[d.Driver]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrExplainUnsupported, d.Driver)
+	}
+
+	result, err := d.Execute(ctx, parser.prefix+query, Options{Timeout: timeout})
+	if err != nil {
+		return nil, err
+	}
+
+	plan := parser.parse(result)
+	plan.Datasource = d.Name
+	return plan, nil
+}
+
+// mysqlAccess maps the EXPLAIN type column to an access method
+var mysqlAccess = map[string]string{
+	"ALL":             models.AccessFullScan,
+	"index":           models.AccessIndexScan,
+	"range":           models.AccessRangeScan,
+	"index_merge":     models.AccessIndexLookup,
+	"ref":             models.AccessIndexLookup,
+	"eq_ref":          models.AccessIndexLookup,
+	"ref_or_null":     models.AccessIndexLookup,
+	"fulltext":        models.AccessIndexLookup,
+	"unique_subquery": models.AccessIndexLookup,
+	"index_subquery":  models.AccessIndexLookup,
+	"const":           models.AccessConst,
+	"system":          models.AccessConst,
+}
+
+// parseMySQLPlan parses the tabular EXPLAIN output of MySQL and MariaDB
+func parseMySQLPlan(result *Result) *models.QueryPlan {
+	plan := &models.QueryPlan{Steps: []models.PlanStep{}, Warnings: []models.PlanWarning{}}
+
+	estimate, outerID := 1.0, -1
+	for _, row := range result.Rows {
+		get := rowGetter(result.Columns, row)
+		step := models.PlanStep{
+			Table:    get("table"),
+			JoinType: get("type"),
+			Index:    get("key"),
+			Detail:   get("extra"),
+		}
+		step.ID, _ = strconv.Atoi(get("id"))
+		step.Access = mysqlAccess[step.JoinType]
+		if rows, err := strconv.ParseFloat(get("rows"), 64); err == nil {
+			step.EstimatedRows = int64(rows)
+		}
+		if partitions := get("partitions"); partitions != "" {
+			step.Partitions = strings.Split(partitions, ",")
+		}
+		plan.Steps = append(plan.Steps, step)
+
+		// The outer query's rows are the product of its joined tables' rows
+		// after the filtered percentage is applied
+		if outerID == -1 {
+			outerID = step.ID
+		}
+		if step.ID == outerID && step.EstimatedRows > 0 {
+			filtered, err := strconv.ParseFloat(get("filtered"), 64)
+			if err != nil || filtered <= 0 {
+				filtered = 100
+			}
+			estimate *= float64(step.EstimatedRows) * filtered / 100
+			plan.EstimatedRows = int64(math.Ceil(estimate))
+		}
+
+		if step.Access == models.AccessFullScan {
+			plan.Warnings = append(plan.Warnings, models.PlanWarning{
+				Code:    models.PlanWarningFullScan,
+				Table:   step.Table,
+				Message: fmt.Sprintf("full scan of %s reading about %d rows", step.Table, step.EstimatedRows),
+			})
+		}
+		if strings.Contains(step.Detail, "Using temporary") || strings.Contains(step.Detail, "Using filesort") {
+			plan.Warnings = append(plan.Warnings, models.PlanWarning{
+				Code:    models.PlanWarningTemporarySort,
+				Table:   step.Table,
+				Message: fmt.Sprintf("%s: %s", step.Table, step.Detail),
+			})
+		}
+	}
+
+	return plan
+}
+
+// parseSQLitePlan parses the EXPLAIN QUERY PLAN output of SQLite, whose
+// detail column reads like "SCAN u" or "SEARCH o USING INDEX i (user_id=?)"
+func parseSQLitePlan(result *Result) *models.QueryPlan {
+	plan := &models.QueryPlan{Steps: []models.PlanStep{}, Warnings: []models.PlanWarning{}}
+
+	// SQLite joins with nested loops; the first table of each level drives the loop
+	driving := make(map[string]bool)
+	for _, row := range result.Rows {
+		get := rowGetter(result.Columns, row)
+		step := models.PlanStep{Detail: get("detail")}
+		step.ID, _ = strconv.Atoi(get("id"))
+
+		fields := strings.Fields(step.Detail)
+		if len(fields) >= 2 && (fields[0] == "SCAN" || fields[0] == "SEARCH") && fields[1] != "CONSTANT" {
+			table := fields[1]
+			if table == "TABLE" && len(fields) >= 3 {
+				// Before SQLite 3.36 the detail read "SCAN TABLE t"
+				table = fields[2]
+			}
+			step.Table = table
+			step.Access, step.Index = sqliteAccess(fields)
+
+			parent := get("parent")
+			if driving[parent] {
+				step.JoinType = "nested_loop"
+			}
+			driving[parent] = true
+		}
+		plan.Steps = append(plan.Steps, step)
+
+		switch {
+		case step.Access == models.AccessFullScan:
+			plan.Warnings = append(plan.Warnings, models.PlanWarning{
+				Code:    models.PlanWarningFullScan,
+				Table:   step.Table,
+				Message: fmt.Sprintf("full scan of %s", step.Table),
+			})
+		case strings.HasPrefix(step.Detail, "USE TEMP B-TREE"):
+			plan.Warnings = append(plan.Warnings, models.PlanWarning{
+				Code:    models.PlanWarningTemporarySort,
+				Message: strings.ToLower(step.Detail),
+			})
+		}
+	}
+
+	return plan
+}
+
+// sqliteAccess classifies a SCAN or SEARCH detail and returns the index it uses
+func sqliteAccess(fields []string) (string, string) {
+	index := ""
+	for i, field := range fields {
+		if field == "INDEX" && i+1 < len(fields) {
+			index = fields[i+1]
+		}
+		if field == "KEY" && i >= 1 && fields[i-1] == "PRIMARY" {
+			index = "PRIMARY"
+		}
+	}
+
+	switch {
+	case fields[0] == "SEARCH":
+		return models.AccessIndexLookup, index
+	case index != "":
+		return models.AccessIndexScan, index
+	default:
+		return models.AccessFullScan, ""
+	}
+}
+
+// postgresAccess maps the scan node types of Postgres to access methods
+var postgresAccess = map[string]string{
+	"Seq Scan":         models.AccessFullScan,
+	"Index Scan":       models.AccessIndexLookup,
+	"Index Only Scan":  models.AccessIndexLookup,
+	"Bitmap Heap Scan": models.AccessRangeScan,
+	"Result":           models.AccessConst,
+}
+
+// postgresNode is a node of the plan tree of EXPLAIN (FORMAT JSON)
+type postgresNode struct {
+	NodeType  string         `json:"Node Type"`
+	JoinType  string         `json:"Join Type"`
+	Relation  string         `json:"Relation Name"`
+	Alias     string         `json:"Alias"`
+	IndexName string         `json:"Index Name"`
+	PlanRows  float64        `json:"Plan Rows"`
+	SortKey   []string       `json:"Sort Key"`
+	Plans     []postgresNode `json:"Plans"`
+}
+
+// parsePostgresPlan parses the EXPLAIN (FORMAT JSON) output of Postgres, a
+// single row holding the plan tree. Nodes become steps in depth-first order;
+// join nodes carry their method, e.g. hash_join, and join type in the detail.
+func parsePostgresPlan(result *Result) *models.QueryPlan {
+	plan := &models.QueryPlan{Steps: []models.PlanStep{}, Warnings: []models.PlanWarning{}}
+	if len(result.Rows) == 0 || len(result.Rows[0]) == 0 {
+		return plan
+	}
+
+	var explained []struct {
+		Plan postgresNode `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(fmt.Sprint(result.Rows[0][0])), &explained); err != nil || len(explained) == 0 {
+		return plan
+	}
+	root := explained[0].Plan
+	plan.EstimatedRows = int64(math.Ceil(root.PlanRows))
+
+	var walk func(node postgresNode)
+	walk = func(node postgresNode) {
+		step := models.PlanStep{
+			ID:            len(plan.Steps) + 1,
+			Table:         node.Alias,
+			Access:        postgresAccess[node.NodeType],
+			Index:         node.IndexName,
+			EstimatedRows: int64(math.Ceil(node.PlanRows)),
+			Detail:        node.NodeType,
+		}
+		if step.Table == "" {
+			step.Table = node.Relation
+		}
+		if node.JoinType != "" {
+			step.JoinType = strings.ReplaceAll(strings.ToLower(node.NodeType), " ", "_")
+			step.Detail = fmt.Sprintf("%s (%s)", node.NodeType, node.JoinType)
+		}
+		plan.Steps = append(plan.Steps, step)
+
+		switch {
+		case step.Access == models.AccessFullScan:
+			plan.Warnings = append(plan.Warnings, models.PlanWarning{
+				Code:    models.PlanWarningFullScan,
+				Table:   step.Table,
+				Message: fmt.Sprintf("full scan of %s reading about %d rows", step.Table, step.EstimatedRows),
+			})
+		case node.NodeType == "Sort":
+			plan.Warnings = append(plan.Warnings, models.PlanWarning{
+				Code:    models.PlanWarningTemporarySort,
+				Message: fmt.Sprintf("sort on %s", strings.Join(node.SortKey, ", ")),
+			})
+		}
+
+		for _, child := range node.Plans {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	return plan
+}
+
+// rowGetter returns a case-insensitive accessor for the columns of row
+func rowGetter(columns []string, row []interface{}) func(string) string {
+	return func(name string) string {
+		for i, column := range columns {
+			if strings.EqualFold(column, name) && row[i] != nil {
+				return fmt.Sprint(row[i])
+			}
+		}
+		return ""
+	}
+}
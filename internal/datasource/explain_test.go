@@ -0,0 +1,119 @@
+package datasource
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"sql_generator/internal/models"
+)
+
+func warningCodes(plan *models.QueryPlan) []string {
+	codes := []string{}
+	for _, warning := range plan.Warnings {
+		codes = append(codes, warning.Code+":"+warning.Table)
+	}
+	return codes
+}
+
+func TestDatasource_ExplainSQLite(t *testing.T) {
+	ds := newTestDatasource(t)
+	for _, stmt := range []string{
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER, amount REAL)",
+		"CREATE INDEX idx_orders_user ON orders(user_id)",
+	} {
+		if _, err := ds.db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to prepare fixture: %v", err)
+		}
+	}
+
+	plan, err := ds.Explain(context.Background(), "SELECT u.name, sum(o.amount) FROM users u JOIN orders o ON o.user_id = u.id GROUP BY u.name", 0)
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	if plan.Datasource != "local" || len(plan.Steps) < 2 {
+		t.Fatalf("Unexpected plan: %+v", plan)
+	}
+	if got := warningCodes(plan); !reflect.DeepEqual(got, []string{"full_scan:o", "temporary_sort:"}) {
+		t.Errorf("Unexpected warnings: %v", got)
+	}
+	search := plan.Steps[1]
+	if search.Table != "u" || search.Access != models.AccessIndexLookup || search.JoinType != "nested_loop" {
+		t.Errorf("Unexpected join step: %+v", search)
+	}
+
+	plan, err = ds.Explain(context.Background(), "SELECT name FROM users WHERE id = 1", 0)
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	if len(plan.Warnings) != 0 || plan.Steps[0].Index != "PRIMARY" {
+		t.Errorf("Expected a primary key lookup without warnings, got %+v", plan)
+	}
+
+	if _, err := ds.Explain(context.Background(), "SELECT * FROM missing", 0); err == nil {
+		t.Error("Expected an error for an unknown table")
+	}
+}
+
+func TestParseMySQLPlan(t *testing.T) {
+	result := &Result{
+		Columns: []string{"id", "select_type", "table", "partitions", "type", "possible_keys", "key", "key_len", "ref", "rows", "filtered", "Extra"},
+		Rows: [][]interface{}{
+			{int64(1), "SIMPLE", "o", "p202401,p202402", "ALL", nil, nil, nil, nil, int64(5000), 10.0, "Using where; Using temporary; Using filesort"},
+			{int64(1), "SIMPLE", "u", nil, "eq_ref", "PRIMARY", "PRIMARY", "8", "shop.o.user_id", int64(1), 100.0, nil},
+		},
+	}
+
+	plan := parseMySQLPlan(result)
+	if len(plan.Steps) != 2 || plan.EstimatedRows != 500 {
+		t.Fatalf("Unexpected plan: %+v", plan)
+	}
+	orders := plan.Steps[0]
+	if orders.Access != models.AccessFullScan || orders.JoinType != "ALL" || orders.EstimatedRows != 5000 || len(orders.Partitions) != 2 {
+		t.Errorf("Unexpected scan step: %+v", orders)
+	}
+	if users := plan.Steps[1]; users.Access != models.AccessIndexLookup || users.Index != "PRIMARY" {
+		t.Errorf("Unexpected lookup step: %+v", users)
+	}
+	if got := warningCodes(plan); !reflect.DeepEqual(got, []string{"full_scan:o", "temporary_sort:o"}) {
+		t.Errorf("Unexpected warnings: %v", got)
+	}
+}
+
+func TestParsePostgresPlan(t *testing.T) {
+	result := &Result{
+		Columns: []string{"QUERY PLAN"},
+		Rows: [][]interface{}{{`[{"Plan": {"Node Type": "Sort", "Plan Rows": 120, "Sort Key": ["o.created_at"],
+			"Plans": [{"Node Type": "Hash Join", "Join Type": "Inner", "Plan Rows": 120,
+				"Plans": [
+					{"Node Type": "Seq Scan", "Relation Name": "orders", "Alias": "o", "Plan Rows": 5000},
+					{"Node Type": "Hash", "Plan Rows": 10,
+						"Plans": [{"Node Type": "Index Scan", "Relation Name": "users", "Alias": "u", "Index Name": "users_pkey", "Plan Rows": 10}]}
+				]}]}}]`}},
+	}
+
+	plan := parsePostgresPlan(result)
+	if len(plan.Steps) != 5 || plan.EstimatedRows != 120 {
+		t.Fatalf("Unexpected plan: %+v", plan)
+	}
+	if join := plan.Steps[1]; join.JoinType != "hash_join" || join.Detail != "Hash Join (Inner)" {
+		t.Errorf("Unexpected join step: %+v", join)
+	}
+	if orders := plan.Steps[2]; orders.Table != "o" || orders.Access != models.AccessFullScan || orders.EstimatedRows != 5000 {
+		t.Errorf("Unexpected scan step: %+v", orders)
+	}
+	if users := plan.Steps[4]; users.Access != models.AccessIndexLookup || users.Index != "users_pkey" {
+		t.Errorf("Unexpected lookup step: %+v", users)
+	}
+	if got := warningCodes(plan); !reflect.DeepEqual(got, []string{"temporary_sort:", "full_scan:o"}) {
+		t.Errorf("Unexpected warnings: %v", got)
+	}
+}
+
+func TestDatasource_ExplainUnsupported(t *testing.T) {
+	ds := New("warehouse", "hive", nil)
+	if _, err := ds.Explain(context.Background(), "SELECT 1", 0); !errors.Is(err, ErrExplainUnsupported) {
+		t.Errorf("Expected ErrExplainUnsupported, got %v", err)
+	}
+}
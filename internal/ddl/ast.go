@@ -0,0 +1,64 @@
+// Package ddl reads and writes table definitions as DDL. Parse reads the
+// CREATE TABLE statements of MySQL, Postgres and Hive scripts; other
+// statements are skipped, except Postgres COMMENT ON TABLE/COLUMN, which
+// attach comments to tables defined earlier in the script. Generate renders
+// a table as MySQL, Postgres, Hive or SQLite DDL.
+package ddl
+
+// CreateTable is a parsed CREATE TABLE statement
+type CreateTable struct {
+	// Schema is the database or schema qualifier of the name, if any
+	Schema      string
+	Name        string
+	External    bool
+	Temporary   bool
+	IfNotExists bool
+	Columns     []ColumnDef
+	// PrimaryKey is the table-level PRIMARY KEY constraint
+	PrimaryKey  []string
+	ForeignKeys []ForeignKey
+	Comment     string
+	// Options are table options such as ENGINE=InnoDB, keyed by upper-cased name
+	Options map[string]string
+
+	// PartitionedBy are the Hive partition columns. Spark-style partitioning
+	// on existing columns gives definitions without a type.
+	PartitionedBy []ColumnDef
+	ClusteredBy   []string
+	SortedBy      []string
+	Buckets       int
+	// StoredAs is the upper-cased STORED AS format, e.g. ORC
+	StoredAs string
+	// InputFormat is the class of STORED AS INPUTFORMAT '...'
+	InputFormat string
+	Location    string
+	Properties  map[string]string
+	// Hive reports whether the statement has any Hive storage clause
+	Hive bool
+}
+
+// ColumnDef is a column definition with its inline constraints
+type ColumnDef struct {
+	Name string
+	// Type is the type as written, with type names upper-cased,
+	// e.g. DECIMAL(10, 2) or MAP<STRING, STRUCT<id:BIGINT>>
+	Type          string
+	NotNull       bool
+	PrimaryKey    bool
+	Unique        bool
+	AutoIncrement bool
+	// Default is the source text of the DEFAULT expression, empty without one
+	Default    string
+	Comment    string
+	References *ForeignKey
+}
+
+// ForeignKey is a FOREIGN KEY constraint or a column-level REFERENCES
+// clause. RefColumns is empty when the referenced table's primary key is
+// implied.
+type ForeignKey struct {
+	Name       string
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+}
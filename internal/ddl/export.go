@@ -0,0 +1,260 @@
+package ddl
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"sql_generator/internal/dialect"
+	"sql_generator/internal/models"
+)
+
+// SQLite is the dialect name of SQLite DDL. It is only an export target;
+// queries cannot be generated for SQLite.
+const SQLite dialect.Dialect = "sqlite"
+
+// ErrUnsupportedDialect is returned for dialects without a DDL generator
+var ErrUnsupportedDialect = errors.New("DDL export is not supported for this dialect")
+
+// generators render a table in one dialect. Spark reads Hive DDL.
+var generators = map[dialect.Dialect]func(table *models.Table) string{
+	dialect.MySQL:    mysqlDDL,
+	dialect.Postgres: postgresDDL,
+	dialect.Hive:     hiveDDL,
+	dialect.Spark:    hiveDDL,
+	SQLite:           sqliteDDL,
+}
+
+// ParseDialect returns the DDL dialect named name, accepting the names and
+// aliases of dialect.Parse and sqlite
+func ParseDialect(name string) (dialect.Dialect, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "sqlite", "sqlite3":
+		return SQLite, nil
+	}
+	d, err := dialect.Parse(name)
+	if err != nil {
+		return "", fmt.Errorf("%w %q, expected one of mysql, postgres, hive, spark, sqlite", dialect.ErrUnknownDialect, name)
+	}
+	if _, ok := generators[d]; !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedDialect, d)
+	}
+	return d, nil
+}
+
+// Generate renders table as a CREATE TABLE IF NOT EXISTS statement of
+// dialect d. Column types are translated to the closest type of the dialect
+// and types it does not know are kept as written. Hive storage clauses are
+// only rendered for Hive and Spark; other dialects get the partition keys as
+// regular columns. Primary and foreign keys are rendered for all dialects
+// but Hive. Descriptions become COMMENT clauses, COMMENT ON statements for
+// Postgres and SQL comments for SQLite.
+func Generate(table *models.Table, d dialect.Dialect) (string, error) {
+	generate, ok := generators[d]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedDialect, d)
+	}
+	return generate(table), nil
+}
+
+// line is one column or constraint of the column list, with an optional
+// trailing SQL comment
+type line struct {
+	text    string
+	comment string
+}
+
+// writeColumnList writes the parenthesised column list, one element per line
+func writeColumnList(b *strings.Builder, lines []line) {
+	b.WriteString("(\n")
+	for i, l := range lines {
+		b.WriteString("  ")
+		b.WriteString(l.text)
+		if i < len(lines)-1 {
+			b.WriteString(",")
+		}
+		if l.comment != "" {
+			b.WriteString(" -- ")
+			b.WriteString(l.comment)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(")")
+}
+
+// keyLines renders the primary key and the relationships as table constraints
+func keyLines(table *models.Table, quote func(string) string) []line {
+	var lines []line
+	var primary []string
+	for _, col := range table.AllColumns() {
+		if col.IsPrimary {
+			primary = append(primary, col.Name)
+		}
+	}
+	if len(primary) > 0 {
+		lines = append(lines, line{text: "PRIMARY KEY (" + quoteList(primary, quote) + ")"})
+	}
+	for _, rel := range table.Relationships {
+		lines = append(lines, line{text: fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)",
+			quoteList(rel.Columns, quote), quote(rel.ToTable), quoteList(rel.ToColumns, quote))})
+	}
+	return lines
+}
+
+func quoteList(names []string, quote func(string) string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quote(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// backquote quotes a MySQL or Hive identifier
+func backquote(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// doubleQuote quotes a Postgres or SQLite identifier
+func doubleQuote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// standardString quotes a string literal by doubling single quotes, as
+// Postgres and SQLite expect
+func standardString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// mysqlString quotes a MySQL string literal. Quotes are doubled, which works
+// with and without NO_BACKSLASH_ESCAPES; backslashes and line breaks are
+// escaped.
+var mysqlString = strings.NewReplacer(`\`, `\\`, "'", "''", "\n", `\n`, "\r", `\r`).Replace
+
+// hiveString escapes a Hive string literal with backslashes; Hive reads
+// doubled quotes as two adjacent literals
+var hiveString = strings.NewReplacer(`\`, `\\`, "'", `\'`, "\n", `\n`, "\r", `\r`).Replace
+
+// lineComment makes text safe for a -- comment
+var lineComment = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace
+
+func mysqlDDL(table *models.Table) string {
+	var lines []line
+	for _, col := range table.AllColumns() {
+		text := backquote(col.Name) + " " + mysqlType(splitType(col.Type))
+		if col.IsRequired || col.IsPrimary {
+			text += " NOT NULL"
+		}
+		if col.Description != "" {
+			text += " COMMENT '" + mysqlString(col.Description) + "'"
+		}
+		lines = append(lines, line{text: text})
+	}
+	lines = append(lines, keyLines(table, backquote)...)
+
+	var b strings.Builder
+	b.WriteString("CREATE TABLE IF NOT EXISTS " + backquote(table.Name) + " ")
+	writeColumnList(&b, lines)
+	b.WriteString(" ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci")
+	if table.Description != "" {
+		b.WriteString(" COMMENT='" + mysqlString(table.Description) + "'")
+	}
+	b.WriteString(";")
+	return b.String()
+}
+
+func postgresDDL(table *models.Table) string {
+	var lines []line
+	var comments []string
+	for _, col := range table.AllColumns() {
+		text := doubleQuote(col.Name) + " " + postgresType(splitType(col.Type))
+		if col.IsRequired || col.IsPrimary {
+			text += " NOT NULL"
+		}
+		lines = append(lines, line{text: text})
+		if col.Description != "" {
+			comments = append(comments, fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s;",
+				doubleQuote(table.Name), doubleQuote(col.Name), standardString(col.Description)))
+		}
+	}
+	lines = append(lines, keyLines(table, doubleQuote)...)
+
+	var b strings.Builder
+	b.WriteString("CREATE TABLE IF NOT EXISTS " + doubleQuote(table.Name) + " ")
+	writeColumnList(&b, lines)
+	b.WriteString(";")
+	if table.Description != "" {
+		b.WriteString("\nCOMMENT ON TABLE " + doubleQuote(table.Name) + " IS " + standardString(table.Description) + ";")
+	}
+	for _, comment := range comments {
+		b.WriteString("\n" + comment)
+	}
+	return b.String()
+}
+
+// hiveColumns renders Hive column definitions, which carry no constraints
+func hiveColumns(columns []models.Column) []line {
+	lines := make([]line, 0, len(columns))
+	for _, col := range columns {
+		text := backquote(col.Name) + " " + hiveType(splitType(col.Type))
+		if col.Description != "" {
+			text += " COMMENT '" + hiveString(col.Description) + "'"
+		}
+		lines = append(lines, line{text: text})
+	}
+	return lines
+}
+
+func hiveDDL(table *models.Table) string {
+	var b strings.Builder
+	b.WriteString("CREATE ")
+	if table.TableType == models.TableTypeExternal {
+		b.WriteString("EXTERNAL ")
+	}
+	b.WriteString("TABLE IF NOT EXISTS " + backquote(table.Name) + " ")
+	writeColumnList(&b, hiveColumns(table.Columns))
+
+	if table.Description != "" {
+		b.WriteString("\nCOMMENT '" + hiveString(table.Description) + "'")
+	}
+	if len(table.PartitionKeys) > 0 {
+		b.WriteString("\nPARTITIONED BY ")
+		writeColumnList(&b, hiveColumns(table.PartitionKeys))
+	}
+	if bucketing := table.Bucketing; bucketing != nil && len(bucketing.Columns) > 0 {
+		b.WriteString("\nCLUSTERED BY (" + quoteList(bucketing.Columns, backquote) + ")")
+		if len(bucketing.SortedBy) > 0 {
+			b.WriteString(" SORTED BY (" + quoteList(bucketing.SortedBy, backquote) + ")")
+		}
+		b.WriteString(" INTO " + strconv.Itoa(bucketing.Buckets) + " BUCKETS")
+	}
+	if table.StorageFormat != "" {
+		b.WriteString("\nSTORED AS " + table.StorageFormat)
+	}
+	if table.Location != "" {
+		b.WriteString("\nLOCATION '" + hiveString(table.Location) + "'")
+	}
+	b.WriteString(";")
+	return b.String()
+}
+
+func sqliteDDL(table *models.Table) string {
+	var lines []line
+	for _, col := range table.AllColumns() {
+		text := doubleQuote(col.Name) + " " + sqliteType(splitType(col.Type))
+		if col.IsRequired || col.IsPrimary {
+			text += " NOT NULL"
+		}
+		lines = append(lines, line{text: text, comment: lineComment(col.Description)})
+	}
+	lines = append(lines, keyLines(table, doubleQuote)...)
+
+	var b strings.Builder
+	if table.Description != "" {
+		b.WriteString("-- " + lineComment(table.Description) + "\n")
+	}
+	b.WriteString("CREATE TABLE IF NOT EXISTS " + doubleQuote(table.Name) + " ")
+	writeColumnList(&b, lines)
+	b.WriteString(";")
+	return b.String()
+}
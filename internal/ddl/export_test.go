@@ -0,0 +1,202 @@
+package ddl
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"sql_generator/internal/dialect"
+	"sql_generator/internal/models"
+)
+
+func exportTable() *models.Table {
+	return &models.Table{
+		Name:        "order_items",
+		Description: "Order lines; it's \"quoted\"",
+		Columns: []models.Column{
+			{Name: "order_id", Type: "BIGINT UNSIGNED", IsPrimary: true, IsRequired: true},
+			{Name: "line", Type: "INT", IsPrimary: true, IsRequired: true},
+			{Name: "sku`code", Type: "VARCHAR(64)", IsRequired: true, Description: `C:\skus 'main'`},
+			{Name: "price", Type: "DECIMAL(10, 2)"},
+			{Name: "tags", Type: "ARRAY<STRING>"},
+		},
+		PartitionKeys: []models.Column{{Name: "dt", Type: "STRING", Description: "day"}},
+		Relationships: []models.Relationship{
+			{Columns: []string{"order_id"}, ToTable: "orders", ToColumns: []string{"id"}, Cardinality: models.ManyToOne},
+		},
+	}
+}
+
+func TestGenerateMySQL(t *testing.T) {
+	got, err := Generate(exportTable(), dialect.MySQL)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	want := "CREATE TABLE IF NOT EXISTS `order_items` (\n" +
+		"  `order_id` BIGINT UNSIGNED NOT NULL,\n" +
+		"  `line` INT NOT NULL,\n" +
+		"  `sku``code` VARCHAR(64) NOT NULL COMMENT 'C:\\\\skus ''main''',\n" +
+		"  `price` DECIMAL(10, 2),\n" +
+		"  `tags` JSON,\n" +
+		"  `dt` LONGTEXT COMMENT 'day',\n" +
+		"  PRIMARY KEY (`order_id`, `line`),\n" +
+		"  FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Order lines; it''s \"quoted\"';"
+	if got != want {
+		t.Errorf("Generate(mysql)\n got %s\nwant %s", got, want)
+	}
+
+	// The output parses back to the same table
+	parsed, err := Parse(got)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	table := Tables(parsed)[0]
+	if table.Description != exportTable().Description || table.Columns[2].Name != "sku`code" || table.Columns[2].Description != `C:\skus 'main'` {
+		t.Errorf("unexpected round trip %+v", table)
+	}
+	if !table.Columns[0].IsPrimary || !table.Columns[1].IsPrimary || len(table.Relationships) != 1 {
+		t.Errorf("keys were lost in the round trip: %+v", table)
+	}
+}
+
+func TestGeneratePostgres(t *testing.T) {
+	got, err := Generate(exportTable(), dialect.Postgres)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	want := `CREATE TABLE IF NOT EXISTS "order_items" (
+  "order_id" NUMERIC(20) NOT NULL,
+  "line" INTEGER NOT NULL,
+  "sku` + "`" + `code" VARCHAR(64) NOT NULL,
+  "price" NUMERIC(10, 2),
+  "tags" TEXT[],
+  "dt" TEXT,
+  PRIMARY KEY ("order_id", "line"),
+  FOREIGN KEY ("order_id") REFERENCES "orders" ("id")
+);
+COMMENT ON TABLE "order_items" IS 'Order lines; it''s "quoted"';
+COMMENT ON COLUMN "order_items"."sku` + "`" + `code" IS 'C:\skus ''main''';
+COMMENT ON COLUMN "order_items"."dt" IS 'day';`
+	if got != want {
+		t.Errorf("Generate(postgres)\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestGenerateHive(t *testing.T) {
+	table := exportTable()
+	table.TableType = models.TableTypeExternal
+	table.Bucketing = &models.Bucketing{Columns: []string{"order_id"}, SortedBy: []string{"line"}, Buckets: 8}
+	table.StorageFormat = "ORC"
+	table.Location = "hdfs:///warehouse/order_items"
+
+	got, err := Generate(table, dialect.Hive)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	want := "CREATE EXTERNAL TABLE IF NOT EXISTS `order_items` (\n" +
+		"  `order_id` DECIMAL(20,0),\n" +
+		"  `line` INT,\n" +
+		"  `sku``code` VARCHAR(64) COMMENT 'C:\\\\skus \\'main\\'',\n" +
+		"  `price` DECIMAL(10, 2),\n" +
+		"  `tags` ARRAY<STRING>\n" +
+		")\n" +
+		"COMMENT 'Order lines; it\\'s \"quoted\"'\n" +
+		"PARTITIONED BY (\n" +
+		"  `dt` STRING COMMENT 'day'\n" +
+		")\n" +
+		"CLUSTERED BY (`order_id`) SORTED BY (`line`) INTO 8 BUCKETS\n" +
+		"STORED AS ORC\n" +
+		"LOCATION 'hdfs:///warehouse/order_items';"
+	if got != want {
+		t.Errorf("Generate(hive)\n got %s\nwant %s", got, want)
+	}
+
+	parsed, err := Parse(got)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	back := Tables(parsed)[0]
+	if back.TableType != table.TableType || back.StorageFormat != "ORC" || back.Location != table.Location ||
+		!reflect.DeepEqual(back.Bucketing, table.Bucketing) || len(back.PartitionKeys) != 1 || back.Description != table.Description {
+		t.Errorf("unexpected round trip %+v", back)
+	}
+}
+
+func TestGenerateSQLite(t *testing.T) {
+	got, err := Generate(exportTable(), SQLite)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "export.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(got); err != nil {
+		t.Fatalf("generated DDL does not run: %v\n%s", err, got)
+	}
+
+	rows, err := db.Query(`SELECT name, type, "notnull", pk FROM pragma_table_info('order_items')`)
+	if err != nil {
+		t.Fatalf("failed to read columns: %v", err)
+	}
+	defer rows.Close()
+	var columns []string
+	for rows.Next() {
+		var name, typ string
+		var notNull, pk int
+		if err := rows.Scan(&name, &typ, &notNull, &pk); err != nil {
+			t.Fatal(err)
+		}
+		columns = append(columns, name+" "+typ)
+		if (name == "order_id" || name == "line") && (pk == 0 || notNull == 0) {
+			t.Errorf("expected %s to be a NOT NULL primary key column", name)
+		}
+	}
+	want := []string{"order_id INTEGER", "line INTEGER", "sku`code TEXT", "price NUMERIC(10, 2)", "tags TEXT", "dt TEXT"}
+	if !reflect.DeepEqual(columns, want) {
+		t.Errorf("unexpected columns %q", columns)
+	}
+}
+
+func TestParseDialect(t *testing.T) {
+	for name, want := range map[string]dialect.Dialect{"SQLite3": SQLite, "postgresql": dialect.Postgres, "sparksql": dialect.Spark, "mysql": dialect.MySQL} {
+		if got, err := ParseDialect(name); err != nil || got != want {
+			t.Errorf("ParseDialect(%q) = %q, %v", name, got, err)
+		}
+	}
+	if _, err := ParseDialect("presto"); !errors.Is(err, ErrUnsupportedDialect) {
+		t.Errorf("expected ErrUnsupportedDialect, got %v", err)
+	}
+	if _, err := ParseDialect("oracle"); !errors.Is(err, dialect.ErrUnknownDialect) {
+		t.Errorf("expected ErrUnknownDialect, got %v", err)
+	}
+}
+
+func TestTypeTranslation(t *testing.T) {
+	tests := []struct {
+		typ                           string
+		mysql, postgres, hive, sqlite string
+	}{
+		{"character varying(20)", "VARCHAR(20)", "VARCHAR(20)", "VARCHAR(20)", "TEXT"},
+		{"TIMESTAMP(3) WITH TIME ZONE", "DATETIME(3)", "TIMESTAMPTZ(3)", "TIMESTAMP", "TIMESTAMP"},
+		{"INT[]", "JSON", "INTEGER[]", "ARRAY<INT>", "TEXT"},
+		{"MAP<STRING, STRUCT<id:BIGINT, note:TEXT>>", "JSON", "JSONB", "MAP<STRING, STRUCT<id:BIGINT, note:STRING>>", "TEXT"},
+		{"ENUM('a','b')", "ENUM('a','b')", "TEXT", "STRING", "TEXT"},
+		{"DOUBLE PRECISION", "DOUBLE", "DOUBLE PRECISION", "DOUBLE", "REAL"},
+		{"GEOMETRY", "GEOMETRY", "GEOMETRY", "GEOMETRY", "GEOMETRY"},
+	}
+	for _, tt := range tests {
+		st := splitType(tt.typ)
+		got := []string{mysqlType(st), postgresType(st), hiveType(st), sqliteType(st)}
+		if want := []string{tt.mysql, tt.postgres, tt.hive, tt.sqlite}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s translated to %q, want %q", tt.typ, got, want)
+		}
+	}
+}
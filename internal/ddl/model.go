@@ -0,0 +1,145 @@
+package ddl
+
+import (
+	"strings"
+
+	"sql_generator/internal/models"
+)
+
+// storageFormats are the STORED AS formats the table model accepts
+var storageFormats = map[string]bool{
+	"ORC": true, "PARQUET": true, "TEXTFILE": true, "SEQUENCEFILE": true, "RCFILE": true, "AVRO": true, "JSONFILE": true,
+}
+
+// Tables converts parsed statements to table definitions without ID or
+// timestamps. Primary key columns are required; other columns are required
+// when declared NOT NULL. A foreign key without referenced columns refers
+// to the primary key of its table when that table is in defs, and is
+// dropped otherwise.
+func Tables(defs []*CreateTable) []*models.Table {
+	tables := make([]*models.Table, 0, len(defs))
+	for _, def := range defs {
+		tables = append(tables, def.table(defs))
+	}
+	return tables
+}
+
+func (t *CreateTable) table(defs []*CreateTable) *models.Table {
+	table := &models.Table{
+		Name:        t.Name,
+		Description: t.Comment,
+		Columns:     []models.Column{},
+		Location:    t.Location,
+	}
+
+	primary := make(map[string]bool)
+	for _, name := range t.primaryKey() {
+		primary[strings.ToLower(name)] = true
+	}
+	for _, def := range t.Columns {
+		table.Columns = append(table.Columns, def.column(primary[strings.ToLower(def.Name)]))
+	}
+
+	// Spark partitions on existing columns, which then become partition keys
+	for _, def := range t.PartitionedBy {
+		if def.Type != "" {
+			table.PartitionKeys = append(table.PartitionKeys, def.column(false))
+			continue
+		}
+		for i, col := range table.Columns {
+			if strings.EqualFold(col.Name, def.Name) {
+				table.PartitionKeys = append(table.PartitionKeys, col)
+				table.Columns = append(table.Columns[:i], table.Columns[i+1:]...)
+				break
+			}
+		}
+	}
+
+	if len(t.ClusteredBy) > 0 {
+		table.Bucketing = &models.Bucketing{Columns: t.ClusteredBy, SortedBy: t.SortedBy, Buckets: t.Buckets}
+	}
+	format := t.StoredAs
+	if t.InputFormat != "" {
+		format = inputFormatName(t.InputFormat)
+	}
+	if storageFormats[format] {
+		table.StorageFormat = format
+	}
+	switch {
+	case t.External:
+		table.TableType = models.TableTypeExternal
+	case t.Hive:
+		table.TableType = models.TableTypeManaged
+	}
+
+	var foreignKeys []ForeignKey
+	for _, def := range t.Columns {
+		if def.References != nil {
+			foreignKeys = append(foreignKeys, *def.References)
+		}
+	}
+	for _, fk := range append(foreignKeys, t.ForeignKeys...) {
+		toColumns := fk.RefColumns
+		if len(toColumns) == 0 {
+			if ref := findTable(defs, fk.RefTable); ref != nil {
+				toColumns = ref.primaryKey()
+			}
+		}
+		if len(toColumns) == 0 || len(toColumns) != len(fk.Columns) {
+			continue
+		}
+		table.Relationships = append(table.Relationships, models.Relationship{
+			Columns:   fk.Columns,
+			ToTable:   fk.RefTable,
+			ToColumns: toColumns,
+		})
+	}
+	table.SetCardinalities()
+	return table
+}
+
+// primaryKey returns the table-level primary key, or the columns declared
+// PRIMARY KEY inline
+func (t *CreateTable) primaryKey() []string {
+	if len(t.PrimaryKey) > 0 {
+		return t.PrimaryKey
+	}
+	var columns []string
+	for _, def := range t.Columns {
+		if def.PrimaryKey {
+			columns = append(columns, def.Name)
+		}
+	}
+	return columns
+}
+
+func (c ColumnDef) column(primary bool) models.Column {
+	primary = primary || c.PrimaryKey
+	return models.Column{
+		Name:        c.Name,
+		Type:        c.Type,
+		Description: c.Comment,
+		IsPrimary:   primary,
+		IsRequired:  primary || c.NotNull,
+	}
+}
+
+// inputFormatName maps a Hadoop input format class to its STORED AS name
+func inputFormatName(class string) string {
+	lower := strings.ToLower(class)
+	switch {
+	case strings.Contains(lower, "orc"):
+		return "ORC"
+	case strings.Contains(lower, "parquet"):
+		return "PARQUET"
+	case strings.Contains(lower, "avro"):
+		return "AVRO"
+	case strings.Contains(lower, "rcfile"):
+		return "RCFILE"
+	case strings.Contains(lower, "sequencefile"):
+		return "SEQUENCEFILE"
+	case strings.Contains(lower, "textinputformat"):
+		return "TEXTFILE"
+	}
+	return ""
+}
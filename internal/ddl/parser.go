@@ -0,0 +1,799 @@
+package ddl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"sql_generator/internal/sqlparser"
+)
+
+// Parse parses the CREATE TABLE statements of a DDL script. Backquoted and
+// double-quoted identifiers, comments and nested parentheses are handled by
+// the sqlparser lexer; double-quoted text is read as an identifier where a
+// name is expected and as a string elsewhere, so MySQL and Postgres scripts
+// both parse. CREATE TABLE ... AS SELECT and CREATE TABLE ... LIKE have no
+// column list and are skipped.
+func Parse(src string) ([]*CreateTable, error) {
+	tokens, err := sqlparser.Tokenize(src, sqlparser.Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{src: src, tokens: tokens}
+	var tables []*CreateTable
+	for p.peek().Kind != sqlparser.EOF {
+		if p.acceptOp(";") {
+			continue
+		}
+
+		switch {
+		case p.isKeyword("CREATE"):
+			table, err := p.parseCreate()
+			if err != nil {
+				return nil, err
+			}
+			if table != nil {
+				tables = append(tables, table)
+			}
+		case p.isKeyword("COMMENT") && tokenIs(p.peekN(1), "ON"):
+			if err := p.parseCommentOn(tables); err != nil {
+				return nil, err
+			}
+		}
+		p.skipStatement()
+	}
+	return tables, nil
+}
+
+// parser is a recursive-descent parser over the tokens of a whole script
+type parser struct {
+	src    string
+	tokens []sqlparser.Token
+	pos    int
+}
+
+func (p *parser) peek() sqlparser.Token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekN(n int) sqlparser.Token {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+func (p *parser) next() sqlparser.Token {
+	tok := p.tokens[p.pos]
+	if tok.Kind != sqlparser.EOF {
+		p.pos++
+	}
+	return tok
+}
+
+func tokenIs(tok sqlparser.Token, words ...string) bool {
+	if tok.Kind != sqlparser.Ident {
+		return false
+	}
+	for _, w := range words {
+		if tok.Upper == w {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) isKeyword(words ...string) bool {
+	return tokenIs(p.peek(), words...)
+}
+
+// acceptKeywords consumes the keyword sequence if all of it is present
+func (p *parser) acceptKeywords(words ...string) bool {
+	for i, w := range words {
+		if !tokenIs(p.peekN(i), w) {
+			return false
+		}
+	}
+	p.pos += len(words)
+	return true
+}
+
+func (p *parser) isOp(op string) bool {
+	tok := p.peek()
+	return tok.Kind == sqlparser.Operator && tok.Value == op
+}
+
+func (p *parser) acceptOp(op string) bool {
+	if p.isOp(op) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectOp(op string) error {
+	if !p.acceptOp(op) {
+		return p.errorf("expected %q, found %s", op, p.describe())
+	}
+	return nil
+}
+
+// atElementEnd reports whether the current token ends a column or
+// constraint definition
+func (p *parser) atElementEnd() bool {
+	return p.isOp(",") || p.isOp(")") || p.atStatementEnd()
+}
+
+func (p *parser) atStatementEnd() bool {
+	return p.isOp(";") || p.peek().Kind == sqlparser.EOF
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	tok := p.peek()
+	pos := tok.Pos
+	line := strings.Count(p.src[:pos], "\n") + 1
+	column := pos - strings.LastIndex(p.src[:pos], "\n")
+	near := ""
+	if tok.Kind != sqlparser.EOF {
+		near = p.src[tok.Pos:tok.End(p.src)]
+	}
+	return &sqlparser.SyntaxError{Message: fmt.Sprintf(format, args...), Line: line, Column: column, Near: near}
+}
+
+func (p *parser) describe() string {
+	tok := p.peek()
+	if tok.Kind == sqlparser.EOF {
+		return "end of input"
+	}
+	return tok.Kind.String() + " " + p.src[tok.Pos:tok.End(p.src)]
+}
+
+// isName reports whether tok can be a table or column name
+func (p *parser) isName(tok sqlparser.Token) bool {
+	switch tok.Kind {
+	case sqlparser.Ident, sqlparser.QuotedIdent:
+		return true
+	case sqlparser.String:
+		return p.src[tok.Pos] == '"'
+	}
+	return false
+}
+
+func (p *parser) name() (string, error) {
+	if !p.isName(p.peek()) {
+		return "", p.errorf("expected a name, found %s", p.describe())
+	}
+	return p.next().Value, nil
+}
+
+// qualifiedName reads a dotted name such as db.table or schema.table.column
+func (p *parser) qualifiedName() ([]string, error) {
+	part, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	parts := []string{part}
+	for p.acceptOp(".") {
+		if part, err = p.name(); err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+func (p *parser) stringLiteral() (string, error) {
+	tok := p.peek()
+	if tok.Kind != sqlparser.String {
+		return "", p.errorf("expected a string, found %s", p.describe())
+	}
+	p.next()
+	return tok.Value, nil
+}
+
+// text returns the source text of the tokens from..to-1
+func (p *parser) text(from, to int) string {
+	if to <= from {
+		return ""
+	}
+	return p.src[p.tokens[from].Pos:p.tokens[to-1].End(p.src)]
+}
+
+// skipStatement skips to the semicolon ending the current statement
+func (p *parser) skipStatement() {
+	for !p.atStatementEnd() {
+		p.next()
+	}
+}
+
+// skipToken skips one token, or a whole parenthesised group
+func (p *parser) skipToken() error {
+	if p.isOp("(") {
+		return p.skipGroup("(", ")")
+	}
+	p.next()
+	return nil
+}
+
+// skipGroup skips a balanced group starting at the open token
+func (p *parser) skipGroup(open, close string) error {
+	depth := 0
+	for {
+		if p.atStatementEnd() {
+			return p.errorf("expected %q, found %s", close, p.describe())
+		}
+		switch tok := p.next(); {
+		case tok.Kind != sqlparser.Operator:
+		case tok.Value == open:
+			depth++
+		case tok.Value == close:
+			depth--
+		case open == "<" && tok.Value == ">>":
+			depth -= 2
+		case open == "<" && tok.Value == "<<":
+			depth += 2
+		}
+		if depth <= 0 {
+			return nil
+		}
+	}
+}
+
+// skipElement skips what is left of a column or constraint definition
+func (p *parser) skipElement() error {
+	for !p.atElementEnd() {
+		if err := p.skipToken(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseCreate parses a CREATE statement, returning nil for anything other
+// than a CREATE TABLE with a column list
+func (p *parser) parseCreate() (*CreateTable, error) {
+	p.next()
+	p.acceptKeywords("OR", "REPLACE")
+
+	table := &CreateTable{}
+	for p.isKeyword("TEMPORARY", "TEMP", "EXTERNAL", "GLOBAL", "LOCAL", "UNLOGGED", "TRANSACTIONAL") {
+		switch p.next().Upper {
+		case "TEMPORARY", "TEMP":
+			table.Temporary = true
+		case "EXTERNAL":
+			table.External = true
+		}
+	}
+	if !p.acceptKeywords("TABLE") {
+		return nil, nil
+	}
+	table.IfNotExists = p.acceptKeywords("IF", "NOT", "EXISTS")
+
+	parts, err := p.qualifiedName()
+	if err != nil {
+		return nil, err
+	}
+	table.Name = parts[len(parts)-1]
+	table.Schema = strings.Join(parts[:len(parts)-1], ".")
+
+	if !p.acceptOp("(") {
+		return nil, nil
+	}
+	for {
+		if err := p.parseElement(table); err != nil {
+			return nil, err
+		}
+		if p.acceptOp(")") {
+			break
+		}
+		if err := p.expectOp(","); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.parseTableOptions(table); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// parseElement parses a column definition or a table constraint
+func (p *parser) parseElement(table *CreateTable) error {
+	var constraint string
+	if p.acceptKeywords("CONSTRAINT") && !p.isKeyword("PRIMARY", "FOREIGN", "UNIQUE", "CHECK") {
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+		constraint = name
+	}
+
+	switch {
+	case p.acceptKeywords("PRIMARY", "KEY"):
+		p.skipIndexType()
+		columns, err := p.columnList()
+		if err != nil {
+			return err
+		}
+		table.PrimaryKey = columns
+	case p.acceptKeywords("FOREIGN", "KEY"):
+		if !p.isOp("(") {
+			if _, err := p.name(); err != nil {
+				return err
+			}
+		}
+		columns, err := p.columnList()
+		if err != nil {
+			return err
+		}
+		if !p.isKeyword("REFERENCES") {
+			return p.errorf("expected REFERENCES, found %s", p.describe())
+		}
+		fk, err := p.parseReferences()
+		if err != nil {
+			return err
+		}
+		fk.Name = constraint
+		fk.Columns = columns
+		table.ForeignKeys = append(table.ForeignKeys, *fk)
+	case p.isKeyword("UNIQUE", "CHECK", "FULLTEXT", "SPATIAL", "LIKE", "EXCLUDE"), p.isIndex():
+		// Indexes and other constraints do not change the table model
+	default:
+		column, err := p.parseColumn()
+		if err != nil {
+			return err
+		}
+		table.Columns = append(table.Columns, *column)
+	}
+	return p.skipElement()
+}
+
+// isIndex reports whether the element is a MySQL KEY or INDEX definition
+// rather than a column named key or index: "KEY idx (a)" lists a name in
+// parentheses where "key VARCHAR(10)" has a length
+func (p *parser) isIndex() bool {
+	if !p.isKeyword("KEY", "INDEX") {
+		return false
+	}
+	next := p.peekN(1)
+	if next.Kind == sqlparser.Operator {
+		return next.Value == "("
+	}
+	if tokenIs(p.peekN(2), "USING") {
+		return true
+	}
+	open := p.peekN(2)
+	return open.Kind == sqlparser.Operator && open.Value == "(" && p.isName(p.peekN(3))
+}
+
+// skipIndexType skips MySQL's optional USING BTREE|HASH
+func (p *parser) skipIndexType() {
+	if p.acceptKeywords("USING") {
+		p.next()
+	}
+}
+
+// columnList reads a parenthesised list of column names. MySQL key prefix
+// lengths such as name(10) and ASC/DESC are skipped.
+func (p *parser) columnList() ([]string, error) {
+	if err := p.expectOp("("); err != nil {
+		return nil, err
+	}
+	var columns []string
+	for {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, name)
+		if p.isOp("(") {
+			if err := p.skipGroup("(", ")"); err != nil {
+				return nil, err
+			}
+		}
+		p.acceptKeywords("ASC")
+		p.acceptKeywords("DESC")
+
+		if p.acceptOp(")") {
+			return columns, nil
+		}
+		if err := p.expectOp(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseColumn parses a column name, its type and its inline constraints.
+// The type may be missing, as in Spark's PARTITIONED BY (dt).
+func (p *parser) parseColumn() (*ColumnDef, error) {
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	column := &ColumnDef{Name: name}
+	if p.atElementEnd() {
+		return column, nil
+	}
+	if column.Type, err = p.parseType(); err != nil {
+		return nil, err
+	}
+
+	for !p.atElementEnd() {
+		switch {
+		case p.acceptKeywords("NOT", "NULL"):
+			column.NotNull = true
+		case p.acceptKeywords("NULL"):
+			column.NotNull = false
+		case p.acceptKeywords("PRIMARY", "KEY"), p.acceptKeywords("KEY"):
+			column.PrimaryKey = true
+		case p.acceptKeywords("UNIQUE"):
+			column.Unique = true
+			p.acceptKeywords("KEY")
+		case p.acceptKeywords("DEFAULT"):
+			if column.Default, err = p.parseDefault(); err != nil {
+				return nil, err
+			}
+		case p.acceptKeywords("ON", "UPDATE"):
+			if _, err := p.parseDefault(); err != nil {
+				return nil, err
+			}
+		case p.acceptKeywords("AUTO_INCREMENT"), p.acceptKeywords("AUTOINCREMENT"), p.acceptKeywords("IDENTITY"):
+			column.AutoIncrement = true
+			if p.isOp("(") {
+				if err := p.skipGroup("(", ")"); err != nil {
+					return nil, err
+				}
+			}
+		case p.acceptKeywords("GENERATED"):
+			// GENERATED {ALWAYS | BY DEFAULT} AS {IDENTITY [(...)] | (expr) [STORED]}
+			if !p.acceptKeywords("ALWAYS") {
+				p.acceptKeywords("BY", "DEFAULT")
+			}
+			p.acceptKeywords("AS")
+			if p.acceptKeywords("IDENTITY") {
+				column.AutoIncrement = true
+			}
+		case p.acceptKeywords("COMMENT"):
+			if column.Comment, err = p.stringLiteral(); err != nil {
+				return nil, err
+			}
+		case p.isKeyword("REFERENCES"):
+			fk, err := p.parseReferences()
+			if err != nil {
+				return nil, err
+			}
+			fk.Columns = []string{column.Name}
+			column.References = fk
+		case p.acceptKeywords("CONSTRAINT"):
+			if _, err := p.name(); err != nil {
+				return nil, err
+			}
+		case p.acceptKeywords("COLLATE"), p.acceptKeywords("CHARACTER", "SET"), p.acceptKeywords("CHARSET"):
+			p.next()
+		default:
+			// CHECK (...), STORAGE, VISIBLE and other attributes
+			if err := p.skipToken(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return column, nil
+}
+
+// parseType reads a column type: one or more type words with optional
+// arguments, Hive generics, Postgres array brackets and MySQL UNSIGNED
+func (p *parser) parseType() (string, error) {
+	start := p.pos
+	if !p.isName(p.peek()) {
+		return "", p.errorf("expected a column type, found %s", p.describe())
+	}
+	p.next()
+	// CHARACTER VARYING, DOUBLE PRECISION, BIT VARYING
+	for p.isKeyword("VARYING", "PRECISION") {
+		p.next()
+	}
+
+	switch {
+	case p.isOp("("):
+		if err := p.skipGroup("(", ")"); err != nil {
+			return "", err
+		}
+	case p.isOp("<"):
+		if err := p.skipGroup("<", ">"); err != nil {
+			return "", err
+		}
+	}
+	// TIMESTAMP(3) WITH TIME ZONE
+	if p.isKeyword("WITH", "WITHOUT") && tokenIs(p.peekN(1), "TIME") && tokenIs(p.peekN(2), "ZONE") {
+		p.pos += 3
+	}
+	for p.isKeyword("UNSIGNED", "SIGNED", "ZEROFILL") {
+		p.next()
+	}
+	for p.isOp("[") {
+		if err := p.skipGroup("[", "]"); err != nil {
+			return "", err
+		}
+	}
+	return p.typeText(start, p.pos), nil
+}
+
+// typeText returns the source text of a type with its type names
+// upper-cased; STRUCT field names, the names before a colon, keep their case
+func (p *parser) typeText(from, to int) string {
+	var b strings.Builder
+	last := p.tokens[from].Pos
+	for i := from; i < to; i++ {
+		tok := p.tokens[i]
+		b.WriteString(p.src[last:tok.Pos])
+		end := tok.End(p.src)
+		text := p.src[tok.Pos:end]
+		next := p.tokens[i+1]
+		if tok.Kind == sqlparser.Ident && !(next.Kind == sqlparser.Operator && next.Value == ":") {
+			text = strings.ToUpper(text)
+		}
+		b.WriteString(text)
+		last = end
+	}
+	return b.String()
+}
+
+// parseDefault reads a DEFAULT expression and returns its source text. The
+// expression is a literal, a keyword such as CURRENT_TIMESTAMP, a function
+// call or a parenthesised expression, optionally with Postgres ::casts.
+func (p *parser) parseDefault() (string, error) {
+	start := p.pos
+	switch {
+	case p.isOp("("):
+		if err := p.skipGroup("(", ")"); err != nil {
+			return "", err
+		}
+	case p.isOp("-"), p.isOp("+"):
+		p.next()
+		p.next()
+	case p.atElementEnd():
+		return "", p.errorf("expected a default value, found %s", p.describe())
+	default:
+		tok := p.next()
+		// Prefixed literals such as b'0' or X'FF'
+		if next := p.peek(); tok.Kind == sqlparser.Ident && len(tok.Value) == 1 && next.Kind == sqlparser.String && next.Pos == tok.End(p.src) {
+			p.next()
+		}
+		if p.isOp("(") {
+			if err := p.skipGroup("(", ")"); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	for p.acceptOp("::") {
+		if _, err := p.parseType(); err != nil {
+			return "", err
+		}
+	}
+	return p.text(start, p.pos), nil
+}
+
+// parseReferences parses "REFERENCES table [(columns)]" and the referential
+// actions and options that may follow
+func (p *parser) parseReferences() (*ForeignKey, error) {
+	p.next()
+	parts, err := p.qualifiedName()
+	if err != nil {
+		return nil, err
+	}
+	fk := &ForeignKey{RefTable: parts[len(parts)-1]}
+	if p.isOp("(") {
+		if fk.RefColumns, err = p.columnList(); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		switch {
+		case p.acceptKeywords("ON", "DELETE"), p.acceptKeywords("ON", "UPDATE"):
+			if p.isKeyword("SET", "NO") {
+				p.next()
+			}
+			p.next()
+		case p.acceptKeywords("MATCH"):
+			p.next()
+		case p.acceptKeywords("DEFERRABLE"), p.acceptKeywords("NOT", "DEFERRABLE"):
+		case p.acceptKeywords("INITIALLY"):
+			p.next()
+		default:
+			return fk, nil
+		}
+	}
+}
+
+// parseTableOptions parses what follows the column list: table comments,
+// MySQL options such as ENGINE=InnoDB and the Hive PARTITIONED BY,
+// CLUSTERED BY, STORED AS, LOCATION and TBLPROPERTIES clauses. ROW FORMAT,
+// Postgres storage parameters and MySQL partitioning are skipped.
+func (p *parser) parseTableOptions(table *CreateTable) error {
+	for !p.atStatementEnd() {
+		var err error
+		switch {
+		case p.acceptKeywords("COMMENT"):
+			p.acceptOp("=")
+			table.Comment, err = p.stringLiteral()
+		case p.acceptKeywords("PARTITIONED", "BY"):
+			table.Hive = true
+			table.PartitionedBy, err = p.partitionColumns()
+		case p.acceptKeywords("CLUSTERED", "BY"):
+			table.Hive = true
+			err = p.parseBucketing(table)
+		case p.acceptKeywords("STORED", "AS"):
+			table.Hive = true
+			if p.acceptKeywords("INPUTFORMAT") {
+				table.InputFormat, err = p.stringLiteral()
+				if err == nil && p.acceptKeywords("OUTPUTFORMAT") {
+					_, err = p.stringLiteral()
+				}
+			} else {
+				table.StoredAs = strings.ToUpper(p.next().Value)
+			}
+		case p.acceptKeywords("STORED", "BY"), p.acceptKeywords("ROW", "FORMAT"):
+			table.Hive = true
+		case p.acceptKeywords("LOCATION"):
+			table.Hive = true
+			table.Location, err = p.stringLiteral()
+		case p.acceptKeywords("TBLPROPERTIES"):
+			table.Hive = true
+			table.Properties, err = p.properties()
+		case p.isKeyword("AS"):
+			// CREATE TABLE t (...) AS SELECT
+			p.skipStatement()
+		case p.acceptKeywords("CHARACTER", "SET"):
+			p.acceptOp("=")
+			table.setOption("CHARSET", p.next().Value)
+		case p.peek().Kind == sqlparser.Ident && p.peekN(1).Kind == sqlparser.Operator && p.peekN(1).Value == "=":
+			name := p.next().Upper
+			p.next()
+			table.setOption(name, p.next().Value)
+		default:
+			err = p.skipToken()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *CreateTable) setOption(name, value string) {
+	if t.Options == nil {
+		t.Options = make(map[string]string)
+	}
+	t.Options[name] = value
+}
+
+// partitionColumns reads the column list of PARTITIONED BY
+func (p *parser) partitionColumns() ([]ColumnDef, error) {
+	if err := p.expectOp("("); err != nil {
+		return nil, err
+	}
+	var columns []ColumnDef
+	for {
+		column, err := p.parseColumn()
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, *column)
+		if p.acceptOp(")") {
+			return columns, nil
+		}
+		if err := p.expectOp(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseBucketing parses "(columns) [SORTED BY (columns)] INTO n BUCKETS"
+func (p *parser) parseBucketing(table *CreateTable) error {
+	var err error
+	if table.ClusteredBy, err = p.columnList(); err != nil {
+		return err
+	}
+	if p.acceptKeywords("SORTED", "BY") {
+		if table.SortedBy, err = p.columnList(); err != nil {
+			return err
+		}
+	}
+	if !p.acceptKeywords("INTO") || p.peek().Kind != sqlparser.Number {
+		return p.errorf("expected INTO n BUCKETS, found %s", p.describe())
+	}
+	if table.Buckets, err = strconv.Atoi(p.next().Value); err != nil {
+		return p.errorf("invalid bucket count: %v", err)
+	}
+	if !p.acceptKeywords("BUCKETS") {
+		return p.errorf("expected BUCKETS, found %s", p.describe())
+	}
+	return nil
+}
+
+// properties reads a ('key'='value', ...) list
+func (p *parser) properties() (map[string]string, error) {
+	if err := p.expectOp("("); err != nil {
+		return nil, err
+	}
+	properties := make(map[string]string)
+	for !p.acceptOp(")") {
+		key, err := p.stringLiteral()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectOp("="); err != nil {
+			return nil, err
+		}
+		value, err := p.stringLiteral()
+		if err != nil {
+			return nil, err
+		}
+		properties[key] = value
+		if !p.isOp(")") {
+			if err := p.expectOp(","); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return properties, nil
+}
+
+// parseCommentOn applies Postgres COMMENT ON TABLE t IS '...' and COMMENT ON
+// COLUMN t.c IS '...' to the tables parsed so far
+func (p *parser) parseCommentOn(tables []*CreateTable) error {
+	p.pos += 2
+	isColumn := p.isKeyword("COLUMN")
+	if !p.acceptKeywords("TABLE") && !p.acceptKeywords("COLUMN") {
+		return nil
+	}
+
+	parts, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+	if !p.acceptKeywords("IS") {
+		return p.errorf("expected IS, found %s", p.describe())
+	}
+	comment := ""
+	if !p.acceptKeywords("NULL") {
+		if comment, err = p.stringLiteral(); err != nil {
+			return err
+		}
+	}
+
+	tableName := parts[len(parts)-1]
+	if isColumn {
+		if len(parts) < 2 {
+			return nil
+		}
+		tableName = parts[len(parts)-2]
+	}
+	table := findTable(tables, tableName)
+	if table == nil {
+		return nil
+	}
+	if !isColumn {
+		table.Comment = comment
+		return nil
+	}
+	for i := range table.Columns {
+		if strings.EqualFold(table.Columns[i].Name, parts[len(parts)-1]) {
+			table.Columns[i].Comment = comment
+		}
+	}
+	return nil
+}
+
+// findTable returns the last definition of the named table
+func findTable(tables []*CreateTable, name string) *CreateTable {
+	for i := len(tables) - 1; i >= 0; i-- {
+		if strings.EqualFold(tables[i].Name, name) {
+			return tables[i]
+		}
+	}
+	return nil
+}
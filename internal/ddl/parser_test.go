@@ -0,0 +1,295 @@
+package ddl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sql_generator/internal/models"
+	"sql_generator/internal/sqlparser"
+)
+
+func parseFixture(t *testing.T, name string) []*CreateTable {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	tables, err := Parse(string(data))
+	if err != nil {
+		t.Fatalf("Parse(%s) failed: %v", name, err)
+	}
+	return tables
+}
+
+func tableNames(tables []*CreateTable) string {
+	names := make([]string, len(tables))
+	for i, table := range tables {
+		names[i] = table.Name
+	}
+	return strings.Join(names, ",")
+}
+
+// describeColumns renders name:type and flags, e.g. "id:BIGINT pk nn auto"
+func describeColumns(columns []ColumnDef) []string {
+	var out []string
+	for _, c := range columns {
+		s := c.Name + ":" + c.Type
+		if c.PrimaryKey {
+			s += " pk"
+		}
+		if c.NotNull {
+			s += " nn"
+		}
+		if c.Unique {
+			s += " uq"
+		}
+		if c.AutoIncrement {
+			s += " auto"
+		}
+		if c.Default != "" {
+			s += " default=" + c.Default
+		}
+		if c.Comment != "" {
+			s += " comment=" + c.Comment
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func describeRelationships(table *models.Table) string {
+	var parts []string
+	for _, rel := range table.Relationships {
+		parts = append(parts, fmt.Sprintf("%s->%s.%s %s", strings.Join(rel.Columns, ","), rel.ToTable, strings.Join(rel.ToColumns, ","), rel.Cardinality))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func assertColumns(t *testing.T, table *CreateTable, want []string) {
+	t.Helper()
+	if got := describeColumns(table.Columns); !reflect.DeepEqual(got, want) {
+		t.Errorf("%s columns:\n got %q\nwant %q", table.Name, got, want)
+	}
+}
+
+func TestParseMySQL(t *testing.T) {
+	tables := parseFixture(t, "mysql.sql")
+	if got := tableNames(tables); got != "users,orders" {
+		t.Fatalf("unexpected tables %q", got)
+	}
+
+	users, orders := tables[0], tables[1]
+	assertColumns(t, users, []string{
+		"id:BIGINT UNSIGNED nn auto comment=用户ID",
+		"name:VARCHAR(100) nn default='' comment=name, (display)",
+		"email:VARCHAR(200) default=NULL",
+		"status:ENUM('active','Disabled') nn default='active'",
+		"key:VARCHAR(32) default=NULL",
+		"created_at:TIMESTAMP(3) nn default=CURRENT_TIMESTAMP(3)",
+	})
+	if !reflect.DeepEqual(users.PrimaryKey, []string{"id"}) {
+		t.Errorf("unexpected primary key %v", users.PrimaryKey)
+	}
+	if users.Comment != `用户表; it's "quoted"` {
+		t.Errorf("unexpected table comment %q", users.Comment)
+	}
+	wantOptions := map[string]string{"ENGINE": "InnoDB", "AUTO_INCREMENT": "42", "CHARSET": "utf8mb4"}
+	if !reflect.DeepEqual(users.Options, wantOptions) {
+		t.Errorf("unexpected options %v", users.Options)
+	}
+
+	if orders.Schema != "shop" || !orders.IfNotExists || orders.Comment != "" {
+		t.Errorf("unexpected orders statement %+v", orders)
+	}
+	assertColumns(t, orders, []string{
+		"id:BIGINT nn",
+		"user_id:BIGINT nn",
+		"amount:DECIMAL(10, 2) nn default=0.00",
+		"discount:DECIMAL(5,2) default=-1",
+		"flags:BIT(1) default=b'0'",
+		"note:TEXT",
+	})
+	want := []ForeignKey{{Name: "fk_orders_user", Columns: []string{"user_id"}, RefTable: "users", RefColumns: []string{"id"}}}
+	if !reflect.DeepEqual(orders.ForeignKeys, want) {
+		t.Errorf("unexpected foreign keys %+v", orders.ForeignKeys)
+	}
+
+	converted := Tables(tables)
+	if id := converted[0].Columns[0]; !id.IsPrimary || !id.IsRequired {
+		t.Errorf("expected users.id to be a required primary key: %+v", id)
+	}
+	if note := converted[1].Columns[5]; note.IsRequired {
+		t.Errorf("expected orders.note to be optional: %+v", note)
+	}
+	if amount := converted[1].Columns[2]; !amount.IsRequired {
+		t.Errorf("expected NOT NULL orders.amount with a default to be required: %+v", amount)
+	}
+	if got := describeRelationships(converted[1]); got != "user_id->users.id many_to_one" {
+		t.Errorf("unexpected relationships %q", got)
+	}
+	if converted[0].Description != users.Comment || converted[1].Description != "" || converted[0].TableType != "" {
+		t.Errorf("unexpected table metadata %+v", converted[0])
+	}
+}
+
+func TestParsePostgres(t *testing.T) {
+	tables := parseFixture(t, "postgres.sql")
+	if got := tableNames(tables); got != "Customers,invoices,invoice_notes" {
+		t.Fatalf("unexpected tables %q", got)
+	}
+
+	customers, invoices := tables[0], tables[1]
+	if customers.Schema != "public" || customers.Comment != "Customer master data" {
+		t.Errorf("unexpected customers statement %+v", customers)
+	}
+	assertColumns(t, customers, []string{
+		"id:SERIAL pk",
+		"Full Name:CHARACTER VARYING(255) nn comment=客户全名",
+		"balance:NUMERIC(12,2) nn default=0",
+		"tags:TEXT[] default='{}'::text[]",
+		"kind:CHARACTER VARYING(20) default='retail'::character varying",
+		"signed_up:TIMESTAMP(3) WITH TIME ZONE nn default=now()",
+		"score:DOUBLE PRECISION",
+	})
+	assertColumns(t, invoices, []string{
+		"id:BIGINT auto",
+		"customer_id:INTEGER nn",
+		"line:INTEGER nn",
+		"total:NUMERIC",
+	})
+	if !reflect.DeepEqual(invoices.PrimaryKey, []string{"id", "line"}) {
+		t.Errorf("unexpected primary key %v", invoices.PrimaryKey)
+	}
+	if ref := invoices.Columns[1].References; ref == nil || ref.RefTable != "Customers" || len(ref.RefColumns) != 0 {
+		t.Errorf("unexpected inline reference %+v", ref)
+	}
+
+	converted := Tables(tables)
+	if got := describeRelationships(converted[1]); got != "customer_id->Customers.id many_to_one" {
+		t.Errorf("unexpected invoices relationships %q", got)
+	}
+	if got := describeRelationships(converted[2]); got != "invoice_id,line->invoices.id,line one_to_one" {
+		t.Errorf("unexpected invoice_notes relationships %q", got)
+	}
+	if id := converted[1].Columns[0]; !id.IsPrimary || !id.IsRequired {
+		t.Errorf("expected invoices.id to be part of the primary key: %+v", id)
+	}
+}
+
+func TestParseHive(t *testing.T) {
+	tables := parseFixture(t, "hive.sql")
+	if got := tableNames(tables); got != "page_views,daily_totals,events" {
+		t.Fatalf("unexpected tables %q", got)
+	}
+
+	views := tables[0]
+	if views.Schema != "dw" || !views.External || views.Comment != "Page views" {
+		t.Errorf("unexpected page_views statement %+v", views)
+	}
+	assertColumns(t, views, []string{
+		"user_id:BIGINT comment=viewer",
+		"url:STRING",
+		"tags:ARRAY<STRING>",
+		"props:MAP<STRING, ARRAY<INT>> comment=key, value",
+		"device:STRUCT<os:STRING, `version`:INT, geo:STRUCT<lat:DOUBLE,lng:DOUBLE>>",
+	})
+	if got := describeColumns(views.PartitionedBy); !reflect.DeepEqual(got, []string{"dt:STRING comment=date partition", "hour:INT"}) {
+		t.Errorf("unexpected partition columns %q", got)
+	}
+	if views.Properties["orc.compress"] != "SNAPPY" || views.Location != "hdfs:///warehouse/dw/page_views" {
+		t.Errorf("unexpected storage clauses %+v", views)
+	}
+
+	converted := Tables(tables)
+	table := converted[0]
+	want := &models.Bucketing{Columns: []string{"user_id"}, SortedBy: []string{"user_id", "url"}, Buckets: 32}
+	if !reflect.DeepEqual(table.Bucketing, want) {
+		t.Errorf("unexpected bucketing %+v", table.Bucketing)
+	}
+	if table.TableType != models.TableTypeExternal || table.StorageFormat != "ORC" || len(table.PartitionKeys) != 2 {
+		t.Errorf("unexpected page_views table %+v", table)
+	}
+
+	totals := converted[1]
+	if totals.TableType != models.TableTypeManaged || totals.StorageFormat != "TEXTFILE" || totals.Columns[1].Type != "DECIMAL(18,2)" {
+		t.Errorf("unexpected daily_totals table %+v", totals)
+	}
+
+	// Spark-style partitioning moves the existing column to the partition keys
+	events := converted[2]
+	if len(events.Columns) != 2 || len(events.PartitionKeys) != 1 || events.PartitionKeys[0].Type != "STRING" {
+		t.Errorf("unexpected events table %+v", events)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		sql  string
+		line int
+	}{
+		{"CREATE TABLE t (\n  id INT,\n  tags ARRAY<STRING\n);", 4},
+		{"CREATE TABLE t (id INT\nCREATE TABLE u (id INT);", 2},
+		{"CREATE TABLE t (id INT) CLUSTERED BY (id) INTO x BUCKETS;", 1},
+		{"CREATE TABLE t (id INT COMMENT 'unterminated);", 1},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.sql)
+		var syntaxErr *sqlparser.SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("Parse(%q): expected a SyntaxError, got %v", tt.sql, err)
+			continue
+		}
+		if syntaxErr.Line != tt.line {
+			t.Errorf("Parse(%q): expected an error on line %d, got %v", tt.sql, tt.line, err)
+		}
+	}
+}
+
+// TestTablesHiveMetadata covers the Hive table metadata first read by the
+// populate_tables importer: clause keywords inside comments, input format
+// classes and MySQL table options that must not mark a table as Hive
+func TestTablesHiveMetadata(t *testing.T) {
+	statements, err := Parse(`
+CREATE EXTERNAL TABLE IF NOT EXISTS dw.user_events (
+    user_id BIGINT COMMENT 'id; STORED AS TEXTFILE',
+    attributes MAP<STRING, INT> COMMENT 'key, value; pairs'
+)
+COMMENT '用户行为 (LOCATION-free comment)'
+STORED AS ORC
+LOCATION 'hdfs://warehouse/dw/user_events';
+
+CREATE TABLE daily_totals (dt STRING, total DECIMAL(18,2))
+STORED AS INPUTFORMAT 'org.apache.hadoop.hive.ql.io.parquet.MapredParquetInputFormat'
+OUTPUTFORMAT 'org.apache.hadoop.hive.ql.io.parquet.MapredParquetOutputFormat';
+
+CREATE TABLE accounts (id BIGINT) ENGINE=InnoDB COMMENT='账户表';
+`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	tables := Tables(statements)
+	if len(tables) != 3 {
+		t.Fatalf("expected 3 tables, got %d", len(tables))
+	}
+
+	events := tables[0]
+	if events.Description != "用户行为 (LOCATION-free comment)" || events.TableType != models.TableTypeExternal || events.StorageFormat != "ORC" || events.Location != "hdfs://warehouse/dw/user_events" {
+		t.Errorf("unexpected user_events table %+v", events)
+	}
+	if events.Columns[0].Description != "id; STORED AS TEXTFILE" || events.Columns[1].Description != "key, value; pairs" {
+		t.Errorf("unexpected column comments %+v", events.Columns)
+	}
+
+	if totals := tables[1]; totals.TableType != models.TableTypeManaged || totals.StorageFormat != "PARQUET" || totals.Description != "" {
+		t.Errorf("unexpected daily_totals table %+v", totals)
+	}
+
+	if accounts := tables[2]; accounts.Description != "账户表" || accounts.TableType != "" || accounts.StorageFormat != "" {
+		t.Errorf("unexpected accounts table %+v", accounts)
+	}
+}
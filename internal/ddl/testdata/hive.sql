@@ -0,0 +1,27 @@
+-- Hive fixture
+CREATE DATABASE IF NOT EXISTS dw;
+
+CREATE EXTERNAL TABLE IF NOT EXISTS dw.page_views (
+  user_id BIGINT COMMENT 'viewer',
+  url STRING,
+  tags array<string>,
+  props MAP<STRING, ARRAY<INT>> COMMENT 'key, value',
+  device struct<os:string, `version`:int, geo:struct<lat:double,lng:double>>
+)
+COMMENT 'Page views'
+PARTITIONED BY (dt STRING COMMENT 'date partition', hour INT)
+CLUSTERED BY (user_id) SORTED BY (user_id ASC, url DESC) INTO 32 BUCKETS
+ROW FORMAT SERDE 'org.apache.hadoop.hive.ql.io.orc.OrcSerde'
+WITH SERDEPROPERTIES ('serialization.format' = '1')
+STORED AS ORC
+LOCATION 'hdfs:///warehouse/dw/page_views'
+TBLPROPERTIES ('orc.compress'='SNAPPY', 'comment'='LOCATION is not a clause here');
+
+CREATE TABLE daily_totals (dt STRING, total DECIMAL(18,2))
+ROW FORMAT DELIMITED FIELDS TERMINATED BY '\t' LINES TERMINATED BY '\n'
+STORED AS INPUTFORMAT 'org.apache.hadoop.mapred.TextInputFormat'
+OUTPUTFORMAT 'org.apache.hadoop.hive.ql.io.HiveIgnoreKeyTextOutputFormat';
+
+CREATE TABLE events (id BIGINT, payload STRING, dt STRING)
+USING parquet
+PARTITIONED BY (dt);
@@ -0,0 +1,31 @@
+-- MySQL dump fixture
+/*!40101 SET NAMES utf8mb4 */;
+DROP TABLE IF EXISTS `orders`;
+
+CREATE TABLE `users` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '用户ID',
+  `name` varchar(100) NOT NULL DEFAULT '' COMMENT 'name, (display)',
+  `email` varchar(200) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin DEFAULT NULL,
+  `status` enum('active','Disabled') NOT NULL DEFAULT 'active',
+  `key` varchar(32) DEFAULT NULL,
+  `created_at` timestamp(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_email` (`email`(100)),
+  KEY `idx_name` (`name`) USING BTREE
+) ENGINE=InnoDB AUTO_INCREMENT=42 DEFAULT CHARSET=utf8mb4 COMMENT='用户表; it''s "quoted"';
+
+# Orders reference users
+CREATE TABLE IF NOT EXISTS shop.orders (
+  id BIGINT NOT NULL,
+  user_id BIGINT NOT NULL,
+  amount DECIMAL(10, 2) NOT NULL DEFAULT 0.00,
+  discount DECIMAL(5,2) DEFAULT -1,
+  flags BIT(1) DEFAULT b'0',
+  note TEXT,
+  PRIMARY KEY (id),
+  CONSTRAINT fk_orders_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE NO ACTION
+) ENGINE=InnoDB;
+
+CREATE TABLE order_archive LIKE orders;
+CREATE TABLE big_orders AS SELECT * FROM orders WHERE amount > 100;
+INSERT INTO users (name) VALUES ('CREATE TABLE fake (x INT)');
@@ -0,0 +1,31 @@
+CREATE TABLE public."Customers" (
+    id serial PRIMARY KEY,
+    "Full Name" character varying(255) NOT NULL,
+    balance numeric(12,2) DEFAULT 0 NOT NULL,
+    tags text[] DEFAULT '{}'::text[],
+    kind character varying(20) DEFAULT 'retail'::character varying,
+    signed_up timestamp(3) with time zone DEFAULT now() NOT NULL,
+    score double precision CHECK (score >= 0),
+    CONSTRAINT customers_name_key UNIQUE ("Full Name")
+);
+
+CREATE TABLE invoices (
+    id bigint GENERATED ALWAYS AS IDENTITY,
+    customer_id integer NOT NULL CONSTRAINT invoices_customer_fk REFERENCES "Customers" ON DELETE RESTRICT,
+    line integer NOT NULL,
+    total numeric NULL,
+    CONSTRAINT invoices_pkey PRIMARY KEY (id, line)
+) WITH (fillfactor = 70);
+
+CREATE TABLE invoice_notes (
+    invoice_id bigint NOT NULL,
+    line integer NOT NULL,
+    body text,
+    PRIMARY KEY (invoice_id, line),
+    FOREIGN KEY (invoice_id, line) REFERENCES invoices (id, line) DEFERRABLE INITIALLY DEFERRED
+);
+
+CREATE INDEX invoices_customer_idx ON invoices (customer_id);
+COMMENT ON TABLE public."Customers" IS 'Customer master data';
+COMMENT ON COLUMN "Customers"."Full Name" IS '客户全名';
+COMMENT ON COLUMN invoices.total IS NULL;
@@ -0,0 +1,388 @@
+package ddl
+
+import (
+	"strings"
+)
+
+// sqlType is a column type split into its parts, e.g. DECIMAL(10, 2),
+// INT UNSIGNED, ARRAY<STRING> or Postgres TEXT[]
+type sqlType struct {
+	raw string
+	// name is the upper-cased type name, e.g. DOUBLE PRECISION or
+	// TIMESTAMP WITH TIME ZONE
+	name string
+	// args is the text inside parentheses
+	args string
+	// generic is the text inside angle brackets
+	generic  string
+	unsigned bool
+	// element is the element type of a Postgres array such as TEXT[]
+	element *sqlType
+}
+
+// typeKinds groups the type names of all supported dialects
+var typeKinds = map[string]string{
+	"TINYINT": "tinyint", "INT1": "tinyint",
+	"SMALLINT": "smallint", "INT2": "smallint", "SMALLSERIAL": "smallint",
+	"MEDIUMINT": "int", "INT": "int", "INTEGER": "int", "INT4": "int", "SERIAL": "int",
+	"BIGINT": "bigint", "INT8": "bigint", "BIGSERIAL": "bigint",
+	"FLOAT": "float", "REAL": "float", "FLOAT4": "float",
+	"DOUBLE": "double", "DOUBLE PRECISION": "double", "FLOAT8": "double",
+	"DECIMAL": "decimal", "NUMERIC": "decimal", "DEC": "decimal", "NUMBER": "decimal",
+	"BOOLEAN": "boolean", "BOOL": "boolean",
+	"CHAR": "char", "CHARACTER": "char", "NCHAR": "char", "BPCHAR": "char",
+	"VARCHAR": "varchar", "CHARACTER VARYING": "varchar", "NVARCHAR": "varchar", "VARCHAR2": "varchar",
+	"TEXT": "text", "TINYTEXT": "text", "MEDIUMTEXT": "text", "LONGTEXT": "text", "STRING": "text", "CLOB": "text",
+	"BINARY": "binary", "VARBINARY": "binary", "BLOB": "binary", "TINYBLOB": "binary", "MEDIUMBLOB": "binary", "LONGBLOB": "binary", "BYTEA": "binary",
+	"DATE": "date",
+	"TIME": "time", "TIME WITHOUT TIME ZONE": "time", "TIME WITH TIME ZONE": "time", "TIMETZ": "time",
+	"DATETIME": "timestamp", "TIMESTAMP": "timestamp", "TIMESTAMP WITHOUT TIME ZONE": "timestamp",
+	"TIMESTAMP WITH TIME ZONE": "timestamptz", "TIMESTAMPTZ": "timestamptz",
+	"JSON": "json", "JSONB": "json",
+	"UUID": "uuid",
+	"ENUM": "enum", "SET": "enum",
+	"ARRAY": "array", "MAP": "map", "STRUCT": "struct",
+}
+
+// kind returns the type group of t, or "" for types of no known dialect
+func (t sqlType) kind() string {
+	if t.element != nil {
+		return "array"
+	}
+	return typeKinds[t.name]
+}
+
+// withArgs appends the type's arguments, if any, to name
+func (t sqlType) withArgs(name string) string {
+	if t.args == "" {
+		return name
+	}
+	return name + "(" + t.args + ")"
+}
+
+// splitType splits a column type as stored in the table model
+func splitType(raw string) sqlType {
+	raw = strings.TrimSpace(raw)
+	t := sqlType{raw: raw}
+	if strings.HasSuffix(raw, "[]") {
+		element := splitType(strings.TrimSuffix(raw, "[]"))
+		t.element = &element
+		return t
+	}
+
+	var words []string
+	rest := raw
+	for rest != "" {
+		switch rest[0] {
+		case ' ', '\t', '\n':
+			rest = rest[1:]
+		case '(', '<':
+			end := closingBracket(rest)
+			if rest[0] == '(' {
+				t.args = strings.TrimSpace(rest[1:end])
+			} else {
+				t.generic = strings.TrimSpace(rest[1:end])
+			}
+			rest = rest[end+1:]
+		default:
+			end := strings.IndexAny(rest, " \t\n(<")
+			if end < 0 {
+				end = len(rest)
+			}
+			word := strings.ToUpper(rest[:end])
+			switch word {
+			case "UNSIGNED":
+				t.unsigned = true
+			case "SIGNED", "ZEROFILL":
+			default:
+				words = append(words, word)
+			}
+			rest = rest[end:]
+		}
+	}
+	t.name = strings.Join(words, " ")
+	return t
+}
+
+// closingBracket returns the index of the bracket closing s[0], or the
+// last index of s if it is not closed
+func closingBracket(s string) int {
+	open, close := s[0], byte(')')
+	if open == '<' {
+		close = '>'
+	}
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(s) - 1
+}
+
+// splitGeneric splits the arguments of a generic type at top-level commas
+func splitGeneric(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '<', '(':
+			depth++
+		case '>', ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(s[start:]))
+}
+
+// mysqlType translates t to a MySQL type
+func mysqlType(t sqlType) string {
+	unsigned := func(name string) string {
+		if t.unsigned {
+			return name + " UNSIGNED"
+		}
+		return name
+	}
+	switch t.kind() {
+	case "tinyint":
+		return unsigned("TINYINT")
+	case "smallint":
+		return unsigned("SMALLINT")
+	case "int":
+		return unsigned("INT")
+	case "bigint":
+		return unsigned("BIGINT")
+	case "float":
+		return "FLOAT"
+	case "double":
+		return "DOUBLE"
+	case "decimal":
+		return unsigned(t.withArgs("DECIMAL"))
+	case "boolean":
+		return "BOOLEAN"
+	case "char":
+		return t.withArgs("CHAR")
+	case "varchar":
+		if t.args == "" {
+			return "TEXT"
+		}
+		return t.withArgs("VARCHAR")
+	case "text":
+		if strings.HasSuffix(t.name, "TEXT") {
+			return t.name
+		}
+		return "LONGTEXT"
+	case "binary":
+		if strings.HasSuffix(t.name, "BLOB") || (t.name == "BINARY" || t.name == "VARBINARY") && t.args != "" {
+			return t.withArgs(t.name)
+		}
+		return "LONGBLOB"
+	case "date":
+		return "DATE"
+	case "time":
+		return t.withArgs("TIME")
+	case "timestamp", "timestamptz":
+		if t.name == "TIMESTAMP" {
+			return t.withArgs("TIMESTAMP")
+		}
+		return t.withArgs("DATETIME")
+	case "json", "array", "map", "struct":
+		return "JSON"
+	case "uuid":
+		return "CHAR(36)"
+	case "enum":
+		if t.args == "" {
+			return "VARCHAR(255)"
+		}
+		return t.withArgs(t.name)
+	}
+	return t.raw
+}
+
+// postgresType translates t to a Postgres type. Unsigned integers are
+// widened to the next type that holds all their values.
+func postgresType(t sqlType) string {
+	switch t.kind() {
+	case "tinyint":
+		return "SMALLINT"
+	case "smallint":
+		if t.unsigned {
+			return "INTEGER"
+		}
+		if t.name == "SMALLSERIAL" {
+			return t.name
+		}
+		return "SMALLINT"
+	case "int":
+		if t.unsigned {
+			return "BIGINT"
+		}
+		if t.name == "SERIAL" {
+			return t.name
+		}
+		return "INTEGER"
+	case "bigint":
+		if t.unsigned {
+			return "NUMERIC(20)"
+		}
+		if t.name == "BIGSERIAL" {
+			return t.name
+		}
+		return "BIGINT"
+	case "float":
+		return "REAL"
+	case "double":
+		return "DOUBLE PRECISION"
+	case "decimal":
+		return t.withArgs("NUMERIC")
+	case "boolean":
+		return "BOOLEAN"
+	case "char":
+		return t.withArgs("CHAR")
+	case "varchar":
+		return t.withArgs("VARCHAR")
+	case "text", "enum":
+		return "TEXT"
+	case "binary":
+		return "BYTEA"
+	case "date":
+		return "DATE"
+	case "time":
+		return t.withArgs("TIME")
+	case "timestamp":
+		return t.withArgs("TIMESTAMP")
+	case "timestamptz":
+		return t.withArgs("TIMESTAMPTZ")
+	case "json":
+		if t.name == "JSONB" {
+			return "JSONB"
+		}
+		return "JSON"
+	case "uuid":
+		return "UUID"
+	case "array":
+		element := t.element
+		if element == nil {
+			e := splitType(t.generic)
+			element = &e
+		}
+		if kind := element.kind(); kind == "array" || kind == "map" || kind == "struct" {
+			return "JSONB"
+		}
+		return postgresType(*element) + "[]"
+	case "map", "struct":
+		return "JSONB"
+	}
+	return t.raw
+}
+
+// hiveType translates t to a Hive type. Unsigned integers are widened to
+// the next type that holds all their values.
+func hiveType(t sqlType) string {
+	switch t.kind() {
+	case "tinyint":
+		if t.unsigned {
+			return "SMALLINT"
+		}
+		return "TINYINT"
+	case "smallint":
+		if t.unsigned {
+			return "INT"
+		}
+		return "SMALLINT"
+	case "int":
+		if t.unsigned {
+			return "BIGINT"
+		}
+		return "INT"
+	case "bigint":
+		if t.unsigned {
+			return "DECIMAL(20,0)"
+		}
+		return "BIGINT"
+	case "float":
+		return "FLOAT"
+	case "double":
+		return "DOUBLE"
+	case "decimal":
+		return t.withArgs("DECIMAL")
+	case "boolean":
+		return "BOOLEAN"
+	case "char":
+		if t.args == "" {
+			return "STRING"
+		}
+		return t.withArgs("CHAR")
+	case "varchar":
+		if t.args == "" {
+			return "STRING"
+		}
+		return t.withArgs("VARCHAR")
+	case "binary":
+		return "BINARY"
+	case "date":
+		return "DATE"
+	case "timestamp", "timestamptz":
+		return "TIMESTAMP"
+	case "text", "time", "json", "uuid", "enum":
+		return "STRING"
+	case "array":
+		if t.element != nil {
+			return "ARRAY<" + hiveType(*t.element) + ">"
+		}
+		return "ARRAY<" + hiveType(splitType(t.generic)) + ">"
+	case "map":
+		parts := splitGeneric(t.generic)
+		for i, part := range parts {
+			parts[i] = hiveType(splitType(part))
+		}
+		return "MAP<" + strings.Join(parts, ", ") + ">"
+	case "struct":
+		parts := splitGeneric(t.generic)
+		for i, part := range parts {
+			if colon := strings.Index(part, ":"); colon > 0 {
+				parts[i] = strings.TrimSpace(part[:colon]) + ":" + hiveType(splitType(part[colon+1:]))
+			}
+		}
+		return "STRUCT<" + strings.Join(parts, ", ") + ">"
+	}
+	return t.raw
+}
+
+// sqliteType translates t to a SQLite type with the matching affinity;
+// INTEGER keeps a single-column integer primary key a rowid alias
+func sqliteType(t sqlType) string {
+	switch t.kind() {
+	case "tinyint", "smallint", "int", "bigint":
+		return "INTEGER"
+	case "float", "double":
+		return "REAL"
+	case "decimal":
+		return t.withArgs("NUMERIC")
+	case "boolean":
+		return "BOOLEAN"
+	case "binary":
+		return "BLOB"
+	case "date":
+		return "DATE"
+	case "timestamp", "timestamptz":
+		return "TIMESTAMP"
+	case "":
+		if strings.ContainsAny(t.raw, "<[") {
+			return "TEXT"
+		}
+		return t.raw
+	}
+	return "TEXT"
+}
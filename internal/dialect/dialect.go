@@ -0,0 +1,90 @@
+// Package dialect describes the SQL dialects the generator can target: the
+// prompt rules given to the model, the parser options used to read the
+// generated SQL and the constructs each dialect supports.
+package dialect
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"sql_generator/internal/sqlparser"
+)
+
+// Dialect is the name of a target SQL dialect
+type Dialect string
+
+// Supported dialects
+const (
+	Hive       Dialect = "hive"
+	MySQL      Dialect = "mysql"
+	Postgres   Dialect = "postgres"
+	Spark      Dialect = "spark"
+	Presto     Dialect = "presto"
+	ClickHouse Dialect = "clickhouse"
+)
+
+// ErrUnknownDialect is returned by Parse for unsupported dialect names
+var ErrUnknownDialect = errors.New("unknown SQL dialect")
+
+// aliases maps accepted spellings to dialects
+var aliases = map[string]Dialect{
+	"hive":       Hive,
+	"hiveql":     Hive,
+	"mysql":      MySQL,
+	"postgres":   Postgres,
+	"postgresql": Postgres,
+	"spark":      Spark,
+	"sparksql":   Spark,
+	"spark_sql":  Spark,
+	"presto":     Presto,
+	"trino":      Presto,
+	"clickhouse": ClickHouse,
+}
+
+// Supported lists the supported dialects
+func Supported() []Dialect {
+	return []Dialect{Hive, MySQL, Postgres, Spark, Presto, ClickHouse}
+}
+
+// Parse returns the dialect named name, ignoring case
+func Parse(name string) (Dialect, error) {
+	if d, ok := aliases[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return d, nil
+	}
+	names := make([]string, 0, len(Supported()))
+	for _, d := range Supported() {
+		names = append(names, string(d))
+	}
+	return "", fmt.Errorf("%w %q, expected one of %s", ErrUnknownDialect, name, strings.Join(names, ", "))
+}
+
+// DisplayName returns the name used in prompts and messages
+func (d Dialect) DisplayName() string {
+	switch d {
+	case Hive:
+		return "Hive SQL (HiveQL)"
+	case MySQL:
+		return "MySQL"
+	case Postgres:
+		return "PostgreSQL"
+	case Spark:
+		return "Spark SQL"
+	case Presto:
+		return "Presto/Trino"
+	case ClickHouse:
+		return "ClickHouse"
+	default:
+		return "standard SQL"
+	}
+}
+
+// ParserOptions returns the options for parsing SQL written in d
+func (d Dialect) ParserOptions() sqlparser.Options {
+	switch d {
+	case Postgres, Presto, ClickHouse:
+		return sqlparser.Options{DoubleQuotedIdentifiers: true}
+	default:
+		return sqlparser.Options{}
+	}
+}
@@ -0,0 +1,58 @@
+package dialect
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]Dialect{
+		"hive":       Hive,
+		"HiveQL":     Hive,
+		" MySQL ":    MySQL,
+		"postgresql": Postgres,
+		"spark":      Spark,
+		"trino":      Presto,
+		"presto":     Presto,
+		"ClickHouse": ClickHouse,
+	}
+	for name, want := range tests {
+		got, err := Parse(name)
+		if err != nil || got != want {
+			t.Errorf("Parse(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+
+	if _, err := Parse("oracle"); !errors.Is(err, ErrUnknownDialect) {
+		t.Errorf("expected ErrUnknownDialect, got %v", err)
+	}
+}
+
+func TestSupports(t *testing.T) {
+	if !Hive.Supports(LateralView) || !Spark.Supports(LateralView) {
+		t.Error("Hive and Spark should support LATERAL VIEW")
+	}
+	if Presto.Supports(LateralView) || Presto.Alternative(LateralView) == "" {
+		t.Error("Presto should reject LATERAL VIEW with an alternative")
+	}
+	if MySQL.Supports(FullJoin) || !Postgres.Supports(FullJoin) {
+		t.Error("unexpected FULL JOIN support")
+	}
+	if !Dialect("").Supports(BacktickQuotes) {
+		t.Error("an unspecified dialect should support every feature")
+	}
+}
+
+func TestEveryDialectHasPromptRules(t *testing.T) {
+	for _, d := range Supported() {
+		if _, ok := promptRules[d]; !ok {
+			t.Errorf("no prompt rules for %s", d)
+		}
+		if len(englishPromptRules[d]) != len(promptRules[d]) {
+			t.Errorf("English prompt rules of %s do not match the Chinese ones", d)
+		}
+		if d.DisplayName() == "standard SQL" {
+			t.Errorf("no display name for %s", d)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package dialect
+
+// Feature is a SQL construct that only some dialects support
+type Feature string
+
+// Dialect-specific features checked by validation
+const (
+	// LateralView is Hive/Spark "LATERAL VIEW explode(...)"
+	LateralView Feature = "LATERAL VIEW"
+	// SemiJoin is "LEFT SEMI JOIN" and "LEFT ANTI JOIN"
+	SemiJoin Feature = "LEFT SEMI/ANTI JOIN"
+	// FullJoin is "FULL [OUTER] JOIN"
+	FullJoin Feature = "FULL OUTER JOIN"
+	// DistributeBy is Hive/Spark "DISTRIBUTE BY", "SORT BY" and "CLUSTER BY"
+	DistributeBy Feature = "DISTRIBUTE/SORT/CLUSTER BY"
+	// BacktickQuotes is quoting identifiers with backticks
+	BacktickQuotes Feature = "backtick quoting"
+)
+
+// unsupported lists the features each dialect lacks, with the alternative to suggest
+var unsupported = map[Dialect]map[Feature]string{
+	Hive:  {},
+	Spark: {},
+	MySQL: {
+		LateralView:  "use JSON_TABLE or a join instead",
+		SemiJoin:     "use EXISTS or NOT EXISTS instead",
+		FullJoin:     "combine LEFT and RIGHT joins with UNION instead",
+		DistributeBy: "use ORDER BY instead",
+	},
+	Postgres: {
+		LateralView:    "use CROSS JOIN LATERAL unnest(...) instead",
+		SemiJoin:       "use EXISTS or NOT EXISTS instead",
+		DistributeBy:   "use ORDER BY instead",
+		BacktickQuotes: "quote identifiers with double quotes instead",
+	},
+	Presto: {
+		LateralView:    "use CROSS JOIN UNNEST(...) AS t(x) instead",
+		SemiJoin:       "use EXISTS or NOT EXISTS instead",
+		DistributeBy:   "use ORDER BY instead",
+		BacktickQuotes: "quote identifiers with double quotes instead",
+	},
+	ClickHouse: {
+		LateralView:  "use ARRAY JOIN or arrayJoin(...) instead",
+		DistributeBy: "use ORDER BY instead",
+	},
+}
+
+// Supports reports whether d supports feature. Unknown dialects support everything.
+func (d Dialect) Supports(feature Feature) bool {
+	_, missing := unsupported[d][feature]
+	return !missing
+}
+
+// Alternative suggests what to use in d instead of an unsupported feature
+func (d Dialect) Alternative(feature Feature) string {
+	return unsupported[d][feature]
+}
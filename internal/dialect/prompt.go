@@ -0,0 +1,91 @@
+package dialect
+
+import "sql_generator/internal/language"
+
+// promptRules 是各方言在中文提示词中追加的生成要求
+var promptRules = map[Dialect][]string{
+	Hive: {
+		"使用HiveQL语法，生成的SQL必须能在Hive中直接执行，不要使用MySQL或PostgreSQL特有的语法和函数",
+		"查询分区表时必须在WHERE子句中对分区字段（如dt、ds、pt等）添加过滤条件以实现分区裁剪，避免全表扫描",
+		"分区字段直接与常量比较（如 dt = '2024-01-01' 或 dt BETWEEN '2024-01-01' AND '2024-01-31'），不要对分区字段套用函数",
+		"展开array或map字段时使用 LATERAL VIEW explode(...) 或 posexplode(...)，需要保留数组为空的行时使用 LATERAL VIEW OUTER",
+		"判断存在性时优先使用 LEFT SEMI JOIN，日期处理使用 date_format、date_sub、datediff、from_unixtime 等Hive内置函数",
+		"标识符需要转义时使用反引号，字符串使用单引号",
+	},
+	Spark: {
+		"使用Spark SQL 3.x语法，生成的SQL必须能在Spark中直接执行",
+		"查询分区表时必须在WHERE子句中对分区字段（如dt、ds、pt等）添加过滤条件以实现分区裁剪",
+		"展开array或map字段时使用 LATERAL VIEW explode(...) 或在SELECT中使用 explode(...)",
+		"标识符需要转义时使用反引号，字符串使用单引号",
+	},
+	MySQL: {
+		"使用MySQL 8.0语法，标识符需要转义时使用反引号",
+		"MySQL不支持FULL OUTER JOIN，需要时使用LEFT JOIN与RIGHT JOIN的UNION代替",
+		"日期处理使用 DATE_FORMAT、DATE_SUB(NOW(), INTERVAL n DAY) 等MySQL函数",
+	},
+	Postgres: {
+		"使用PostgreSQL语法，标识符需要转义时使用双引号，不要使用反引号",
+		"日期处理使用 date_trunc、now() - interval '7 days'、to_char 等PostgreSQL函数",
+		"展开数组使用 unnest(...)，分页使用 LIMIT ... OFFSET ...",
+	},
+	Presto: {
+		"使用Presto/Trino语法，标识符需要转义时使用双引号，不要使用反引号",
+		"展开数组或map使用 CROSS JOIN UNNEST(...) AS t(x)，不要使用 LATERAL VIEW",
+		"日期处理使用 date_trunc、date_add('day', -7, current_date)、date_format 等函数，字符串转日期使用 date '2024-01-01'",
+	},
+	ClickHouse: {
+		"使用ClickHouse语法，日期处理使用 toDate、toStartOfMonth、toYYYYMM 等ClickHouse函数",
+		"展开数组使用 ARRAY JOIN 或 arrayJoin(...)，不要使用 LATERAL VIEW",
+		"去重计数可使用 uniq(...) 或 uniqExact(...)",
+	},
+}
+
+// englishPromptRules 是各方言在英文提示词中追加的生成要求，与 promptRules 一一对应
+var englishPromptRules = map[Dialect][]string{
+	Hive: {
+		"Use HiveQL syntax. The SQL must run in Hive as is; do not use syntax or functions specific to MySQL or PostgreSQL",
+		"When querying a partitioned table, filter its partition columns (such as dt, ds or pt) in the WHERE clause so that partitions are pruned instead of scanning the whole table",
+		"Compare partition columns directly with constants (e.g. dt = '2024-01-01' or dt BETWEEN '2024-01-01' AND '2024-01-31'); do not wrap them in functions",
+		"Expand array or map columns with LATERAL VIEW explode(...) or posexplode(...); use LATERAL VIEW OUTER to keep rows whose array is empty",
+		"Prefer LEFT SEMI JOIN for existence checks, and use Hive built-in functions such as date_format, date_sub, datediff and from_unixtime for dates",
+		"Quote identifiers with backticks when needed and strings with single quotes",
+	},
+	Spark: {
+		"Use Spark SQL 3.x syntax. The SQL must run in Spark as is",
+		"When querying a partitioned table, filter its partition columns (such as dt, ds or pt) in the WHERE clause so that partitions are pruned",
+		"Expand array or map columns with LATERAL VIEW explode(...) or explode(...) in the SELECT list",
+		"Quote identifiers with backticks when needed and strings with single quotes",
+	},
+	MySQL: {
+		"Use MySQL 8.0 syntax and quote identifiers with backticks when needed",
+		"MySQL does not support FULL OUTER JOIN; use a UNION of a LEFT JOIN and a RIGHT JOIN instead",
+		"Use MySQL functions such as DATE_FORMAT and DATE_SUB(NOW(), INTERVAL n DAY) for dates",
+	},
+	Postgres: {
+		"Use PostgreSQL syntax and quote identifiers with double quotes, never backticks",
+		"Use PostgreSQL functions such as date_trunc, now() - interval '7 days' and to_char for dates",
+		"Expand arrays with unnest(...) and paginate with LIMIT ... OFFSET ...",
+	},
+	Presto: {
+		"Use Presto/Trino syntax and quote identifiers with double quotes, never backticks",
+		"Expand arrays or maps with CROSS JOIN UNNEST(...) AS t(x), not LATERAL VIEW",
+		"Use functions such as date_trunc, date_add('day', -7, current_date) and date_format for dates, and date '2024-01-01' to convert strings to dates",
+	},
+	ClickHouse: {
+		"Use ClickHouse syntax and ClickHouse functions such as toDate, toStartOfMonth and toYYYYMM for dates",
+		"Expand arrays with ARRAY JOIN or arrayJoin(...), not LATERAL VIEW",
+		"Use uniq(...) or uniqExact(...) for distinct counts",
+	},
+}
+
+// PromptRules 返回该方言在指定语言的提示词中追加的生成要求，未知方言要求使用标准SQL
+func (d Dialect) PromptRules(lang language.Language) []string {
+	rules, fallback := promptRules, "使用标准SQL语法"
+	if lang == language.English {
+		rules, fallback = englishPromptRules, "Use standard SQL syntax"
+	}
+	if r, ok := rules[d]; ok {
+		return r
+	}
+	return []string{fallback}
+}
@@ -1,12 +1,29 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"sql_generator/internal/datasource"
+	"sql_generator/internal/ddl"
+	"sql_generator/internal/dialect"
+	"sql_generator/internal/importer"
+	"sql_generator/internal/language"
 	"sql_generator/internal/llm"
 	"sql_generator/internal/models"
+	"sql_generator/internal/policy"
+	"sql_generator/internal/prompt"
+	"sql_generator/internal/rag"
+	"sql_generator/internal/schemagraph"
+	"sql_generator/internal/sensitivity"
 	"sql_generator/internal/storage"
+	"sql_generator/internal/validation"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -16,13 +33,36 @@ import (
 type Handler struct {
 	store storage.Store
 	llm   llm.Client
+	// dialect is used for generation requests that do not specify one
+	dialect dialect.Dialect
+	graph   *schemagraph.Graph
+	// datasources are the sandbox databases generated queries can be executed against
+	datasources *datasource.Registry
+	// policy decides which generated statements may be saved and returned
+	policy *policy.Policy
+	// sensitivity resolves the role of a request for the sensitive column rules
+	sensitivity *sensitivity.Policy
+	// templates select the prompt template of each generation request
+	templates *prompt.Templates
+	// prompts renders template previews with the budget of the model
+	prompts *prompt.Builder
+	// memory finds past queries whose descriptions are similar to a question
+	memory *rag.QueryMemory
 }
 
 // NewHandler creates a new Handler
-func NewHandler(store storage.Store, llmClient llm.Client) *Handler {
+func NewHandler(store storage.Store, llmClient llm.Client, defaultDialect dialect.Dialect, graph *schemagraph.Graph, datasources *datasource.Registry, sqlPolicy *policy.Policy, sensitivityPolicy *sensitivity.Policy, templates *prompt.Templates, prompts *prompt.Builder, memory *rag.QueryMemory) *Handler {
 	return &Handler{
-		store: store,
-		llm:   llmClient,
+		store:       store,
+		llm:         llmClient,
+		dialect:     defaultDialect,
+		graph:       graph,
+		datasources: datasources,
+		policy:      sqlPolicy,
+		sensitivity: sensitivityPolicy,
+		templates:   templates,
+		prompts:     prompts,
+		memory:      memory,
 	}
 }
 
@@ -37,9 +77,13 @@ func (h *Handler) RegisterRoutes(router *gin.Engine) {
 		tables.POST("", h.CreateTable)
 		tables.GET("", h.ListTables)
 		tables.GET("/:name", h.GetTable)
+		tables.GET("/:name/ddl", h.GetTableDDL)
 		tables.PUT("/:name", h.UpdateTable)
+		tables.PATCH("/:name", h.SetColumnSensitivity)
 		tables.DELETE("/:name", h.DeleteTable)
 		tables.GET("/search/:keyword", h.SearchTables)
+		tables.GET("/graph/path", h.GetJoinPath)
+		tables.POST("/import", h.ImportTables)
 	}
 
 	// Query routes
@@ -47,7 +91,20 @@ func (h *Handler) RegisterRoutes(router *gin.Engine) {
 	{
 		queries.POST("/generate", h.GenerateQuery)
 		queries.GET("", h.ListQueries)
+		queries.GET("/similar", h.SimilarQueries)
 		queries.GET("/:id", h.GetQuery)
+		queries.POST("/:id/execute", h.ExecuteQuery)
+		queries.POST("/:id/explain", h.ExplainQuery)
+		queries.PUT("/:id/feedback", h.SetQueryFeedback)
+	}
+
+	// Prompt template routes
+	templates := router.Group("/prompt-templates")
+	{
+		templates.GET("", h.ListPromptTemplates)
+		templates.POST("", h.CreatePromptTemplate)
+		templates.POST("/preview", h.PreviewPrompt)
+		templates.POST("/:name/versions/:version/activate", h.ActivatePromptTemplate)
 	}
 }
 
@@ -84,7 +141,16 @@ func (h *Handler) CreateTable(c *gin.Context) {
 		return
 	}
 
-	if err := h.store.CreateTable(&table); err != nil {
+	if err := validateRelationships(&table); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateSensitivity(&table); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.store.CreateTable(c.Request.Context(), &table); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -105,7 +171,7 @@ func (h *Handler) CreateTable(c *gin.Context) {
 func (h *Handler) GetTable(c *gin.Context) {
 	name := c.Param("name")
 
-	table, err := h.store.GetTableByName(name)
+	table, err := h.store.GetTableByName(c.Request.Context(), name)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -114,6 +180,49 @@ func (h *Handler) GetTable(c *gin.Context) {
 	c.JSON(http.StatusOK, table)
 }
 
+// GetTableDDL godoc
+// @Summary Export a table as DDL
+// @Description Render a stored table definition as a CREATE TABLE statement for MySQL, Postgres, Hive, Spark or SQLite
+// @Tags tables
+// @Produce json
+// @Param name path string true "Table name"
+// @Param dialect query string false "Target dialect (default: the server's default dialect)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tables/{name}/ddl [get]
+func (h *Handler) GetTableDDL(c *gin.Context) {
+	name := c.Param("name")
+
+	d := h.dialect
+	if value := c.Query("dialect"); value != "" {
+		parsed, err := ddl.ParseDialect(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		d = parsed
+	}
+
+	table, err := h.store.GetTableByName(c.Request.Context(), name)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	text, err := ddl.Generate(table, d)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"table":   table.Name,
+		"dialect": d,
+		"ddl":     text,
+	})
+}
+
 // ListTables godoc
 // @Summary List all tables
 // @Description Get all table definitions with pagination
@@ -128,7 +237,7 @@ func (h *Handler) ListTables(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 
-	tables, err := h.store.ListTables(limit, offset)
+	tables, err := h.store.ListTables(c.Request.Context(), limit, offset)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -153,7 +262,7 @@ func (h *Handler) SearchTables(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 
-	tables, err := h.store.SearchTables(keyword, limit, offset)
+	tables, err := h.store.SearchTables(c.Request.Context(), keyword, limit, offset)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -162,6 +271,43 @@ func (h *Handler) SearchTables(c *gin.Context) {
 	c.JSON(http.StatusOK, tables)
 }
 
+// GetJoinPath godoc
+// @Summary Find a join path between two tables
+// @Description Return the cheapest chain of joins between two tables, using declared relationships and xxx_id naming conventions
+// @Tags tables
+// @Produce json
+// @Param from query string true "Source table"
+// @Param to query string true "Target table"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tables/graph/path [get]
+func (h *Handler) GetJoinPath(c *gin.Context) {
+	from, to := c.Query("from"), c.Query("to")
+	if from == "" || to == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "both from and to are required"})
+		return
+	}
+
+	path, err := h.graph.ShortestPath(from, to)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	tables := []string{from}
+	for _, edge := range path {
+		tables = append(tables, edge.To)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":   from,
+		"to":     to,
+		"tables": tables,
+		"edges":  path,
+	})
+}
+
 // UpdateTable godoc
 // @Summary Update a table
 // @Description Update a table definition by name
@@ -187,7 +333,16 @@ func (h *Handler) UpdateTable(c *gin.Context) {
 	// Ensure the name in the URL matches the name in the body
 	table.Name = name
 
-	if err := h.store.UpdateTable(name, &table); err != nil {
+	if err := validateRelationships(&table); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateSensitivity(&table); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.store.UpdateTable(c.Request.Context(), name, &table); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -195,6 +350,153 @@ func (h *Handler) UpdateTable(c *gin.Context) {
 	c.JSON(http.StatusOK, table)
 }
 
+// validateRelationships checks that every relationship pairs up existing
+// columns of the table with the same number of target columns
+func validateRelationships(table *models.Table) error {
+	columns := make(map[string]bool)
+	for _, column := range table.AllColumns() {
+		columns[strings.ToLower(column.Name)] = true
+	}
+
+	for _, rel := range table.Relationships {
+		if len(rel.Columns) != len(rel.ToColumns) {
+			return fmt.Errorf("relationship to %s has %d columns but %d target columns", rel.ToTable, len(rel.Columns), len(rel.ToColumns))
+		}
+		for _, name := range rel.Columns {
+			if !columns[strings.ToLower(name)] {
+				return fmt.Errorf("relationship to %s uses unknown column %q", rel.ToTable, name)
+			}
+		}
+	}
+	return nil
+}
+
+// validateSensitivity checks the sensitivity tags of the table's columns
+func validateSensitivity(table *models.Table) error {
+	for _, column := range table.AllColumns() {
+		if !sensitivity.ValidTag(column.Sensitivity) {
+			return fmt.Errorf("column %s has unknown sensitivity %q, expected one of %s",
+				column.Name, column.Sensitivity, strings.Join(sensitivity.Tags(), ", "))
+		}
+	}
+	return nil
+}
+
+// SetColumnSensitivity godoc
+// @Summary Tag sensitive columns
+// @Description Set or clear the sensitivity tags (pii, financial, secret) of table columns
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Param name path string true "Table name"
+// @Param request body models.SensitivityRequest true "Sensitivity tags by column name"
+// @Success 200 {object} models.Table
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /tables/{name} [patch]
+func (h *Handler) SetColumnSensitivity(c *gin.Context) {
+	name := c.Param("name")
+	var req models.SensitivityRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	table, err := h.store.GetTableByName(ctx, name)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	tags := make(map[string]string, len(req.Sensitivity))
+	for column, tag := range req.Sensitivity {
+		tags[strings.ToLower(column)] = tag
+	}
+	tag := func(columns []models.Column) {
+		for i := range columns {
+			key := strings.ToLower(columns[i].Name)
+			if value, ok := tags[key]; ok {
+				columns[i].Sensitivity = value
+				delete(tags, key)
+			}
+		}
+	}
+	tag(table.Columns)
+	tag(table.PartitionKeys)
+	for column := range tags {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("column %q does not exist in table %s", column, table.Name)})
+		return
+	}
+
+	if err := validateSensitivity(table); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.store.UpdateTable(ctx, table.Name, table); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, table)
+}
+
+// ImportTables godoc
+// @Summary Import tables from a datasource
+// @Description Read table definitions from the catalog of a configured MySQL, Postgres or SQLite datasource and create or update them
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Param request body models.ImportRequest false "Datasource, schema and tables to import"
+// @Success 200 {object} importer.Summary
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /tables/import [post]
+func (h *Handler) ImportTables(c *gin.Context) {
+	var req models.ImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ds, err := h.datasources.Get(req.Datasource)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, datasource.ErrNoDatasources) {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	tables, err := importer.Import(ctx, ds.DB(), ds.Driver, importer.Options{Schema: req.Schema, Tables: req.Tables})
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, importer.ErrUnsupportedDriver):
+			status = http.StatusBadRequest
+		case errors.Is(err, storage.ErrTableNotFound):
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	summary, err := importer.Save(ctx, h.store, tables)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
 // DeleteTable godoc
 // @Summary Delete a table
 // @Description Remove a table definition by name
@@ -207,7 +509,7 @@ func (h *Handler) UpdateTable(c *gin.Context) {
 func (h *Handler) DeleteTable(c *gin.Context) {
 	name := c.Param("name")
 
-	if err := h.store.DeleteTable(name); err != nil {
+	if err := h.store.DeleteTable(c.Request.Context(), name); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -223,7 +525,11 @@ func (h *Handler) DeleteTable(c *gin.Context) {
 // @Produce json
 // @Param request body models.QueryRequest true "Query description"
 // @Success 201 {object} models.Query
+// @Param X-User-Role header string false "Caller role selecting the sensitive column rules, used when SENSITIVITY_TRUST_ROLE_HEADER is set"
+// @Param X-Tenant-ID header string false "Tenant selecting its active prompt template"
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
 // @Failure 500 {object} map[string]string
 // @Router /queries/generate [post]
 func (h *Handler) GenerateQuery(c *gin.Context) {
@@ -234,16 +540,49 @@ func (h *Handler) GenerateQuery(c *gin.Context) {
 		return
 	}
 
+	sqlDialect := h.dialect
+	if req.Dialect != "" {
+		d, err := dialect.Parse(req.Dialect)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		sqlDialect = d
+	}
+
+	if req.AllowDDL && !h.policy.DDLOptInEnabled() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "allow_ddl is disabled by the statement policy"})
+		return
+	}
+
+	lang, err := requestLanguage(req.Language, req.Description)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Cancel the downstream LLM and storage calls when the client goes away
+	ctx := c.Request.Context()
+
+	template, err := h.templates.Select(ctx, req.Template, req.TemplateVersion, sqlDialect, c.GetHeader("X-Tenant-ID"), lang)
+	if err != nil {
+		if errors.Is(err, prompt.ErrTemplateNotFound) || errors.Is(err, prompt.ErrInvalidTemplate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Get relevant tables
 	var tables []*models.Table
-	var err error
 
 	if len(req.TableNames) > 0 {
 		// If table names are specified, get those tables
-		tables, err = h.getSpecifiedTables(req.TableNames)
+		tables, err = h.getSpecifiedTables(ctx, req.TableNames)
 	} else {
 		// Otherwise, search for relevant tables based on description
-		tables, err = h.store.SearchTables(req.Description, 20, 0)
+		tables, err = h.store.SearchTables(ctx, req.Description, 20, 0)
 	}
 
 	if err != nil {
@@ -252,20 +591,73 @@ func (h *Handler) GenerateQuery(c *gin.Context) {
 	}
 
 	// Generate SQL using LLM
-	sql, err := h.llm.GenerateSQL(req.Description, tables)
+	result, err := h.llm.GenerateSQL(ctx, &llm.Request{
+		Description: req.Description,
+		Tables:      tables,
+		Dialect:     sqlDialect,
+		AllowDDL:    req.AllowDDL,
+		Role:        h.sensitivity.RequestRole(c.GetHeader("X-User-Role")),
+		Language:    lang,
+		Template:    template,
+	})
 	if err != nil {
+		var noSQL *llm.NoSQLError
+		var sensitive *sensitivity.ViolationError
+		switch {
+		case errors.As(err, &noSQL):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		case errors.As(err, &sensitive):
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":      err.Error(),
+				"code":       "sensitive_column",
+				"violations": sensitive.Violations,
+			})
+			return
+		case errors.Is(err, sensitivity.ErrUnknownRole):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Refuse to save or return statements the policy does not allow
+	statements := llm.ExtractStatements(result.SQL)
+	err = h.policy.CheckStatements(statements, sqlDialect.ParserOptions(), policy.Options{AllowDDL: req.AllowDDL})
+	if err != nil {
+		var violation *policy.ViolationError
+		if errors.As(err, &violation) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":      err.Error(),
+				"code":       "policy_violation",
+				"violations": violation.Violations,
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Save the query
 	query := &models.Query{
-		ID:          uuid.New().String(),
-		Description: req.Description,
-		SQL:         sql,
+		ID:                    uuid.New().String(),
+		Description:           req.Description,
+		SQL:                   result.SQL,
+		Dialect:               string(sqlDialect),
+		Language:              string(lang),
+		Explanation:           result.Explanation,
+		TablesUsed:            result.TablesUsed,
+		Assumptions:           result.Assumptions,
+		Confidence:            result.Confidence,
+		Validation:            result.Validation,
+		Attempts:              result.Attempts,
+		PromptTruncation:      result.PromptTruncation,
+		PromptTemplate:        template.Name,
+		PromptTemplateVersion: template.Version,
 	}
 
-	if err := h.store.CreateQuery(query); err != nil {
+	if err := h.store.CreateQuery(ctx, query); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -273,12 +665,21 @@ func (h *Handler) GenerateQuery(c *gin.Context) {
 	c.JSON(http.StatusCreated, query)
 }
 
+// requestLanguage returns the language named by a request, or else the
+// language its description is written in
+func requestLanguage(name, description string) (language.Language, error) {
+	if name != "" {
+		return language.Parse(name)
+	}
+	return language.Detect(description), nil
+}
+
 // getSpecifiedTables gets tables by their names
-func (h *Handler) getSpecifiedTables(tableNames []string) ([]*models.Table, error) {
+func (h *Handler) getSpecifiedTables(ctx context.Context, tableNames []string) ([]*models.Table, error) {
 	var tables []*models.Table
 
 	for _, name := range tableNames {
-		table, err := h.store.GetTableByName(name)
+		table, err := h.store.GetTableByName(ctx, name)
 		if err != nil {
 			return nil, err
 		}
@@ -301,7 +702,7 @@ func (h *Handler) getSpecifiedTables(tableNames []string) ([]*models.Table, erro
 func (h *Handler) GetQuery(c *gin.Context) {
 	id := c.Param("id")
 
-	query, err := h.store.GetQueryByID(id)
+	query, err := h.store.GetQueryByID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -310,6 +711,341 @@ func (h *Handler) GetQuery(c *gin.Context) {
 	c.JSON(http.StatusOK, query)
 }
 
+// ExecuteQuery godoc
+// @Summary Execute a generated query
+// @Description Run a stored query read-only against a sandbox datasource and record the outcome on the query
+// @Tags queries
+// @Accept json
+// @Produce json
+// @Param id path string true "Query ID"
+// @Param request body models.ExecuteRequest false "Datasource and row limit"
+// @Param X-User-Role header string false "Caller role selecting the sensitive column rules, used when SENSITIVITY_TRUST_ROLE_HEADER is set"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]interface{}
+// @Failure 503 {object} map[string]string
+// @Failure 504 {object} map[string]interface{}
+// @Router /queries/{id}/execute [post]
+func (h *Handler) ExecuteQuery(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.ExecuteRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	ds, query, ok := h.queryAndDatasource(c, id, req.Datasource)
+	if !ok {
+		return
+	}
+	sql, ok := h.executableSQL(c, query)
+	if !ok {
+		return
+	}
+
+	execution := &models.QueryExecution{
+		Datasource: ds.Name,
+		ExecutedAt: time.Now(),
+	}
+	start := time.Now()
+	result, execErr := ds.Execute(ctx, sql, h.datasources.Options(req.MaxRows))
+	execution.DurationMs = time.Since(start).Milliseconds()
+
+	switch {
+	case execErr == nil:
+		execution.Status = models.ExecutionSucceeded
+		execution.RowCount = len(result.Rows)
+		execution.Truncated = result.Truncated
+	case errors.Is(execErr, datasource.ErrTimeout):
+		execution.Status = models.ExecutionTimedOut
+		execution.Error = execErr.Error()
+	default:
+		execution.Status = models.ExecutionFailed
+		execution.Error = execErr.Error()
+	}
+
+	if err := h.store.UpdateQueryExecution(ctx, id, execution); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch execution.Status {
+	case models.ExecutionTimedOut:
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": execution.Error, "execution": execution})
+	case models.ExecutionFailed:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": execution.Error, "execution": execution})
+	default:
+		c.JSON(http.StatusOK, gin.H{
+			"execution": execution,
+			"columns":   result.Columns,
+			"rows":      result.Rows,
+		})
+	}
+}
+
+// ExplainQuery godoc
+// @Summary Explain a generated query
+// @Description Run EXPLAIN for a stored query against a sandbox datasource, parse the plan and record it with its warnings on the query
+// @Tags queries
+// @Accept json
+// @Produce json
+// @Param id path string true "Query ID"
+// @Param request body models.ExplainRequest false "Datasource"
+// @Param X-User-Role header string false "Caller role selecting the sensitive column rules, used when SENSITIVITY_TRUST_ROLE_HEADER is set"
+// @Success 200 {object} models.QueryPlan
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Failure 504 {object} map[string]string
+// @Router /queries/{id}/explain [post]
+func (h *Handler) ExplainQuery(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.ExplainRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	ds, query, ok := h.queryAndDatasource(c, id, req.Datasource)
+	if !ok {
+		return
+	}
+	sql, ok := h.executableSQL(c, query)
+	if !ok {
+		return
+	}
+
+	plan, err := ds.Explain(ctx, sql, h.datasources.Options(0).Timeout)
+	if err != nil {
+		switch {
+		case errors.Is(err, datasource.ErrExplainUnsupported):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, datasource.ErrTimeout):
+			c.JSON(http.StatusGatewayTimeout, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	// The sandbox may not be partitioned like the warehouse, so partition
+	// filters are checked against the stored table definitions instead
+	warnings, err := h.partitionWarnings(ctx, query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	plan.Warnings = append(plan.Warnings, warnings...)
+	plan.ExplainedAt = time.Now()
+
+	if err := h.store.UpdateQueryPlan(ctx, id, plan); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// SetQueryFeedback godoc
+// @Summary Record feedback on a generated query
+// @Description Mark whether a stored query answers its question; correct queries are used as few-shot examples for similar questions
+// @Tags queries
+// @Accept json
+// @Produce json
+// @Param id path string true "Query ID"
+// @Param request body models.FeedbackRequest true "Feedback"
+// @Success 200 {object} models.QueryFeedback
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /queries/{id}/feedback [put]
+func (h *Handler) SetQueryFeedback(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.FeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	feedback := &models.QueryFeedback{
+		Correct:   *req.Correct,
+		Comment:   req.Comment,
+		UpdatedAt: time.Now(),
+	}
+	if err := h.store.UpdateQueryFeedback(c.Request.Context(), id, feedback); err != nil {
+		if errors.Is(err, storage.ErrQueryNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, feedback)
+}
+
+// queryAndDatasource resolves the datasource and loads the query of an
+// execute or explain request, writing the error response when either fails
+func (h *Handler) queryAndDatasource(c *gin.Context, id, name string) (*datasource.Datasource, *models.Query, bool) {
+	ds, err := h.datasources.Get(name)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, datasource.ErrNoDatasources) {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+
+	query, err := h.store.GetQueryByID(c.Request.Context(), id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, storage.ErrQueryNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+
+	return ds, query, true
+}
+
+// executableSQL checks the SQL of a stored query before it is run against a
+// datasource. The SQL must be a single query, so that a statement saved under
+// a more permissive policy, or one that ends the read-only transaction, is
+// never executed. The sensitivity rules of the caller's role are applied as
+// for generation: masked columns are hashed in the returned SQL and excluded
+// or refused columns are rejected with 403.
+func (h *Handler) executableSQL(c *gin.Context, query *models.Query) (string, bool) {
+	// An unknown stored dialect only disables the dialect checks
+	d, _ := dialect.Parse(query.Dialect)
+
+	if err := policy.ReadOnly().Check(query.SQL, d.ParserOptions(), policy.Options{}); err != nil {
+		var violation *policy.ViolationError
+		if errors.As(err, &violation) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":      err.Error(),
+				"code":       "policy_violation",
+				"violations": violation.Violations,
+			})
+			return "", false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return "", false
+	}
+
+	rules, err := h.sensitivity.Rules(h.sensitivity.RequestRole(c.GetHeader("X-User-Role")))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", false
+	}
+	sql, err := sensitivity.Enforce(c.Request.Context(), validation.NewValidator(h.store), query.SQL, d, nil, rules)
+	if err != nil {
+		var sensitive *sensitivity.ViolationError
+		if errors.As(err, &sensitive) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":      err.Error(),
+				"code":       "sensitive_column",
+				"violations": sensitive.Violations,
+			})
+			return "", false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return "", false
+	}
+	return sql, true
+}
+
+// partitionWarnings reports the partitioned tables the query does not filter
+// on a partition key, according to the stored table definitions
+func (h *Handler) partitionWarnings(ctx context.Context, query *models.Query) ([]models.PlanWarning, error) {
+	// An unknown stored dialect only disables the dialect checks
+	d, _ := dialect.Parse(query.Dialect)
+
+	report, err := validation.NewValidator(h.store).Validate(ctx, query.SQL, d, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []models.PlanWarning
+	for _, issue := range report.Issues {
+		if issue.Code == validation.CodePartitionFilterMissing {
+			warnings = append(warnings, models.PlanWarning{
+				Code:    models.PlanWarningPartitionFilterMissing,
+				Table:   issue.Table,
+				Message: issue.Message,
+			})
+		}
+	}
+	return warnings, nil
+}
+
+// SimilarQueries godoc
+// @Summary Find similar past queries
+// @Description Find the stored queries whose descriptions are most similar to a question by embedding cosine similarity
+// @Tags queries
+// @Produce json
+// @Param q query string true "Question"
+// @Param limit query int false "Limit (default: 5, max: 50)"
+// @Param dialect query string false "Only queries of this dialect"
+// @Param correct query bool false "Only queries marked correct"
+// @Success 200 {array} models.SimilarQuery
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /queries/similar [get]
+func (h *Handler) SimilarQueries(c *gin.Context) {
+	question := strings.TrimSpace(c.Query("q"))
+	if question == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if limit <= 0 {
+		limit = 5
+	}
+	if limit > 50 {
+		limit = 50
+	}
+
+	var filter rag.QueryFilter
+	if name := c.Query("dialect"); name != "" {
+		d, err := dialect.Parse(name)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		filter.Dialect = string(d)
+	}
+	if correct := c.Query("correct"); correct != "" {
+		correctOnly, err := strconv.ParseBool(correct)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "correct must be true or false"})
+			return
+		}
+		filter.CorrectOnly = correctOnly
+	}
+
+	similar, err := h.memory.SearchSimilar(c.Request.Context(), question, limit, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, similar)
+}
+
 // ListQueries godoc
 // @Summary List all generated queries
 // @Description Get all previously generated queries with pagination
@@ -324,7 +1060,7 @@ func (h *Handler) ListQueries(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 
-	queries, err := h.store.ListQueries(limit, offset)
+	queries, err := h.store.ListQueries(c.Request.Context(), limit, offset)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -332,3 +1068,193 @@ func (h *Handler) ListQueries(c *gin.Context) {
 
 	c.JSON(http.StatusOK, queries)
 }
+
+// ListPromptTemplates godoc
+// @Summary List prompt templates
+// @Description Get the built-in and file templates and every stored template version
+// @Tags prompt-templates
+// @Produce json
+// @Success 200 {array} models.PromptTemplate
+// @Failure 500 {object} map[string]string
+// @Router /prompt-templates [get]
+func (h *Handler) ListPromptTemplates(c *gin.Context) {
+	templates, err := h.templates.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// CreatePromptTemplate godoc
+// @Summary Create a prompt template version
+// @Description Store a text/template body as the next, inactive version of its name
+// @Tags prompt-templates
+// @Accept json
+// @Produce json
+// @Param template body models.PromptTemplate true "Template name, body and scope"
+// @Success 201 {object} models.PromptTemplate
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /prompt-templates [post]
+func (h *Handler) CreatePromptTemplate(c *gin.Context) {
+	var template models.PromptTemplate
+	if err := c.ShouldBindJSON(&template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.templates.Create(c.Request.Context(), &template); err != nil {
+		if errors.Is(err, prompt.ErrInvalidTemplate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// PreviewPrompt godoc
+// @Summary Preview a prompt
+// @Description Render the prompt messages of a generation request without calling the model; few-shot examples are not selected
+// @Tags prompt-templates
+// @Accept json
+// @Produce json
+// @Param request body models.PromptPreviewRequest true "Request and template to render"
+// @Param X-Tenant-ID header string false "Tenant selecting its active prompt template"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /prompt-templates/preview [post]
+func (h *Handler) PreviewPrompt(c *gin.Context) {
+	var req models.PromptPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sqlDialect := h.dialect
+	if req.Dialect != "" {
+		d, err := dialect.Parse(req.Dialect)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		sqlDialect = d
+	}
+
+	lang, err := requestLanguage(req.Language, req.Description)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	var template *prompt.Template
+	if req.Body != "" {
+		template, err = prompt.ParseTemplate(models.PromptTemplate{Name: req.Name, Dialect: string(sqlDialect), Language: string(lang), Body: req.Body})
+	} else {
+		template, err = h.templates.Select(ctx, req.Name, req.Version, sqlDialect, c.GetHeader("X-Tenant-ID"), lang)
+	}
+	if err != nil {
+		if errors.Is(err, prompt.ErrTemplateNotFound) || errors.Is(err, prompt.ErrInvalidTemplate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var tables []*models.Table
+	if len(req.TableNames) > 0 {
+		tables, err = h.getSpecifiedTables(ctx, req.TableNames)
+	} else {
+		tables, err = h.store.SearchTables(ctx, req.Description, 20, 0)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	in := &prompt.Input{
+		Description: req.Description,
+		Tables:      tables,
+		Dialect:     sqlDialect,
+		AllowDDL:    req.AllowDDL,
+		Language:    lang,
+		Template:    template,
+	}
+	h.planJoins(in)
+
+	p, err := h.prompts.Build(in)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"language":          lang,
+		"template":          template.Name,
+		"template_version":  template.Version,
+		"messages":          p.Messages,
+		"prompt":            p.Text(),
+		"prompt_truncation": p.Truncation,
+	})
+}
+
+// planJoins adds the join plan of the schema graph and its bridge tables to
+// a previewed prompt, as the generation pipeline does
+func (h *Handler) planJoins(in *prompt.Input) {
+	if h.graph == nil || len(in.Tables) < 2 {
+		return
+	}
+	names := make([]string, len(in.Tables))
+	for i, table := range in.Tables {
+		names[i] = table.Name
+	}
+	plan := h.graph.JoinPlan(names, schemagraph.DefaultMaxHops)
+	if len(plan.Edges) == 0 {
+		return
+	}
+	for _, name := range plan.Bridges {
+		if table := h.graph.Table(name); table != nil {
+			in.Tables = append(in.Tables, table)
+		}
+	}
+	in.JoinPlan = plan.Edges
+}
+
+// ActivatePromptTemplate godoc
+// @Summary Activate a prompt template version
+// @Description Make a stored version the template used for its tenant and dialect
+// @Tags prompt-templates
+// @Produce json
+// @Param name path string true "Template name"
+// @Param version path int true "Template version"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /prompt-templates/{name}/versions/{version}/activate [post]
+func (h *Handler) ActivatePromptTemplate(c *gin.Context) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "version must be a positive integer"})
+		return
+	}
+
+	if err := h.templates.Activate(c.Request.Context(), c.Param("name"), version); err != nil {
+		if errors.Is(err, prompt.ErrTemplateNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
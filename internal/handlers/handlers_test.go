@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sql_generator/internal/config"
+	"sql_generator/internal/datasource"
+	"sql_generator/internal/models"
+	"sql_generator/internal/sensitivity"
+	"sql_generator/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// memoryStore is a storage.Store holding tables and queries in memory;
+// other methods are not implemented
+type memoryStore struct {
+	storage.Store
+	tables  map[string]*models.Table
+	queries map[string]*models.Query
+}
+
+func (s *memoryStore) GetTableByName(ctx context.Context, name string) (*models.Table, error) {
+	table, ok := s.tables[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", storage.ErrTableNotFound, name)
+	}
+	return table, nil
+}
+
+func (s *memoryStore) GetQueryByID(ctx context.Context, id string) (*models.Query, error) {
+	query, ok := s.queries[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", storage.ErrQueryNotFound, id)
+	}
+	return query, nil
+}
+
+func (s *memoryStore) UpdateQueryExecution(ctx context.Context, id string, execution *models.QueryExecution) error {
+	query, err := s.GetQueryByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	query.Execution = execution
+	return nil
+}
+
+func (s *memoryStore) UpdateQueryPlan(ctx context.Context, id string, plan *models.QueryPlan) error {
+	query, err := s.GetQueryByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	query.Plan = plan
+	return nil
+}
+
+func (s *memoryStore) UpdateQueryFeedback(ctx context.Context, id string, feedback *models.QueryFeedback) error {
+	query, err := s.GetQueryByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	query.Feedback = feedback
+	return nil
+}
+
+// newTestRouter serves a handler whose store describes a users table with
+// pii, financial and secret columns and whose only datasource is a SQLite
+// database holding that table. Analysts, the default role, mask pii,
+// refuse financial and exclude secret columns; admins allow all of them.
+func newTestRouter(t *testing.T, trustRoleHeader bool) (*gin.Engine, *memoryStore) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "sandbox.db"))
+	if err != nil {
+		t.Fatalf("Failed to open SQLite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	for _, stmt := range []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, email TEXT, salary INTEGER, password TEXT)",
+		"INSERT INTO users VALUES (1, 'alice', 'alice@example.com', 100, 'x'), (2, 'bob', 'bob@example.com', 200, 'y')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to prepare fixture: %v", err)
+		}
+	}
+	datasources := datasource.NewRegistry("", datasource.Options{})
+	datasources.Register(datasource.New("local", "sqlite", db))
+
+	sensitivityPolicy, err := sensitivity.New(config.SensitivityConfig{
+		DefaultRole:     "analyst",
+		TrustRoleHeader: trustRoleHeader,
+		Rules: map[string]map[string]string{
+			"analyst": {"pii": "mask", "financial": "refuse", "secret": "exclude"},
+			"admin":   {"pii": "allow", "financial": "allow", "secret": "allow"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create sensitivity policy: %v", err)
+	}
+
+	store := &memoryStore{
+		tables: map[string]*models.Table{
+			"users": {
+				Name: "users",
+				Columns: []models.Column{
+					{Name: "id", Type: "INTEGER", IsPrimary: true},
+					{Name: "name", Type: "TEXT"},
+					{Name: "email", Type: "TEXT", Sensitivity: "pii"},
+					{Name: "salary", Type: "INTEGER", Sensitivity: "financial"},
+					{Name: "password", Type: "TEXT", Sensitivity: "secret"},
+				},
+			},
+		},
+		queries: make(map[string]*models.Query),
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewHandler(store, nil, "", nil, datasources, nil, sensitivityPolicy, nil, nil, nil).RegisterRoutes(router)
+	return router, store
+}
+
+// do sends a request with a JSON body and the given headers and decodes the
+// JSON response
+func do(t *testing.T, router *gin.Engine, method, path, body string, headers map[string]string) (int, map[string]interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response %q: %v", w.Body.String(), err)
+	}
+	return w.Code, response
+}
+
+func TestExecuteQuery(t *testing.T) {
+	router, store := newTestRouter(t, false)
+	store.queries["q1"] = &models.Query{ID: "q1", SQL: "SELECT id, name FROM users ORDER BY id"}
+
+	code, response := do(t, router, http.MethodPost, "/queries/q1/execute", `{"max_rows": 1}`, nil)
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %v", code, response)
+	}
+	rows, _ := response["rows"].([]interface{})
+	if len(rows) != 1 {
+		t.Errorf("Expected 1 row, got %v", response["rows"])
+	}
+	execution := store.queries["q1"].Execution
+	if execution == nil || execution.Status != models.ExecutionSucceeded || execution.RowCount != 1 || !execution.Truncated || execution.Datasource != "local" {
+		t.Errorf("Unexpected recorded execution %+v", execution)
+	}
+
+	if code, _ := do(t, router, http.MethodPost, "/queries/missing/execute", "", nil); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown query, got %d", code)
+	}
+	if code, _ := do(t, router, http.MethodPost, "/queries/q1/execute", `{"datasource": "other"}`, nil); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown datasource, got %d", code)
+	}
+
+	// Failures of the statement itself are recorded on the query
+	store.queries["q2"] = &models.Query{ID: "q2", SQL: "SELECT missing FROM users"}
+	if code, _ := do(t, router, http.MethodPost, "/queries/q2/execute", "", nil); code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a failing statement, got %d", code)
+	}
+	if execution := store.queries["q2"].Execution; execution == nil || execution.Status != models.ExecutionFailed {
+		t.Errorf("Unexpected recorded execution %+v", execution)
+	}
+}
+
+func TestExecuteQueryPolicy(t *testing.T) {
+	router, store := newTestRouter(t, false)
+
+	for _, stmt := range []string{
+		"DELETE FROM users",
+		"SELECT id FROM users; DELETE FROM users",
+		"COMMIT",
+	} {
+		store.queries["q"] = &models.Query{ID: "q", SQL: stmt}
+		for _, action := range []string{"execute", "explain"} {
+			code, response := do(t, router, http.MethodPost, "/queries/q/"+action, "", nil)
+			if code != http.StatusUnprocessableEntity || response["code"] != "policy_violation" {
+				t.Errorf("%s %q: expected a 422 policy violation, got %d: %v", action, stmt, code, response)
+			}
+		}
+		if store.queries["q"].Execution != nil || store.queries["q"].Plan != nil {
+			t.Errorf("%q: expected nothing to be recorded", stmt)
+		}
+	}
+
+	store.queries["count"] = &models.Query{ID: "count", SQL: "SELECT count(*) FROM users"}
+	_, response := do(t, router, http.MethodPost, "/queries/count/execute", "", nil)
+	if rows, _ := response["rows"].([]interface{}); len(rows) != 1 || fmt.Sprint(rows[0]) != "[2]" {
+		t.Errorf("Expected the rows to be kept, got %v", response)
+	}
+}
+
+func TestExecuteQuerySensitivity(t *testing.T) {
+	router, store := newTestRouter(t, false)
+
+	for _, stmt := range []string{
+		"SELECT salary FROM users",
+		"SELECT id FROM users WHERE password = 'x'",
+		"SELECT * FROM users",
+	} {
+		store.queries["q"] = &models.Query{ID: "q", SQL: stmt}
+		for _, action := range []string{"execute", "explain"} {
+			code, response := do(t, router, http.MethodPost, "/queries/q/"+action, "", nil)
+			if code != http.StatusForbidden || response["code"] != "sensitive_column" {
+				t.Errorf("%s %q: expected a 403 sensitive column error, got %d: %v", action, stmt, code, response)
+			}
+		}
+	}
+
+	// Masked columns are hashed in the SQL that is run; SQLite has no sha2,
+	// so the rewritten statement fails where the original would succeed
+	store.queries["q"] = &models.Query{ID: "q", SQL: "SELECT email FROM users"}
+	code, response := do(t, router, http.MethodPost, "/queries/q/execute", "", nil)
+	if code != http.StatusUnprocessableEntity || !strings.Contains(fmt.Sprint(response["error"]), "sha2") {
+		t.Errorf("Expected the masked statement to be executed, got %d: %v", code, response)
+	}
+}
+
+func TestRoleHeader(t *testing.T) {
+	headers := map[string]string{"X-User-Role": "admin"}
+
+	// The header is ignored unless it is trusted
+	router, store := newTestRouter(t, false)
+	store.queries["q"] = &models.Query{ID: "q", SQL: "SELECT salary FROM users"}
+	if code, response := do(t, router, http.MethodPost, "/queries/q/execute", "", headers); code != http.StatusForbidden {
+		t.Errorf("Expected the default role to apply, got %d: %v", code, response)
+	}
+
+	router, store = newTestRouter(t, true)
+	store.queries["q"] = &models.Query{ID: "q", SQL: "SELECT salary FROM users"}
+	if code, response := do(t, router, http.MethodPost, "/queries/q/execute", "", headers); code != http.StatusOK {
+		t.Errorf("Expected the admin role to allow the query, got %d: %v", code, response)
+	}
+	if code, response := do(t, router, http.MethodPost, "/queries/q/execute", "", nil); code != http.StatusForbidden {
+		t.Errorf("Expected the default role without a header, got %d: %v", code, response)
+	}
+	if code, response := do(t, router, http.MethodPost, "/queries/q/execute", "", map[string]string{"X-User-Role": "guest"}); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown role, got %d: %v", code, response)
+	}
+}
+
+func TestExplainQuery(t *testing.T) {
+	router, store := newTestRouter(t, false)
+	store.queries["q"] = &models.Query{ID: "q", SQL: "SELECT id, name FROM users WHERE name = 'alice'"}
+
+	code, response := do(t, router, http.MethodPost, "/queries/q/explain", "", nil)
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %v", code, response)
+	}
+	plan := store.queries["q"].Plan
+	if plan == nil || plan.Datasource != "local" || len(plan.Steps) == 0 || plan.ExplainedAt.IsZero() {
+		t.Fatalf("Unexpected recorded plan %+v", plan)
+	}
+	var fullScan bool
+	for _, warning := range plan.Warnings {
+		fullScan = fullScan || warning.Code == models.PlanWarningFullScan
+	}
+	if !fullScan {
+		t.Errorf("Expected a full scan warning, got %+v", plan.Warnings)
+	}
+
+	if code, _ := do(t, router, http.MethodPost, "/queries/missing/explain", "", nil); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown query, got %d", code)
+	}
+}
+
+func TestSetQueryFeedback(t *testing.T) {
+	router, store := newTestRouter(t, false)
+	store.queries["q"] = &models.Query{ID: "q", SQL: "SELECT id FROM users"}
+
+	code, response := do(t, router, http.MethodPut, "/queries/q/feedback", `{"correct": true, "comment": "good"}`, nil)
+	if code != http.StatusOK || response["correct"] != true {
+		t.Fatalf("Expected 200, got %d: %v", code, response)
+	}
+	if feedback := store.queries["q"].Feedback; feedback == nil || !feedback.Correct || feedback.Comment != "good" {
+		t.Errorf("Unexpected recorded feedback %+v", feedback)
+	}
+
+	if code, _ := do(t, router, http.MethodPut, "/queries/q/feedback", `{"comment": "no verdict"}`, nil); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without correct, got %d", code)
+	}
+	if code, _ := do(t, router, http.MethodPut, "/queries/missing/feedback", `{"correct": false}`, nil); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown query, got %d", code)
+	}
+}
@@ -0,0 +1,263 @@
+// Package importer reads table definitions from the catalog of a live
+// database (information_schema for MySQL and Postgres, the sqlite_master
+// table and table pragmas for SQLite) and upserts them into a storage.Store.
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"sql_generator/internal/models"
+	"sql_generator/internal/storage"
+)
+
+// ErrUnsupportedDriver is returned for databases without a catalog reader
+var ErrUnsupportedDriver = errors.New("schema import is not supported for this driver")
+
+// Options select what is imported
+type Options struct {
+	// Schema is the database (MySQL) or schema (Postgres) to read. MySQL
+	// defaults to the connection's database and Postgres to "public";
+	// SQLite always reads the main database.
+	Schema string
+	// Tables limits the import to these tables; empty imports all tables and views
+	Tables []string
+}
+
+// catalogReader reads the raw catalog of one database type
+type catalogReader func(ctx context.Context, db *sql.DB, schema string) (*catalog, error)
+
+var readers = map[string]catalogReader{
+	"mysql":    readMySQL,
+	"postgres": readPostgres,
+	"sqlite":   readSQLite,
+}
+
+// catalog holds the catalog rows common to all supported databases
+type catalog struct {
+	tables      []tableInfo
+	columns     []columnInfo
+	foreignKeys []foreignKeyInfo
+}
+
+type tableInfo struct {
+	name    string
+	comment string
+}
+
+// columnInfo is a column in ordinal order
+type columnInfo struct {
+	table    string
+	name     string
+	dataType string
+	nullable bool
+	primary  bool
+	comment  string
+}
+
+// foreignKeyInfo is one column of a foreign key, in key order
+type foreignKeyInfo struct {
+	table      string
+	constraint string
+	column     string
+	refTable   string
+	refColumn  string
+}
+
+// Import reads the table definitions of db. Driver is the database/sql
+// driver name: mysql, postgres or sqlite.
+func Import(ctx context.Context, db *sql.DB, driver string, opts Options) ([]*models.Table, error) {
+	read, ok := readers[driver]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDriver, driver)
+	}
+
+	cat, err := read(ctx, db, opts.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := cat.build()
+	if len(opts.Tables) == 0 {
+		return tables, nil
+	}
+
+	wanted := make(map[string]bool)
+	for _, name := range opts.Tables {
+		wanted[strings.ToLower(name)] = true
+	}
+	var selected []*models.Table
+	for _, table := range tables {
+		if wanted[strings.ToLower(table.Name)] {
+			selected = append(selected, table)
+			delete(wanted, strings.ToLower(table.Name))
+		}
+	}
+	for name := range wanted {
+		return nil, fmt.Errorf("%w: %s", storage.ErrTableNotFound, name)
+	}
+	return selected, nil
+}
+
+// build converts the catalog rows into table definitions
+func (c *catalog) build() []*models.Table {
+	byName := make(map[string]*models.Table)
+	tables := make([]*models.Table, 0, len(c.tables))
+	for _, info := range c.tables {
+		table := &models.Table{Name: info.name, Description: info.comment, Columns: []models.Column{}}
+		byName[info.name] = table
+		tables = append(tables, table)
+	}
+
+	for _, info := range c.columns {
+		table, ok := byName[info.table]
+		if !ok {
+			continue
+		}
+		table.Columns = append(table.Columns, models.Column{
+			Name:        info.name,
+			Type:        info.dataType,
+			Description: info.comment,
+			IsPrimary:   info.primary,
+			IsRequired:  !info.nullable || info.primary,
+		})
+	}
+
+	// Key columns arrive in order, so consecutive rows of the same
+	// constraint form one relationship
+	var current *models.Relationship
+	var currentKey string
+	for _, fk := range c.foreignKeys {
+		table, ok := byName[fk.table]
+		if !ok {
+			continue
+		}
+		key := fk.table + "\x00" + fk.constraint
+		if current == nil || key != currentKey {
+			table.Relationships = append(table.Relationships, models.Relationship{ToTable: fk.refTable})
+			current = &table.Relationships[len(table.Relationships)-1]
+			currentKey = key
+		}
+		current.Columns = append(current.Columns, fk.column)
+		current.ToColumns = append(current.ToColumns, fk.refColumn)
+	}
+
+	for _, table := range tables {
+		table.SetCardinalities()
+	}
+	return tables
+}
+
+// Failure is a table that could not be saved
+type Failure struct {
+	Table string `json:"table"`
+	Error string `json:"error"`
+}
+
+// Summary reports the outcome of saving imported tables
+type Summary struct {
+	Created []string  `json:"created"`
+	Updated []string  `json:"updated"`
+	Failed  []Failure `json:"failed"`
+}
+
+// Save upserts tables into store. Existing tables keep their ID, their
+// Hive storage metadata, and the descriptions and sensitivity tags curated
+// in the store where the database has no comment. A table that fails to
+// save is reported in the summary and does not stop the others; only a
+// cancelled context aborts the import.
+func Save(ctx context.Context, store storage.Store, tables []*models.Table) (*Summary, error) {
+	summary := &Summary{Created: []string{}, Updated: []string{}, Failed: []Failure{}}
+	for _, table := range tables {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+
+		existing, err := store.GetTableByName(ctx, table.Name)
+		switch {
+		case errors.Is(err, storage.ErrTableNotFound):
+			table.ID = uuid.New().String()
+			err = store.CreateTable(ctx, table)
+			if err == nil {
+				summary.Created = append(summary.Created, table.Name)
+			}
+		case err == nil:
+			merge(existing, table)
+			err = store.UpdateTable(ctx, existing.Name, existing)
+			if err == nil {
+				summary.Updated = append(summary.Updated, table.Name)
+			}
+		}
+		if err != nil {
+			summary.Failed = append(summary.Failed, Failure{Table: table.Name, Error: err.Error()})
+		}
+	}
+	return summary, nil
+}
+
+// merge replaces the columns and relationships of existing with the
+// imported ones, keeping what the database does not know about
+func merge(existing, imported *models.Table) {
+	curated := make(map[string]models.Column)
+	for _, col := range existing.AllColumns() {
+		curated[strings.ToLower(col.Name)] = col
+	}
+	for i := range imported.Columns {
+		col := &imported.Columns[i]
+		if old, ok := curated[strings.ToLower(col.Name)]; ok {
+			if col.Description == "" {
+				col.Description = old.Description
+			}
+			col.Sensitivity = old.Sensitivity
+		}
+	}
+
+	if imported.Description != "" {
+		existing.Description = imported.Description
+	}
+	existing.Columns = imported.Columns
+	existing.Relationships = imported.Relationships
+}
+
+// scanStrings reads the rows of a catalog query whose columns are all text;
+// NULL values become empty strings
+func scanStrings(rows *sql.Rows, each func(values []string) error) error {
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		raw := make([]sql.NullString, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range raw {
+			pointers[i] = &raw[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+		values := make([]string, len(columns))
+		for i, v := range raw {
+			values[i] = v.String
+		}
+		if err := each(values); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// query runs a catalog query and passes every row to each
+func query(ctx context.Context, db *sql.DB, each func(values []string) error, q string, args ...interface{}) error {
+	rows, err := db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	return scanStrings(rows, each)
+}
@@ -0,0 +1,224 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"sql_generator/internal/models"
+	"sql_generator/internal/storage"
+)
+
+const sqliteSchema = `
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    email TEXT
+);
+CREATE TABLE orders (
+    id INTEGER PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users,
+    amount DECIMAL(10, 2)
+);
+CREATE TABLE order_items (
+    order_id INTEGER NOT NULL,
+    line INTEGER NOT NULL,
+    sku TEXT,
+    PRIMARY KEY (order_id, line),
+    FOREIGN KEY (order_id) REFERENCES orders (id)
+);
+CREATE TABLE shipments (
+    order_id INTEGER NOT NULL,
+    line INTEGER NOT NULL,
+    FOREIGN KEY (order_id, line) REFERENCES order_items (order_id, line)
+);
+CREATE VIEW big_orders AS SELECT * FROM orders WHERE amount > 100;
+`
+
+func TestImportSQLite(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "import.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+
+	tables, err := Import(context.Background(), db, "sqlite", Options{})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	var names []string
+	for _, table := range tables {
+		names = append(names, table.Name)
+	}
+	if strings.Join(names, ",") != "big_orders,order_items,orders,shipments,users" {
+		t.Fatalf("unexpected tables %v", names)
+	}
+
+	users := tables[4]
+	if len(users.Columns) != 3 || !users.Columns[0].IsPrimary || !users.Columns[1].IsRequired || users.Columns[2].IsRequired {
+		t.Errorf("unexpected users columns %+v", users.Columns)
+	}
+	if users.Columns[1].Type != "VARCHAR(100)" {
+		t.Errorf("unexpected type %q", users.Columns[1].Type)
+	}
+
+	orders := tables[2]
+	if got := describeRelationships(orders); got != "user_id->users.id many_to_one" {
+		t.Errorf("unexpected orders relationships %q", got)
+	}
+	if got := describeRelationships(tables[1]); got != "order_id->orders.id many_to_one" {
+		t.Errorf("unexpected order_items relationships %q", got)
+	}
+	if got := describeRelationships(tables[3]); got != "order_id,line->order_items.order_id,line many_to_one" {
+		t.Errorf("unexpected shipments relationships %q", got)
+	}
+
+	tables, err = Import(context.Background(), db, "sqlite", Options{Tables: []string{"USERS"}})
+	if err != nil || len(tables) != 1 || tables[0].Name != "users" {
+		t.Errorf("unexpected filtered import %v: %v", tables, err)
+	}
+	if _, err := Import(context.Background(), db, "sqlite", Options{Tables: []string{"missing"}}); !errors.Is(err, storage.ErrTableNotFound) {
+		t.Errorf("expected ErrTableNotFound, got %v", err)
+	}
+	if _, err := Import(context.Background(), db, "oracle", Options{}); !errors.Is(err, ErrUnsupportedDriver) {
+		t.Errorf("expected ErrUnsupportedDriver, got %v", err)
+	}
+}
+
+func describeRelationships(table *models.Table) string {
+	var parts []string
+	for _, rel := range table.Relationships {
+		parts = append(parts, fmt.Sprintf("%s->%s.%s %s", strings.Join(rel.Columns, ","), rel.ToTable, strings.Join(rel.ToColumns, ","), rel.Cardinality))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func TestBuild(t *testing.T) {
+	cat := &catalog{
+		tables: []tableInfo{{name: "users", comment: "用户表"}, {name: "user_profiles"}},
+		columns: []columnInfo{
+			{table: "users", name: "id", dataType: "BIGINT", primary: true},
+			{table: "users", name: "email", dataType: "VARCHAR(200)", nullable: true, comment: "邮箱"},
+			{table: "user_profiles", name: "user_id", dataType: "BIGINT", primary: true},
+			{table: "dropped", name: "x", dataType: "INT"},
+		},
+		foreignKeys: []foreignKeyInfo{
+			{table: "user_profiles", constraint: "fk_user", column: "user_id", refTable: "users", refColumn: "id"},
+		},
+	}
+
+	tables := cat.build()
+	if len(tables) != 2 || tables[0].Description != "用户表" || tables[0].Columns[1].Description != "邮箱" {
+		t.Fatalf("unexpected tables %+v", tables)
+	}
+	if !tables[0].Columns[0].IsRequired || tables[0].Columns[1].IsRequired {
+		t.Errorf("unexpected nullability %+v", tables[0].Columns)
+	}
+	if got := describeRelationships(tables[1]); got != "user_id->users.id one_to_one" {
+		t.Errorf("unexpected relationships %q", got)
+	}
+}
+
+func TestTypeRendering(t *testing.T) {
+	if got := upperType("enum('new','Paid') unsigned"); got != "ENUM('new','Paid') UNSIGNED" {
+		t.Errorf("upperType = %q", got)
+	}
+	tests := []struct {
+		args [5]string
+		want string
+	}{
+		{[5]string{"character varying", "varchar", "100", "", ""}, "VARCHAR(100)"},
+		{[5]string{"numeric", "numeric", "", "10", "2"}, "NUMERIC(10,2)"},
+		{[5]string{"ARRAY", "_int4", "", "", ""}, "INT4[]"},
+		{[5]string{"USER-DEFINED", "mood", "", "", ""}, "MOOD"},
+		{[5]string{"timestamp without time zone", "timestamp", "", "", ""}, "TIMESTAMP WITHOUT TIME ZONE"},
+	}
+	for _, tt := range tests {
+		if got := postgresType(tt.args[0], tt.args[1], tt.args[2], tt.args[3], tt.args[4]); got != tt.want {
+			t.Errorf("postgresType(%v) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+// memoryStore keeps tables in a map; other Store methods are not used
+type memoryStore struct {
+	storage.Store
+	tables map[string]*models.Table
+}
+
+func (m *memoryStore) GetTableByName(ctx context.Context, name string) (*models.Table, error) {
+	if table, ok := m.tables[name]; ok {
+		copied := *table
+		return &copied, nil
+	}
+	return nil, fmt.Errorf("%w: %s", storage.ErrTableNotFound, name)
+}
+
+func (m *memoryStore) CreateTable(ctx context.Context, table *models.Table) error {
+	if table.Name == "broken" {
+		return errors.New("insert failed")
+	}
+	m.tables[table.Name] = table
+	return nil
+}
+
+func (m *memoryStore) UpdateTable(ctx context.Context, name string, table *models.Table) error {
+	m.tables[name] = table
+	return nil
+}
+
+func TestSave(t *testing.T) {
+	store := &memoryStore{tables: map[string]*models.Table{
+		"users": {
+			ID:            "existing-id",
+			Name:          "users",
+			Description:   "curated description",
+			StorageFormat: "ORC",
+			Columns: []models.Column{
+				{Name: "id", Type: "INT"},
+				{Name: "email", Type: "TEXT", Description: "登录邮箱", Sensitivity: models.SensitivityPII},
+			},
+		},
+	}}
+
+	imported := []*models.Table{
+		{Name: "users", Columns: []models.Column{
+			{Name: "id", Type: "BIGINT", IsPrimary: true},
+			{Name: "email", Type: "VARCHAR(200)"},
+			{Name: "name", Type: "VARCHAR(100)"},
+		}},
+		{Name: "orders", Columns: []models.Column{{Name: "id", Type: "BIGINT"}}},
+		{Name: "broken"},
+	}
+
+	summary, err := Save(context.Background(), store, imported)
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if fmt.Sprint(summary.Created, summary.Updated) != "[orders] [users]" || len(summary.Failed) != 1 || summary.Failed[0].Table != "broken" {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+
+	users := store.tables["users"]
+	if users.ID != "existing-id" || users.Description != "curated description" || users.StorageFormat != "ORC" {
+		t.Errorf("existing metadata was not kept: %+v", users)
+	}
+	if len(users.Columns) != 3 || users.Columns[0].Type != "BIGINT" {
+		t.Errorf("columns were not replaced: %+v", users.Columns)
+	}
+	if email := users.Columns[1]; email.Description != "登录邮箱" || email.Sensitivity != models.SensitivityPII {
+		t.Errorf("curated column metadata was not kept: %+v", email)
+	}
+	if store.tables["orders"].ID == "" {
+		t.Error("expected new tables to get an ID")
+	}
+}
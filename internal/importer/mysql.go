@@ -0,0 +1,92 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// readMySQL reads tables, columns and foreign keys from information_schema
+func readMySQL(ctx context.Context, db *sql.DB, schema string) (*catalog, error) {
+	if schema == "" {
+		var current sql.NullString
+		if err := db.QueryRowContext(ctx, "SELECT DATABASE()").Scan(&current); err != nil {
+			return nil, fmt.Errorf("failed to read the current database: %w", err)
+		}
+		if !current.Valid {
+			return nil, errors.New("no database selected; set the schema to import")
+		}
+		schema = current.String
+	}
+
+	cat := &catalog{}
+	err := query(ctx, db, func(v []string) error {
+		cat.tables = append(cat.tables, tableInfo{name: v[0], comment: v[1]})
+		return nil
+	}, `
+		SELECT TABLE_NAME, TABLE_COMMENT
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = ? AND TABLE_TYPE IN ('BASE TABLE', 'VIEW')
+		ORDER BY TABLE_NAME`, schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tables: %w", err)
+	}
+
+	err = query(ctx, db, func(v []string) error {
+		cat.columns = append(cat.columns, columnInfo{
+			table:    v[0],
+			name:     v[1],
+			dataType: upperType(v[2]),
+			nullable: v[3] == "YES",
+			primary:  v[4] == "PRI",
+			comment:  v[5],
+		})
+		return nil
+	}, `
+		SELECT TABLE_NAME, COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_KEY, COLUMN_COMMENT
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ?
+		ORDER BY TABLE_NAME, ORDINAL_POSITION`, schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns: %w", err)
+	}
+
+	err = query(ctx, db, func(v []string) error {
+		cat.foreignKeys = append(cat.foreignKeys, foreignKeyInfo{
+			table:      v[0],
+			constraint: v[1],
+			column:     v[2],
+			refTable:   v[3],
+			refColumn:  v[4],
+		})
+		return nil
+	}, `
+		SELECT TABLE_NAME, CONSTRAINT_NAME, COLUMN_NAME, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME
+		FROM information_schema.KEY_COLUMN_USAGE
+		WHERE TABLE_SCHEMA = ? AND REFERENCED_TABLE_NAME IS NOT NULL
+		ORDER BY TABLE_NAME, CONSTRAINT_NAME, ORDINAL_POSITION`, schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read foreign keys: %w", err)
+	}
+
+	return cat, nil
+}
+
+// upperType upper-cases a column type outside quoted ENUM and SET values,
+// e.g. "enum('a','b') unsigned" becomes "ENUM('a','b') UNSIGNED"
+func upperType(t string) string {
+	var b strings.Builder
+	quoted := false
+	for _, r := range t {
+		if r == '\'' {
+			quoted = !quoted
+		}
+		if !quoted {
+			r = []rune(strings.ToUpper(string(r)))[0]
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
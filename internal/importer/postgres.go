@@ -0,0 +1,120 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// readPostgres reads tables, columns and keys from information_schema.
+// Comments are not part of information_schema and come from
+// obj_description and col_description.
+func readPostgres(ctx context.Context, db *sql.DB, schema string) (*catalog, error) {
+	if schema == "" {
+		schema = "public"
+	}
+
+	cat := &catalog{}
+	err := query(ctx, db, func(v []string) error {
+		cat.tables = append(cat.tables, tableInfo{name: v[0], comment: v[1]})
+		return nil
+	}, `
+		SELECT t.table_name,
+		       obj_description(format('%I.%I', t.table_schema, t.table_name)::regclass, 'pg_class')
+		FROM information_schema.tables t
+		WHERE t.table_schema = $1 AND t.table_type IN ('BASE TABLE', 'VIEW')
+		ORDER BY t.table_name`, schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tables: %w", err)
+	}
+
+	primary := make(map[string]bool)
+	err = query(ctx, db, func(v []string) error {
+		primary[v[0]+"\x00"+v[1]] = true
+		return nil
+	}, `
+		SELECT kcu.table_name, kcu.column_name
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage kcu
+		  ON kcu.constraint_schema = tc.constraint_schema AND kcu.constraint_name = tc.constraint_name
+		WHERE tc.table_schema = $1 AND tc.constraint_type = 'PRIMARY KEY'`, schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read primary keys: %w", err)
+	}
+
+	err = query(ctx, db, func(v []string) error {
+		cat.columns = append(cat.columns, columnInfo{
+			table:    v[0],
+			name:     v[1],
+			dataType: postgresType(v[2], v[3], v[4], v[5], v[6]),
+			nullable: v[7] == "YES",
+			primary:  primary[v[0]+"\x00"+v[1]],
+			comment:  v[8],
+		})
+		return nil
+	}, `
+		SELECT c.table_name, c.column_name, c.data_type, c.udt_name,
+		       c.character_maximum_length, c.numeric_precision, c.numeric_scale, c.is_nullable,
+		       col_description(format('%I.%I', c.table_schema, c.table_name)::regclass, c.ordinal_position)
+		FROM information_schema.columns c
+		WHERE c.table_schema = $1
+		ORDER BY c.table_name, c.ordinal_position`, schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns: %w", err)
+	}
+
+	// Columns of a foreign key pair up with the referenced unique key by position
+	err = query(ctx, db, func(v []string) error {
+		cat.foreignKeys = append(cat.foreignKeys, foreignKeyInfo{
+			table:      v[0],
+			constraint: v[1],
+			column:     v[2],
+			refTable:   v[3],
+			refColumn:  v[4],
+		})
+		return nil
+	}, `
+		SELECT kcu.table_name, kcu.constraint_name, kcu.column_name, ref.table_name, ref.column_name
+		FROM information_schema.referential_constraints rc
+		JOIN information_schema.key_column_usage kcu
+		  ON kcu.constraint_schema = rc.constraint_schema AND kcu.constraint_name = rc.constraint_name
+		JOIN information_schema.key_column_usage ref
+		  ON ref.constraint_schema = rc.unique_constraint_schema AND ref.constraint_name = rc.unique_constraint_name
+		 AND ref.ordinal_position = kcu.position_in_unique_constraint
+		WHERE kcu.table_schema = $1
+		ORDER BY kcu.table_name, kcu.constraint_name, kcu.ordinal_position`, schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read foreign keys: %w", err)
+	}
+
+	return cat, nil
+}
+
+// postgresType renders an information_schema column type the way it is
+// written in DDL, e.g. VARCHAR(100), NUMERIC(10,2) or INT4[]
+func postgresType(dataType, udtName, length, precision, scale string) string {
+	switch dataType {
+	case "character varying":
+		if length != "" {
+			return "VARCHAR(" + length + ")"
+		}
+		return "VARCHAR"
+	case "character":
+		if length != "" {
+			return "CHAR(" + length + ")"
+		}
+		return "CHAR"
+	case "numeric":
+		if precision != "" && scale != "" {
+			return "NUMERIC(" + precision + "," + scale + ")"
+		}
+		return "NUMERIC"
+	case "ARRAY":
+		return strings.ToUpper(strings.TrimPrefix(udtName, "_")) + "[]"
+	case "USER-DEFINED":
+		return strings.ToUpper(udtName)
+	default:
+		return strings.ToUpper(dataType)
+	}
+}
@@ -0,0 +1,91 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// readSQLite reads the main database through sqlite_master and the
+// table_info and foreign_key_list pragmas. SQLite keeps no comments.
+func readSQLite(ctx context.Context, db *sql.DB, _ string) (*catalog, error) {
+	cat := &catalog{}
+	err := query(ctx, db, func(v []string) error {
+		cat.tables = append(cat.tables, tableInfo{name: v[0]})
+		return nil
+	}, `
+		SELECT name FROM sqlite_master
+		WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%'
+		ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tables: %w", err)
+	}
+
+	for _, table := range cat.tables {
+		err := query(ctx, db, func(v []string) error {
+			cat.columns = append(cat.columns, columnInfo{
+				table:    table.name,
+				name:     v[0],
+				dataType: strings.ToUpper(v[1]),
+				nullable: v[2] == "0",
+				primary:  v[3] != "0",
+			})
+			return nil
+		}, `SELECT name, type, "notnull", pk FROM pragma_table_info(?) ORDER BY cid`, table.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read columns of %s: %w", table.name, err)
+		}
+	}
+
+	for _, table := range cat.tables {
+		var keys []foreignKeyInfo
+		var seqs []int
+		err := query(ctx, db, func(v []string) error {
+			seq, err := strconv.Atoi(v[1])
+			if err != nil {
+				return err
+			}
+			seqs = append(seqs, seq)
+			keys = append(keys, foreignKeyInfo{
+				table:      table.name,
+				constraint: v[0],
+				column:     v[2],
+				refTable:   v[3],
+				refColumn:  v[4],
+			})
+			return nil
+		}, `SELECT id, seq, "from", "table", "to" FROM pragma_foreign_key_list(?) ORDER BY id, seq`, table.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read foreign keys of %s: %w", table.name, err)
+		}
+
+		for i := range keys {
+			if keys[i].refColumn != "" {
+				continue
+			}
+			// "REFERENCES t" without columns refers to the primary key of t
+			pk, err := sqlitePrimaryKey(ctx, db, keys[i].refTable)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read the primary key of %s: %w", keys[i].refTable, err)
+			}
+			if seqs[i] < len(pk) {
+				keys[i].refColumn = pk[seqs[i]]
+			}
+		}
+		cat.foreignKeys = append(cat.foreignKeys, keys...)
+	}
+
+	return cat, nil
+}
+
+// sqlitePrimaryKey returns the primary key columns of table in key order
+func sqlitePrimaryKey(ctx context.Context, db *sql.DB, table string) ([]string, error) {
+	var columns []string
+	err := query(ctx, db, func(v []string) error {
+		columns = append(columns, v[0])
+		return nil
+	}, `SELECT name FROM pragma_table_info(?) WHERE pk > 0 ORDER BY pk`, table)
+	return columns, err
+}
@@ -0,0 +1,94 @@
+// Package language identifies the natural language of questions, which
+// selects the language prompts are written in and the model explains its
+// SQL in.
+package language
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Language is a natural language prompts can be written in
+type Language string
+
+const (
+	Chinese Language = "zh"
+	English Language = "en"
+)
+
+// Default is the language of text without letters, and of the prompts
+// before languages were selectable
+const Default = Chinese
+
+// ErrUnknownLanguage is returned by Parse for unsupported languages
+var ErrUnknownLanguage = errors.New("unknown language")
+
+// aliases maps accepted spellings to languages
+var aliases = map[string]Language{
+	"zh":      Chinese,
+	"zh-cn":   Chinese,
+	"zh-hans": Chinese,
+	"chinese": Chinese,
+	"中文":      Chinese,
+	"en":      English,
+	"en-us":   English,
+	"en-gb":   English,
+	"english": English,
+}
+
+// Supported returns the supported languages
+func Supported() []Language {
+	return []Language{Chinese, English}
+}
+
+// Parse returns the language named by name, such as zh, en-US or english
+func Parse(name string) (Language, error) {
+	key := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), "_", "-"))
+	if l, ok := aliases[key]; ok {
+		return l, nil
+	}
+	return "", fmt.Errorf("%w: %q (supported: zh, en)", ErrUnknownLanguage, name)
+}
+
+// Detect returns the language text is written in. Text counts as Chinese
+// when it has at least as many Han characters as Latin words, so English
+// questions naming a Chinese term and Chinese questions naming columns
+// such as user_id are both recognized; text without either is Default.
+func Detect(text string) Language {
+	han, words := 0, 0
+	inWord := false
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+			inWord = false
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'):
+			if !inWord && unicode.IsLetter(r) {
+				words++
+				inWord = true
+			}
+		default:
+			inWord = false
+		}
+	}
+	if han == 0 && words == 0 {
+		return Default
+	}
+	if han >= words {
+		return Chinese
+	}
+	return English
+}
+
+// DisplayName returns the name of the language in that language
+func (l Language) DisplayName() string {
+	switch l {
+	case Chinese:
+		return "中文"
+	case English:
+		return "English"
+	}
+	return string(l)
+}
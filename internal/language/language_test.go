@@ -0,0 +1,46 @@
+package language
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]Language{
+		"zh":      Chinese,
+		"zh_CN":   Chinese,
+		"Chinese": Chinese,
+		"中文":      Chinese,
+		" en ":    English,
+		"en-US":   English,
+		"ENGLISH": English,
+	}
+	for name, want := range tests {
+		got, err := Parse(name)
+		if err != nil || got != want {
+			t.Errorf("Parse(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+
+	if _, err := Parse("fr"); !errors.Is(err, ErrUnknownLanguage) {
+		t.Errorf("expected ErrUnknownLanguage, got %v", err)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := map[string]Language{
+		"":            Default,
+		"2024-01-01":  Default,
+		"查询每个用户的订单数量": Chinese,
+		"统计 order_items 表中 amount 的总和":               Chinese,
+		"Count the orders of every user":             English,
+		"List users whose city is 北京 by signup date": English,
+		"show user_id and 姓名":                        English,
+		"查询 users":                                   Chinese,
+	}
+	for text, want := range tests {
+		if got := Detect(text); got != want {
+			t.Errorf("Detect(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
@@ -1,10 +1,50 @@
 package llm
 
 import (
+	"context"
+
+	"sql_generator/internal/dialect"
+	"sql_generator/internal/language"
 	"sql_generator/internal/models"
+	"sql_generator/internal/prompt"
+	"sql_generator/internal/schemagraph"
 )
 
+// Request describes one SQL generation request
+type Request struct {
+	Description string
+	Tables      []*models.Table
+	// Dialect selects the dialect-specific prompt rules and validation
+	Dialect dialect.Dialect
+	// JoinPlan lists the join conditions connecting Tables, if known
+	JoinPlan []schemagraph.Edge
+	// AllowDDL tells the model it may answer with DDL statements
+	AllowDDL bool
+	// Role selects the sensitivity rules applied to tagged columns; empty uses the default role
+	Role string
+	// Language is the language of the prompt and of the explanation; empty uses language.Default
+	Language language.Language
+	// Template renders the prompt; nil uses the built-in template of Language
+	Template *prompt.Template
+	// Examples are correct past queries shown to the model as few-shot examples, most similar first
+	Examples []*models.Query
+}
+
 // Client defines the interface for LLM clients
 type Client interface {
-	GenerateSQL(description string, tables []*models.Table) (string, error)
+	GenerateSQL(ctx context.Context, req *Request) (*Result, error)
+}
+
+// promptInput returns the prompt input of the request
+func (r *Request) promptInput() *prompt.Input {
+	return &prompt.Input{
+		Description: r.Description,
+		Tables:      r.Tables,
+		Dialect:     r.Dialect,
+		JoinPlan:    r.JoinPlan,
+		AllowDDL:    r.AllowDDL,
+		Language:    r.Language,
+		Template:    r.Template,
+		Examples:    r.Examples,
+	}
 }
@@ -0,0 +1,50 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"sql_generator/internal/models"
+)
+
+// ExampleSelector selects correct past queries whose questions are similar
+// to a question, most similar first. It is implemented by
+// *rag.ExampleSelector.
+type ExampleSelector interface {
+	SelectExamples(ctx context.Context, question, dialect string, k int) ([]*models.Query, error)
+}
+
+// ExampleClient adds few-shot examples to requests before passing them to
+// another Client
+type ExampleClient struct {
+	client   Client
+	selector ExampleSelector
+	count    int
+}
+
+// NewExampleClient creates a new ExampleClient adding up to count examples;
+// a count of 0 disables examples
+func NewExampleClient(client Client, selector ExampleSelector, count int) Client {
+	return &ExampleClient{
+		client:   client,
+		selector: selector,
+		count:    count,
+	}
+}
+
+// GenerateSQL selects examples for requests that have none. Examples only
+// improve the prompt, so the SQL is generated without them when they
+// cannot be selected.
+func (e *ExampleClient) GenerateSQL(ctx context.Context, req *Request) (*Result, error) {
+	if e.count > 0 && len(req.Examples) == 0 {
+		examples, err := e.selector.SelectExamples(ctx, req.Description, string(req.Dialect), e.count)
+		if err != nil {
+			fmt.Printf("Warning: failed to select few-shot examples: %v\n", err)
+		} else if len(examples) > 0 {
+			withExamples := *req
+			withExamples.Examples = examples
+			req = &withExamples
+		}
+	}
+	return e.client.GenerateSQL(ctx, req)
+}
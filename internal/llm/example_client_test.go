@@ -0,0 +1,47 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"sql_generator/internal/dialect"
+	"sql_generator/internal/models"
+)
+
+// fixedSelector returns fixed examples and records what it was asked for
+type fixedSelector struct {
+	examples []*models.Query
+	err      error
+	dialect  string
+	k        int
+}
+
+func (s *fixedSelector) SelectExamples(ctx context.Context, question, dialect string, k int) ([]*models.Query, error) {
+	s.dialect, s.k = dialect, k
+	return s.examples, s.err
+}
+
+func TestExampleClient(t *testing.T) {
+	example := &models.Query{Description: "用户数", SQL: "SELECT COUNT(*) FROM users"}
+	selector := &fixedSelector{examples: []*models.Query{example}}
+	base := &tableRecorder{sql: "SELECT 1"}
+	client := NewExampleClient(base, selector, 3)
+
+	request := &Request{Description: "每个城市的用户数", Dialect: dialect.Hive}
+	if _, err := client.GenerateSQL(context.Background(), request); err != nil {
+		t.Fatalf("GenerateSQL failed: %v", err)
+	}
+	if len(base.examples) != 1 || base.examples[0] != example || selector.dialect != "hive" || selector.k != 3 {
+		t.Errorf("Expected the selected example, got %+v for %q, %d", base.examples, selector.dialect, selector.k)
+	}
+	if request.Examples != nil {
+		t.Error("Expected the request of the caller to be left unchanged")
+	}
+
+	// Generation goes on without examples when they cannot be selected
+	selector.err = errors.New("embedding service unavailable")
+	if _, err := client.GenerateSQL(context.Background(), request); err != nil || base.examples != nil {
+		t.Errorf("Expected SQL without examples, got %+v: %v", base.examples, err)
+	}
+}
@@ -0,0 +1,260 @@
+package llm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// NoSQLError is returned when an LLM response contains no SQL statement
+type NoSQLError struct {
+	Response string
+}
+
+func (e *NoSQLError) Error() string {
+	response := e.Response
+	if len([]rune(response)) > 200 {
+		response = string([]rune(response)[:200]) + "..."
+	}
+	return fmt.Sprintf("no SQL statement found in LLM response: %q", response)
+}
+
+// fencePattern matches markdown code blocks and captures their language tag and body
+var fencePattern = regexp.MustCompile("(?s)```[ \\t]*([\\w-]*)[^\\n]*\\n(.*?)```")
+
+// statementKeywords are the words a SQL statement may start with
+var statementKeywords = map[string]bool{
+	"SELECT": true, "WITH": true, "INSERT": true, "UPDATE": true, "DELETE": true,
+	"MERGE": true, "REPLACE": true, "UPSERT": true, "CREATE": true, "ALTER": true,
+	"DROP": true, "TRUNCATE": true, "RENAME": true, "GRANT": true, "REVOKE": true,
+	"SHOW": true, "DESCRIBE": true, "DESC": true, "EXPLAIN": true, "USE": true,
+	"SET": true, "FROM": true, "VALUES": true, "CALL": true, "LOAD": true,
+	"ANALYZE": true, "MSCK": true,
+}
+
+// clauseKeywords may start a continuation line of a statement
+var clauseKeywords = map[string]bool{
+	"FROM": true, "WHERE": true, "JOIN": true, "INNER": true, "LEFT": true,
+	"RIGHT": true, "FULL": true, "CROSS": true, "LATERAL": true, "ON": true,
+	"USING": true, "GROUP": true, "ORDER": true, "HAVING": true, "LIMIT": true,
+	"OFFSET": true, "UNION": true, "INTERSECT": true, "EXCEPT": true, "AND": true,
+	"OR": true, "NOT": true, "AS": true, "CASE": true, "WHEN": true, "THEN": true,
+	"ELSE": true, "END": true, "WINDOW": true, "QUALIFY": true, "DISTRIBUTE": true,
+	"SORT": true, "CLUSTER": true, "PARTITION": true, "INTO": true, "SET": true,
+	"VALUES": true, "SELECT": true,
+}
+
+// ExtractSQL extracts the SQL statement(s) from a raw LLM response. Markdown
+// fences, leading prose and trailing explanations are dropped; multiple
+// statements are kept and joined by JoinStatements.
+func ExtractSQL(response string) (string, error) {
+	statements := ExtractStatements(response)
+	if len(statements) == 0 {
+		return "", &NoSQLError{Response: strings.TrimSpace(response)}
+	}
+	return JoinStatements(statements), nil
+}
+
+// JoinStatements joins statements with ";\n". A statement whose last line
+// holds a "--" comment gets its semicolon on the next line, so that the
+// separator does not become part of the comment.
+func JoinStatements(statements []string) string {
+	var b strings.Builder
+	for i, stmt := range statements {
+		b.WriteString(stmt)
+		if i == len(statements)-1 {
+			break
+		}
+		if strings.Contains(stmt[strings.LastIndex(stmt, "\n")+1:], "--") {
+			b.WriteString("\n")
+		}
+		b.WriteString(";\n")
+	}
+	return b.String()
+}
+
+// ExtractStatements returns the individual SQL statements found in a raw LLM
+// response, without trailing semicolons
+func ExtractStatements(response string) []string {
+	var statements []string
+
+	// Prefer fenced code blocks: any block tagged as SQL, otherwise untagged blocks
+	matches := fencePattern.FindAllStringSubmatch(response, -1)
+	for _, preferTagged := range []bool{true, false} {
+		for _, m := range matches {
+			lang := strings.ToLower(m[1])
+			tagged := strings.Contains(lang, "sql")
+			if tagged != preferTagged || (!tagged && lang != "") {
+				continue
+			}
+			statements = append(statements, statementsFromText(m[2])...)
+		}
+		if len(statements) > 0 {
+			return statements
+		}
+	}
+
+	return statementsFromText(response)
+}
+
+// statementsFromText locates SQL within free text and splits it into statements
+func statementsFromText(text string) []string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	start := -1
+	for i, line := range lines {
+		if statementKeywords[firstWord(line)] && looksLikeStatement(line) {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return nil
+	}
+
+	var statements []string
+	for _, stmt := range splitStatements(strings.Join(lines[start:], "\n")) {
+		stmt = trimTrailingProse(stmt)
+		if stmt != "" && statementKeywords[firstWord(stmt)] {
+			statements = append(statements, stmt)
+		}
+	}
+	return statements
+}
+
+// looksLikeStatement rejects prose lines such as "Show all users." that
+// merely start with a SQL keyword
+func looksLikeStatement(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	word := firstWord(trimmed)
+	rest := strings.TrimSpace(trimmed[len(word):])
+
+	// A bare keyword line ("SELECT") is valid in multi-line formatted SQL
+	if rest == "" {
+		return true
+	}
+	// Keywords written in upper case are a strong signal
+	if trimmed[:len(word)] == word {
+		return true
+	}
+	// Lower-case SQL usually contains punctuation or a FROM clause
+	return strings.ContainsAny(rest, "*,()=;`") || strings.Contains(strings.ToLower(rest), " from ")
+}
+
+// trimTrailingProse cuts explanation text that follows an unterminated statement
+func trimTrailingProse(stmt string) string {
+	lines := strings.Split(stmt, "\n")
+	kept := lines[:1]
+	afterBlank := false
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			afterBlank = true
+			kept = append(kept, line)
+			continue
+		}
+		if isProseLine(trimmed, afterBlank) {
+			break
+		}
+		afterBlank = false
+		kept = append(kept, line)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// isProseLine reports whether a non-empty line reads as natural language
+// rather than a continuation of SQL
+func isProseLine(line string, afterBlank bool) bool {
+	if strings.HasPrefix(line, "--") || strings.HasPrefix(line, "/*") || strings.HasPrefix(line, ")") {
+		return false
+	}
+	word := firstWord(line)
+	if clauseKeywords[word] || statementKeywords[word] {
+		return false
+	}
+
+	// Lines starting with CJK text are explanations, e.g. "该查询返回..."
+	if r := []rune(line)[0]; r > unicode.MaxASCII && unicode.IsLetter(r) {
+		return true
+	}
+	// A new paragraph that does not start with a SQL keyword ends the statement
+	if afterBlank {
+		return true
+	}
+	// Sentences such as "This query returns all users." end with punctuation
+	return strings.Count(line, " ") >= 2 && strings.HasSuffix(line, ".") || strings.HasSuffix(line, ":")
+}
+
+// firstWord returns the upper-cased leading identifier of s
+func firstWord(s string) string {
+	s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return !(r < unicode.MaxASCII && unicode.IsLetter(r) || r == '_')
+	})
+	if end < 0 {
+		end = len(s)
+	}
+	return strings.ToUpper(s[:end])
+}
+
+// splitStatements splits SQL on semicolons that are outside quotes and comments
+func splitStatements(sql string) []string {
+	var statements []string
+	var current strings.Builder
+	runes := []rune(sql)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\'' || r == '"' || r == '`':
+			// Copy the quoted literal, honouring doubled and backslash escapes
+			current.WriteRune(r)
+			for i++; i < len(runes); i++ {
+				current.WriteRune(runes[i])
+				if runes[i] == '\\' && r != '`' && i+1 < len(runes) {
+					i++
+					current.WriteRune(runes[i])
+					continue
+				}
+				if runes[i] == r {
+					if i+1 < len(runes) && runes[i+1] == r {
+						i++
+						current.WriteRune(runes[i])
+						continue
+					}
+					break
+				}
+			}
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for ; i < len(runes) && runes[i] != '\n'; i++ {
+				current.WriteRune(runes[i])
+			}
+			if i < len(runes) {
+				current.WriteRune(runes[i])
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			end := strings.Index(string(runes[i+2:]), "*/")
+			if end < 0 {
+				current.WriteString(string(runes[i:]))
+				i = len(runes)
+			} else {
+				n := len([]rune(string(runes[i+2:])[:end]))
+				current.WriteString(string(runes[i : i+2+n+2]))
+				i += 2 + n + 1
+			}
+		case r == ';':
+			if stmt := strings.TrimSpace(current.String()); stmt != "" {
+				statements = append(statements, stmt)
+			}
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+
+	if stmt := strings.TrimSpace(current.String()); stmt != "" {
+		statements = append(statements, stmt)
+	}
+	return statements
+}
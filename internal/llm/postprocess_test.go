@@ -0,0 +1,76 @@
+package llm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExtractSQL(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{
+			name:     "plain statement",
+			response: "SELECT * FROM users;",
+			want:     "SELECT * FROM users",
+		},
+		{
+			name:     "fenced block with prose",
+			response: "以下是查询语句：\n```sql\nSELECT id, name\nFROM users\nWHERE id > 100;\n```\n该查询返回ID大于100的用户。",
+			want:     "SELECT id, name\nFROM users\nWHERE id > 100",
+		},
+		{
+			name:     "untagged fence",
+			response: "```\nselect count(*) from orders\n```",
+			want:     "select count(*) from orders",
+		},
+		{
+			name:     "leading and trailing prose",
+			response: "Here is the query:\nSELECT name FROM users\nWHERE active = 1\n\nThis query returns active users.",
+			want:     "SELECT name FROM users\nWHERE active = 1",
+		},
+		{
+			name:     "trailing chinese explanation",
+			response: "SELECT name FROM users\n这个查询返回所有用户名",
+			want:     "SELECT name FROM users",
+		},
+		{
+			name:     "multiple statements",
+			response: "SELECT 1; SELECT 'a;b' FROM t -- done;\n; SELECT 2;",
+			want:     "SELECT 1;\nSELECT 'a;b' FROM t -- done;\n;\nSELECT 2",
+		},
+		{
+			name:     "statement ending in a comment",
+			response: "SELECT * FROM t -- note\n; DROP TABLE x",
+			want:     "SELECT * FROM t -- note\n;\nDROP TABLE x",
+		},
+		{
+			name:     "sentence starting with keyword",
+			response: "Show all users.\nSELECT * FROM users",
+			want:     "SELECT * FROM users",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractSQL(tt.response)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestExtractSQL_NoSQL(t *testing.T) {
+	_, err := ExtractSQL("抱歉，我无法根据提供的表结构回答这个问题。")
+
+	var noSQL *NoSQLError
+	if !errors.As(err, &noSQL) {
+		t.Fatalf("Expected NoSQLError, got %v", err)
+	}
+}
@@ -1,11 +1,13 @@
 package llm
 
 import (
+	"context"
 	"fmt"
 
 	"sql_generator/internal/config"
 	"sql_generator/internal/models"
 	"sql_generator/internal/rag"
+	"sql_generator/internal/schemagraph"
 )
 
 // RAGEnhancedClient 实现结合RAG的LLM客户端
@@ -14,44 +16,52 @@ type RAGEnhancedClient struct {
 	baseClient   Client
 	embeddingSvc rag.EmbeddingService
 	vectorStore  rag.VectorStore
+	graph        *schemagraph.Graph
 }
 
-// NewRAGEnhancedClient 创建新的RAG增强客户端
-func NewRAGEnhancedClient(config config.LLMConfig, baseClient Client, embeddingSvc rag.EmbeddingService, vectorStore rag.VectorStore) Client {
+// NewRAGEnhancedClient 创建新的RAG增强客户端，graph为nil时不计算关联路径
+func NewRAGEnhancedClient(config config.LLMConfig, baseClient Client, embeddingSvc rag.EmbeddingService, vectorStore rag.VectorStore, graph *schemagraph.Graph) Client {
 	return &RAGEnhancedClient{
 		config:       config,
 		baseClient:   baseClient,
 		embeddingSvc: embeddingSvc,
 		vectorStore:  vectorStore,
+		graph:        graph,
 	}
 }
 
 // GenerateSQL 使用RAG增强的方式生成SQL
-func (r *RAGEnhancedClient) GenerateSQL(description string, tables []*models.Table) (string, error) {
+func (r *RAGEnhancedClient) GenerateSQL(ctx context.Context, req *Request) (*Result, error) {
 	// 如果没有提供表，则使用RAG检索相关表
-	if len(tables) == 0 {
-		var err error
-		tables, err = r.retrieveRelevantTables(description)
+	if len(req.Tables) == 0 {
+		tables, err := r.retrieveRelevantTables(ctx, req.Description)
 		if err != nil {
-			return "", fmt.Errorf("failed to retrieve relevant tables: %w", err)
+			return nil, fmt.Errorf("failed to retrieve relevant tables: %w", err)
 		}
+		enriched := *req
+		enriched.Tables = tables
+		req = &enriched
+	}
+
+	// 计算连接这些表的关联路径，并补充检索时遗漏的中间表
+	if r.graph != nil && len(req.Tables) > 1 && len(req.JoinPlan) == 0 {
+		req = r.planJoins(req)
 	}
 
-	fmt.Println(r.config.Model)
 	// 使用基础客户端生成SQL
-	return r.baseClient.GenerateSQL(description, tables)
+	return r.baseClient.GenerateSQL(ctx, req)
 }
 
 // retrieveRelevantTables 使用RAG检索相关表
-func (r *RAGEnhancedClient) retrieveRelevantTables(description string) ([]*models.Table, error) {
+func (r *RAGEnhancedClient) retrieveRelevantTables(ctx context.Context, description string) ([]*models.Table, error) {
 	// 生成查询的向量表示
-	queryVector, err := r.embeddingSvc.GenerateEmbedding(description)
+	queryVector, err := r.embeddingSvc.GenerateEmbedding(ctx, description)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
 	}
 
 	// 在向量数据库中搜索相似表
-	tables, err := r.vectorStore.SearchSimilarTables(queryVector, 10) // 获取前10个最相关表
+	tables, err := r.vectorStore.SearchSimilarTables(ctx, queryVector, 10) // 获取前10个最相关表
 	if err != nil {
 		return nil, fmt.Errorf("failed to search similar tables: %w", err)
 	}
@@ -59,54 +69,26 @@ func (r *RAGEnhancedClient) retrieveRelevantTables(description string) ([]*model
 	return tables, nil
 }
 
-// buildPrompt 构建提示词
-func (r *RAGEnhancedClient) buildPrompt(description string, tables []*models.Table) string {
-	prompt := "根据以下表结构和用户需求生成SQL查询语句：\n\n"
-	prompt += fmt.Sprintf("用户需求：%s\n\n", description)
-	prompt += "相关表结构：\n"
-
-	// 添加表结构信息，但控制总长度
-	totalLength := 0
-	for _, table := range tables {
-		tableInfo := fmt.Sprintf("\n表名: %s\n描述: %s\n", table.Name, table.Description)
-
-		// 检查是否超出长度限制（大约8000字符）
-		if totalLength+len(tableInfo) > 8000 {
-			prompt += "\n... (为控制提示词长度，省略了部分表结构) ...\n"
-			break
-		}
-
-		prompt += tableInfo
-		prompt += "字段:\n"
-
-		for _, column := range table.Columns {
-			columnInfo := fmt.Sprintf("  - %s (%s): %s", column.Name, column.Type, column.Description)
-			if column.IsPrimary {
-				columnInfo += " [主键]"
-			}
-			if column.IsRequired {
-				columnInfo += " [必填]"
-			}
-			columnInfo += "\n"
+// planJoins 在表关系图中计算连接请求中各表的最小关联路径（Steiner树），
+// 将路径中的中间表加入表列表，并把关联路径附加到请求中
+func (r *RAGEnhancedClient) planJoins(req *Request) *Request {
+	names := make([]string, len(req.Tables))
+	for i, table := range req.Tables {
+		names[i] = table.Name
+	}
 
-			if totalLength+len(columnInfo) > 8000 {
-				prompt += "  ... (字段信息省略)\n"
-				break
-			}
+	plan := r.graph.JoinPlan(names, schemagraph.DefaultMaxHops)
+	if len(plan.Edges) == 0 {
+		return req
+	}
 
-			prompt += columnInfo
-			totalLength += len(columnInfo)
+	planned := *req
+	planned.Tables = append([]*models.Table(nil), req.Tables...)
+	for _, name := range plan.Bridges {
+		if table := r.graph.Table(name); table != nil {
+			planned.Tables = append(planned.Tables, table)
 		}
-
-		totalLength += len(tableInfo)
 	}
-
-	prompt += "\n请根据用户需求和提供的表结构生成相应的SQL查询语句：\n"
-	prompt += "要求：\n"
-	prompt += "1. 只返回有效的SQL语句\n"
-	prompt += "2. 如需要多表关联，请使用适当的JOIN语句\n"
-	prompt += "3. 不要包含任何解释或其他文本，只返回SQL\n"
-	prompt += "4. 使用标准SQL语法\n"
-
-	return prompt
+	planned.JoinPlan = plan.Edges
+	return &planned
 }
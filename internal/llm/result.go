@@ -0,0 +1,76 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"sql_generator/internal/models"
+)
+
+// Result holds the structured output of a SQL generation
+type Result struct {
+	SQL         string              `json:"sql"`
+	Explanation string              `json:"explanation"`
+	TablesUsed  []models.TableUsage `json:"tables_used"`
+	Assumptions []string            `json:"assumptions"`
+	Confidence  float64             `json:"confidence"`
+	// Validation and Attempts are set by ValidatingClient and are not part of the model output
+	Validation *models.Validation         `json:"-"`
+	Attempts   []models.GenerationAttempt `json:"-"`
+	// PromptTruncation is set by the model clients when schema was left out of the prompt
+	PromptTruncation *models.PromptTruncation `json:"-"`
+}
+
+// parseResult parses the model response into the Result requested by the
+// format instructions of the prompt package. Responses that are not
+// valid JSON are treated as raw SQL with zero confidence. In both cases the
+// SQL is cleaned by ExtractSQL, so a *NoSQLError is returned when the model
+// produced no statement at all.
+func parseResult(content string) (*Result, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, fmt.Errorf("empty response from LLM")
+	}
+
+	if raw, ok := extractJSONObject(content); ok {
+		var result Result
+		if err := json.Unmarshal([]byte(raw), &result); err == nil && strings.TrimSpace(result.SQL) != "" {
+			sql, err := ExtractSQL(result.SQL)
+			if err != nil {
+				return nil, err
+			}
+			result.SQL = sql
+			result.Confidence = clampConfidence(result.Confidence)
+			return &result, nil
+		}
+	}
+
+	sql, err := ExtractSQL(content)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{SQL: sql}, nil
+}
+
+// extractJSONObject returns the outermost JSON object in s, ignoring
+// surrounding markdown fences or prose
+func extractJSONObject(s string) (string, bool) {
+	start := strings.Index(s, "{")
+	end := strings.LastIndex(s, "}")
+	if start < 0 || end <= start {
+		return "", false
+	}
+	return s[start : end+1], true
+}
+
+// clampConfidence keeps the confidence score within [0, 1]
+func clampConfidence(c float64) float64 {
+	if c < 0 {
+		return 0
+	}
+	if c > 1 {
+		return 1
+	}
+	return c
+}
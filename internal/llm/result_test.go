@@ -0,0 +1,48 @@
+package llm
+
+import "testing"
+
+func TestParseResult_JSON(t *testing.T) {
+	content := "```json\n" + `{
+  "sql": "SELECT name FROM users",
+  "explanation": "查询所有用户名",
+  "tables_used": [{"table": "users", "columns": ["name"]}],
+  "assumptions": ["users表包含全部用户"],
+  "confidence": 1.5
+}` + "\n```"
+
+	result, err := parseResult(content)
+	if err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+
+	if result.SQL != "SELECT name FROM users" {
+		t.Errorf("Unexpected SQL: %q", result.SQL)
+	}
+	if result.Explanation != "查询所有用户名" {
+		t.Errorf("Unexpected explanation: %q", result.Explanation)
+	}
+	if len(result.TablesUsed) != 1 || result.TablesUsed[0].Table != "users" || result.TablesUsed[0].Columns[0] != "name" {
+		t.Errorf("Unexpected tables used: %+v", result.TablesUsed)
+	}
+	if len(result.Assumptions) != 1 {
+		t.Errorf("Expected 1 assumption, got %d", len(result.Assumptions))
+	}
+	if result.Confidence != 1 {
+		t.Errorf("Expected confidence to be clamped to 1, got %v", result.Confidence)
+	}
+}
+
+func TestParseResult_PlainSQL(t *testing.T) {
+	result, err := parseResult("SELECT * FROM orders")
+	if err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if result.SQL != "SELECT * FROM orders" || result.Confidence != 0 {
+		t.Errorf("Unexpected fallback result: %+v", result)
+	}
+
+	if _, err := parseResult("   "); err == nil {
+		t.Error("Expected error for empty response")
+	}
+}
@@ -0,0 +1,94 @@
+package llm
+
+import (
+	"context"
+
+	"sql_generator/internal/dialect"
+	"sql_generator/internal/models"
+	"sql_generator/internal/sensitivity"
+	"sql_generator/internal/validation"
+)
+
+// SensitivityClient applies the column sensitivity rules of the requesting
+// role around another Client. Excluded columns are removed from the schema
+// the model sees; the generated SQL is then checked against the full schema,
+// masked columns are wrapped in a hash function and SQL that still uses
+// excluded or refused columns is rejected with a sensitivity.ViolationError.
+type SensitivityClient struct {
+	client    Client
+	policy    *sensitivity.Policy
+	validator *validation.Validator
+}
+
+// NewSensitivityClient creates a new SensitivityClient
+func NewSensitivityClient(client Client, policy *sensitivity.Policy, validator *validation.Validator) Client {
+	return &SensitivityClient{
+		client:    client,
+		policy:    policy,
+		validator: validator,
+	}
+}
+
+// GenerateSQL generates SQL from the restricted schema and enforces the
+// role's rules on the result. Unknown roles return sensitivity.ErrUnknownRole.
+func (s *SensitivityClient) GenerateSQL(ctx context.Context, req *Request) (*Result, error) {
+	rules, err := s.policy.Rules(req.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	restricted := *req
+	restricted.Tables = sensitivity.Restrict(req.Tables, rules, req.Language)
+	restricted.JoinPlan = nil
+	for _, edge := range req.JoinPlan {
+		if sensitivity.Excluded(req.Tables, rules, edge.From, edge.FromColumns) ||
+			sensitivity.Excluded(req.Tables, rules, edge.To, edge.ToColumns) {
+			continue
+		}
+		restricted.JoinPlan = append(restricted.JoinPlan, edge)
+	}
+	restricted.Examples = s.examples(ctx, req, rules)
+
+	result, err := s.client.GenerateSQL(ctx, &restricted)
+	if err != nil {
+		return nil, err
+	}
+
+	sql, err := sensitivity.Enforce(ctx, s.validator, result.SQL, req.Dialect, req.Tables, rules)
+	if err != nil {
+		return nil, err
+	}
+	result.SQL = sql
+	return result, nil
+}
+
+// examples returns the examples of a request the role may see. The SQL of
+// each example is enforced like generated SQL, so that the columns it
+// actually references are checked rather than the tables it reports to use:
+// examples using excluded or refused columns are dropped, and masked columns
+// are shown masked.
+func (s *SensitivityClient) examples(ctx context.Context, req *Request, rules sensitivity.Rules) []*models.Query {
+	var examples []*models.Query
+	for _, example := range req.Examples {
+		d := req.Dialect
+		if example.Dialect != "" {
+			parsed, err := dialect.Parse(example.Dialect)
+			if err != nil {
+				continue
+			}
+			d = parsed
+		}
+
+		sql, err := sensitivity.Enforce(ctx, s.validator, example.SQL, d, req.Tables, rules)
+		if err != nil {
+			continue
+		}
+		if sql != example.SQL {
+			masked := *example
+			masked.SQL = sql
+			example = &masked
+		}
+		examples = append(examples, example)
+	}
+	return examples
+}
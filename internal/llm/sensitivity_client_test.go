@@ -0,0 +1,90 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"sql_generator/internal/config"
+	"sql_generator/internal/dialect"
+	"sql_generator/internal/models"
+	"sql_generator/internal/sensitivity"
+	"sql_generator/internal/validation"
+)
+
+// tableRecorder returns a fixed SQL statement and records the tables and examples it was given
+type tableRecorder struct {
+	sql      string
+	tables   []*models.Table
+	examples []*models.Query
+}
+
+func (r *tableRecorder) GenerateSQL(ctx context.Context, req *Request) (*Result, error) {
+	r.tables = req.Tables
+	r.examples = req.Examples
+	return &Result{SQL: r.sql}, nil
+}
+
+func TestSensitivityClient(t *testing.T) {
+	policy, err := sensitivity.New(config.SensitivityConfig{
+		DefaultRole: "analyst",
+		Rules: map[string]map[string]string{
+			"analyst": {"pii": "mask", "secret": "exclude"},
+			"admin":   {"pii": "allow", "secret": "allow"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("sensitivity.New failed: %v", err)
+	}
+	customers := &models.Table{
+		Name: "customers",
+		Columns: []models.Column{
+			{Name: "name", Type: "VARCHAR(100)"},
+			{Name: "phone", Type: "VARCHAR(20)", Sensitivity: models.SensitivityPII},
+			{Name: "api_token", Type: "VARCHAR(64)", Sensitivity: models.SensitivitySecret},
+		},
+	}
+	names := &models.Query{Description: "客户名单", SQL: "SELECT name FROM customers", TablesUsed: []models.TableUsage{{Table: "customers", Columns: []string{"name"}}}}
+	tokens := &models.Query{Description: "客户令牌", SQL: "SELECT api_token FROM customers", TablesUsed: []models.TableUsage{{Table: "customers", Columns: []string{"api_token"}}}}
+	// The reported tables of an example are not trusted, its SQL is checked
+	filtered := &models.Query{Description: "有令牌的客户", SQL: "SELECT name FROM customers WHERE api_token IS NOT NULL", TablesUsed: []models.TableUsage{{Table: "customers", Columns: []string{"name"}}}}
+	phones := &models.Query{Description: "客户电话", SQL: "SELECT phone FROM customers", Dialect: "mysql"}
+	request := &Request{Description: "客户电话", Tables: []*models.Table{customers}, Dialect: dialect.MySQL, Examples: []*models.Query{names, tokens, filtered, phones}}
+
+	base := &tableRecorder{sql: "SELECT name, phone FROM customers"}
+	client := NewSensitivityClient(base, policy, validation.NewValidator(nil))
+	result, err := client.GenerateSQL(context.Background(), request)
+	if err != nil {
+		t.Fatalf("GenerateSQL failed: %v", err)
+	}
+	if result.SQL != "SELECT name, SHA2(phone, 256) AS phone FROM customers" {
+		t.Errorf("Expected the phone column to be masked, got %q", result.SQL)
+	}
+	if len(base.tables[0].Columns) != 2 {
+		t.Errorf("Expected api_token to be hidden from the model, got %+v", base.tables[0].Columns)
+	}
+	if len(base.examples) != 2 || base.examples[0] != names {
+		t.Fatalf("Expected the examples using api_token to be dropped, got %+v", base.examples)
+	}
+	if sql := base.examples[1].SQL; sql != "SELECT SHA2(phone, 256) AS phone FROM customers" || phones.SQL != "SELECT phone FROM customers" {
+		t.Errorf("Expected a masked copy of the phone example, got %q", sql)
+	}
+
+	base.sql = "SELECT name, api_token FROM customers"
+	_, err = client.GenerateSQL(context.Background(), request)
+	var violation *sensitivity.ViolationError
+	if !errors.As(err, &violation) {
+		t.Errorf("Expected a ViolationError, got %v", err)
+	}
+
+	admin := *request
+	admin.Role = "admin"
+	if result, err := client.GenerateSQL(context.Background(), &admin); err != nil || result.SQL != base.sql {
+		t.Errorf("Expected admins to select api_token, got %v: %v", result, err)
+	}
+
+	admin.Role = "guest"
+	if _, err := client.GenerateSQL(context.Background(), &admin); !errors.Is(err, sensitivity.ErrUnknownRole) {
+		t.Errorf("Expected ErrUnknownRole, got %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"sql_generator/internal/language"
+	"sql_generator/internal/models"
+	"sql_generator/internal/validation"
+)
+
+// ValidatingClient runs a generate → validate → repair loop around another
+// Client. SQL that fails parsing or schema validation is sent back to the
+// model together with the issues found, for at most maxRepairs rounds.
+type ValidatingClient struct {
+	client     Client
+	validator  *validation.Validator
+	maxRepairs int
+}
+
+// NewValidatingClient creates a new ValidatingClient. maxRepairs is the
+// number of repair rounds after the first attempt; 0 only validates.
+func NewValidatingClient(client Client, validator *validation.Validator, maxRepairs int) Client {
+	if maxRepairs < 0 {
+		maxRepairs = 0
+	}
+	return &ValidatingClient{
+		client:     client,
+		validator:  validator,
+		maxRepairs: maxRepairs,
+	}
+}
+
+// GenerateSQL generates SQL with the wrapped client, repairing it while it
+// fails validation. The result with the fewest validation errors is returned
+// together with the history of all attempts.
+func (v *ValidatingClient) GenerateSQL(ctx context.Context, req *Request) (*Result, error) {
+	var (
+		attempts   []models.GenerationAttempt
+		best       *Result
+		bestReport *validation.Report
+		bestIndex  int
+		lastErr    error
+	)
+
+	prompt := *req
+	for round := 0; round <= v.maxRepairs; round++ {
+		result, err := v.client.GenerateSQL(ctx, &prompt)
+		if err != nil {
+			var noSQL *NoSQLError
+			if !errors.As(err, &noSQL) {
+				if best == nil || ctx.Err() != nil {
+					return nil, err
+				}
+				// Keep the best result so far when a repair round fails
+				fmt.Printf("Warning: failed to repair generated SQL: %v\n", err)
+				break
+			}
+			lastErr = err
+			attempts = append(attempts, models.GenerationAttempt{Error: err.Error()})
+			prompt.Description = RepairDescription(req.Description, "", []string{repairPhrases(req.Language).noSQL}, req.Language)
+			continue
+		}
+
+		report, err := v.validator.Validate(ctx, result.SQL, req.Dialect, req.Tables)
+		if err != nil {
+			return nil, fmt.Errorf("failed to validate generated SQL: %w", err)
+		}
+		attempts = append(attempts, models.GenerationAttempt{
+			SQL:    result.SQL,
+			Valid:  report.Valid(),
+			Issues: report.Validation().Issues,
+		})
+
+		if best == nil || len(report.Errors()) < len(bestReport.Errors()) {
+			best, bestReport, bestIndex = result, report, len(attempts)-1
+		}
+		if report.Valid() {
+			break
+		}
+		prompt.Description = RepairDescription(req.Description, result.SQL, report.Errors(), req.Language)
+	}
+
+	if best == nil {
+		return nil, lastErr
+	}
+
+	best.Validation = bestReport.Validation()
+	best.Validation.Repaired = bestIndex > 0
+	best.Attempts = attempts
+	return best, nil
+}
+
+// repairText holds the texts of a repair request in one language
+type repairText struct {
+	failed, problems, instruction, noSQL string
+}
+
+// repairTexts 是各语言的修正请求文本
+var repairTexts = map[language.Language]repairText{
+	language.Chinese: {
+		failed:      "\n\n上一次生成的SQL未通过表结构校验：\n```sql\n",
+		problems:    "\n\n发现的问题：\n",
+		instruction: "请修正上述问题，只使用给定表结构中存在的表和字段，重新生成SQL。",
+		noSQL:       "回复中没有找到SQL语句",
+	},
+	language.English: {
+		failed:      "\n\nThe previously generated SQL failed schema validation:\n```sql\n",
+		problems:    "\n\nProblems found:\n",
+		instruction: "Fix the problems above and generate the SQL again, using only tables and columns that exist in the given schema.",
+		noSQL:       "no SQL statement was found in the reply",
+	},
+}
+
+// repairPhrases returns the repair texts of lang, or of language.Default for unknown languages
+func repairPhrases(lang language.Language) repairText {
+	if text, ok := repairTexts[lang]; ok {
+		return text
+	}
+	return repairTexts[language.Default]
+}
+
+// RepairDescription extends a query description with the SQL that failed
+// validation and the problems found, asking the model in lang to correct them
+func RepairDescription(description, sql string, problems []string, lang language.Language) string {
+	text := repairPhrases(lang)
+	var b strings.Builder
+	b.WriteString(description)
+	if sql != "" {
+		b.WriteString(text.failed)
+		b.WriteString(sql)
+		b.WriteString("\n```")
+	}
+	b.WriteString(text.problems)
+	for _, problem := range problems {
+		b.WriteString("- ")
+		b.WriteString(problem)
+		b.WriteString("\n")
+	}
+	b.WriteString(text.instruction)
+	return b.String()
+}
@@ -0,0 +1,186 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"sql_generator/internal/dialect"
+	"sql_generator/internal/language"
+	"sql_generator/internal/models"
+	"sql_generator/internal/validation"
+)
+
+// scriptedClient returns the queued SQL statements in order and records the
+// descriptions it received. An empty response is reported as a NoSQLError.
+type scriptedClient struct {
+	responses    []string
+	descriptions []string
+}
+
+func (s *scriptedClient) GenerateSQL(ctx context.Context, req *Request) (*Result, error) {
+	s.descriptions = append(s.descriptions, req.Description)
+	sql := s.responses[0]
+	s.responses = s.responses[1:]
+	if sql == "" {
+		return nil, &NoSQLError{Response: "无法回答"}
+	}
+	return &Result{SQL: sql}, nil
+}
+
+var usersTable = &models.Table{
+	Name: "users",
+	Columns: []models.Column{
+		{Name: "id", Type: "BIGINT"},
+		{Name: "name", Type: "VARCHAR(100)"},
+	},
+}
+
+func TestValidatingClient_Valid(t *testing.T) {
+	base := &scriptedClient{responses: []string{"SELECT name FROM users"}}
+	client := NewValidatingClient(base, validation.NewValidator(nil), 1)
+
+	result, err := client.GenerateSQL(context.Background(), &Request{Description: "用户名", Tables: []*models.Table{usersTable}})
+	if err != nil {
+		t.Fatalf("GenerateSQL failed: %v", err)
+	}
+	if result.Validation == nil || !result.Validation.Valid || result.Validation.Repaired {
+		t.Errorf("Unexpected validation: %+v", result.Validation)
+	}
+	if len(base.descriptions) != 1 {
+		t.Errorf("Expected a single LLM call, got %d", len(base.descriptions))
+	}
+}
+
+func TestValidatingClient_Repair(t *testing.T) {
+	base := &scriptedClient{responses: []string{"SELECT email FROM users", "SELECT name FROM users"}}
+	client := NewValidatingClient(base, validation.NewValidator(nil), 1)
+
+	result, err := client.GenerateSQL(context.Background(), &Request{Description: "用户名", Tables: []*models.Table{usersTable}})
+	if err != nil {
+		t.Fatalf("GenerateSQL failed: %v", err)
+	}
+	if result.SQL != "SELECT name FROM users" {
+		t.Errorf("Expected repaired SQL, got %q", result.SQL)
+	}
+	if !result.Validation.Valid || !result.Validation.Repaired {
+		t.Errorf("Unexpected validation: %+v", result.Validation)
+	}
+	if len(result.Attempts) != 2 || result.Attempts[0].Valid || !result.Attempts[1].Valid {
+		t.Errorf("Unexpected attempts: %+v", result.Attempts)
+	}
+
+	repairPrompt := base.descriptions[1]
+	if !strings.Contains(repairPrompt, "SELECT email FROM users") || !strings.Contains(repairPrompt, `column "email" does not exist`) {
+		t.Errorf("Repair prompt lacks the failed SQL or its issues: %q", repairPrompt)
+	}
+	if !strings.Contains(repairPrompt, "上一次生成的SQL未通过表结构校验") {
+		t.Errorf("Repair prompt is not in the language of the request: %q", repairPrompt)
+	}
+}
+
+func TestRepairDescriptionLanguage(t *testing.T) {
+	english := RepairDescription("user names", "SELECT email FROM users", []string{`column "email" does not exist`}, language.English)
+	if !strings.HasPrefix(english, "user names\n\nThe previously generated SQL failed schema validation:") || !strings.HasSuffix(english, "that exist in the given schema.") {
+		t.Errorf("Unexpected English repair prompt: %q", english)
+	}
+}
+
+func TestValidatingClient_KeepsOriginalWhenRepairFails(t *testing.T) {
+	base := &scriptedClient{responses: []string{"SELECT email FROM users", "SELECT email, phone FROM users"}}
+	client := NewValidatingClient(base, validation.NewValidator(nil), 1)
+
+	result, err := client.GenerateSQL(context.Background(), &Request{Description: "用户邮箱", Tables: []*models.Table{usersTable}})
+	if err != nil {
+		t.Fatalf("GenerateSQL failed: %v", err)
+	}
+	if result.SQL != "SELECT email FROM users" || result.Validation.Valid || result.Validation.Repaired {
+		t.Errorf("Expected the original invalid result, got %q %+v", result.SQL, result.Validation)
+	}
+}
+
+func TestValidatingClient_RepairDisabled(t *testing.T) {
+	base := &scriptedClient{responses: []string{"SELECT email FROM users"}}
+	client := NewValidatingClient(base, validation.NewValidator(nil), 0)
+
+	result, err := client.GenerateSQL(context.Background(), &Request{Description: "用户邮箱", Tables: []*models.Table{usersTable}})
+	if err != nil {
+		t.Fatalf("GenerateSQL failed: %v", err)
+	}
+	if result.Validation.Valid || len(result.Validation.Issues) != 1 {
+		t.Errorf("Unexpected validation: %+v", result.Validation)
+	}
+	if len(base.descriptions) != 1 {
+		t.Errorf("Expected no repair attempt, got %d calls", len(base.descriptions))
+	}
+}
+
+func TestValidatingClient_BoundedRounds(t *testing.T) {
+	base := &scriptedClient{responses: []string{
+		"SELECT email, phone FROM users",
+		"SELECT email, phone, age FROM users",
+		"SELECT email FROM users",
+		"SELECT name FROM users",
+	}}
+	client := NewValidatingClient(base, validation.NewValidator(nil), 2)
+
+	result, err := client.GenerateSQL(context.Background(), &Request{Description: "用户邮箱", Tables: []*models.Table{usersTable}})
+	if err != nil {
+		t.Fatalf("GenerateSQL failed: %v", err)
+	}
+	if len(base.descriptions) != 3 {
+		t.Errorf("Expected 3 LLM calls, got %d", len(base.descriptions))
+	}
+	if len(result.Attempts) != 3 {
+		t.Fatalf("Expected 3 attempts, got %+v", result.Attempts)
+	}
+	// The attempt with the fewest errors wins
+	if result.SQL != "SELECT email FROM users" || result.Validation.Valid || !result.Validation.Repaired {
+		t.Errorf("Unexpected result %q %+v", result.SQL, result.Validation)
+	}
+	if !strings.Contains(base.descriptions[2], "SELECT email, phone, age FROM users") {
+		t.Errorf("Repair prompt should contain the previous attempt: %q", base.descriptions[2])
+	}
+}
+
+func TestValidatingClient_RetriesWithoutSQL(t *testing.T) {
+	base := &scriptedClient{responses: []string{"", "SELECT name FROM users"}}
+	client := NewValidatingClient(base, validation.NewValidator(nil), 1)
+
+	result, err := client.GenerateSQL(context.Background(), &Request{Description: "用户名", Tables: []*models.Table{usersTable}})
+	if err != nil {
+		t.Fatalf("GenerateSQL failed: %v", err)
+	}
+	if len(result.Attempts) != 2 || result.Attempts[0].Error == "" || !result.Validation.Repaired {
+		t.Errorf("Unexpected attempts %+v, validation %+v", result.Attempts, result.Validation)
+	}
+
+	base = &scriptedClient{responses: []string{"", ""}}
+	client = NewValidatingClient(base, validation.NewValidator(nil), 1)
+	_, err = client.GenerateSQL(context.Background(), &Request{Description: "用户名", Tables: []*models.Table{usersTable}})
+	var noSQL *NoSQLError
+	if !errors.As(err, &noSQL) {
+		t.Errorf("Expected NoSQLError, got %v", err)
+	}
+}
+
+func TestValidatingClient_DialectMismatch(t *testing.T) {
+	base := &scriptedClient{responses: []string{
+		"SELECT name, part FROM users LATERAL VIEW explode(split(name, ',')) t AS part",
+		"SELECT name, part FROM users CROSS JOIN UNNEST(split(name, ',')) AS t(part)",
+	}}
+	client := NewValidatingClient(base, validation.NewValidator(nil), 1)
+
+	request := &Request{Description: "用户标签", Tables: []*models.Table{usersTable}, Dialect: dialect.Presto}
+	result, err := client.GenerateSQL(context.Background(), request)
+	if err != nil {
+		t.Fatalf("GenerateSQL failed: %v", err)
+	}
+	if !result.Validation.Valid || len(result.Attempts) != 2 {
+		t.Errorf("Unexpected result %q %+v", result.SQL, result.Attempts)
+	}
+	if !strings.Contains(base.descriptions[1], "LATERAL VIEW is not supported in Presto/Trino") {
+		t.Errorf("Repair prompt lacks the dialect issue: %q", base.descriptions[1])
+	}
+}
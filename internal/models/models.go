@@ -1,15 +1,122 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // Table represents a database table structure
 type Table struct {
-	ID          string    `json:"id" bson:"_id,omitempty"`
-	Name        string    `json:"name" bson:"name" binding:"required"`
-	Description string    `json:"description" bson:"description"`
-	Columns     []Column  `json:"columns" bson:"columns"`
-	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" bson:"updated_at"`
+	ID          string   `json:"id" bson:"_id,omitempty"`
+	Name        string   `json:"name" bson:"name" binding:"required"`
+	Description string   `json:"description" bson:"description"`
+	Columns     []Column `json:"columns" bson:"columns"`
+	// PartitionKeys are the partition columns of a Hive-style table. As in
+	// Hive DDL they are not repeated in Columns but can be queried like them.
+	PartitionKeys []Column   `json:"partition_keys,omitempty" bson:"partition_keys"`
+	Bucketing     *Bucketing `json:"bucketing,omitempty" bson:"bucketing"`
+	// StorageFormat is the file format, e.g. ORC, PARQUET or TEXTFILE
+	StorageFormat string `json:"storage_format,omitempty" bson:"storage_format" binding:"omitempty,oneof=ORC PARQUET TEXTFILE SEQUENCEFILE RCFILE AVRO JSONFILE"`
+	Location      string `json:"location,omitempty" bson:"location"`
+	TableType     string `json:"table_type,omitempty" bson:"table_type" binding:"omitempty,oneof=MANAGED EXTERNAL"`
+	// Relationships are the foreign keys from this table to other tables
+	Relationships []Relationship `json:"relationships,omitempty" bson:"relationships" binding:"dive"`
+	CreatedAt     time.Time      `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at" bson:"updated_at"`
+}
+
+// Table types
+const (
+	TableTypeManaged  = "MANAGED"
+	TableTypeExternal = "EXTERNAL"
+)
+
+// Bucketing describes "CLUSTERED BY (...) [SORTED BY (...)] INTO n BUCKETS"
+type Bucketing struct {
+	Columns  []string `json:"columns" bson:"columns" binding:"required,min=1"`
+	SortedBy []string `json:"sorted_by,omitempty" bson:"sorted_by"`
+	Buckets  int      `json:"buckets" bson:"buckets" binding:"required,min=1"`
+}
+
+// Relationship links columns of the owning table to columns of another
+// table, usually a foreign key. Columns and ToColumns pair up by position.
+type Relationship struct {
+	Columns   []string `json:"columns" bson:"columns" binding:"required,min=1"`
+	ToTable   string   `json:"to_table" bson:"to_table" binding:"required"`
+	ToColumns []string `json:"to_columns" bson:"to_columns" binding:"required,min=1"`
+	// Cardinality is seen from the owning table, e.g. many orders to one user
+	Cardinality string `json:"cardinality,omitempty" bson:"cardinality" binding:"omitempty,oneof=one_to_one many_to_one one_to_many many_to_many"`
+	Description string `json:"description,omitempty" bson:"description"`
+}
+
+// Relationship cardinalities
+const (
+	OneToOne   = "one_to_one"
+	ManyToOne  = "many_to_one"
+	OneToMany  = "one_to_many"
+	ManyToMany = "many_to_many"
+)
+
+// AllColumns returns the regular columns followed by the partition keys
+func (t *Table) AllColumns() []Column {
+	if len(t.PartitionKeys) == 0 {
+		return t.Columns
+	}
+	columns := make([]Column, 0, len(t.Columns)+len(t.PartitionKeys))
+	columns = append(columns, t.Columns...)
+	return append(columns, t.PartitionKeys...)
+}
+
+// SetCardinalities marks foreign keys over the whole primary key as
+// one-to-one and all others as many-to-one
+func (t *Table) SetCardinalities() {
+	primary := make(map[string]bool)
+	for _, column := range t.Columns {
+		if column.IsPrimary {
+			primary[strings.ToLower(column.Name)] = true
+		}
+	}
+
+	for i := range t.Relationships {
+		rel := &t.Relationships[i]
+		rel.Cardinality = ManyToOne
+		if len(primary) == len(rel.Columns) {
+			oneToOne := true
+			for _, name := range rel.Columns {
+				oneToOne = oneToOne && primary[strings.ToLower(name)]
+			}
+			if oneToOne {
+				rel.Cardinality = OneToOne
+			}
+		}
+	}
+}
+
+// Clone returns a deep copy of the table that shares no slices with it
+func (t *Table) Clone() *Table {
+	clone := *t
+	clone.Columns = cloneSlice(t.Columns)
+	clone.PartitionKeys = cloneSlice(t.PartitionKeys)
+	if t.Bucketing != nil {
+		bucketing := *t.Bucketing
+		bucketing.Columns = cloneSlice(t.Bucketing.Columns)
+		bucketing.SortedBy = cloneSlice(t.Bucketing.SortedBy)
+		clone.Bucketing = &bucketing
+	}
+	clone.Relationships = cloneSlice(t.Relationships)
+	for i := range clone.Relationships {
+		clone.Relationships[i].Columns = cloneSlice(clone.Relationships[i].Columns)
+		clone.Relationships[i].ToColumns = cloneSlice(clone.Relationships[i].ToColumns)
+	}
+	return &clone
+}
+
+// cloneSlice copies s, keeping nil slices nil
+func cloneSlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
 }
 
 // Column represents a column in a database table
@@ -19,18 +126,269 @@ type Column struct {
 	Description string `json:"description" bson:"description"`
 	IsPrimary   bool   `json:"is_primary" bson:"is_primary"`
 	IsRequired  bool   `json:"is_required" bson:"is_required"`
+	// Sensitivity tags personal, financial or secret data: pii, financial or secret
+	Sensitivity string `json:"sensitivity,omitempty" bson:"sensitivity,omitempty"`
 }
 
+// Column sensitivity tags
+const (
+	SensitivityPII       = "pii"
+	SensitivityFinancial = "financial"
+	SensitivitySecret    = "secret"
+)
+
 // Query represents a generated SQL query
 type Query struct {
-	ID          string    `json:"id" bson:"_id,omitempty"`
-	Description string    `json:"description" bson:"description"`
-	SQL         string    `json:"sql" bson:"sql"`
-	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
+	ID          string              `json:"id" bson:"_id,omitempty"`
+	Description string              `json:"description" bson:"description"`
+	SQL         string              `json:"sql" bson:"sql"`
+	Dialect     string              `json:"dialect,omitempty" bson:"dialect,omitempty"`
+	Language    string              `json:"language,omitempty" bson:"language,omitempty"`
+	Explanation string              `json:"explanation" bson:"explanation"`
+	TablesUsed  []TableUsage        `json:"tables_used" bson:"tables_used"`
+	Assumptions []string            `json:"assumptions" bson:"assumptions"`
+	Confidence  float64             `json:"confidence" bson:"confidence"`
+	Validation  *Validation         `json:"validation,omitempty" bson:"validation,omitempty"`
+	Attempts    []GenerationAttempt `json:"attempts,omitempty" bson:"attempts,omitempty"`
+	// PromptTruncation lists the schema and examples left out of the prompt to fit the model's context window
+	PromptTruncation *PromptTruncation `json:"prompt_truncation,omitempty" bson:"prompt_truncation,omitempty"`
+	// PromptTemplate and PromptTemplateVersion identify the template the prompt was rendered from
+	PromptTemplate        string `json:"prompt_template,omitempty" bson:"prompt_template,omitempty"`
+	PromptTemplateVersion int    `json:"prompt_template_version,omitempty" bson:"prompt_template_version,omitempty"`
+	// Execution is the outcome of the latest run against a sandbox datasource
+	Execution *QueryExecution `json:"execution,omitempty" bson:"execution,omitempty"`
+	// Plan is the latest EXPLAIN output of the query and the warnings derived from it
+	Plan *QueryPlan `json:"plan,omitempty" bson:"plan,omitempty"`
+	// Feedback is the latest verdict of a user on the query; correct queries serve as few-shot examples
+	Feedback  *QueryFeedback `json:"feedback,omitempty" bson:"feedback,omitempty"`
+	CreatedAt time.Time      `json:"created_at" bson:"created_at"`
+}
+
+// SimilarQuery is a past query whose description is similar to a question
+type SimilarQuery struct {
+	Query *Query `json:"query"`
+	// Score is the cosine similarity of the embeddings of the description and the question
+	Score float64 `json:"score"`
+}
+
+// QueryFeedback records whether a generated query answers its question
+type QueryFeedback struct {
+	Correct   bool      `json:"correct" bson:"correct"`
+	Comment   string    `json:"comment,omitempty" bson:"comment,omitempty"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// TableUsage lists the columns of a table referenced by a generated query
+type TableUsage struct {
+	Table   string   `json:"table" bson:"table"`
+	Columns []string `json:"columns" bson:"columns"`
+}
+
+// Validation is the result of checking a generated query against the stored table schemas
+type Validation struct {
+	Valid    bool              `json:"valid" bson:"valid"`
+	Issues   []ValidationIssue `json:"issues" bson:"issues"`
+	Repaired bool              `json:"repaired" bson:"repaired"`
+}
+
+// ValidationIssue describes an unknown, ambiguous or mistyped identifier in a generated query
+type ValidationIssue struct {
+	Code     string `json:"code" bson:"code"`
+	Severity string `json:"severity" bson:"severity"`
+	Message  string `json:"message" bson:"message"`
+	Table    string `json:"table,omitempty" bson:"table,omitempty"`
+	Column   string `json:"column,omitempty" bson:"column,omitempty"`
+}
+
+// GenerationAttempt records one round of the generate, validate and repair loop
+type GenerationAttempt struct {
+	SQL    string            `json:"sql" bson:"sql"`
+	Valid  bool              `json:"valid" bson:"valid"`
+	Issues []ValidationIssue `json:"issues" bson:"issues"`
+	// Error is set when the model response contained no usable SQL
+	Error string `json:"error,omitempty" bson:"error,omitempty"`
+}
+
+// PromptTruncation reports the tables, columns and few-shot examples
+// dropped from a prompt because they did not fit the token budget
+type PromptTruncation struct {
+	// Budget is the token budget of the prompt and EstimatedTokens the estimated size of the prompt sent
+	Budget          int              `json:"budget" bson:"budget"`
+	EstimatedTokens int              `json:"estimated_tokens" bson:"estimated_tokens"`
+	OmittedTables   []string         `json:"omitted_tables,omitempty" bson:"omitted_tables,omitempty"`
+	OmittedColumns  []OmittedColumns `json:"omitted_columns,omitempty" bson:"omitted_columns,omitempty"`
+	// OmittedExamples is the number of few-shot examples left out
+	OmittedExamples int `json:"omitted_examples,omitempty" bson:"omitted_examples,omitempty"`
+}
+
+// OmittedColumns lists the columns of a table dropped from a prompt
+type OmittedColumns struct {
+	Table   string   `json:"table" bson:"table"`
+	Columns []string `json:"columns" bson:"columns"`
+}
+
+// QueryExecution records one run of a generated query against a datasource
+type QueryExecution struct {
+	Datasource string `json:"datasource" bson:"datasource"`
+	Status     string `json:"status" bson:"status"`
+	DurationMs int64  `json:"duration_ms" bson:"duration_ms"`
+	RowCount   int    `json:"row_count" bson:"row_count"`
+	// Truncated is set when the result had more rows than the row limit
+	Truncated  bool      `json:"truncated" bson:"truncated"`
+	Error      string    `json:"error,omitempty" bson:"error,omitempty"`
+	ExecutedAt time.Time `json:"executed_at" bson:"executed_at"`
+}
+
+// Execution statuses
+const (
+	ExecutionSucceeded = "succeeded"
+	ExecutionFailed    = "failed"
+	ExecutionTimedOut  = "timed_out"
+)
+
+// QueryPlan is the parsed EXPLAIN output of a query
+type QueryPlan struct {
+	Datasource string     `json:"datasource" bson:"datasource"`
+	Steps      []PlanStep `json:"steps" bson:"steps"`
+	// EstimatedRows is the optimizer's estimate of the rows produced by the
+	// joins of the outer query, 0 when the database does not report one
+	EstimatedRows int64         `json:"estimated_rows,omitempty" bson:"estimated_rows,omitempty"`
+	Warnings      []PlanWarning `json:"warnings" bson:"warnings"`
+	ExplainedAt   time.Time     `json:"explained_at" bson:"explained_at"`
+}
+
+// PlanStep is one table access or operation of a query plan
+type PlanStep struct {
+	ID int `json:"id" bson:"id"`
+	// Table is the table or alias as reported by the database
+	Table string `json:"table,omitempty" bson:"table,omitempty"`
+	// Access is one of full_scan, index_scan, range_scan, index_lookup and const
+	Access string `json:"access,omitempty" bson:"access,omitempty"`
+	// JoinType is the join method, e.g. MySQL's access type (ALL, ref, eq_ref) or nested_loop
+	JoinType      string   `json:"join_type,omitempty" bson:"join_type,omitempty"`
+	Index         string   `json:"index,omitempty" bson:"index,omitempty"`
+	EstimatedRows int64    `json:"estimated_rows,omitempty" bson:"estimated_rows,omitempty"`
+	Partitions    []string `json:"partitions,omitempty" bson:"partitions,omitempty"`
+	Detail        string   `json:"detail,omitempty" bson:"detail,omitempty"`
+}
+
+// PlanWarning flags a potentially expensive part of a query plan
+type PlanWarning struct {
+	Code    string `json:"code" bson:"code"`
+	Table   string `json:"table,omitempty" bson:"table,omitempty"`
+	Message string `json:"message" bson:"message"`
+}
+
+// Plan step access methods
+const (
+	AccessFullScan    = "full_scan"
+	AccessIndexScan   = "index_scan"
+	AccessRangeScan   = "range_scan"
+	AccessIndexLookup = "index_lookup"
+	AccessConst       = "const"
+)
+
+// Plan warning codes
+const (
+	PlanWarningFullScan               = "full_scan"
+	PlanWarningTemporarySort          = "temporary_sort"
+	PlanWarningPartitionFilterMissing = "partition_filter_missing"
+)
+
+// ImportRequest represents the optional body of a schema import request
+type ImportRequest struct {
+	// Datasource is the name of a configured datasource; the default is used when it is empty
+	Datasource string `json:"datasource,omitempty"`
+	// Schema is the MySQL database or Postgres schema to import; the
+	// connection's database or "public" is used when it is empty
+	Schema string `json:"schema,omitempty"`
+	// Tables limits the import to these tables
+	Tables []string `json:"tables,omitempty"`
+}
+
+// ExplainRequest represents the optional body of a query explain request
+type ExplainRequest struct {
+	// Datasource is the name of a configured datasource; the default is used when it is empty
+	Datasource string `json:"datasource,omitempty"`
+}
+
+// FeedbackRequest represents the body of a query feedback request
+type FeedbackRequest struct {
+	// Correct marks whether the SQL answers the question
+	Correct *bool  `json:"correct" binding:"required"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// ExecuteRequest represents the optional body of a query execution request
+type ExecuteRequest struct {
+	// Datasource is the name of a configured datasource; the default is used when it is empty
+	Datasource string `json:"datasource,omitempty"`
+	// MaxRows lowers the configured row limit for this execution
+	MaxRows int `json:"max_rows,omitempty" binding:"omitempty,min=1"`
+}
+
+// SensitivityRequest sets the sensitivity tags of table columns by column
+// name; an empty tag clears it
+type SensitivityRequest struct {
+	Sensitivity map[string]string `json:"sensitivity" binding:"required"`
 }
 
 // QueryRequest represents the request to generate a query
 type QueryRequest struct {
 	Description string   `json:"description" binding:"required"`
 	TableNames  []string `json:"table_names,omitempty"`
-}
\ No newline at end of file
+	// Dialect is one of hive, mysql, postgres, spark, presto (or trino) and
+	// clickhouse; the server default is used when it is empty
+	Dialect string `json:"dialect,omitempty"`
+	// AllowDDL permits DDL statements such as CREATE TABLE in the generated
+	// SQL when the statement policy allows opting in
+	AllowDDL bool `json:"allow_ddl,omitempty"`
+	// Template names the prompt template to use instead of the active one,
+	// at TemplateVersion or, when it is 0, at its active or latest version
+	Template        string `json:"template,omitempty"`
+	TemplateVersion int    `json:"template_version,omitempty" binding:"omitempty,min=1"`
+	// Language (zh or en) is the language of the prompt and of the
+	// explanation; it is detected from Description when empty
+	Language string `json:"language,omitempty"`
+}
+
+// Prompt template sources
+const (
+	TemplateSourceBuiltin = "builtin"
+	TemplateSourceFile    = "file"
+	TemplateSourceStore   = "store"
+)
+
+// PromptTemplate is a version of a text/template the generation prompt is
+// rendered from. Stored templates get a new version on every change.
+type PromptTemplate struct {
+	Name    string `json:"name" bson:"name" binding:"required"`
+	Version int    `json:"version" bson:"version"`
+	// Dialect limits the template to requests for one dialect; empty serves all dialects
+	Dialect string `json:"dialect,omitempty" bson:"dialect,omitempty"`
+	// Tenant limits the template to requests with this X-Tenant-ID header; empty serves all tenants
+	Tenant string `json:"tenant,omitempty" bson:"tenant,omitempty"`
+	// Language limits the template to prompts in one language (zh or en); empty serves all languages
+	Language    string `json:"language,omitempty" bson:"language,omitempty"`
+	Description string `json:"description,omitempty" bson:"description,omitempty"`
+	Body        string `json:"body" bson:"body" binding:"required"`
+	// Active marks the version used for the requests of its tenant, dialect and language that do not name a template
+	Active bool `json:"active" bson:"active"`
+	// Source is where the template was loaded from: builtin, file or store
+	Source    string    `json:"source" bson:"-"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// PromptPreviewRequest renders a prompt without calling the model. The
+// template is given inline by Body or by Name and Version; without either
+// the template a generation request would use is rendered.
+type PromptPreviewRequest struct {
+	Description string   `json:"description" binding:"required"`
+	TableNames  []string `json:"table_names,omitempty"`
+	Dialect     string   `json:"dialect,omitempty"`
+	AllowDDL    bool     `json:"allow_ddl,omitempty"`
+	Language    string   `json:"language,omitempty"`
+	Name        string   `json:"name,omitempty"`
+	Version     int      `json:"version,omitempty" binding:"omitempty,min=1"`
+	Body        string   `json:"body,omitempty"`
+}
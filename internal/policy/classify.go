@@ -0,0 +1,265 @@
+// Package policy decides which generated SQL statements may be saved and
+// returned. Statements are classified from their tokens rather than parsed,
+// so that dialect-specific syntax the parser does not understand is still
+// classified.
+package policy
+
+import (
+	"strings"
+
+	"sql_generator/internal/sqlparser"
+)
+
+// Class is the category of a SQL statement
+type Class string
+
+// Statement classes
+const (
+	// ClassQuery covers read-only SELECT and WITH statements
+	ClassQuery Class = "query"
+	// ClassDML covers statements that modify data
+	ClassDML Class = "dml"
+	// ClassDDL covers statements that create, change or remove objects
+	ClassDDL Class = "ddl"
+	// ClassDCL covers privilege statements
+	ClassDCL Class = "dcl"
+	// ClassOther covers session, transaction and utility statements
+	ClassOther Class = "other"
+)
+
+// Classes lists every statement class
+func Classes() []Class {
+	return []Class{ClassQuery, ClassDML, ClassDDL, ClassDCL, ClassOther}
+}
+
+// verbClasses maps leading keywords to their class; unlisted verbs are ClassOther
+var verbClasses = map[string]Class{
+	"SELECT":   ClassQuery,
+	"WITH":     ClassQuery,
+	"FROM":     ClassQuery, // Hive's FROM ... SELECT / FROM ... INSERT
+	"INSERT":   ClassDML,
+	"UPDATE":   ClassDML,
+	"DELETE":   ClassDML,
+	"MERGE":    ClassDML,
+	"REPLACE":  ClassDML,
+	"UPSERT":   ClassDML,
+	"LOAD":     ClassDML,
+	"COPY":     ClassDML,
+	"CREATE":   ClassDDL,
+	"ALTER":    ClassDDL,
+	"DROP":     ClassDDL,
+	"TRUNCATE": ClassDDL,
+	"RENAME":   ClassDDL,
+	"COMMENT":  ClassDDL,
+	"MSCK":     ClassDDL,
+	"GRANT":    ClassDCL,
+	"REVOKE":   ClassDCL,
+}
+
+// Statement is one classified statement of a SQL text
+type Statement struct {
+	// Index is the zero-based position of the statement in the text
+	Index int    `json:"index"`
+	Verb  string `json:"verb"`
+	Class Class  `json:"class"`
+	// Text is kept out of API responses so that blocked SQL is not returned
+	Text string `json:"-"`
+}
+
+// Classify splits sql into statements and classifies each. A WITH statement
+// takes the class of its main statement, and a query that writes through a
+// data-modifying CTE or SELECT ... INTO is classified by that write. A query
+// in which another DML, DDL or DCL statement follows the leading keyword,
+// e.g. "SELECT * FROM t DROP TABLE x", is classified by that statement.
+func Classify(sql string, opts sqlparser.Options) ([]Statement, error) {
+	tokens, err := sqlparser.Tokenize(sql, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var statements []Statement
+	start := 0
+	for i, tok := range tokens {
+		if tok.Kind == sqlparser.EOF || (tok.Kind == sqlparser.Operator && tok.Value == ";") {
+			if i > start {
+				end := tok.Pos
+				if tok.Kind == sqlparser.EOF {
+					end = len(sql)
+				}
+				stmt := classify(tokens[start:i])
+				stmt.Index = len(statements)
+				stmt.Text = strings.TrimSpace(sql[tokens[start].Pos:end])
+				statements = append(statements, stmt)
+			}
+			start = i + 1
+		}
+	}
+	return statements, nil
+}
+
+// classify determines the verb and class of the tokens of one statement
+func classify(tokens []sqlparser.Token) Statement {
+	// Skip the parentheses of "(SELECT ...) UNION (...)"
+	first := 0
+	for first < len(tokens)-1 && isOp(tokens[first], "(") {
+		first++
+	}
+
+	verb := tokens[first].Upper
+	if verb == "" {
+		verb = tokens[first].Value
+	}
+	class, ok := verbClasses[verb]
+	if !ok {
+		class = ClassOther
+	}
+	if class != ClassQuery {
+		return Statement{Verb: verb, Class: class}
+	}
+
+	depth := 0
+	for i := first; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch {
+		case isOp(tok, "("):
+			depth++
+			// A CTE or subquery whose body writes, e.g. WITH d AS (DELETE ... RETURNING *)
+			if i+1 < len(tokens) {
+				if c := verbClasses[tokens[i+1].Upper]; (c == ClassDML || c == ClassDDL) && formsStatement(tokens, i+1) {
+					return Statement{Verb: tokens[i+1].Upper, Class: c}
+				}
+			}
+		case isOp(tok, ")"):
+			depth--
+		case depth == 0 && i > first && tok.Kind == sqlparser.Ident:
+			if tok.Upper == "INTO" {
+				// SELECT ... INTO creates a table or writes a file
+				return Statement{Verb: "SELECT INTO", Class: ClassDDL}
+			}
+			// The main statement after a WITH or Hive FROM clause, or a
+			// statement appended without a separator
+			if c := verbClasses[tok.Upper]; c != ClassQuery && c != "" && startsStatement(tokens, i) {
+				return Statement{Verb: tok.Upper, Class: c}
+			}
+		}
+	}
+	return Statement{Verb: verb, Class: ClassQuery}
+}
+
+// startsStatement reports whether the verb at tokens[i] starts a statement
+// rather than being used as a name, alias or function, as in "SELECT comment
+// FROM posts", "ORDER BY t.update", "SELECT max(id) update FROM t" or
+// "REPLACE(name, 'a', 'b')"
+func startsStatement(tokens []sqlparser.Token, i int) bool {
+	switch prev := tokens[i-1]; {
+	case prev.Kind == sqlparser.Operator && prev.Value != ")":
+		return false
+	case prev.Upper == "SELECT" || prev.Upper == "BY" || prev.Upper == "AS":
+		return false
+	case prev.Upper == "FOR":
+		// SELECT ... FOR UPDATE only locks the rows it reads
+		return false
+	}
+	return formsStatement(tokens, i)
+}
+
+// objectKinds are the object keywords following CREATE, ALTER and DROP
+var objectKinds = []string{
+	"TABLE", "VIEW", "INDEX", "DATABASE", "SCHEMA", "FUNCTION", "PROCEDURE", "TRIGGER",
+	"SEQUENCE", "USER", "ROLE", "MATERIALIZED", "TEMPORARY", "TEMP", "EXTERNAL", "UNIQUE", "OR",
+}
+
+// privileges are the keywords a GRANT or REVOKE of privileges starts with
+var privileges = []string{
+	"ALL", "SELECT", "INSERT", "UPDATE", "DELETE", "USAGE", "EXECUTE", "CREATE", "ALTER",
+	"DROP", "REFERENCES", "TRIGGER", "TRUNCATE", "CONNECT", "TEMPORARY", "INDEX",
+}
+
+// formsStatement reports whether the tokens from the verb at tokens[i] on
+// have the shape of that statement, e.g. DELETE FROM, DROP TABLE, UPDATE
+// <name> SET or COPY <name> FROM. An alias that happens to be a verb, as in
+// "FROM logs load WHERE load.id > 1", is followed by something else.
+func formsStatement(tokens []sqlparser.Token, i int) bool {
+	switch tokens[i].Upper {
+	case "INSERT":
+		return wordAt(tokens, i+1, "INTO", "OVERWRITE")
+	case "DELETE":
+		return wordAt(tokens, i+1, "FROM")
+	case "MERGE", "REPLACE", "UPSERT":
+		return wordAt(tokens, i+1, "INTO")
+	case "UPDATE":
+		// UPDATE <name> [[AS] alias] SET
+		j := skipName(tokens, i+1)
+		if j == i+1 {
+			return false
+		}
+		if wordAt(tokens, j, "AS") {
+			j++
+		}
+		if !wordAt(tokens, j, "SET") && isName(tokens, j) {
+			j++
+		}
+		return wordAt(tokens, j, "SET")
+	case "COPY":
+		// COPY <name> [(columns)] FROM/TO, or COPY (query) TO
+		if i+1 < len(tokens) && isOp(tokens[i+1], "(") {
+			return wordAt(tokens, i+2, "SELECT", "WITH")
+		}
+		j := skipName(tokens, i+1)
+		return j > i+1 && (wordAt(tokens, j, "FROM", "TO", "INTO") || (j < len(tokens) && isOp(tokens[j], "(")))
+	case "LOAD":
+		return wordAt(tokens, i+1, "DATA")
+	case "CREATE", "ALTER", "DROP":
+		return wordAt(tokens, i+1, objectKinds...)
+	case "TRUNCATE":
+		return wordAt(tokens, i+1, "TABLE")
+	case "RENAME":
+		return wordAt(tokens, i+1, "TABLE", "USER")
+	case "COMMENT":
+		return wordAt(tokens, i+1, "ON")
+	case "MSCK":
+		return wordAt(tokens, i+1, "REPAIR")
+	case "GRANT", "REVOKE":
+		// A privilege, or a role granted TO or revoked FROM someone
+		if wordAt(tokens, i+1, privileges...) {
+			return true
+		}
+		return isName(tokens, i+1) && wordAt(tokens, i+2, "TO", "FROM")
+	}
+	return false
+}
+
+// wordAt reports whether tokens[i] is an identifier spelled like one of words
+func wordAt(tokens []sqlparser.Token, i int, words ...string) bool {
+	if i >= len(tokens) || tokens[i].Kind != sqlparser.Ident {
+		return false
+	}
+	for _, w := range words {
+		if tokens[i].Upper == w {
+			return true
+		}
+	}
+	return false
+}
+
+// isName reports whether tokens[i] is an identifier or quoted identifier
+func isName(tokens []sqlparser.Token, i int) bool {
+	return i < len(tokens) && (tokens[i].Kind == sqlparser.Ident || tokens[i].Kind == sqlparser.QuotedIdent)
+}
+
+// skipName returns the index after the possibly qualified name at
+// tokens[i], or i when there is none
+func skipName(tokens []sqlparser.Token, i int) int {
+	if !isName(tokens, i) {
+		return i
+	}
+	j := i + 1
+	for j+1 < len(tokens) && isOp(tokens[j], ".") && isName(tokens, j+1) {
+		j += 2
+	}
+	return j
+}
+
+func isOp(tok sqlparser.Token, op string) bool {
+	return tok.Kind == sqlparser.Operator && tok.Value == op
+}
@@ -0,0 +1,143 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	"sql_generator/internal/config"
+	"sql_generator/internal/sqlparser"
+)
+
+// Violation codes
+const (
+	CodeStatementNotAllowed = "statement_not_allowed"
+	CodeTooManyStatements   = "too_many_statements"
+	CodeUnclassified        = "unclassified_statement"
+)
+
+// Violation describes why a statement was blocked
+type Violation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Statement is the offending statement, nil for violations of the whole text
+	Statement *Statement `json:"statement,omitempty"`
+}
+
+// ViolationError is returned by Check when the SQL breaks the policy
+type ViolationError struct {
+	Violations []Violation
+}
+
+func (e *ViolationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+	return "generated SQL violates the statement policy: " + strings.Join(messages, "; ")
+}
+
+// Options are the per-request policy settings
+type Options struct {
+	// AllowDDL additionally permits DDL statements, if the policy allows opting in
+	AllowDDL bool
+}
+
+// Policy decides which statement classes may be generated
+type Policy struct {
+	allowed       map[Class]bool
+	allowDDLOptIn bool
+	maxStatements int
+}
+
+// New creates a policy from its configuration
+func New(cfg config.PolicyConfig) (*Policy, error) {
+	known := make(map[Class]bool)
+	for _, class := range Classes() {
+		known[class] = true
+	}
+
+	p := &Policy{
+		allowed:       make(map[Class]bool),
+		allowDDLOptIn: cfg.AllowDDLOptIn,
+		maxStatements: cfg.MaxStatements,
+	}
+	for _, name := range cfg.AllowedStatements {
+		class := Class(strings.ToLower(strings.TrimSpace(name)))
+		if !known[class] {
+			return nil, fmt.Errorf("unknown statement class %q, expected one of query, dml, ddl, dcl, other", name)
+		}
+		p.allowed[class] = true
+	}
+	return p, nil
+}
+
+// ReadOnly returns the policy stored SQL is checked against before it is
+// executed or explained: a single query statement, whatever the generation
+// policy allowed when the SQL was saved
+func ReadOnly() *Policy {
+	return &Policy{allowed: map[Class]bool{ClassQuery: true}, maxStatements: 1}
+}
+
+// DDLOptInEnabled reports whether requests may opt in to DDL generation
+func (p *Policy) DDLOptInEnabled() bool {
+	return p.allowDDLOptIn
+}
+
+// Allows reports whether statements of class may be generated with opts
+func (p *Policy) Allows(class Class, opts Options) bool {
+	if class == ClassDDL && opts.AllowDDL && p.allowDDLOptIn {
+		return true
+	}
+	return p.allowed[class]
+}
+
+// Check classifies every statement of sql and returns a *ViolationError
+// naming the statements the policy does not allow
+func (p *Policy) Check(sql string, parserOpts sqlparser.Options, opts Options) error {
+	return p.CheckStatements([]string{sql}, parserOpts, opts)
+}
+
+// CheckStatements is Check for SQL that has already been split into
+// statements, such as the result of llm.ExtractStatements. Each statement is
+// classified on its own, so that a separator hidden in a comment of the
+// joined text cannot merge two statements into one.
+func (p *Policy) CheckStatements(texts []string, parserOpts sqlparser.Options, opts Options) error {
+	var statements []Statement
+	for _, text := range texts {
+		classified, err := Classify(text, parserOpts)
+		if err != nil {
+			return &ViolationError{Violations: []Violation{{
+				Code:    CodeUnclassified,
+				Message: fmt.Sprintf("the SQL could not be split into statements: %v", err),
+			}}}
+		}
+		for _, stmt := range classified {
+			stmt.Index = len(statements)
+			statements = append(statements, stmt)
+		}
+	}
+
+	var violations []Violation
+	if p.maxStatements > 0 && len(statements) > p.maxStatements {
+		violations = append(violations, Violation{
+			Code:    CodeTooManyStatements,
+			Message: fmt.Sprintf("found %d statements but at most %d are allowed", len(statements), p.maxStatements),
+		})
+	}
+	for i := range statements {
+		stmt := &statements[i]
+		if p.Allows(stmt.Class, opts) {
+			continue
+		}
+		message := fmt.Sprintf("statement %d (%s) is %s, which is not allowed", stmt.Index+1, stmt.Verb, stmt.Class)
+		if stmt.Class == ClassDDL && p.allowDDLOptIn && !opts.AllowDDL {
+			message += "; set allow_ddl to generate DDL"
+		}
+		violations = append(violations, Violation{Code: CodeStatementNotAllowed, Message: message, Statement: stmt})
+	}
+
+	if len(violations) > 0 {
+		return &ViolationError{Violations: violations}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package policy
+
+import (
+	"errors"
+	"testing"
+
+	"sql_generator/internal/config"
+	"sql_generator/internal/llm"
+	"sql_generator/internal/sqlparser"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		sql   string
+		verb  string
+		class Class
+	}{
+		{"SELECT * FROM users", "SELECT", ClassQuery},
+		{"WITH t AS (SELECT id FROM users) SELECT * FROM t", "WITH", ClassQuery},
+		{"(SELECT id FROM a) UNION (SELECT id FROM b)", "SELECT", ClassQuery},
+		{"SELECT * FROM users WHERE id = 1 FOR UPDATE", "SELECT", ClassQuery},
+		{"SELECT 'DELETE FROM users' AS note", "SELECT", ClassQuery},
+		{"delete from users", "DELETE", ClassDML},
+		{"WITH t AS (SELECT id FROM users) INSERT INTO archive SELECT * FROM t", "INSERT", ClassDML},
+		{"WITH d AS (DELETE FROM users RETURNING *) SELECT * FROM d", "DELETE", ClassDML},
+		{"FROM src INSERT OVERWRITE TABLE dst SELECT *", "INSERT", ClassDML},
+		{"SELECT * INTO backup FROM users", "SELECT INTO", ClassDDL},
+		{"DROP TABLE users", "DROP", ClassDDL},
+		{"TRUNCATE TABLE users", "TRUNCATE", ClassDDL},
+		{"CREATE TABLE t (id INT)", "CREATE", ClassDDL},
+		{"GRANT SELECT ON users TO bob", "GRANT", ClassDCL},
+		{"SET hive.exec.dynamic.partition = true", "SET", ClassOther},
+		{"SELECT * FROM t DROP TABLE x", "DROP", ClassDDL},
+		{"SELECT id FROM users GRANT ALL ON users TO bob", "GRANT", ClassDCL},
+		{"SELECT comment, t.update FROM posts t ORDER BY comment", "SELECT", ClassQuery},
+		{"SELECT REPLACE(name, 'a', 'b') AS load FROM users", "SELECT", ClassQuery},
+		{"SELECT count(*) copy FROM users", "SELECT", ClassQuery},
+		{"SELECT c.id FROM comments comment WHERE comment.id = 1", "SELECT", ClassQuery},
+		{"SELECT max(id) update FROM t", "SELECT", ClassQuery},
+		{"SELECT id FROM logs load WHERE load.id > 1", "SELECT", ClassQuery},
+		{"SELECT a.id FROM a JOIN s replace ON replace.id = a.id", "SELECT", ClassQuery},
+		{"SELECT d.id FROM drops drop JOIN grants grant ON grant.id = drop.id", "SELECT", ClassQuery},
+		{"SELECT * FROM t UPDATE t SET x = 1", "UPDATE", ClassDML},
+		{"SELECT * FROM t COPY t TO '/tmp/t.csv'", "COPY", ClassDML},
+		{"SELECT * FROM t LOAD DATA INPATH '/x' INTO TABLE t", "LOAD", ClassDML},
+		{"SELECT * FROM t COMMENT ON TABLE t IS 'x'", "COMMENT", ClassDDL},
+		{"WITH d AS (SELECT id FROM t) MERGE INTO t USING d ON t.id = d.id WHEN MATCHED THEN DELETE", "MERGE", ClassDML},
+		{"WITH u AS (UPDATE t SET x = 1 RETURNING id) SELECT * FROM u", "UPDATE", ClassDML},
+	}
+
+	for _, tt := range tests {
+		statements, err := Classify(tt.sql, sqlparser.Options{})
+		if err != nil {
+			t.Errorf("Classify(%q) failed: %v", tt.sql, err)
+			continue
+		}
+		if len(statements) != 1 || statements[0].Verb != tt.verb || statements[0].Class != tt.class {
+			t.Errorf("Classify(%q) = %+v, want %s %s", tt.sql, statements, tt.verb, tt.class)
+		}
+	}
+
+	statements, err := Classify("SELECT 1;\n-- cleanup\nDROP TABLE users;", sqlparser.Options{})
+	if err != nil || len(statements) != 2 {
+		t.Fatalf("Expected two statements, got %+v, %v", statements, err)
+	}
+	if statements[1].Index != 1 || statements[1].Text != "DROP TABLE users" {
+		t.Errorf("Unexpected second statement: %+v", statements[1])
+	}
+}
+
+func newPolicy(t *testing.T, cfg config.PolicyConfig) *Policy {
+	p, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return p
+}
+
+func TestPolicy_Check(t *testing.T) {
+	p := newPolicy(t, config.PolicyConfig{AllowedStatements: []string{"query"}, AllowDDLOptIn: true, MaxStatements: 1})
+
+	if err := p.Check("SELECT * FROM users;", sqlparser.Options{}, Options{}); err != nil {
+		t.Errorf("Expected a query to pass, got %v", err)
+	}
+
+	err := p.Check("SELECT * FROM users; DELETE FROM users", sqlparser.Options{}, Options{})
+	var violation *ViolationError
+	if !errors.As(err, &violation) || len(violation.Violations) != 2 {
+		t.Fatalf("Expected two violations, got %v", err)
+	}
+	if violation.Violations[0].Code != CodeTooManyStatements {
+		t.Errorf("Unexpected first violation: %+v", violation.Violations[0])
+	}
+	if v := violation.Violations[1]; v.Code != CodeStatementNotAllowed || v.Statement.Verb != "DELETE" || v.Statement.Index != 1 {
+		t.Errorf("Unexpected second violation: %+v", v)
+	}
+
+	if err := p.Check("CREATE TABLE t (id INT)", sqlparser.Options{}, Options{}); err == nil {
+		t.Error("Expected DDL to be blocked without opt-in")
+	}
+	if err := p.Check("CREATE TABLE t (id INT)", sqlparser.Options{}, Options{AllowDDL: true}); err != nil {
+		t.Errorf("Expected DDL to pass with opt-in, got %v", err)
+	}
+	if err := p.Check("DROP TABLE t; SELECT 1", sqlparser.Options{}, Options{AllowDDL: true}); err == nil {
+		t.Error("Expected the statement limit to apply with opt-in")
+	}
+	if err := p.Check("UPDATE t SET a = 1", sqlparser.Options{}, Options{AllowDDL: true}); err == nil {
+		t.Error("Expected DML to stay blocked with DDL opt-in")
+	}
+	if err := p.Check("SELECT 'unterminated", sqlparser.Options{}, Options{}); !errors.As(err, &violation) || violation.Violations[0].Code != CodeUnclassified {
+		t.Errorf("Expected an unclassified violation, got %v", err)
+	}
+}
+
+func TestPolicy_CheckStatements(t *testing.T) {
+	p := newPolicy(t, config.PolicyConfig{AllowedStatements: []string{"query"}, MaxStatements: 1})
+
+	statements := llm.ExtractStatements("SELECT * FROM t -- note\n; DROP TABLE x")
+	err := p.CheckStatements(statements, sqlparser.Options{}, Options{})
+	var violation *ViolationError
+	if !errors.As(err, &violation) || len(violation.Violations) != 2 {
+		t.Fatalf("Expected too many statements and a DROP violation, got %v", err)
+	}
+	if v := violation.Violations[1]; v.Statement.Verb != "DROP" || v.Statement.Index != 1 {
+		t.Errorf("Unexpected violation: %+v", v)
+	}
+
+	// The joined text is classified the same way
+	if err := p.Check(llm.JoinStatements(statements), sqlparser.Options{}, Options{}); err == nil {
+		t.Error("Expected the joined statements to be blocked")
+	}
+	if err := p.Check("SELECT * FROM t -- note;\nDROP TABLE x", sqlparser.Options{}, Options{}); err == nil {
+		t.Error("Expected DROP after a query to be blocked")
+	}
+}
+
+func TestPolicy_Config(t *testing.T) {
+	p := newPolicy(t, config.PolicyConfig{AllowedStatements: []string{"query"}})
+	if err := p.Check("CREATE TABLE t (id INT)", sqlparser.Options{}, Options{AllowDDL: true}); err == nil {
+		t.Error("Expected opt-in to be ignored when disabled")
+	}
+	if err := p.Check("SELECT 1; SELECT 2", sqlparser.Options{}, Options{}); err != nil {
+		t.Errorf("Expected no statement limit, got %v", err)
+	}
+
+	p = newPolicy(t, config.PolicyConfig{AllowedStatements: []string{"query", "DML"}, MaxStatements: 1})
+	if err := p.Check("INSERT INTO t VALUES (1)", sqlparser.Options{}, Options{}); err != nil {
+		t.Errorf("Expected configured DML to pass, got %v", err)
+	}
+
+	if _, err := New(config.PolicyConfig{AllowedStatements: []string{"select"}}); err == nil {
+		t.Error("Expected an unknown class to be rejected")
+	}
+}
@@ -0,0 +1,329 @@
+// Package prompt builds the SQL generation prompt shared by the model
+// clients. The prompt is a list of chat messages rendered from a
+// text/template selected by Templates: the rules in a system message, the
+// table schema in a context message, few-shot examples of past questions
+// and their SQL, and the question. The examples and the table schema are
+// fitted into a token budget derived from the model's context window: when
+// they do not fit, the least similar examples and the least relevant tables
+// and columns are left out and reported in a models.PromptTruncation.
+package prompt
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"sql_generator/internal/config"
+	"sql_generator/internal/dialect"
+	"sql_generator/internal/language"
+	"sql_generator/internal/models"
+	"sql_generator/internal/schemagraph"
+)
+
+// Input is what a prompt is built from
+type Input struct {
+	Description string
+	// Tables are the candidate tables, most relevant first when they were retrieved by search
+	Tables   []*models.Table
+	Dialect  dialect.Dialect
+	JoinPlan []schemagraph.Edge
+	AllowDDL bool
+	// Language is the language of the prompt and of the explanation asked
+	// for; empty selects language.Default
+	Language language.Language
+	// Template renders the prompt; nil selects the built-in template of Language
+	Template *Template
+	// Examples are answered questions shown to the model before the question,
+	// most similar first
+	Examples []*models.Query
+}
+
+// Builder builds prompts within a token budget
+type Builder struct {
+	// Budget is the maximum estimated size of a prompt in tokens; 0 disables truncation
+	Budget int
+}
+
+// NewBuilder creates a Builder with the budget of cfg
+func NewBuilder(cfg config.LLMConfig) *Builder {
+	return &Builder{Budget: Budget(cfg)}
+}
+
+// Build returns the prompt for in and, when examples, tables or columns had
+// to be left out to stay within the budget, what was omitted. Examples may
+// take a quarter of the budget and the schema what the rest of the prompt
+// leaves. Primary keys, join columns, partition keys and relationships of
+// the tables kept are never dropped. An error is returned when the template
+// cannot be executed.
+func (b *Builder) Build(in *Input) (*Prompt, error) {
+	tmpl := in.Template
+	if tmpl == nil {
+		tmpl = builtinTemplate(in.Language)
+	}
+
+	schemas := make([]*tableSchema, len(in.Tables))
+	for i, table := range in.Tables {
+		schemas[i] = newTableSchema(in, table)
+	}
+
+	examples := in.Examples
+	if b.Budget > 0 {
+		examples = fitExamples(in.Examples, b.Budget/exampleShare)
+
+		// The requirements of the full table list are at least as long as
+		// those of any subset, so their estimate is safe to reserve
+		fixed, err := tmpl.messages(templateData(in, ""), examples)
+		if err != nil {
+			return nil, err
+		}
+		fit(in, schemas, b.Budget-messageTokens(fixed))
+	}
+
+	var schema strings.Builder
+	kept := *in
+	kept.Tables = nil
+	omittedTables := 0
+	for _, s := range schemas {
+		if s.omitted {
+			omittedTables++
+			continue
+		}
+		schema.WriteString(s.render())
+		kept.Tables = append(kept.Tables, s.table)
+	}
+	if omittedTables > 0 {
+		schema.WriteString(tablesNote(omittedTables, in.Language))
+	}
+
+	messages, err := tmpl.messages(templateData(&kept, schema.String()), examples)
+	if err != nil {
+		return nil, err
+	}
+	p := &Prompt{Messages: messages}
+	p.Truncation = truncation(schemas, len(in.Examples)-len(examples), b.Budget, p)
+	return p, nil
+}
+
+// templateData is what the template is executed with for in and the schema
+// section of its tables
+func templateData(in *Input, schema string) *TemplateData {
+	data := &TemplateData{
+		Description:  in.Description,
+		Language:     string(promptLanguage(in.Language)),
+		Schema:       schema,
+		JoinPlan:     joinPlanInfo(in.JoinPlan, in.Language),
+		Requirements: promptRequirements(in),
+		ResultFormat: phrasesFor(in.Language).resultFormat,
+		Tables:       in.Tables,
+		AllowDDL:     in.AllowDDL,
+	}
+	if in.Dialect != "" {
+		data.Dialect = in.Dialect.DisplayName()
+	}
+	return data
+}
+
+// tablesNote tells the model that n tables were left out
+func tablesNote(n int, lang language.Language) string {
+	return fmt.Sprintf(phrasesFor(lang).omittedTables, n)
+}
+
+// columnsNote tells the model that n columns of a table were left out
+func columnsNote(n int, lang language.Language) string {
+	return fmt.Sprintf(phrasesFor(lang).omittedColumns, n)
+}
+
+// tableSchema is the schema section of one table with the columns kept
+type tableSchema struct {
+	table   *models.Table
+	lang    language.Language
+	header  string
+	lines   []string
+	key     []bool
+	kept    []bool
+	extra   string
+	omitted bool
+}
+
+func newTableSchema(in *Input, table *models.Table) *tableSchema {
+	keys := keyColumns(in, table)
+	s := &tableSchema{
+		table:  table,
+		lang:   in.Language,
+		header: fmt.Sprintf(phrasesFor(in.Language).tableHeader, table.Name, table.Description),
+		extra:  partitionKeysInfo(table, in.Language) + relationshipsInfo(table, in.Language),
+	}
+	for _, col := range table.Columns {
+		s.lines = append(s.lines, columnInfo(col, in.Language))
+		s.key = append(s.key, keys[strings.ToLower(col.Name)])
+		s.kept = append(s.kept, true)
+	}
+	return s
+}
+
+// columnInfo 返回字段在提示词中的说明行
+func columnInfo(col models.Column, lang language.Language) string {
+	p := phrasesFor(lang)
+	info := fmt.Sprintf("  - %s (%s): %s", col.Name, col.Type, col.Description)
+	if col.IsPrimary {
+		info += p.primaryKey
+	}
+	if col.IsRequired {
+		info += p.required
+	}
+	return info + "\n"
+}
+
+// omittedColumns returns the names of the columns left out
+func (s *tableSchema) omittedColumns() []string {
+	var names []string
+	for i, kept := range s.kept {
+		if !kept {
+			names = append(names, s.table.Columns[i].Name)
+		}
+	}
+	return names
+}
+
+func (s *tableSchema) render() string {
+	var b strings.Builder
+	b.WriteString(s.header)
+	for i, line := range s.lines {
+		if s.kept[i] {
+			b.WriteString(line)
+		}
+	}
+	if n := len(s.omittedColumns()); n > 0 {
+		b.WriteString(columnsNote(n, s.lang))
+	}
+	b.WriteString(s.extra)
+	return b.String()
+}
+
+// fit leaves tables and columns out until schemas fit into budget tokens.
+// Starting with the least relevant table, tables without key columns or
+// columns sharing terms with the question are left out, and the columns of
+// the others that are neither; then whole tables from the least relevant up,
+// then the other non-key columns of the most relevant table.
+// Columns of the tables kept are then put back, most relevant first, as
+// long as they still fit. When the keys of the most relevant table alone
+// exceed the budget the prompt is left over budget.
+func fit(in *Input, schemas []*tableSchema, budget int) {
+	question := terms(in.Description)
+	ranked := rankTables(in, question)
+
+	sizes := make([]int, len(schemas))
+	total, omittedTables := 0, 0
+	for i, s := range schemas {
+		sizes[i] = EstimateTokens(s.render())
+		total += sizes[i]
+	}
+	over := func() bool {
+		size := total
+		if omittedTables > 0 {
+			size += EstimateTokens(tablesNote(omittedTables, in.Language))
+		}
+		return size > budget
+	}
+	resize := func(index int) {
+		size := 0
+		if s := schemas[index]; !s.omitted {
+			size = EstimateTokens(s.render())
+		}
+		total += size - sizes[index]
+		sizes[index] = size
+	}
+
+	type column struct{ table, index int }
+	var dropped []column
+	dropColumns := func(table int, matching bool) {
+		s := schemas[table]
+		var order []int
+		for i, col := range s.table.Columns {
+			if !s.key[i] && s.kept[i] && (matching || columnScore(question, col) < 1) {
+				order = append(order, i)
+			}
+		}
+		// Lowest scores first, later columns before earlier ones
+		sort.SliceStable(order, func(i, j int) bool {
+			si, sj := columnScore(question, s.table.Columns[order[i]]), columnScore(question, s.table.Columns[order[j]])
+			if si != sj {
+				return si < sj
+			}
+			return order[i] > order[j]
+		})
+		for _, i := range order {
+			if !over() {
+				return
+			}
+			s.kept[i] = false
+			resize(table)
+			dropped = append(dropped, column{table, i})
+		}
+	}
+
+	omit := func(table int) {
+		if !schemas[table].omitted {
+			schemas[table].omitted = true
+			omittedTables++
+			resize(table)
+		}
+	}
+
+	for k := len(ranked) - 1; k >= 0 && over(); k-- {
+		if k > 0 && !relevant(question, schemas[ranked[k].index]) {
+			omit(ranked[k].index)
+			continue
+		}
+		dropColumns(ranked[k].index, false)
+	}
+	for k := len(ranked) - 1; k > 0 && over(); k-- {
+		omit(ranked[k].index)
+	}
+	if len(ranked) > 0 && over() {
+		dropColumns(ranked[0].index, true)
+	}
+
+	for k := len(dropped) - 1; k >= 0; k-- {
+		c := dropped[k]
+		if schemas[c.table].omitted {
+			continue
+		}
+		schemas[c.table].kept[c.index] = true
+		resize(c.table)
+		if over() {
+			schemas[c.table].kept[c.index] = false
+			resize(c.table)
+		}
+	}
+}
+
+// relevant reports whether a table has key columns or columns sharing terms
+// with the question; without either only its name would be left to show
+func relevant(question map[string]bool, s *tableSchema) bool {
+	for i, col := range s.table.Columns {
+		if s.key[i] || columnScore(question, col) >= 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// truncation reports the examples and what fit left out, nil when nothing was
+func truncation(schemas []*tableSchema, omittedExamples, budget int, p *Prompt) *models.PromptTruncation {
+	report := &models.PromptTruncation{Budget: budget, OmittedExamples: omittedExamples}
+	for _, s := range schemas {
+		if s.omitted {
+			report.OmittedTables = append(report.OmittedTables, s.table.Name)
+			continue
+		}
+		if columns := s.omittedColumns(); len(columns) > 0 {
+			report.OmittedColumns = append(report.OmittedColumns, models.OmittedColumns{Table: s.table.Name, Columns: columns})
+		}
+	}
+	if len(report.OmittedTables) == 0 && len(report.OmittedColumns) == 0 && omittedExamples == 0 {
+		return nil
+	}
+	report.EstimatedTokens = p.Tokens()
+	return report
+}
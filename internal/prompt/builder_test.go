@@ -0,0 +1,229 @@
+package prompt
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sql_generator/internal/config"
+	"sql_generator/internal/language"
+	"sql_generator/internal/models"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"用户表", 3},
+		{"hello world", 4},
+		{"user_id", 3},
+		{"查询每个用户的订单数量，按金额排序", 17},
+		{"  - amount (DECIMAL(10,2)): 订单金额\n", 17},
+	}
+	for _, tt := range tests {
+		if got := EstimateTokens(tt.text); got != tt.want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestBudget(t *testing.T) {
+	tests := []struct {
+		cfg  config.LLMConfig
+		want int
+	}{
+		{config.LLMConfig{Model: "gpt-3.5-turbo", MaxTokens: 2000}, 12947},
+		{config.LLMConfig{Model: "gpt-4o-mini", MaxTokens: 0}, 115200},
+		{config.LLMConfig{Model: "GPT-4-0613", MaxTokens: 2000}, 5573},
+		{config.LLMConfig{Model: "qwen-max", ContextWindow: 10000, MaxTokens: 2000}, 7200},
+		// MaxTokens larger than the window leaves a quarter of it to the prompt
+		{config.LLMConfig{Model: "unknown", MaxTokens: 10000}, 1844},
+	}
+	for _, tt := range tests {
+		if got := Budget(tt.cfg); got != tt.want {
+			t.Errorf("Budget(%+v) = %d, want %d", tt.cfg, got, tt.want)
+		}
+	}
+}
+
+// budgetInput has a relevant users/orders pair and a large unrelated table
+func budgetInput() *Input {
+	users := &models.Table{
+		Name:        "users",
+		Description: "用户表",
+		Columns: []models.Column{
+			{Name: "id", Type: "BIGINT", Description: "用户ID", IsPrimary: true},
+			{Name: "name", Type: "VARCHAR(100)", Description: "用户名", IsRequired: true},
+			{Name: "last_login_ip", Type: "VARCHAR(64)", Description: "最近登录IP"},
+			{Name: "avatar_url", Type: "VARCHAR(255)", Description: "头像地址"},
+		},
+	}
+	orders := &models.Table{
+		Name:        "orders",
+		Description: "订单表",
+		Columns: []models.Column{
+			{Name: "id", Type: "BIGINT", Description: "订单ID", IsPrimary: true},
+			{Name: "user_id", Type: "BIGINT", Description: "下单人"},
+			{Name: "amount", Type: "DECIMAL(10,2)", Description: "订单金额"},
+			{Name: "remark", Type: "TEXT", Description: "备注"},
+		},
+		Relationships: []models.Relationship{
+			{Columns: []string{"user_id"}, ToTable: "users", ToColumns: []string{"id"}, Cardinality: models.ManyToOne},
+		},
+	}
+	audit := &models.Table{Name: "audit_log", Description: "系统审计日志"}
+	for i := 0; i < 30; i++ {
+		audit.Columns = append(audit.Columns, models.Column{Name: fmt.Sprintf("field_%d", i), Type: "STRING", Description: "审计字段"})
+	}
+	return &Input{
+		Description: "统计每个用户的订单金额",
+		Tables:      []*models.Table{audit, users, orders},
+	}
+}
+
+// build builds the prompt for in and fails the test on template errors
+func build(t *testing.T, b *Builder, in *Input) *Prompt {
+	t.Helper()
+	p, err := b.Build(in)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	return p
+}
+
+func TestBuildWithoutTruncation(t *testing.T) {
+	in := budgetInput()
+	unlimited := build(t, &Builder{}, in)
+	if unlimited.Truncation != nil {
+		t.Fatalf("expected no truncation without a budget, got %+v", unlimited.Truncation)
+	}
+	p := build(t, &Builder{Budget: unlimited.Tokens()}, in)
+	text := p.Text()
+	if p.Truncation != nil || text != unlimited.Text() {
+		t.Errorf("expected the full prompt when it fits the budget, got %+v", p.Truncation)
+	}
+	for _, want := range []string{"用户需求：统计每个用户的订单金额", "表名: audit_log", "  - remark (TEXT): 备注\n", "关联关系:\n", "要求：\n", `"sql"`} {
+		if !strings.Contains(text, want) {
+			t.Errorf("prompt lacks %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestBuildDropsLeastRelevantTables(t *testing.T) {
+	in := budgetInput()
+	relevant := *in
+	relevant.Tables = in.Tables[1:]
+	full := build(t, &Builder{}, &relevant)
+
+	budget := full.Tokens() + EstimateTokens(tablesNote(1, language.Chinese))
+	p := build(t, &Builder{Budget: budget}, in)
+	text, report := p.Text(), p.Truncation
+	if report == nil || !reflect.DeepEqual(report.OmittedTables, []string{"audit_log"}) || len(report.OmittedColumns) != 0 {
+		t.Fatalf("unexpected truncation %+v", report)
+	}
+	if report.Budget != budget || report.EstimatedTokens > budget || report.EstimatedTokens != p.Tokens() {
+		t.Errorf("prompt exceeds the budget: %+v", report)
+	}
+	if strings.Contains(text, "audit_log") || !strings.Contains(text, "省略了1张相关性较低的表") || !strings.Contains(text, "  - remark (TEXT)") {
+		t.Errorf("unexpected prompt:\n%s", text)
+	}
+}
+
+func TestBuildDropsLeastRelevantColumns(t *testing.T) {
+	in := budgetInput()
+	users, orders := *in.Tables[1], *in.Tables[2]
+	users.Columns = users.Columns[:2]
+	orders.Columns = orders.Columns[:3]
+	trimmed := *in
+	trimmed.Tables = []*models.Table{&users, &orders}
+	expected := build(t, &Builder{}, &trimmed)
+
+	// Room for the relevant tables without their unrelated columns
+	budget := expected.Tokens() + EstimateTokens(columnsNote(2, language.Chinese)) + EstimateTokens(columnsNote(1, language.Chinese)) + EstimateTokens(tablesNote(1, language.Chinese))
+	p := build(t, &Builder{Budget: budget}, in)
+	text, report := p.Text(), p.Truncation
+	if report == nil || report.EstimatedTokens > budget {
+		t.Fatalf("unexpected truncation %+v", report)
+	}
+	// orders.remark is shorter than the note that would replace it
+	want := []models.OmittedColumns{
+		{Table: "users", Columns: []string{"last_login_ip", "avatar_url"}},
+	}
+	if !reflect.DeepEqual(report.OmittedColumns, want) || !reflect.DeepEqual(report.OmittedTables, []string{"audit_log"}) {
+		t.Errorf("unexpected omitted columns %+v", report)
+	}
+	// Keys, join columns and columns matching the question are kept
+	for _, line := range []string{"  - id (BIGINT): 用户ID [主键]\n", "  - user_id (BIGINT): 下单人\n", "  - amount (DECIMAL(10,2)): 订单金额\n", "  - name (VARCHAR(100)): 用户名 [必填]\n"} {
+		if !strings.Contains(text, line) {
+			t.Errorf("prompt lacks %q:\n%s", line, text)
+		}
+	}
+	if !strings.Contains(text, "省略了2个字段") {
+		t.Errorf("prompt lacks the column note:\n%s", text)
+	}
+}
+
+func TestBuildKeepsKeysOverBudget(t *testing.T) {
+	p := build(t, &Builder{Budget: 1}, budgetInput())
+	text, report := p.Text(), p.Truncation
+	if report == nil || !reflect.DeepEqual(report.OmittedTables, []string{"audit_log", "orders"}) || report.EstimatedTokens <= 1 {
+		t.Fatalf("unexpected truncation %+v", report)
+	}
+	if !strings.Contains(text, "  - id (BIGINT): 用户ID [主键]\n") || strings.Contains(text, "用户名") {
+		t.Errorf("expected only the key of the most relevant table:\n%s", text)
+	}
+}
+
+func TestBuildWithExamples(t *testing.T) {
+	short := &models.Query{
+		Description: "统计用户数",
+		SQL:         "SELECT COUNT(*) FROM users",
+		Explanation: "统计users表的行数",
+		TablesUsed:  []models.TableUsage{{Table: "users", Columns: []string{"id"}}},
+		Confidence:  0.9,
+	}
+	long := &models.Query{Description: strings.Repeat("统计每个城市每天新注册的用户数量", 20), SQL: "SELECT city, dt, COUNT(*) FROM users GROUP BY city, dt"}
+	in := budgetInput()
+	in.Examples = []*models.Query{short, long}
+
+	messages := build(t, &Builder{}, in).Messages
+	roles := make([]string, len(messages))
+	for i, m := range messages {
+		roles[i] = m.Role
+	}
+	want := []string{RoleSystem, RoleUser, RoleUser, RoleAssistant, RoleUser, RoleAssistant, RoleUser}
+	if !reflect.DeepEqual(roles, want) {
+		t.Fatalf("roles = %v, want %v", roles, want)
+	}
+	if messages[2].Content != short.Description || messages[6].Content != "用户需求："+in.Description {
+		t.Errorf("unexpected example or question order: %+v", messages)
+	}
+	var answer struct {
+		SQL        string              `json:"sql"`
+		TablesUsed []models.TableUsage `json:"tables_used"`
+	}
+	if err := json.Unmarshal([]byte(messages[3].Content), &answer); err != nil || answer.SQL != short.SQL || len(answer.TablesUsed) != 1 {
+		t.Errorf("unexpected example answer %q: %v", messages[3].Content, err)
+	}
+
+	// Examples get a quarter of the budget; the long one does not fit
+	budget := exampleShare * (messageTokens(exampleMessage(short)) + 1)
+	p := build(t, &Builder{Budget: budget}, in)
+	if p.Truncation == nil || p.Truncation.OmittedExamples != 1 {
+		t.Fatalf("expected one omitted example, got %+v", p.Truncation)
+	}
+	if strings.Contains(p.Text(), "每个城市") || !strings.Contains(p.Text(), short.SQL) {
+		t.Errorf("expected only the short example:\n%s", p.Text())
+	}
+
+	// A template without parts is sent as one message after the examples
+	in.Template = mustParseTemplate(models.PromptTemplate{Name: "single", Body: "{{.Description}}"})
+	messages = build(t, &Builder{}, in).Messages
+	if len(messages) != 5 || messages[4].Role != RoleUser || messages[4].Content != in.Description {
+		t.Errorf("unexpected single template messages %+v", messages)
+	}
+}
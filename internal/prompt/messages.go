@@ -0,0 +1,161 @@
+package prompt
+
+import (
+	"encoding/json"
+	"strings"
+
+	"sql_generator/internal/models"
+)
+
+// Roles of chat messages
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// messageOverhead is the estimated number of tokens chat APIs add to every
+// message for its role and delimiters
+const messageOverhead = 4
+
+// exampleShare is the part of the budget few-shot examples may take: a
+// budget of n leaves n/exampleShare tokens to them
+const exampleShare = 4
+
+// Message is one chat message of a prompt
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Prompt is a built prompt: the chat messages sent to the model and, when
+// something had to be left out to stay within the budget, what was omitted
+type Prompt struct {
+	Messages   []Message
+	Truncation *models.PromptTruncation
+}
+
+// Text returns the contents of the messages separated by blank lines
+func (p *Prompt) Text() string {
+	contents := make([]string, len(p.Messages))
+	for i, m := range p.Messages {
+		contents[i] = m.Content
+	}
+	return strings.Join(contents, "\n\n")
+}
+
+// Tokens estimates the size of the messages in tokens
+func (p *Prompt) Tokens() int {
+	return messageTokens(p.Messages)
+}
+
+// messageTokens estimates the size of messages in tokens
+func messageTokens(messages []Message) int {
+	tokens := 0
+	for _, m := range messages {
+		tokens += EstimateTokens(m.Content) + messageOverhead
+	}
+	return tokens
+}
+
+// templateParts are the templates a prompt template can define with
+// {{define}} to split the prompt into messages, in the order they are sent.
+// Few-shot examples are sent between the context and the question.
+var templateParts = []struct{ name, role string }{
+	{"system", RoleSystem},
+	{"context", RoleUser},
+	{"question", RoleUser},
+}
+
+// hasParts reports whether the template defines any of templateParts
+func (t *Template) hasParts() bool {
+	for _, part := range templateParts {
+		if defined := t.tmpl.Lookup(part.name); defined != nil && defined != t.tmpl {
+			return true
+		}
+	}
+	return false
+}
+
+// messages renders the template with data into chat messages. The parts
+// a template defines are sent as separate messages, leaving out those that
+// render empty; a template without parts is sent as one user message. The
+// examples are sent before the question, or before the only message.
+func (t *Template) messages(data *TemplateData, examples []*models.Query) ([]Message, error) {
+	if !t.hasParts() {
+		text, err := t.execute(t.tmpl.Name(), data)
+		if err != nil {
+			return nil, err
+		}
+		return append(exampleMessages(examples), Message{Role: RoleUser, Content: text}), nil
+	}
+
+	var messages []Message
+	for _, part := range templateParts {
+		if part.name == "question" {
+			messages = append(messages, exampleMessages(examples)...)
+		}
+		if defined := t.tmpl.Lookup(part.name); defined == nil || defined == t.tmpl {
+			continue
+		}
+		text, err := t.execute(part.name, data)
+		if err != nil {
+			return nil, err
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			messages = append(messages, Message{Role: part.role, Content: text})
+		}
+	}
+	return messages, nil
+}
+
+// exampleAnswer is the answer of a few-shot example, in the JSON format
+// asked for by the result format
+type exampleAnswer struct {
+	SQL         string              `json:"sql"`
+	Explanation string              `json:"explanation"`
+	TablesUsed  []models.TableUsage `json:"tables_used"`
+	Assumptions []string            `json:"assumptions"`
+	Confidence  float64             `json:"confidence"`
+}
+
+// exampleMessages returns each example as the question asked by the user
+// followed by the answer of the assistant
+func exampleMessages(examples []*models.Query) []Message {
+	var messages []Message
+	for _, q := range examples {
+		messages = append(messages, exampleMessage(q)...)
+	}
+	return messages
+}
+
+// exampleMessage returns the question and answer of one example
+func exampleMessage(q *models.Query) []Message {
+	// Marshaling strings, numbers and slices of them cannot fail
+	answer, _ := json.Marshal(exampleAnswer{
+		SQL:         q.SQL,
+		Explanation: q.Explanation,
+		TablesUsed:  q.TablesUsed,
+		Assumptions: q.Assumptions,
+		Confidence:  q.Confidence,
+	})
+	return []Message{
+		{Role: RoleUser, Content: q.Description},
+		{Role: RoleAssistant, Content: string(answer)},
+	}
+}
+
+// fitExamples returns the examples that fit into budget tokens, keeping
+// their order; an example too large to fit is skipped for smaller ones
+func fitExamples(examples []*models.Query, budget int) []*models.Query {
+	var kept []*models.Query
+	for _, q := range examples {
+		size := messageTokens(exampleMessage(q))
+		if size > budget {
+			continue
+		}
+		budget -= size
+		kept = append(kept, q)
+	}
+	return kept
+}
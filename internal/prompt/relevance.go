@@ -0,0 +1,157 @@
+package prompt
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"sql_generator/internal/models"
+)
+
+// terms splits s into the lower-cased terms used to match schema against the
+// question: Latin words and identifier parts of at least three characters,
+// whole identifiers such as user_id, and the character bigrams of CJK text,
+// which has no word boundaries
+func terms(s string) map[string]bool {
+	set := make(map[string]bool)
+	var word, cjk []rune
+	flushWord := func() {
+		if len(word) == 0 {
+			return
+		}
+		ident := string(word)
+		for _, part := range strings.Split(ident, "_") {
+			if len(part) >= 3 {
+				set[part] = true
+			}
+		}
+		if len(ident) >= 3 {
+			set[ident] = true
+		}
+		word = word[:0]
+	}
+	flushCJK := func() {
+		if len(cjk) == 1 {
+			set[string(cjk)] = true
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			set[string(cjk[i:i+2])] = true
+		}
+		cjk = cjk[:0]
+	}
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return set
+}
+
+// overlap counts the terms of text that also occur in question
+func overlap(question map[string]bool, text string) int {
+	n := 0
+	for term := range terms(text) {
+		if question[term] {
+			n++
+		}
+	}
+	return n
+}
+
+// rankedTable is a table with its relevance to the question
+type rankedTable struct {
+	table *models.Table
+	// index is the position of the table in the request
+	index int
+	score float64
+}
+
+// rankTables orders tables from the most to the least relevant. A table
+// scores for being named in the question, for terms its name, description
+// and columns share with the question and for being on the join plan. The
+// request order, which is the retrieval ranking for searched tables, adds
+// up to one point and breaks ties.
+func rankTables(in *Input, question map[string]bool) []rankedTable {
+	planned := make(map[string]bool)
+	for _, edge := range in.JoinPlan {
+		planned[edge.From] = true
+		planned[edge.To] = true
+	}
+
+	description := strings.ToLower(in.Description)
+	ranked := make([]rankedTable, len(in.Tables))
+	for i, table := range in.Tables {
+		score := float64(len(in.Tables)-i) / float64(len(in.Tables))
+		if strings.Contains(description, strings.ToLower(table.Name)) {
+			score += 3
+		}
+		score += float64(overlap(question, table.Name+" "+table.Description))
+		for _, col := range table.Columns {
+			if overlap(question, col.Name+" "+col.Description) > 0 {
+				score += 0.5
+			}
+		}
+		if planned[table.Name] {
+			score++
+		}
+		ranked[i] = rankedTable{table: table, index: i, score: score}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+	return ranked
+}
+
+// keyColumns returns the columns of table that are never dropped: primary
+// keys and the columns of relationships and join plan edges, without which
+// the joins between the tables cannot be written
+func keyColumns(in *Input, table *models.Table) map[string]bool {
+	keys := make(map[string]bool)
+	add := func(columns []string) {
+		for _, name := range columns {
+			keys[strings.ToLower(name)] = true
+		}
+	}
+	for _, col := range table.Columns {
+		if col.IsPrimary {
+			keys[strings.ToLower(col.Name)] = true
+		}
+	}
+	for _, rel := range table.Relationships {
+		add(rel.Columns)
+	}
+	for _, other := range in.Tables {
+		for _, rel := range other.Relationships {
+			if strings.EqualFold(rel.ToTable, table.Name) {
+				add(rel.ToColumns)
+			}
+		}
+	}
+	for _, edge := range in.JoinPlan {
+		if edge.From == table.Name {
+			add(edge.FromColumns)
+		}
+		if edge.To == table.Name {
+			add(edge.ToColumns)
+		}
+	}
+	return keys
+}
+
+// columnScore is the relevance of an optional column: the terms it shares
+// with the question, plus half a point for required columns
+func columnScore(question map[string]bool, col models.Column) float64 {
+	score := float64(overlap(question, col.Name+" "+col.Description))
+	if col.IsRequired {
+		score += 0.5
+	}
+	return score
+}
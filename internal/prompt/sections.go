@@ -0,0 +1,234 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+
+	"sql_generator/internal/language"
+	"sql_generator/internal/models"
+	"sql_generator/internal/schemagraph"
+)
+
+// phrases are the fixed texts of the prompt in one language
+type phrases struct {
+	// resultFormat asks for the JSON object parsed by llm.parseResult
+	resultFormat string
+	// general are the requirements of every prompt
+	general []string
+	// targetDialect, partitioned and writeIn take a dialect, a table list and a language name
+	targetDialect, partitioned, writeIn        string
+	allowDDL, followJoinPlan, useRelationships string
+	requirements                               string
+	listSeparator                              string
+	// tableHeader takes the table name and description
+	tableHeader                   string
+	primaryKey, required          string
+	partitionKeys, partitionKey   string
+	relationships                 string
+	cardinalities                 map[string]string
+	joinPlan, inferred            string
+	omittedTables, omittedColumns string
+}
+
+// chinesePhrases 是中文提示词的固定文本
+var chinesePhrases = &phrases{
+	resultFormat: `请严格按照以下JSON格式返回结果，不要输出JSON以外的任何内容：
+{
+  "sql": "生成的SQL语句",
+  "explanation": "用自然语言解释该SQL的查询逻辑",
+  "tables_used": [{"table": "表名", "columns": ["字段名"]}],
+  "assumptions": ["生成SQL时所做的假设"],
+  "confidence": 0.0到1.0之间的数字，表示对SQL正确性的信心
+}
+`,
+	general:          []string{"生成有效的SQL语句", "如需要多表关联，请使用适当的JOIN语句"},
+	targetDialect:    "目标SQL方言为%s",
+	partitioned:      "以下表为分区表，查询时必须在WHERE子句中对其分区字段进行过滤：%s",
+	writeIn:          "explanation和assumptions请使用%s撰写",
+	allowDDL:         "需求涉及建表或修改表结构时，可以生成CREATE、ALTER等DDL语句",
+	followJoinPlan:   "多表关联时优先按照推荐的关联路径进行JOIN，路径中的中间表可用于连接其他表",
+	useRelationships: "多表关联时使用表结构中列出的关联关系作为JOIN条件，不要臆测关联字段",
+	requirements:     "要求：\n",
+	listSeparator:    "、",
+	tableHeader:      "\n表名: %s\n描述: %s\n字段:\n",
+	primaryKey:       " [主键]",
+	required:         " [必填]",
+	partitionKeys:    "分区字段（查询时必须过滤）:\n",
+	partitionKey:     " [分区键]",
+	relationships:    "关联关系:\n",
+	cardinalities: map[string]string{
+		models.OneToOne:   "一对一",
+		models.ManyToOne:  "多对一",
+		models.OneToMany:  "一对多",
+		models.ManyToMany: "多对多",
+	},
+	joinPlan:       "\n推荐的关联路径:\n",
+	inferred:       " [根据字段命名推断]",
+	omittedTables:  "\n... (省略了%d张相关性较低的表) ...\n",
+	omittedColumns: "  ... (省略了%d个字段)\n",
+}
+
+// englishPhrases 是英文提示词的固定文本
+var englishPhrases = &phrases{
+	resultFormat: `Return the result strictly in the following JSON format and output nothing outside the JSON:
+{
+  "sql": "the generated SQL statement",
+  "explanation": "the logic of the SQL in natural language",
+  "tables_used": [{"table": "table name", "columns": ["column name"]}],
+  "assumptions": ["assumptions made while writing the SQL"],
+  "confidence": a number between 0.0 and 1.0 expressing confidence that the SQL is correct
+}
+`,
+	general:          []string{"Generate valid SQL", "Use appropriate JOINs when several tables are needed"},
+	targetDialect:    "The target SQL dialect is %s",
+	partitioned:      "The following tables are partitioned and must be filtered on their partition columns in the WHERE clause: %s",
+	writeIn:          "Write the explanation and assumptions in %s",
+	allowDDL:         "When the request is about creating or changing tables, DDL statements such as CREATE and ALTER may be generated",
+	followJoinPlan:   "When joining tables, prefer the recommended join path; its intermediate tables can be used to connect the others",
+	useRelationships: "When joining tables, use the relationships listed in the schema as join conditions and do not guess join columns",
+	requirements:     "Requirements:\n",
+	listSeparator:    ", ",
+	tableHeader:      "\nTable: %s\nDescription: %s\nColumns:\n",
+	primaryKey:       " [primary key]",
+	required:         " [required]",
+	partitionKeys:    "Partition columns (must be filtered):\n",
+	partitionKey:     " [partition key]",
+	relationships:    "Relationships:\n",
+	cardinalities: map[string]string{
+		models.OneToOne:   "one-to-one",
+		models.ManyToOne:  "many-to-one",
+		models.OneToMany:  "one-to-many",
+		models.ManyToMany: "many-to-many",
+	},
+	joinPlan:       "\nRecommended join path:\n",
+	inferred:       " [inferred from column names]",
+	omittedTables:  "\n... (%d less relevant tables omitted) ...\n",
+	omittedColumns: "  ... (%d columns omitted)\n",
+}
+
+// phrasesFor 返回指定语言的固定文本，未知语言使用中文
+func phrasesFor(lang language.Language) *phrases {
+	if lang == language.English {
+		return englishPhrases
+	}
+	return chinesePhrases
+}
+
+// promptRequirements 返回提示词中的生成要求：通用要求、方言规则以及分区表的过滤要求。
+// 需求描述的语言与提示词语言不同时，要求模型使用提示词语言撰写说明
+func promptRequirements(in *Input) string {
+	p := phrasesFor(in.Language)
+	items := append([]string(nil), p.general...)
+	if in.Dialect != "" {
+		items = append(items, fmt.Sprintf(p.targetDialect, in.Dialect.DisplayName()))
+	}
+	items = append(items, in.Dialect.PromptRules(in.Language)...)
+
+	var partitioned []string
+	for _, table := range in.Tables {
+		if len(table.PartitionKeys) == 0 {
+			continue
+		}
+		keys := make([]string, len(table.PartitionKeys))
+		for i, key := range table.PartitionKeys {
+			keys[i] = key.Name
+		}
+		partitioned = append(partitioned, fmt.Sprintf("%s(%s)", table.Name, strings.Join(keys, ", ")))
+	}
+	if len(partitioned) > 0 {
+		items = append(items, fmt.Sprintf(p.partitioned, strings.Join(partitioned, p.listSeparator)))
+	}
+
+	if in.AllowDDL {
+		items = append(items, p.allowDDL)
+	}
+	if len(in.JoinPlan) > 0 {
+		items = append(items, p.followJoinPlan)
+	}
+	for _, table := range in.Tables {
+		if len(table.Relationships) > 0 {
+			items = append(items, p.useRelationships)
+			break
+		}
+	}
+	if lang := promptLanguage(in.Language); language.Detect(in.Description) != lang {
+		items = append(items, fmt.Sprintf(p.writeIn, lang.DisplayName()))
+	}
+
+	var b strings.Builder
+	b.WriteString(p.requirements)
+	for i, item := range items {
+		b.WriteString(fmt.Sprintf("%d. %s\n", i+1, item))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// partitionKeysInfo 返回表的分区字段说明，非分区表返回空字符串
+func partitionKeysInfo(table *models.Table, lang language.Language) string {
+	if len(table.PartitionKeys) == 0 {
+		return ""
+	}
+	p := phrasesFor(lang)
+	var b strings.Builder
+	b.WriteString(p.partitionKeys)
+	for _, key := range table.PartitionKeys {
+		b.WriteString(fmt.Sprintf("  - %s (%s): %s%s\n", key.Name, key.Type, key.Description, p.partitionKey))
+	}
+	return b.String()
+}
+
+// relationshipsInfo 返回表的关联关系说明，没有关联关系时返回空字符串
+func relationshipsInfo(table *models.Table, lang language.Language) string {
+	if len(table.Relationships) == 0 {
+		return ""
+	}
+	p := phrasesFor(lang)
+	var b strings.Builder
+	b.WriteString(p.relationships)
+	for _, rel := range table.Relationships {
+		from := make([]string, len(rel.Columns))
+		to := make([]string, len(rel.ToColumns))
+		for i, name := range rel.Columns {
+			from[i] = table.Name + "." + name
+		}
+		for i, name := range rel.ToColumns {
+			to[i] = rel.ToTable + "." + name
+		}
+		b.WriteString(fmt.Sprintf("  - %s -> %s", strings.Join(from, ", "), strings.Join(to, ", ")))
+		if name, ok := p.cardinalities[rel.Cardinality]; ok {
+			b.WriteString(fmt.Sprintf(" (%s)", name))
+		}
+		if rel.Description != "" {
+			b.WriteString(": " + rel.Description)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// joinPlanInfo 返回推荐的关联路径说明，没有关联路径时返回空字符串
+func joinPlanInfo(plan []schemagraph.Edge, lang language.Language) string {
+	if len(plan) == 0 {
+		return ""
+	}
+	p := phrasesFor(lang)
+	var b strings.Builder
+	b.WriteString(p.joinPlan)
+	for _, edge := range plan {
+		b.WriteString(fmt.Sprintf("  - %s JOIN %s ON %s", edge.From, edge.To, edge.Condition()))
+		if edge.Inferred {
+			b.WriteString(p.inferred)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// promptLanguage 返回提示词使用的语言，未指定时使用默认语言
+func promptLanguage(lang language.Language) language.Language {
+	if lang == "" {
+		return language.Default
+	}
+	return lang
+}
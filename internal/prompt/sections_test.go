@@ -0,0 +1,113 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+
+	"sql_generator/internal/dialect"
+	"sql_generator/internal/language"
+	"sql_generator/internal/models"
+	"sql_generator/internal/schemagraph"
+)
+
+var usersTable = &models.Table{
+	Name: "users",
+	Columns: []models.Column{
+		{Name: "id", Type: "BIGINT"},
+		{Name: "name", Type: "VARCHAR(100)"},
+	},
+}
+
+func TestPromptRequirements(t *testing.T) {
+	events := &models.Table{
+		Name:          "events",
+		Columns:       []models.Column{{Name: "id", Type: "BIGINT"}},
+		PartitionKeys: []models.Column{{Name: "dt", Type: "STRING"}, {Name: "hour", Type: "STRING"}},
+	}
+	hive := promptRequirements(&Input{Tables: []*models.Table{usersTable, events}, Dialect: dialect.Hive})
+	if !strings.Contains(hive, "3. 目标SQL方言为Hive SQL (HiveQL)\n4. ") || !strings.Contains(hive, "LATERAL VIEW") {
+		t.Errorf("Unexpected Hive requirements: %q", hive)
+	}
+	if !strings.Contains(hive, "必须在WHERE子句中对其分区字段进行过滤：events(dt, hour)\n") {
+		t.Errorf("Hive requirements lack the partition keys: %q", hive)
+	}
+	if got := promptRequirements(&Input{}); !strings.HasSuffix(got, "3. 使用标准SQL语法\n\n") {
+		t.Errorf("Unexpected default requirements: %q", got)
+	}
+	if info := partitionKeysInfo(events, language.Chinese); !strings.Contains(info, "  - dt (STRING):  [分区键]\n") {
+		t.Errorf("Unexpected partition info: %q", info)
+	}
+}
+
+func TestRelationshipsInfo(t *testing.T) {
+	orders := &models.Table{
+		Name:    "orders",
+		Columns: []models.Column{{Name: "id", Type: "BIGINT"}, {Name: "user_id", Type: "BIGINT"}},
+		Relationships: []models.Relationship{
+			{Columns: []string{"user_id"}, ToTable: "users", ToColumns: []string{"id"}, Cardinality: models.ManyToOne, Description: "下单用户"},
+		},
+	}
+	if got := relationshipsInfo(orders, language.Chinese); got != "关联关系:\n  - orders.user_id -> users.id (多对一): 下单用户\n" {
+		t.Errorf("Unexpected relationships info: %q", got)
+	}
+	if got := relationshipsInfo(usersTable, language.Chinese); got != "" {
+		t.Errorf("Expected no relationships info, got %q", got)
+	}
+	if requirements := promptRequirements(&Input{Tables: []*models.Table{orders}}); !strings.Contains(requirements, "关联关系作为JOIN条件") {
+		t.Errorf("Requirements lack the join rule: %q", requirements)
+	}
+}
+
+func TestJoinPlanInfo(t *testing.T) {
+	plan := []schemagraph.Edge{
+		{From: "users", FromColumns: []string{"id"}, To: "orders", ToColumns: []string{"user_id"}},
+		{From: "orders", FromColumns: []string{"id"}, To: "order_items", ToColumns: []string{"order_id"}, Inferred: true},
+	}
+	want := "\n推荐的关联路径:\n" +
+		"  - users JOIN orders ON users.id = orders.user_id\n" +
+		"  - orders JOIN order_items ON orders.id = order_items.order_id [根据字段命名推断]\n"
+	if got := joinPlanInfo(plan, language.Chinese); got != want {
+		t.Errorf("Unexpected join plan info: %q", got)
+	}
+	if requirements := promptRequirements(&Input{JoinPlan: plan}); !strings.Contains(requirements, "推荐的关联路径进行JOIN") {
+		t.Errorf("Requirements lack the join plan rule: %q", requirements)
+	}
+}
+
+func TestEnglishSections(t *testing.T) {
+	orders := &models.Table{
+		Name:          "orders",
+		Columns:       []models.Column{{Name: "id", Type: "BIGINT"}, {Name: "user_id", Type: "BIGINT"}},
+		PartitionKeys: []models.Column{{Name: "dt", Type: "STRING"}},
+		Relationships: []models.Relationship{
+			{Columns: []string{"user_id"}, ToTable: "users", ToColumns: []string{"id"}, Cardinality: models.ManyToOne},
+		},
+	}
+	in := &Input{Description: "Count orders per user", Tables: []*models.Table{orders}, Dialect: dialect.Hive, Language: language.English}
+	requirements := promptRequirements(in)
+	for _, want := range []string{"Requirements:\n1. Generate valid SQL\n", "3. The target SQL dialect is Hive SQL (HiveQL)\n", "LATERAL VIEW explode", ": orders(dt)\n"} {
+		if !strings.Contains(requirements, want) {
+			t.Errorf("English requirements lack %q: %q", want, requirements)
+		}
+	}
+	if strings.Contains(requirements, "Write the explanation") {
+		t.Errorf("English question should not need a language rule: %q", requirements)
+	}
+	if got := relationshipsInfo(orders, language.English); got != "Relationships:\n  - orders.user_id -> users.id (many-to-one)\n" {
+		t.Errorf("Unexpected English relationships info: %q", got)
+	}
+	if got := partitionKeysInfo(orders, language.English); got != "Partition columns (must be filtered):\n  - dt (STRING):  [partition key]\n" {
+		t.Errorf("Unexpected English partition info: %q", got)
+	}
+
+	// A Chinese question answered in English asks for the explanation in English
+	in.Description = "统计每个用户的订单数"
+	if requirements := promptRequirements(in); !strings.Contains(requirements, "Write the explanation and assumptions in English\n") {
+		t.Errorf("Requirements lack the language rule: %q", requirements)
+	}
+	in.Language = language.Chinese
+	in.Description = "Count orders per user"
+	if requirements := promptRequirements(in); !strings.Contains(requirements, "explanation和assumptions请使用中文撰写\n") {
+		t.Errorf("Requirements lack the language rule: %q", requirements)
+	}
+}
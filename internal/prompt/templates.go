@@ -0,0 +1,437 @@
+package prompt
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"sql_generator/internal/dialect"
+	"sql_generator/internal/language"
+	"sql_generator/internal/models"
+	"sql_generator/internal/storage"
+)
+
+// DefaultTemplateName is the name of the built-in templates, which are used
+// when no other template applies
+const DefaultTemplateName = "default"
+
+// builtinFiles holds the built-in template of each language as default.<language>.tmpl
+//
+//go:embed templates/*.tmpl
+var builtinFiles embed.FS
+
+// builtins are the parsed built-in templates by language
+var builtins = loadBuiltins()
+
+func loadBuiltins() map[language.Language]*Template {
+	templates := make(map[language.Language]*Template)
+	for _, lang := range language.Supported() {
+		data, err := builtinFiles.ReadFile("templates/" + DefaultTemplateName + "." + string(lang) + ".tmpl")
+		if err != nil {
+			panic(err)
+		}
+		templates[lang] = mustParseTemplate(models.PromptTemplate{
+			Name:     DefaultTemplateName,
+			Language: string(lang),
+			Body:     strings.TrimSuffix(string(data), "\n"),
+			Source:   models.TemplateSourceBuiltin,
+		})
+	}
+	return templates
+}
+
+// builtinTemplate returns the built-in template of lang, or of
+// language.Default for unknown languages
+func builtinTemplate(lang language.Language) *Template {
+	if t, ok := builtins[lang]; ok {
+		return t
+	}
+	return builtins[language.Default]
+}
+
+var (
+	// ErrTemplateNotFound is returned when a requested template does not exist
+	ErrTemplateNotFound = errors.New("prompt template not found")
+	// ErrInvalidTemplate is returned for templates that cannot be parsed or
+	// executed, and for changes to templates that are not stored
+	ErrInvalidTemplate = errors.New("invalid prompt template")
+)
+
+// TemplateData is what templates are executed with
+type TemplateData struct {
+	// Description is the user's question
+	Description string
+	// Language is the code of the prompt language, zh or en
+	Language string
+	// Dialect is the display name of the target dialect, empty for standard SQL
+	Dialect string
+	// Schema describes the tables that fit the token budget, followed by a
+	// note on the tables left out
+	Schema string
+	// JoinPlan describes the recommended join path, empty without one
+	JoinPlan string
+	// Requirements are the numbered generation rules of the request and dialect
+	Requirements string
+	// ResultFormat asks for the JSON object the result is parsed from
+	ResultFormat string
+	// Tables are the tables described in Schema
+	Tables   []*models.Table
+	AllowDDL bool
+}
+
+// Template is a parsed prompt template. A template that defines any of
+// the templates "system", "context" and "question" is sent as a system
+// message with the rules, a user message with the schema and a user
+// message with the question; otherwise its body is sent as one user
+// message.
+type Template struct {
+	models.PromptTemplate
+	tmpl *template.Template
+}
+
+// ParseTemplate parses the body of t. Missing map keys are errors, so that
+// typos fail when the template is created rather than producing an empty
+// section.
+func ParseTemplate(t models.PromptTemplate) (*Template, error) {
+	tmpl, err := template.New(t.Name).Option("missingkey=error").Parse(t.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return &Template{PromptTemplate: t, tmpl: tmpl}, nil
+}
+
+func mustParseTemplate(t models.PromptTemplate) *Template {
+	parsed, err := ParseTemplate(t)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// DefaultTemplate returns the built-in template of lang
+func DefaultTemplate(lang language.Language) *Template {
+	return builtinTemplate(lang)
+}
+
+// execute renders the named template of t with data
+func (t *Template) execute(name string, data *TemplateData) (string, error) {
+	var b strings.Builder
+	if err := t.tmpl.ExecuteTemplate(&b, name, data); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return b.String(), nil
+}
+
+// TemplateStore persists versioned prompt templates. It is implemented by
+// *storage.MySQLStore.
+type TemplateStore interface {
+	CreatePromptTemplate(ctx context.Context, template *models.PromptTemplate) error
+	GetPromptTemplate(ctx context.Context, name string, version int) (*models.PromptTemplate, error)
+	ListPromptTemplates(ctx context.Context) ([]*models.PromptTemplate, error)
+	ListActivePromptTemplates(ctx context.Context, tenant, dialect, language string) ([]*models.PromptTemplate, error)
+	ListPromptTemplateVersions(ctx context.Context, name string) ([]*models.PromptTemplate, error)
+	ActivatePromptTemplate(ctx context.Context, name string, version int) error
+}
+
+// Templates selects the template a prompt is rendered from. Templates come
+// from the store, from the .tmpl files of a directory and the built-in
+// defaults. Stored templates are versioned and one version per tenant,
+// dialect and language can be active; file and built-in templates have
+// version 0 and serve as fallbacks. A template without a language serves
+// prompts in every language, but one written for the prompt language is
+// preferred at each step of the selection.
+type Templates struct {
+	store TemplateStore
+	// files are the templates read from the template directory
+	files map[fileKey]*Template
+}
+
+// fileKey identifies a file template by name and language
+type fileKey struct {
+	name string
+	lang language.Language
+}
+
+// NewTemplates creates a Templates reading from store, which may be nil,
+// and from the .tmpl files of dir, if dir is not empty. Files are named
+// <name>.tmpl, or <name>.<language>.tmpl for one language such as
+// hive.en.tmpl. A file named after a dialect serves requests for that
+// dialect and default.tmpl replaces the built-in templates. The trailing
+// line break of a file is not part of the template.
+func NewTemplates(store TemplateStore, dir string) (*Templates, error) {
+	t := &Templates{store: store, files: make(map[fileKey]*Template)}
+	if dir == "" {
+		return t, nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompt templates: %w", err)
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read prompt template: %w", err)
+		}
+		file := models.PromptTemplate{
+			Name:   strings.TrimSuffix(filepath.Base(path), ".tmpl"),
+			Body:   strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r"),
+			Source: models.TemplateSourceFile,
+		}
+		if i := strings.LastIndex(file.Name, "."); i > 0 {
+			lang, err := language.Parse(file.Name[i+1:])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			file.Name, file.Language = file.Name[:i], string(lang)
+		}
+		if d, err := dialect.Parse(file.Name); err == nil {
+			file.Dialect = string(d)
+		}
+		parsed, err := ParseTemplate(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		t.files[fileKey{file.Name, language.Language(file.Language)}] = parsed
+	}
+	return t, nil
+}
+
+// Select returns the template for a request in lang. A named template is
+// used at version, or at its active or else latest stored version when
+// version is 0, falling back to the file of that name. Otherwise the active
+// stored template of the tenant and dialect is used, then the one of the
+// tenant for all dialects, then those of all tenants, then the file for the
+// dialect, default.tmpl and the built-in template of lang.
+func (t *Templates) Select(ctx context.Context, name string, version int, d dialect.Dialect, tenant string, lang language.Language) (*Template, error) {
+	if name != "" {
+		return t.Get(ctx, name, version, d, lang)
+	}
+
+	if t.store != nil {
+		active, err := t.store.ListActivePromptTemplates(ctx, tenant, string(d), string(lang))
+		if err != nil {
+			return nil, err
+		}
+		scopes := []struct{ tenant, dialect string }{
+			{tenant, string(d)}, {tenant, ""}, {"", string(d)}, {"", ""},
+		}
+		for _, scope := range scopes {
+			for _, l := range []string{string(lang), ""} {
+				for _, pt := range active {
+					if pt.Tenant == scope.tenant && pt.Dialect == scope.dialect && pt.Language == l {
+						return ParseTemplate(*pt)
+					}
+				}
+			}
+		}
+	}
+
+	if d != "" {
+		if file := t.file(string(d), lang); file != nil {
+			return file, nil
+		}
+	}
+	return t.fallback(lang), nil
+}
+
+// file returns the file template of name for lang, or the one for all
+// languages
+func (t *Templates) file(name string, lang language.Language) *Template {
+	if file, ok := t.files[fileKey{name, lang}]; ok {
+		return file
+	}
+	return t.files[fileKey{name, ""}]
+}
+
+// fallback returns default.tmpl or the built-in template of lang
+func (t *Templates) fallback(lang language.Language) *Template {
+	if file := t.file(DefaultTemplateName, lang); file != nil {
+		return file
+	}
+	return builtinTemplate(lang)
+}
+
+// Get returns a version of the named template. Version 0 selects a stored
+// version for d and lang, the active one before the latest, then the active
+// or latest version for other dialects and languages, and then the file or
+// built-in template of that name for lang.
+func (t *Templates) Get(ctx context.Context, name string, version int, d dialect.Dialect, lang language.Language) (*Template, error) {
+	if version > 0 {
+		if t.store == nil {
+			return nil, fmt.Errorf("%w: %s version %d", ErrTemplateNotFound, name, version)
+		}
+		pt, err := t.store.GetPromptTemplate(ctx, name, version)
+		if err != nil {
+			if errors.Is(err, storage.ErrPromptTemplateNotFound) {
+				return nil, fmt.Errorf("%w: %s version %d", ErrTemplateNotFound, name, version)
+			}
+			return nil, err
+		}
+		return ParseTemplate(*pt)
+	}
+
+	if t.store != nil {
+		versions, err := t.store.ListPromptTemplateVersions(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		var matching []*models.PromptTemplate
+		for _, pt := range versions {
+			if (pt.Dialect == "" || pt.Dialect == string(d)) && (pt.Language == "" || pt.Language == string(lang)) {
+				matching = append(matching, pt)
+			}
+		}
+		if pt := preferredVersion(matching); pt != nil {
+			return ParseTemplate(*pt)
+		}
+		if pt := preferredVersion(versions); pt != nil {
+			return ParseTemplate(*pt)
+		}
+	}
+	if name == DefaultTemplateName {
+		return t.fallback(lang), nil
+	}
+	if file := t.file(name, lang); file != nil {
+		return file, nil
+	}
+	// A file written for another language is better than none
+	for _, other := range language.Supported() {
+		if file, ok := t.files[fileKey{name, other}]; ok {
+			return file, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+}
+
+// preferredVersion returns the active version among versions, or else the
+// latest one
+func preferredVersion(versions []*models.PromptTemplate) *models.PromptTemplate {
+	var latest *models.PromptTemplate
+	for _, pt := range versions {
+		if pt.Active {
+			return pt
+		}
+		if latest == nil || pt.Version > latest.Version {
+			latest = pt
+		}
+	}
+	return latest
+}
+
+// List returns the built-in templates, the file templates and all stored
+// versions
+func (t *Templates) List(ctx context.Context) ([]models.PromptTemplate, error) {
+	var templates []models.PromptTemplate
+	for _, lang := range language.Supported() {
+		templates = append(templates, builtins[lang].PromptTemplate)
+	}
+	keys := make([]fileKey, 0, len(t.files))
+	for key := range t.files {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].name != keys[j].name {
+			return keys[i].name < keys[j].name
+		}
+		return keys[i].lang < keys[j].lang
+	})
+	for _, key := range keys {
+		templates = append(templates, t.files[key].PromptTemplate)
+	}
+
+	stored, err := t.stored(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, pt := range stored {
+		templates = append(templates, *pt)
+	}
+	return templates, nil
+}
+
+// Create validates pt and stores it as the next version of its name.
+// Templates must parse and render a sample request, and file and built-in
+// template names cannot be reused.
+func (t *Templates) Create(ctx context.Context, pt *models.PromptTemplate) error {
+	if t.store == nil {
+		return fmt.Errorf("%w: no template store is configured", ErrInvalidTemplate)
+	}
+	if pt.Name == DefaultTemplateName {
+		return fmt.Errorf("%w: %s is the name of the built-in template", ErrInvalidTemplate, pt.Name)
+	}
+	for key := range t.files {
+		if key.name == pt.Name {
+			return fmt.Errorf("%w: %s is the name of a file template", ErrInvalidTemplate, pt.Name)
+		}
+	}
+	if pt.Dialect != "" {
+		d, err := dialect.Parse(pt.Dialect)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+		}
+		pt.Dialect = string(d)
+	}
+	if pt.Language != "" {
+		lang, err := language.Parse(pt.Language)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+		}
+		pt.Language = string(lang)
+	}
+
+	parsed, err := ParseTemplate(*pt)
+	if err != nil {
+		return err
+	}
+	if _, err := (&Builder{}).Build(sampleInput(parsed, dialect.Dialect(pt.Dialect), language.Language(pt.Language))); err != nil {
+		return err
+	}
+	return t.store.CreatePromptTemplate(ctx, pt)
+}
+
+// Activate makes a stored version the active template of its tenant,
+// dialect and language
+func (t *Templates) Activate(ctx context.Context, name string, version int) error {
+	if t.store == nil {
+		return fmt.Errorf("%w: %s version %d", ErrTemplateNotFound, name, version)
+	}
+	err := t.store.ActivatePromptTemplate(ctx, name, version)
+	if errors.Is(err, storage.ErrPromptTemplateNotFound) {
+		return fmt.Errorf("%w: %s version %d", ErrTemplateNotFound, name, version)
+	}
+	return err
+}
+
+// stored lists the stored templates, none without a store
+func (t *Templates) stored(ctx context.Context) ([]*models.PromptTemplate, error) {
+	if t.store == nil {
+		return nil, nil
+	}
+	return t.store.ListPromptTemplates(ctx)
+}
+
+// sampleInput is a request that exercises every section of a template
+func sampleInput(tmpl *Template, d dialect.Dialect, lang language.Language) *Input {
+	orders := &models.Table{
+		Name:          "orders",
+		Description:   "订单表",
+		Columns:       []models.Column{{Name: "id", Type: "BIGINT", IsPrimary: true}, {Name: "user_id", Type: "BIGINT"}},
+		PartitionKeys: []models.Column{{Name: "dt", Type: "STRING"}},
+		Relationships: []models.Relationship{{Columns: []string{"user_id"}, ToTable: "users", ToColumns: []string{"id"}, Cardinality: models.ManyToOne}},
+	}
+	users := &models.Table{Name: "users", Columns: []models.Column{{Name: "id", Type: "BIGINT", IsPrimary: true}}}
+	return &Input{
+		Description: "每个用户的订单数",
+		Tables:      []*models.Table{orders, users},
+		Dialect:     d,
+		Language:    lang,
+		Template:    tmpl,
+	}
+}
@@ -0,0 +1,361 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sql_generator/internal/dialect"
+	"sql_generator/internal/language"
+	"sql_generator/internal/models"
+	"sql_generator/internal/storage"
+)
+
+// memoryTemplateStore is an in-memory TemplateStore
+type memoryTemplateStore struct {
+	templates []*models.PromptTemplate
+}
+
+func (s *memoryTemplateStore) CreatePromptTemplate(ctx context.Context, template *models.PromptTemplate) error {
+	template.Version = 1
+	for _, t := range s.templates {
+		if t.Name == template.Name && t.Version >= template.Version {
+			template.Version = t.Version + 1
+		}
+	}
+	template.Active = false
+	template.Source = models.TemplateSourceStore
+	stored := *template
+	s.templates = append(s.templates, &stored)
+	return nil
+}
+
+func (s *memoryTemplateStore) GetPromptTemplate(ctx context.Context, name string, version int) (*models.PromptTemplate, error) {
+	for _, t := range s.templates {
+		if t.Name == name && t.Version == version {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s version %d", storage.ErrPromptTemplateNotFound, name, version)
+}
+
+func (s *memoryTemplateStore) ListPromptTemplates(ctx context.Context) ([]*models.PromptTemplate, error) {
+	return s.templates, nil
+}
+
+func (s *memoryTemplateStore) ListActivePromptTemplates(ctx context.Context, tenant, dialect, language string) ([]*models.PromptTemplate, error) {
+	var active []*models.PromptTemplate
+	for _, t := range s.templates {
+		if t.Active && (t.Tenant == tenant || t.Tenant == "") && (t.Dialect == dialect || t.Dialect == "") && (t.Language == language || t.Language == "") {
+			active = append(active, t)
+		}
+	}
+	return active, nil
+}
+
+func (s *memoryTemplateStore) ListPromptTemplateVersions(ctx context.Context, name string) ([]*models.PromptTemplate, error) {
+	var versions []*models.PromptTemplate
+	for _, t := range s.templates {
+		if t.Name == name {
+			versions = append(versions, t)
+		}
+	}
+	return versions, nil
+}
+
+func (s *memoryTemplateStore) ActivatePromptTemplate(ctx context.Context, name string, version int) error {
+	target, err := s.GetPromptTemplate(ctx, name, version)
+	if err != nil {
+		return err
+	}
+	for _, t := range s.templates {
+		if t.Name == name || (t.Dialect == target.Dialect && t.Tenant == target.Tenant && t.Language == target.Language) {
+			t.Active = t == target
+		}
+	}
+	return nil
+}
+
+func TestDefaultTemplateRendersPrompt(t *testing.T) {
+	in := budgetInput()
+	in.Dialect = dialect.Hive
+	messages := build(t, &Builder{}, in).Messages
+
+	if len(messages) != 3 || messages[0].Role != RoleSystem || messages[1].Role != RoleUser || messages[2].Role != RoleUser {
+		t.Fatalf("expected system, context and question messages, got %+v", messages)
+	}
+	system := messages[0].Content
+	if !strings.HasPrefix(system, "你是一名SQL专家") || !strings.Contains(system, "\n要求：\n") || !strings.Contains(system, "目标SQL方言为Hive") {
+		t.Errorf("unexpected system message:\n%s", system)
+	}
+	if !strings.HasSuffix(system, strings.TrimSpace(chinesePhrases.resultFormat)) {
+		t.Errorf("system message does not end with the result format:\n%s", system)
+	}
+	if !strings.HasPrefix(messages[1].Content, "相关表结构：\n\n表名: audit_log\n") || strings.Contains(messages[1].Content, "要求") {
+		t.Errorf("unexpected context message:\n%s", messages[1].Content)
+	}
+	if messages[2].Content != "用户需求：统计每个用户的订单金额" {
+		t.Errorf("unexpected question message %q", messages[2].Content)
+	}
+}
+
+func TestEnglishDefaultTemplate(t *testing.T) {
+	in := budgetInput()
+	in.Description = "Sum the order amount of every user"
+	in.Language = language.English
+	p := build(t, &Builder{}, in)
+	text := p.Text()
+
+	if !strings.HasPrefix(text, "You are an SQL expert") || !strings.HasSuffix(text, "\n\nUser request: Sum the order amount of every user") {
+		t.Errorf("unexpected prompt:\n%s", text)
+	}
+	if context := p.Messages[1].Content; !strings.HasPrefix(context, "Relevant table schemas:\n\nTable: audit_log\n") || !strings.Contains(context, "  - id (BIGINT): 用户ID [primary key]\n") || !strings.Contains(context, "Relationships:\n") {
+		t.Errorf("unexpected English context:\n%s", context)
+	}
+	if !strings.HasSuffix(p.Messages[0].Content, strings.TrimSpace(englishPhrases.resultFormat)) {
+		t.Errorf("unexpected English system message:\n%s", p.Messages[0].Content)
+	}
+	if strings.Contains(text, "要求") {
+		t.Errorf("English prompt contains Chinese instructions:\n%s", text)
+	}
+}
+
+func TestCustomTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate(models.PromptTemplate{
+		Name: "short",
+		Body: "{{.Dialect}}: {{.Description}}\n{{range .Tables}}{{.Name}};{{end}}\n{{.Schema}}",
+	})
+	if err != nil {
+		t.Fatalf("ParseTemplate: %v", err)
+	}
+	in := budgetInput()
+	in.Dialect = dialect.MySQL
+	in.Template = tmpl
+	text := build(t, &Builder{}, in).Text()
+	if !strings.HasPrefix(text, "MySQL: 统计每个用户的订单金额\naudit_log;users;orders;\n\n表名: audit_log") || strings.Contains(text, "要求：") {
+		t.Errorf("unexpected prompt:\n%s", text)
+	}
+
+	// The schema is fitted into what the rest of the template leaves of the budget
+	full := build(t, &Builder{}, in)
+	if report := build(t, &Builder{Budget: full.Tokens() - 1}, in).Truncation; report == nil {
+		t.Error("expected truncation below the size of the prompt")
+	}
+}
+
+func TestCreateRejectsInvalidTemplates(t *testing.T) {
+	templates, err := NewTemplates(&memoryTemplateStore{}, "")
+	if err != nil {
+		t.Fatalf("NewTemplates: %v", err)
+	}
+	invalid := []models.PromptTemplate{
+		{Name: "unclosed", Body: "{{.Description"},
+		{Name: "unknown_field", Body: "{{.Question}}"},
+		{Name: "bad_dialect", Dialect: "cobol", Body: "{{.Description}}"},
+		{Name: DefaultTemplateName, Body: "{{.Description}}"},
+	}
+	for _, pt := range invalid {
+		if err := templates.Create(context.Background(), &pt); !errors.Is(err, ErrInvalidTemplate) {
+			t.Errorf("Create(%s) = %v, want ErrInvalidTemplate", pt.Name, err)
+		}
+	}
+
+	valid := models.PromptTemplate{Name: "hive_short", Dialect: "HIVE", Body: "{{.Description}}"}
+	if err := templates.Create(context.Background(), &valid); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if valid.Version != 1 || valid.Dialect != string(dialect.Hive) {
+		t.Errorf("unexpected stored template %+v", valid)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"hive.tmpl":    "hive file {{.Description}}\n",
+		"default.tmpl": "default file {{.Description}}\n",
+		"terse.tmpl":   "terse file {{.Description}}\n",
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store := &memoryTemplateStore{}
+	templates, err := NewTemplates(store, dir)
+	if err != nil {
+		t.Fatalf("NewTemplates: %v", err)
+	}
+	ctx := context.Background()
+	create := func(name, d, tenant string, active bool) {
+		pt := &models.PromptTemplate{Name: name, Dialect: d, Tenant: tenant, Body: name + " {{.Description}}"}
+		if err := templates.Create(ctx, pt); err != nil {
+			t.Fatalf("Create(%s): %v", name, err)
+		}
+		if active {
+			if err := templates.Activate(ctx, pt.Name, pt.Version); err != nil {
+				t.Fatalf("Activate(%s): %v", name, err)
+			}
+		}
+	}
+	check := func(name string, version int, d dialect.Dialect, tenant, want string, wantVersion int) {
+		t.Helper()
+		tmpl, err := templates.Select(ctx, name, version, d, tenant, language.Chinese)
+		if err != nil {
+			t.Fatalf("Select(%q, %d, %q, %q): %v", name, version, d, tenant, err)
+		}
+		if tmpl.Name != want || tmpl.Version != wantVersion {
+			t.Errorf("Select(%q, %d, %q, %q) = %s v%d, want %s v%d", name, version, d, tenant, tmpl.Name, tmpl.Version, want, wantVersion)
+		}
+	}
+
+	// Files only: the dialect file, then default.tmpl
+	check("", 0, dialect.Hive, "acme", "hive", 0)
+	check("", 0, dialect.MySQL, "acme", DefaultTemplateName, 0)
+	check("terse", 0, dialect.MySQL, "", "terse", 0)
+
+	create("global", "", "", true)
+	create("global_hive", string(dialect.Hive), "", true)
+	create("acme", "", "acme", true)
+	create("acme_hive", string(dialect.Hive), "acme", true)
+	check("", 0, dialect.Hive, "acme", "acme_hive", 1)
+	check("", 0, dialect.MySQL, "acme", "acme", 1)
+	check("", 0, dialect.Hive, "other", "global_hive", 1)
+	check("", 0, dialect.MySQL, "", "global", 1)
+
+	// Named templates use the requested, else the active, else the latest version
+	create("acme", "", "acme", false)
+	check("acme", 0, "", "", "acme", 1)
+	check("acme", 2, "", "", "acme", 2)
+	if err := templates.Activate(ctx, "acme", 2); err != nil {
+		t.Fatal(err)
+	}
+	check("", 0, dialect.MySQL, "acme", "acme", 2)
+	create("draft", "", "", false)
+	create("draft", "", "", false)
+	check("draft", 0, "", "", "draft", 2)
+
+	if _, err := templates.Select(ctx, "missing", 0, "", "", language.Chinese); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("expected ErrTemplateNotFound, got %v", err)
+	}
+	if _, err := templates.Select(ctx, "acme", 9, "", "", language.Chinese); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("expected ErrTemplateNotFound, got %v", err)
+	}
+
+	// The trailing line break of a file is dropped
+	tmpl, _ := templates.Select(ctx, "terse", 0, "", "", language.Chinese)
+	if text := build(t, &Builder{}, &Input{Description: "q", Template: tmpl}).Text(); text != "terse file q" {
+		t.Errorf("unexpected file template output %q", text)
+	}
+
+	list, err := templates.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != len(language.Supported())+len(files)+len(store.templates) || list[0].Source != models.TemplateSourceBuiltin || list[2].Source != models.TemplateSourceFile {
+		t.Errorf("unexpected template list %+v", list)
+	}
+}
+
+func TestSelectByLanguage(t *testing.T) {
+	dir := t.TempDir()
+	for name, body := range map[string]string{
+		"hive.tmpl":       "hive {{.Description}}",
+		"hive.en.tmpl":    "hive en {{.Description}}",
+		"default.en.tmpl": "default en {{.Description}}",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "x.fr.tmpl"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewTemplates(nil, dir); !errors.Is(err, language.ErrUnknownLanguage) {
+		t.Fatalf("expected ErrUnknownLanguage for x.fr.tmpl, got %v", err)
+	}
+	os.Remove(filepath.Join(dir, "x.fr.tmpl"))
+
+	store := &memoryTemplateStore{}
+	templates, err := NewTemplates(store, dir)
+	if err != nil {
+		t.Fatalf("NewTemplates: %v", err)
+	}
+	ctx := context.Background()
+	check := func(d dialect.Dialect, lang language.Language, wantName, wantLanguage string, wantSource string) {
+		t.Helper()
+		tmpl, err := templates.Select(ctx, "", 0, d, "", lang)
+		if err != nil {
+			t.Fatalf("Select: %v", err)
+		}
+		if tmpl.Name != wantName || tmpl.Language != wantLanguage || tmpl.Source != wantSource {
+			t.Errorf("Select(%q, %q) = %s/%s from %s, want %s/%s from %s", d, lang, tmpl.Name, tmpl.Language, tmpl.Source, wantName, wantLanguage, wantSource)
+		}
+	}
+
+	check(dialect.Hive, language.English, "hive", "en", models.TemplateSourceFile)
+	check(dialect.Hive, language.Chinese, "hive", "", models.TemplateSourceFile)
+	check(dialect.MySQL, language.English, DefaultTemplateName, "en", models.TemplateSourceFile)
+	check(dialect.MySQL, language.Chinese, DefaultTemplateName, "zh", models.TemplateSourceBuiltin)
+
+	// Stored templates for the prompt language win over those for all languages
+	for _, pt := range []*models.PromptTemplate{
+		{Name: "any", Body: "{{.Description}}"},
+		{Name: "english", Language: "EN", Body: "{{.Description}}"},
+	} {
+		if err := templates.Create(ctx, pt); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if err := templates.Activate(ctx, pt.Name, pt.Version); err != nil {
+			t.Fatalf("Activate: %v", err)
+		}
+	}
+	check(dialect.MySQL, language.English, "english", "en", models.TemplateSourceStore)
+	check(dialect.MySQL, language.Chinese, "any", "", models.TemplateSourceStore)
+
+	// A named template prefers the versions for the prompt language and dialect
+	for _, pt := range []*models.PromptTemplate{
+		{Name: "named", Language: "en", Body: "{{.Description}}"},
+		{Name: "named", Language: "zh", Dialect: "hive", Body: "{{.Description}}"},
+		{Name: "named", Language: "zh", Body: "{{.Description}}"},
+	} {
+		if err := templates.Create(ctx, pt); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	if err := templates.Activate(ctx, "named", 1); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	for _, tc := range []struct {
+		d           dialect.Dialect
+		lang        language.Language
+		wantVersion int
+	}{
+		{dialect.MySQL, language.English, 1},
+		{dialect.MySQL, language.Chinese, 3},
+		{dialect.Hive, language.Chinese, 3},
+		{dialect.Hive, language.English, 1},
+	} {
+		tmpl, err := templates.Select(ctx, "named", 0, tc.d, "", tc.lang)
+		if err != nil {
+			t.Fatalf("Select: %v", err)
+		}
+		if tmpl.Version != tc.wantVersion {
+			t.Errorf("Select(named, %q, %q) = v%d, want v%d", tc.d, tc.lang, tmpl.Version, tc.wantVersion)
+		}
+	}
+	if err := templates.Activate(ctx, "named", 2); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	if tmpl, _ := templates.Select(ctx, "named", 0, dialect.Hive, "", language.Chinese); tmpl.Version != 2 {
+		t.Errorf("expected the active hive version, got v%d", tmpl.Version)
+	}
+	if tmpl, _ := templates.Select(ctx, "named", 0, "", "", language.English); tmpl.Version != 1 {
+		t.Errorf("expected the latest English version, got v%d", tmpl.Version)
+	}
+}
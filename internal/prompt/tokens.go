@@ -0,0 +1,94 @@
+package prompt
+
+import (
+	"strings"
+	"unicode"
+
+	"sql_generator/internal/config"
+)
+
+// defaultContextWindow is assumed for models missing from contextWindows
+const defaultContextWindow = 8192
+
+// contextWindows maps model name prefixes to their context window in
+// tokens. The longest matching prefix wins.
+var contextWindows = map[string]int{
+	"gpt-3.5-turbo":          16385,
+	"gpt-3.5-turbo-instruct": 4096,
+	"gpt-4":                  8192,
+	"gpt-4-32k":              32768,
+	"gpt-4-turbo":            128000,
+	"gpt-4o":                 128000,
+	"gpt-4.1":                1047576,
+	"deepseek":               65536,
+	"qwen":                   8192,
+	"qwen-turbo":             131072,
+	"qwen-plus":              131072,
+	"qwen-max":               32768,
+}
+
+// ContextWindow returns the context window of model in tokens
+func ContextWindow(model string) int {
+	model = strings.ToLower(strings.TrimSpace(model))
+	window, matched := defaultContextWindow, 0
+	for prefix, size := range contextWindows {
+		if strings.HasPrefix(model, prefix) && len(prefix) > matched {
+			window, matched = size, len(prefix)
+		}
+	}
+	return window
+}
+
+// Budget returns the token budget of a prompt for cfg: the context window,
+// configured or derived from the model name, minus the MaxTokens reserved
+// for the completion. A tenth is kept back because token counts are only
+// estimated, and at least a quarter of the window is always left to the
+// prompt so that an oversized MaxTokens does not starve it.
+func Budget(cfg config.LLMConfig) int {
+	window := cfg.ContextWindow
+	if window <= 0 {
+		window = ContextWindow(cfg.Model)
+	}
+	budget := window - cfg.MaxTokens
+	if budget < window/4 {
+		budget = window / 4
+	}
+	return budget - budget/10
+}
+
+// EstimateTokens estimates the number of tokens s is encoded to. BPE
+// tokenizers encode a CJK character as about one token and an English word
+// as one token per four letters, so counting bytes overestimates Chinese
+// text threefold. Each run of Latin letters and digits counts a token per
+// four characters, other letters a token per two, CJK characters and
+// punctuation a token each; whitespace is merged into the next token.
+func EstimateTokens(s string) int {
+	tokens, word, wide := 0, 0, 0
+	flush := func() {
+		tokens += (word+3)/4 + (wide+1)/2
+		word, wide = 0, 0
+	}
+	for _, r := range s {
+		switch {
+		case isCJK(r):
+			flush()
+			tokens++
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word++
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r):
+			wide++
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			tokens++
+		}
+	}
+	flush()
+	return tokens
+}
+
+// isCJK reports whether r is a Chinese, Japanese or Korean character
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
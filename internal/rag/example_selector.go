@@ -0,0 +1,33 @@
+package rag
+
+import (
+	"context"
+
+	"sql_generator/internal/models"
+)
+
+// ExampleSelector 从被用户标记为正确的历史查询中选出与问题最相似的查询，作为few-shot示例。
+// 相似查询由QueryMemory从持久化的查询向量索引中检索，描述只在保存或更换嵌入模型时生成一次向量
+type ExampleSelector struct {
+	memory *QueryMemory
+}
+
+// NewExampleSelector 创建示例选择器，从memory检索正确的历史查询
+func NewExampleSelector(memory *QueryMemory) *ExampleSelector {
+	return &ExampleSelector{memory: memory}
+}
+
+// SelectExamples 返回描述与question余弦相似度最高的至多k条正确查询，按相似度从高到低排列。
+// dialect不为空时只使用该方言或未记录方言的查询
+func (s *ExampleSelector) SelectExamples(ctx context.Context, question, dialect string, k int) ([]*models.Query, error) {
+	similar, err := s.memory.SearchSimilar(ctx, question, k, QueryFilter{Dialect: dialect, CorrectOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	examples := make([]*models.Query, len(similar))
+	for i, sq := range similar {
+		examples[i] = sq.Query
+	}
+	return examples, nil
+}
@@ -0,0 +1,39 @@
+package rag
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExampleSelector_SelectExamples(t *testing.T) {
+	memory, index, _, _ := newTestQueryMemory(t)
+	selector := NewExampleSelector(memory)
+	ctx := context.Background()
+
+	// Examples are the most similar correct queries of the dialect
+	examples, err := selector.SelectExamples(ctx, "每个用户有多少订单", "mysql", 2)
+	if err != nil {
+		t.Fatalf("SelectExamples: %v", err)
+	}
+	if len(examples) != 2 || examples[0].ID != "1" || examples[1].ID != "2" {
+		t.Fatalf("unexpected examples %+v", examples)
+	}
+
+	// Without a dialect all correct queries are candidates
+	examples, err = selector.SelectExamples(ctx, "用户订单", "", 2)
+	if err != nil || len(examples) != 2 || examples[0].ID != "1" || examples[1].ID != "3" {
+		t.Errorf("unexpected examples %+v: %v", examples, err)
+	}
+
+	// Feedback changes which queries serve as examples
+	index.SetCorrect("1", false)
+	index.SetCorrect("4", true)
+	examples, _ = selector.SelectExamples(ctx, "每个用户有多少订单", "mysql", 1)
+	if len(examples) != 1 || examples[0].ID != "4" {
+		t.Errorf("expected the query marked correct later, got %+v", examples)
+	}
+
+	if examples, err := selector.SelectExamples(ctx, "用户订单", "mysql", 0); err != nil || len(examples) != 0 {
+		t.Errorf("expected no examples for k = 0, got %+v: %v", examples, err)
+	}
+}
@@ -1,8 +1,11 @@
 package rag
 
 import (
+	"context"
 	"fmt"
 	"sql_generator/internal/config"
+	"sql_generator/internal/rag/hnsw"
+	"sql_generator/internal/storage"
 )
 
 // NewEmbeddingService 根据配置创建嵌入服务
@@ -29,3 +32,44 @@ func NewEmbeddingService(config config.EmbeddingConfig) (EmbeddingService, error
 		return nil, fmt.Errorf("unsupported embedding provider: %s", config.Provider)
 	}
 }
+
+// NewVectorStore 根据配置创建向量存储
+func NewVectorStore(ctx context.Context, config config.VectorDBConfig, embedding config.EmbeddingConfig, store storage.Store) (VectorStore, error) {
+	switch config.Provider {
+	case "", "memory":
+		// 使用本地内存向量存储，并按配置持久化到快照文件
+		return NewMemoryVectorStore(config.Metric, config.SnapshotPath)
+	case "mysql":
+		// 向量持久化到MySQL的table_vectors表，需要表结构也存储在MySQL中
+		mysqlStore, ok := store.(*storage.MySQLStore)
+		if !ok {
+			return nil, fmt.Errorf("mysql vector store requires MySQL table storage")
+		}
+		return NewMySQLVectorStore(ctx, mysqlStore, EmbeddingModelName(embedding), config.Metric)
+	case "hnsw":
+		// 使用HNSW近似最近邻索引，适用于海量表结构
+		return NewHNSWVectorStore(hnsw.Config{
+			M:              config.HNSWM,
+			EfConstruction: config.HNSWEfConstruction,
+			EfSearch:       config.HNSWEfSearch,
+			Metric:         config.Metric,
+		}, config.SnapshotPath, store)
+	case "pinecone":
+		return NewPineconeVectorStore(config.APIKey, config.IndexName, store)
+	default:
+		return nil, fmt.Errorf("unsupported vector store provider: %s", config.Provider)
+	}
+}
+
+// EmbeddingModelName 返回嵌入服务实际使用的模型标识，用于区分不同模型生成的向量
+func EmbeddingModelName(config config.EmbeddingConfig) string {
+	switch config.Provider {
+	case "qwen":
+		if config.QwenModel != "" {
+			return config.Provider + ":" + config.QwenModel
+		}
+	case "huggingface":
+		return config.Provider + ":" + config.HFModel
+	}
+	return config.Provider + ":" + config.Model
+}
@@ -101,7 +101,7 @@ type HFEmbeddingErrorResponse struct {
 }
 
 // GenerateEmbedding generates vector representation of text
-func (h *HFEmbeddingService) GenerateEmbedding(text string) ([]float32, error) {
+func (h *HFEmbeddingService) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
 	// Prepare request
 	reqBody := HFEmbeddingRequest{
 		Inputs: text,
@@ -127,13 +127,17 @@ func (h *HFEmbeddingService) GenerateEmbedding(text string) ([]float32, error) {
 	for attempt := 0; attempt < 3; attempt++ {
 		if attempt > 0 {
 			// Exponential backoff: 1s, 2s, 4s
-			time.Sleep(time.Duration(1<<uint(attempt)) * time.Second)
+			select {
+			case <-time.After(time.Duration(1<<uint(attempt)) * time.Second):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
 		}
 
 		// Create context with timeout
-		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		reqCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
 
-		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+		req, err := http.NewRequestWithContext(reqCtx, "POST", url, bytes.NewBuffer(jsonData))
 		if err != nil {
 			cancel()
 			lastErr = fmt.Errorf("failed to create request to %s: %w", url, err)
@@ -150,6 +154,10 @@ func (h *HFEmbeddingService) GenerateEmbedding(text string) ([]float32, error) {
 		resp, err := h.http.Do(req)
 		if err != nil {
 			cancel()
+			// Stop retrying once the caller gave up
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
 			// Check if it's a network error that might be temporary
 			if netErr, ok := err.(net.Error); ok && (netErr.Timeout() || netErr.Temporary()) {
 				lastErr = fmt.Errorf("temporary network error: %w", err)
@@ -210,21 +218,6 @@ func (h *HFEmbeddingService) GenerateEmbedding(text string) ([]float32, error) {
 }
 
 // GenerateTableEmbedding generates embedding vector for table structure
-func (h *HFEmbeddingService) GenerateTableEmbedding(table *models.Table) ([]float32, error) {
-	// Construct textual representation of table structure
-	tableText := fmt.Sprintf("Table name: %s\nDescription: %s\n", table.Name, table.Description)
-
-	for _, column := range table.Columns {
-		columnText := fmt.Sprintf("Column: %s, Type: %s, Description: %s",
-			column.Name, column.Type, column.Description)
-		if column.IsPrimary {
-			columnText += ", Primary Key"
-		}
-		if column.IsRequired {
-			columnText += ", Required"
-		}
-		tableText += columnText + "\n"
-	}
-
-	return h.GenerateEmbedding(tableText)
+func (h *HFEmbeddingService) GenerateTableEmbedding(ctx context.Context, table *models.Table) ([]float32, error) {
+	return h.GenerateEmbedding(ctx, TableEmbeddingText(table))
 }
@@ -0,0 +1,170 @@
+package hnsw
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+)
+
+// snapshot is the serialized form of an Index
+type snapshot struct {
+	Config    Config
+	Dimension int
+	Entry     int64
+	MaxLevel  int
+	Nodes     []nodeSnapshot
+}
+
+type nodeSnapshot struct {
+	ID        string
+	Vector    []float32
+	Level     int
+	Neighbors [][]uint32
+	Deleted   bool
+}
+
+// Encode writes the index to w
+func (idx *Index) Encode(w io.Writer) error {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	s := snapshot{
+		Config:    idx.cfg,
+		Dimension: idx.dimension,
+		Entry:     idx.entry,
+		MaxLevel:  idx.maxLevel,
+		Nodes:     make([]nodeSnapshot, len(idx.nodes)),
+	}
+	for i, n := range idx.nodes {
+		s.Nodes[i] = nodeSnapshot{
+			ID:        n.id,
+			Vector:    n.vector,
+			Level:     n.level,
+			Neighbors: n.neighbors,
+			Deleted:   n.deleted,
+		}
+	}
+
+	return gob.NewEncoder(w).Encode(&s)
+}
+
+// Decode reads an index previously written by Encode
+func Decode(r io.Reader) (*Index, error) {
+	var s snapshot
+	if err := gob.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("failed to decode index: %w", err)
+	}
+
+	idx, err := New(s.Config)
+	if err != nil {
+		return nil, err
+	}
+	if s.Entry >= int64(len(s.Nodes)) {
+		return nil, fmt.Errorf("corrupted index: entry point %d out of range", s.Entry)
+	}
+
+	idx.dimension = s.Dimension
+	idx.entry = s.Entry
+	idx.maxLevel = s.MaxLevel
+	idx.nodes = make([]*node, len(s.Nodes))
+	for i, ns := range s.Nodes {
+		if len(ns.Vector) != s.Dimension || len(ns.Neighbors) != ns.Level+1 {
+			return nil, fmt.Errorf("corrupted index: invalid node %d", i)
+		}
+		for _, level := range ns.Neighbors {
+			for _, nb := range level {
+				if int(nb) >= len(s.Nodes) {
+					return nil, fmt.Errorf("corrupted index: node %d links to %d", i, nb)
+				}
+			}
+		}
+		idx.nodes[i] = &node{
+			id:        ns.ID,
+			vector:    ns.Vector,
+			level:     ns.Level,
+			neighbors: ns.Neighbors,
+			deleted:   ns.Deleted,
+		}
+		if ns.Deleted {
+			idx.deleted++
+		} else {
+			idx.ids[ns.ID] = uint32(i)
+		}
+	}
+
+	return idx, nil
+}
+
+// GobEncode implements gob.GobEncoder so an Index can be embedded in other snapshots
+func (idx *Index) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := idx.Encode(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements gob.GobDecoder
+func (idx *Index) GobDecode(data []byte) error {
+	decoded, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.cfg = decoded.cfg
+	idx.dimension = decoded.dimension
+	idx.nodes = decoded.nodes
+	idx.ids = decoded.ids
+	idx.entry = decoded.entry
+	idx.maxLevel = decoded.maxLevel
+	idx.deleted = decoded.deleted
+	idx.levelMult = 1 / math.Log(float64(decoded.cfg.M))
+	idx.rng = rand.New(rand.NewSource(rand.Int63()))
+	return nil
+}
+
+// SaveFile atomically writes the index to path
+func (idx *Index) SaveFile(path string) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create index file: %w", err)
+	}
+	if err := idx.Encode(tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to close index file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to replace index file: %w", err)
+	}
+
+	return nil
+}
+
+// LoadFile reads an index written by SaveFile
+func LoadFile(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Decode(f)
+}
@@ -0,0 +1,556 @@
+// Package hnsw implements a Hierarchical Navigable Small World graph for
+// approximate nearest-neighbour search over string-keyed vectors.
+package hnsw
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+)
+
+// Supported distance metrics
+const (
+	MetricCosine = "cosine"
+	MetricDot    = "dot"
+)
+
+// Config holds the HNSW construction and search parameters
+type Config struct {
+	// M is the number of bi-directional links per node on upper layers;
+	// layer 0 keeps up to 2*M links
+	M int
+	// EfConstruction is the candidate list size used while inserting
+	EfConstruction int
+	// EfSearch is the default candidate list size used while searching
+	EfSearch int
+	// Metric is either MetricCosine or MetricDot
+	Metric string
+	// Seed makes level assignment reproducible when non-zero
+	Seed int64
+}
+
+// DefaultConfig returns commonly used HNSW parameters
+func DefaultConfig() Config {
+	return Config{
+		M:              16,
+		EfConstruction: 200,
+		EfSearch:       64,
+		Metric:         MetricCosine,
+	}
+}
+
+// Result is a single search hit
+type Result struct {
+	ID string
+	// Score is the similarity: cosine similarity or dot product
+	Score float64
+}
+
+// node is a vertex of the graph
+type node struct {
+	id        string
+	vector    []float32
+	level     int
+	neighbors [][]uint32
+	deleted   bool
+}
+
+// Index is a concurrent-safe HNSW index
+type Index struct {
+	mu        sync.RWMutex
+	cfg       Config
+	dimension int
+	nodes     []*node
+	ids       map[string]uint32
+	entry     int64
+	maxLevel  int
+	deleted   int
+	levelMult float64
+	rng       *rand.Rand
+	visited   sync.Pool
+}
+
+// New creates an empty index
+func New(cfg Config) (*Index, error) {
+	defaults := DefaultConfig()
+	if cfg.M <= 0 {
+		cfg.M = defaults.M
+	}
+	if cfg.EfConstruction <= 0 {
+		cfg.EfConstruction = defaults.EfConstruction
+	}
+	if cfg.EfSearch <= 0 {
+		cfg.EfSearch = defaults.EfSearch
+	}
+	if cfg.Metric == "" {
+		cfg.Metric = defaults.Metric
+	}
+	if cfg.Metric != MetricCosine && cfg.Metric != MetricDot {
+		return nil, fmt.Errorf("unsupported metric: %s", cfg.Metric)
+	}
+	if cfg.M < 2 {
+		return nil, fmt.Errorf("M must be at least 2, got %d", cfg.M)
+	}
+
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+
+	return &Index{
+		cfg:       cfg,
+		ids:       make(map[string]uint32),
+		entry:     -1,
+		levelMult: 1 / math.Log(float64(cfg.M)),
+		rng:       rand.New(rand.NewSource(seed)),
+	}, nil
+}
+
+// Config returns the parameters the index was built with
+func (idx *Index) Config() Config {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.cfg
+}
+
+// SetEfSearch changes the default search candidate list size
+func (idx *Index) SetEfSearch(ef int) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if ef > 0 {
+		idx.cfg.EfSearch = ef
+	}
+}
+
+// Len returns the number of live (not deleted) vectors
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.ids)
+}
+
+// Deleted returns the number of tombstoned nodes still kept for routing
+func (idx *Index) Deleted() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.deleted
+}
+
+// Contains reports whether id is present in the index
+func (idx *Index) Contains(id string) bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	_, ok := idx.ids[id]
+	return ok
+}
+
+// Insert adds a vector under id, replacing any previous vector with the same id
+func (idx *Index) Insert(id string, vector []float32) error {
+	if len(vector) == 0 {
+		return fmt.Errorf("empty vector for %s", id)
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if idx.dimension != 0 && len(vector) != idx.dimension {
+		return fmt.Errorf("vector dimension mismatch for %s: expected %d, got %d", id, idx.dimension, len(vector))
+	}
+	idx.dimension = len(vector)
+
+	if old, ok := idx.ids[id]; ok {
+		idx.markDeleted(old)
+	}
+	return idx.insertLocked(id, vector)
+}
+
+// insertLocked links a new node for id into the graph; callers must hold the write lock
+func (idx *Index) insertLocked(id string, vector []float32) error {
+	vec := make([]float32, len(vector))
+	copy(vec, vector)
+	if idx.cfg.Metric == MetricCosine {
+		normalize(vec)
+	}
+
+	level := int(math.Floor(-math.Log(1-idx.rng.Float64()) * idx.levelMult))
+	n := &node{
+		id:        id,
+		vector:    vec,
+		level:     level,
+		neighbors: make([][]uint32, level+1),
+	}
+	if len(idx.nodes) >= math.MaxUint32 {
+		return fmt.Errorf("index is full")
+	}
+	nid := uint32(len(idx.nodes))
+	idx.nodes = append(idx.nodes, n)
+	idx.ids[id] = nid
+
+	if idx.entry < 0 {
+		idx.entry = int64(nid)
+		idx.maxLevel = level
+		return nil
+	}
+
+	ep := uint32(idx.entry)
+	epDist := idx.distance(vec, idx.nodes[ep].vector)
+	for l := idx.maxLevel; l > level; l-- {
+		ep, epDist = idx.greedy(vec, ep, epDist, l)
+	}
+
+	eps := []candidate{{id: ep, dist: epDist}}
+	for l := minInt(level, idx.maxLevel); l >= 0; l-- {
+		found := idx.searchLayer(vec, eps, idx.cfg.EfConstruction, l)
+		selected := idx.selectNeighbors(found, idx.cfg.M)
+		n.neighbors[l] = make([]uint32, 0, len(selected))
+		for _, c := range selected {
+			n.neighbors[l] = append(n.neighbors[l], c.id)
+			idx.link(c.id, nid, l)
+		}
+		eps = found
+	}
+
+	if level > idx.maxLevel {
+		idx.entry = int64(nid)
+		idx.maxLevel = level
+	}
+
+	return nil
+}
+
+// Delete removes id from the index. The node stays in the graph as a
+// routing-only tombstone so that existing links remain navigable.
+func (idx *Index) Delete(id string) bool {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	nid, ok := idx.ids[id]
+	if !ok {
+		return false
+	}
+	idx.markDeleted(nid)
+	return true
+}
+
+// Compact rebuilds the graph from the live vectors, dropping the tombstones
+// left by Delete and Insert, and returns the number of nodes removed
+func (idx *Index) Compact() (int, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	removed := idx.deleted
+	if removed == 0 {
+		return 0, nil
+	}
+
+	live := make([]*node, 0, len(idx.ids))
+	for _, n := range idx.nodes {
+		if !n.deleted {
+			live = append(live, n)
+		}
+	}
+
+	idx.nodes = make([]*node, 0, len(live))
+	idx.ids = make(map[string]uint32, len(live))
+	idx.entry = -1
+	idx.maxLevel = 0
+	idx.deleted = 0
+	for _, n := range live {
+		if err := idx.insertLocked(n.id, n.vector); err != nil {
+			return 0, err
+		}
+	}
+	return removed, nil
+}
+
+// Search returns up to k nearest vectors ordered by descending similarity,
+// using the configured EfSearch
+func (idx *Index) Search(query []float32, k int) ([]Result, error) {
+	return idx.SearchWithEf(query, k, 0)
+}
+
+// SearchWithEf is Search with an explicit candidate list size; ef <= 0
+// falls back to the configured EfSearch
+func (idx *Index) SearchWithEf(query []float32, k, ef int) ([]Result, error) {
+	if k <= 0 {
+		return nil, nil
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if len(idx.ids) == 0 {
+		return nil, nil
+	}
+	if len(query) != idx.dimension {
+		return nil, fmt.Errorf("query vector dimension mismatch: expected %d, got %d", idx.dimension, len(query))
+	}
+
+	q := query
+	if idx.cfg.Metric == MetricCosine {
+		q = make([]float32, len(query))
+		copy(q, query)
+		normalize(q)
+	}
+
+	if ef <= 0 {
+		ef = idx.cfg.EfSearch
+	}
+	ef = maxInt(ef, k)
+
+	ep := uint32(idx.entry)
+	epDist := idx.distance(q, idx.nodes[ep].vector)
+	for l := idx.maxLevel; l > 0; l-- {
+		ep, epDist = idx.greedy(q, ep, epDist, l)
+	}
+
+	// Tombstones occupy slots in the candidate list, so widen the search
+	// until enough live results are found or the whole graph was visited
+	for {
+		found := idx.searchLayer(q, []candidate{{id: ep, dist: epDist}}, ef, 0)
+		results := make([]Result, 0, k)
+		for _, c := range found {
+			n := idx.nodes[c.id]
+			if n.deleted {
+				continue
+			}
+			results = append(results, Result{ID: n.id, Score: idx.score(c.dist)})
+			if len(results) == k {
+				break
+			}
+		}
+		if len(results) == k || len(results) == len(idx.ids) || ef >= len(idx.nodes) {
+			return results, nil
+		}
+		ef *= 2
+	}
+}
+
+// markDeleted tombstones a node; callers must hold the write lock
+func (idx *Index) markDeleted(nid uint32) {
+	n := idx.nodes[nid]
+	if n.deleted {
+		return
+	}
+	n.deleted = true
+	delete(idx.ids, n.id)
+	idx.deleted++
+}
+
+// greedy walks a single layer towards the query, returning the closest node
+func (idx *Index) greedy(q []float32, ep uint32, epDist float32, level int) (uint32, float32) {
+	for changed := true; changed; {
+		changed = false
+		for _, nb := range idx.nodes[ep].neighbors[level] {
+			if d := idx.distance(q, idx.nodes[nb].vector); d < epDist {
+				ep, epDist = nb, d
+				changed = true
+			}
+		}
+	}
+	return ep, epDist
+}
+
+// searchLayer runs the beam search of the HNSW paper on one layer and
+// returns up to ef candidates ordered by ascending distance
+func (idx *Index) searchLayer(q []float32, eps []candidate, ef, level int) []candidate {
+	visited := idx.getVisited()
+	defer idx.visited.Put(visited)
+
+	candidates := &minHeap{}
+	results := &maxHeap{}
+	for _, ep := range eps {
+		if visited.visit(ep.id) {
+			continue
+		}
+		heap.Push(candidates, ep)
+		heap.Push(results, ep)
+		if results.Len() > ef {
+			heap.Pop(results)
+		}
+	}
+
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(candidate)
+		if results.Len() >= ef && c.dist > (*results)[0].dist {
+			break
+		}
+
+		n := idx.nodes[c.id]
+		if level >= len(n.neighbors) {
+			continue
+		}
+		for _, nb := range n.neighbors[level] {
+			if visited.visit(nb) {
+				continue
+			}
+			d := idx.distance(q, idx.nodes[nb].vector)
+			if results.Len() < ef || d < (*results)[0].dist {
+				heap.Push(candidates, candidate{id: nb, dist: d})
+				heap.Push(results, candidate{id: nb, dist: d})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	sorted := make([]candidate, results.Len())
+	for i := len(sorted) - 1; i >= 0; i-- {
+		sorted[i] = heap.Pop(results).(candidate)
+	}
+	return sorted
+}
+
+// selectNeighbors applies the diversity heuristic of the HNSW paper to
+// candidates ordered by ascending distance
+func (idx *Index) selectNeighbors(candidates []candidate, m int) []candidate {
+	if len(candidates) <= m {
+		return candidates
+	}
+
+	selected := make([]candidate, 0, m)
+	var pruned []candidate
+	for _, c := range candidates {
+		if len(selected) >= m {
+			break
+		}
+		good := true
+		for _, s := range selected {
+			if idx.distance(idx.nodes[c.id].vector, idx.nodes[s.id].vector) < c.dist {
+				good = false
+				break
+			}
+		}
+		if good {
+			selected = append(selected, c)
+		} else {
+			pruned = append(pruned, c)
+		}
+	}
+
+	// Keep the graph well connected by filling up with the closest pruned candidates
+	for _, c := range pruned {
+		if len(selected) >= m {
+			break
+		}
+		selected = append(selected, c)
+	}
+
+	return selected
+}
+
+// link adds a reverse edge from -> to on level, shrinking the neighbour list if needed
+func (idx *Index) link(from, to uint32, level int) {
+	n := idx.nodes[from]
+	n.neighbors[level] = append(n.neighbors[level], to)
+
+	maxLinks := idx.cfg.M
+	if level == 0 {
+		maxLinks = 2 * idx.cfg.M
+	}
+	if len(n.neighbors[level]) <= maxLinks {
+		return
+	}
+
+	candidates := make([]candidate, 0, len(n.neighbors[level]))
+	for _, nb := range n.neighbors[level] {
+		candidates = append(candidates, candidate{id: nb, dist: idx.distance(n.vector, idx.nodes[nb].vector)})
+	}
+	sortCandidates(candidates)
+
+	selected := idx.selectNeighbors(candidates, maxLinks)
+	n.neighbors[level] = n.neighbors[level][:0]
+	for _, c := range selected {
+		n.neighbors[level] = append(n.neighbors[level], c.id)
+	}
+}
+
+// distance returns a value where smaller means more similar
+func (idx *Index) distance(a, b []float32) float32 {
+	var dot float32
+	for i := range a {
+		dot += a[i] * b[i]
+	}
+	if idx.cfg.Metric == MetricCosine {
+		return 1 - dot
+	}
+	return -dot
+}
+
+// score converts a distance back into a similarity
+func (idx *Index) score(dist float32) float64 {
+	if idx.cfg.Metric == MetricCosine {
+		return float64(1 - dist)
+	}
+	return float64(-dist)
+}
+
+// getVisited returns a visited set sized for the current graph
+func (idx *Index) getVisited() *visitedSet {
+	v, _ := idx.visited.Get().(*visitedSet)
+	if v == nil {
+		v = &visitedSet{}
+	}
+	v.reset(len(idx.nodes))
+	return v
+}
+
+// visitedSet is a reusable epoch-based visited marker
+type visitedSet struct {
+	marks []uint16
+	epoch uint16
+}
+
+func (v *visitedSet) reset(size int) {
+	if len(v.marks) < size {
+		v.marks = make([]uint16, size+size/4)
+		v.epoch = 0
+	}
+	v.epoch++
+	if v.epoch == 0 {
+		for i := range v.marks {
+			v.marks[i] = 0
+		}
+		v.epoch = 1
+	}
+}
+
+// visit marks id and reports whether it had already been visited
+func (v *visitedSet) visit(id uint32) bool {
+	if v.marks[id] == v.epoch {
+		return true
+	}
+	v.marks[id] = v.epoch
+	return false
+}
+
+func normalize(v []float32) {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return
+	}
+	inv := float32(1 / math.Sqrt(sum))
+	for i := range v {
+		v[i] *= inv
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,240 @@
+package hnsw
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+)
+
+var (
+	benchSize = flag.Int("hnsw.n", 1000000, "number of synthetic vectors indexed by the search benchmark")
+	benchDim  = flag.Int("hnsw.dim", 64, "dimension of synthetic benchmark vectors")
+)
+
+func randomVectors(rng *rand.Rand, n, dim int) [][]float32 {
+	vectors := make([][]float32, n)
+	for i := range vectors {
+		v := make([]float32, dim)
+		for j := range v {
+			v[j] = rng.Float32()*2 - 1
+		}
+		vectors[i] = v
+	}
+	return vectors
+}
+
+// bruteForce returns the ids of the k most similar vectors by cosine similarity
+func bruteForce(vectors [][]float32, query []float32, k int) []string {
+	q := append([]float32(nil), query...)
+	normalize(q)
+
+	type scored struct {
+		id    int
+		score float32
+	}
+	scores := make([]scored, len(vectors))
+	for i, v := range vectors {
+		u := append([]float32(nil), v...)
+		normalize(u)
+		var dot float32
+		for j := range u {
+			dot += u[j] * q[j]
+		}
+		scores[i] = scored{id: i, score: dot}
+	}
+	sort.Slice(scores, func(i, j int) bool { return scores[i].score > scores[j].score })
+
+	ids := make([]string, k)
+	for i := range ids {
+		ids[i] = fmt.Sprint(scores[i].id)
+	}
+	return ids
+}
+
+func recall(results []Result, truth []string) float64 {
+	want := make(map[string]bool, len(truth))
+	for _, id := range truth {
+		want[id] = true
+	}
+	hits := 0
+	for _, r := range results {
+		if want[r.ID] {
+			hits++
+		}
+	}
+	return float64(hits) / float64(len(truth))
+}
+
+func buildIndex(t testing.TB, vectors [][]float32) *Index {
+	idx, err := New(Config{M: 16, EfConstruction: 200, EfSearch: 64, Seed: 42})
+	if err != nil {
+		t.Fatalf("Failed to create index: %v", err)
+	}
+	for i, v := range vectors {
+		if err := idx.Insert(fmt.Sprint(i), v); err != nil {
+			t.Fatalf("Failed to insert vector %d: %v", i, err)
+		}
+	}
+	return idx
+}
+
+func TestIndex_Recall(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	vectors := randomVectors(rng, 2000, 16)
+	idx := buildIndex(t, vectors)
+
+	queries := randomVectors(rng, 50, 16)
+	total := 0.0
+	for _, q := range queries {
+		results, err := idx.Search(q, 10)
+		if err != nil {
+			t.Fatalf("Failed to search: %v", err)
+		}
+		total += recall(results, bruteForce(vectors, q, 10))
+	}
+
+	if avg := total / float64(len(queries)); avg < 0.9 {
+		t.Errorf("Expected recall@10 >= 0.9, got %.3f", avg)
+	}
+}
+
+func TestIndex_DeleteAndReplace(t *testing.T) {
+	idx, _ := New(Config{Seed: 7})
+	idx.Insert("users", []float32{1, 0})
+	idx.Insert("orders", []float32{0, 1})
+	idx.Insert("payments", []float32{0.1, 1})
+
+	if !idx.Delete("orders") {
+		t.Fatal("Expected orders to be deleted")
+	}
+	if idx.Delete("orders") {
+		t.Error("Expected second delete to report missing id")
+	}
+
+	results, err := idx.Search([]float32{0, 1}, 3)
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(results) != 2 || results[0].ID != "payments" {
+		t.Errorf("Unexpected results after delete: %+v", results)
+	}
+
+	// 重新插入同名向量应覆盖旧向量
+	idx.Insert("users", []float32{0, 1})
+	results, _ = idx.Search([]float32{0, 1}, 1)
+	if results[0].ID != "users" {
+		t.Errorf("Expected replaced users vector to rank first, got %s", results[0].ID)
+	}
+	if idx.Len() != 2 {
+		t.Errorf("Expected 2 live vectors, got %d", idx.Len())
+	}
+
+	if err := idx.Insert("bad", []float32{1, 2, 3}); err == nil {
+		t.Error("Expected error for mismatched dimension")
+	}
+
+	// 压缩后只保留存活的向量
+	removed, err := idx.Compact()
+	if err != nil || removed != 2 || idx.Deleted() != 0 || idx.Len() != 2 {
+		t.Fatalf("Unexpected compaction: removed %d, deleted %d, live %d: %v", removed, idx.Deleted(), idx.Len(), err)
+	}
+	results, _ = idx.Search([]float32{1, 0}, 2)
+	if len(results) != 2 || results[0].ID != "payments" || results[1].ID != "users" {
+		t.Errorf("Unexpected results after compaction: %+v", results)
+	}
+}
+
+func TestIndex_EncodeDecode(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	vectors := randomVectors(rng, 500, 8)
+	idx := buildIndex(t, vectors)
+	idx.Delete("3")
+
+	var buf bytes.Buffer
+	if err := idx.Encode(&buf); err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	decoded, err := Decode(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+
+	if decoded.Len() != idx.Len() || decoded.Contains("3") {
+		t.Fatalf("Decoded index differs: %d vs %d live vectors", decoded.Len(), idx.Len())
+	}
+
+	query := vectors[10]
+	want, _ := idx.Search(query, 5)
+	got, _ := decoded.Search(query, 5)
+	for i := range want {
+		if want[i].ID != got[i].ID {
+			t.Errorf("Result %d: expected %s, got %s", i, want[i].ID, got[i].ID)
+		}
+	}
+}
+
+func BenchmarkInsert(b *testing.B) {
+	rng := rand.New(rand.NewSource(3))
+	vectors := randomVectors(rng, b.N, *benchDim)
+	idx, err := New(Config{Seed: 3})
+	if err != nil {
+		b.Fatalf("Failed to create index: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := idx.Insert(fmt.Sprint(i), vectors[i]); err != nil {
+			b.Fatalf("Failed to insert vector %d: %v", i, err)
+		}
+	}
+}
+
+var (
+	benchOnce    sync.Once
+	benchIndex   *Index
+	benchVectors [][]float32
+)
+
+// BenchmarkSearch indexes -hnsw.n synthetic vectors (1M by default) once and
+// reports latency together with recall@10 for several efSearch values, e.g.
+//
+//	go test ./internal/rag/hnsw -run '^$' -bench Search -benchtime 2000x
+func BenchmarkSearch(b *testing.B) {
+	benchOnce.Do(func() {
+		rng := rand.New(rand.NewSource(4))
+		benchVectors = randomVectors(rng, *benchSize, *benchDim)
+		benchIndex = buildIndex(b, benchVectors)
+	})
+
+	rng := rand.New(rand.NewSource(5))
+	queries := randomVectors(rng, 100, *benchDim)
+	truth := make([][]string, len(queries))
+	for i, q := range queries {
+		truth[i] = bruteForce(benchVectors, q, 10)
+	}
+
+	for _, ef := range []int{16, 32, 64, 128, 256} {
+		b.Run(fmt.Sprintf("ef=%d", ef), func(b *testing.B) {
+			total := 0.0
+			for i, q := range queries {
+				results, err := benchIndex.SearchWithEf(q, 10, ef)
+				if err != nil {
+					b.Fatalf("Failed to search: %v", err)
+				}
+				total += recall(results, truth[i])
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := benchIndex.SearchWithEf(queries[i%len(queries)], 10, ef); err != nil {
+					b.Fatalf("Failed to search: %v", err)
+				}
+			}
+			b.ReportMetric(total/float64(len(queries)), "recall@10")
+		})
+	}
+}
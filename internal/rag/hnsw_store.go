@@ -0,0 +1,214 @@
+package rag
+
+import (
+	"bufio"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"sql_generator/internal/models"
+	"sql_generator/internal/rag/hnsw"
+	"sql_generator/internal/storage"
+)
+
+// HNSWVectorStore 实现基于HNSW近似最近邻索引的向量存储
+// 索引中只保存表名和向量，完整表结构在检索后从storage.Store读取
+type HNSWVectorStore struct {
+	mu           sync.Mutex
+	index        *hnsw.Index
+	store        storage.Store
+	snapshotPath string
+	hashes       map[string]string
+	dirty        bool
+	lastSave     time.Time
+}
+
+// hnswSnapshot 表示写入磁盘的快照格式
+type hnswSnapshot struct {
+	Hashes map[string]string
+	Index  *hnsw.Index
+}
+
+// NewHNSWVectorStore 创建新的HNSW向量存储，快照文件存在时会在创建时加载。
+// 快照的构建参数（M、efConstruction、度量方式）与cfg不一致时丢弃快照并重新构建索引
+func NewHNSWVectorStore(cfg hnsw.Config, snapshotPath string, store storage.Store) (*HNSWVectorStore, error) {
+	index, err := hnsw.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &HNSWVectorStore{
+		store:        store,
+		snapshotPath: snapshotPath,
+		hashes:       make(map[string]string),
+		lastSave:     time.Now(),
+	}
+
+	if snapshotPath != "" {
+		loaded, err := h.load()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load HNSW snapshot: %w", err)
+		}
+		if loaded {
+			built, want := h.index.Config(), index.Config()
+			if built.M == want.M && built.EfConstruction == want.EfConstruction && built.Metric == want.Metric {
+				// 搜索参数可随时调整
+				h.index.SetEfSearch(want.EfSearch)
+				return h, nil
+			}
+			fmt.Printf("Warning: %s was built with M=%d, efConstruction=%d, metric=%s instead of M=%d, efConstruction=%d, metric=%s, rebuilding the index\n",
+				snapshotPath, built.M, built.EfConstruction, built.Metric, want.M, want.EfConstruction, want.Metric)
+			h.hashes = make(map[string]string)
+		}
+	}
+
+	h.index = index
+	return h, nil
+}
+
+// IndexTableStructure 将表向量插入HNSW索引，同名表会被覆盖
+func (h *HNSWVectorStore) IndexTableStructure(ctx context.Context, table *models.Table, vector []float32) error {
+	if err := h.index.Insert(table.Name, vector); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hashes[table.Name] = TableContentHash(table)
+	h.dirty = true
+	return h.maybeSaveLocked()
+}
+
+// SearchSimilarTables 检索最相似的topK张表
+func (h *HNSWVectorStore) SearchSimilarTables(ctx context.Context, queryVector []float32, topK int) ([]*models.Table, error) {
+	results, err := h.index.Search(queryVector, topK)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]*models.Table, 0, len(results))
+	for _, result := range results {
+		table, err := h.store.GetTableByName(ctx, result.ID)
+		if err != nil {
+			// 索引与存储短暂不一致时跳过该表
+			fmt.Printf("Warning: failed to load indexed table %s: %v\n", result.ID, err)
+			continue
+		}
+		tables = append(tables, table)
+	}
+
+	return tables, nil
+}
+
+// DeleteTableVectors 从HNSW索引中删除表
+func (h *HNSWVectorStore) DeleteTableVectors(ctx context.Context, tableName string) error {
+	if !h.index.Delete(tableName) {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.hashes, tableName)
+	h.dirty = true
+	return h.maybeSaveLocked()
+}
+
+// IsIndexed 判断表是否已有与当前结构一致的向量
+func (h *HNSWVectorStore) IsIndexed(table *models.Table) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.hashes[table.Name] == TableContentHash(table)
+}
+
+// Flush 将未保存的变更写入快照文件
+func (h *HNSWVectorStore) Flush() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.dirty || h.snapshotPath == "" {
+		return nil
+	}
+	return h.saveLocked()
+}
+
+// maybeSaveLocked 距上次保存超过snapshotSaveInterval时写快照，调用方需持有锁
+func (h *HNSWVectorStore) maybeSaveLocked() error {
+	if h.snapshotPath == "" || time.Since(h.lastSave) < snapshotSaveInterval {
+		return nil
+	}
+	return h.saveLocked()
+}
+
+// saveLocked 原子地写入快照文件，调用方需持有锁。
+// 已删除的节点多于存活节点时先压缩索引，避免墓碑节点无限增长
+func (h *HNSWVectorStore) saveLocked() error {
+	if h.index.Deleted() > h.index.Len() {
+		if _, err := h.index.Compact(); err != nil {
+			return fmt.Errorf("failed to compact HNSW index: %w", err)
+		}
+	}
+
+	dir := filepath.Dir(h.snapshotPath)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(h.snapshotPath)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	if err := gob.NewEncoder(tmp).Encode(&hnswSnapshot{Hashes: h.hashes, Index: h.index}); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to close snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), h.snapshotPath); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+
+	h.dirty = false
+	h.lastSave = time.Now()
+	return nil
+}
+
+// load 读取快照文件，文件不存在时返回false。
+// 文件是内存向量存储的JSON快照时同样返回false，索引会重新构建并在保存时覆盖该文件
+func (h *HNSWVectorStore) load() (bool, error) {
+	f, err := os.Open(h.snapshotPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	if first, err := r.Peek(1); err == nil && first[0] == '{' {
+		fmt.Printf("Warning: %s is not an HNSW snapshot, rebuilding the index\n", h.snapshotPath)
+		return false, nil
+	}
+
+	var snapshot hnswSnapshot
+	if err := gob.NewDecoder(r).Decode(&snapshot); err != nil {
+		return false, fmt.Errorf("failed to parse snapshot %s: %w", h.snapshotPath, err)
+	}
+	if snapshot.Index == nil {
+		return false, fmt.Errorf("snapshot %s contains no index", h.snapshotPath)
+	}
+
+	h.index = snapshot.Index
+	if snapshot.Hashes != nil {
+		h.hashes = snapshot.Hashes
+	}
+	return true, nil
+}
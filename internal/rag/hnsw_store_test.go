@@ -0,0 +1,146 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"sql_generator/internal/models"
+	"sql_generator/internal/rag/hnsw"
+	"sql_generator/internal/storage"
+)
+
+// tableStore 是仅支持按名称读取表的storage.Store测试实现
+type tableStore struct {
+	storage.Store
+	tables map[string]*models.Table
+}
+
+func (s *tableStore) GetTableByName(ctx context.Context, name string) (*models.Table, error) {
+	table, ok := s.tables[name]
+	if !ok {
+		return nil, fmt.Errorf("table not found: %s", name)
+	}
+	return table, nil
+}
+
+func TestHNSWVectorStore_SnapshotRoundTrip(t *testing.T) {
+	store := &tableStore{tables: map[string]*models.Table{
+		"users":  {Name: "users", Description: "用户表"},
+		"orders": {Name: "orders", Description: "订单表"},
+	}}
+	path := filepath.Join(t.TempDir(), "hnsw.gob")
+	cfg := hnsw.Config{M: 4, EfConstruction: 16, EfSearch: 8, Seed: 1}
+
+	ctx := context.Background()
+	vs, err := NewHNSWVectorStore(cfg, path, store)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	vs.IndexTableStructure(ctx, store.tables["users"], []float32{1, 0})
+	vs.IndexTableStructure(ctx, store.tables["orders"], []float32{0, 1})
+	if err := vs.Flush(); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	reloaded, err := NewHNSWVectorStore(cfg, path, store)
+	if err != nil {
+		t.Fatalf("Failed to reload store: %v", err)
+	}
+	if !reloaded.IsIndexed(store.tables["orders"]) {
+		t.Error("Expected orders to be indexed after reload")
+	}
+
+	tables, err := reloaded.SearchSimilarTables(ctx, []float32{0.1, 1}, 1)
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(tables) != 1 || tables[0].Name != "orders" {
+		t.Errorf("Unexpected search result: %+v", tables)
+	}
+}
+
+func TestHNSWVectorStore_SnapshotConfigMismatch(t *testing.T) {
+	store := &tableStore{tables: map[string]*models.Table{"users": {Name: "users"}}}
+	path := filepath.Join(t.TempDir(), "hnsw.gob")
+	cfg := hnsw.Config{M: 4, EfConstruction: 16, EfSearch: 8, Seed: 1}
+	ctx := context.Background()
+
+	vs, err := NewHNSWVectorStore(cfg, path, store)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	vs.IndexTableStructure(ctx, store.tables["users"], []float32{1, 0})
+	if err := vs.Flush(); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	// 只修改搜索参数时沿用快照
+	cfg.EfSearch = 32
+	reloaded, err := NewHNSWVectorStore(cfg, path, store)
+	if err != nil {
+		t.Fatalf("Failed to reload store: %v", err)
+	}
+	if !reloaded.IsIndexed(store.tables["users"]) || reloaded.index.Config().EfSearch != 32 {
+		t.Errorf("Expected the snapshot to be kept with efSearch 32, got %+v", reloaded.index.Config())
+	}
+
+	// 构建参数不同时丢弃快照，按新参数重新构建
+	for _, changed := range []hnsw.Config{
+		{M: 8, EfConstruction: 16, EfSearch: 8, Seed: 1},
+		{M: 4, EfConstruction: 64, EfSearch: 8, Seed: 1},
+		{M: 4, EfConstruction: 16, EfSearch: 8, Metric: hnsw.MetricDot, Seed: 1},
+	} {
+		rebuilt, err := NewHNSWVectorStore(changed, path, store)
+		if err != nil {
+			t.Fatalf("Failed to reload store: %v", err)
+		}
+		if rebuilt.IsIndexed(store.tables["users"]) || rebuilt.index.Len() != 0 {
+			t.Errorf("Expected an empty index for %+v", changed)
+		}
+		if got := rebuilt.index.Config(); got.M != changed.M || got.EfConstruction != changed.EfConstruction {
+			t.Errorf("Expected the index to use %+v, got %+v", changed, got)
+		}
+	}
+}
+
+func TestHNSWVectorStore_SnapshotOfOtherProvider(t *testing.T) {
+	store := &tableStore{tables: map[string]*models.Table{"users": {Name: "users"}}}
+	path := filepath.Join(t.TempDir(), "vectors")
+	cfg := hnsw.Config{M: 4, EfConstruction: 16, EfSearch: 8, Seed: 1}
+	ctx := context.Background()
+
+	// 内存存储的JSON快照不能作为HNSW快照加载，索引被重新构建
+	memory, _ := NewMemoryVectorStore(MetricCosine, path)
+	memory.IndexTableStructure(ctx, store.tables["users"], []float32{1, 0})
+	if err := memory.Flush(); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	vs, err := NewHNSWVectorStore(cfg, path, store)
+	if err != nil {
+		t.Fatalf("Expected the JSON snapshot to be rebuilt, got %v", err)
+	}
+	if vs.IsIndexed(store.tables["users"]) {
+		t.Error("Expected an empty index")
+	}
+
+	// 删除的节点多于存活节点时保存前压缩索引
+	vs.IndexTableStructure(ctx, store.tables["users"], []float32{1, 0})
+	for i := 0; i < 3; i++ {
+		vs.IndexTableStructure(ctx, &models.Table{Name: fmt.Sprintf("tmp%d", i)}, []float32{0, 1})
+		vs.DeleteTableVectors(ctx, fmt.Sprintf("tmp%d", i))
+	}
+	if err := vs.Flush(); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	if vs.index.Deleted() != 0 || vs.index.Len() != 1 {
+		t.Errorf("Expected the index to be compacted, got %d deleted and %d live", vs.index.Deleted(), vs.index.Len())
+	}
+
+	// 反之HNSW的gob快照也不能作为内存存储快照加载
+	memory, err = NewMemoryVectorStore(MetricCosine, path)
+	if err != nil || memory.Len() != 0 {
+		t.Errorf("Expected the HNSW snapshot to be rebuilt, got %d tables: %v", memory.Len(), err)
+	}
+}
@@ -2,18 +2,30 @@
 package rag
 
 import (
+	"context"
+
 	"sql_generator/internal/models"
 )
 
 // VectorStore 定义向量存储接口
 type VectorStore interface {
-	IndexTableStructure(table *models.Table, vector []float32) error
-	SearchSimilarTables(queryVector []float32, topK int) ([]*models.Table, error)
-	DeleteTableVectors(tableName string) error
+	IndexTableStructure(ctx context.Context, table *models.Table, vector []float32) error
+	SearchSimilarTables(ctx context.Context, queryVector []float32, topK int) ([]*models.Table, error)
+	DeleteTableVectors(ctx context.Context, tableName string) error
+}
+
+// IndexedChecker 由持久化向量的存储实现，用于判断表是否需要重新生成嵌入
+type IndexedChecker interface {
+	IsIndexed(table *models.Table) bool
+}
+
+// Flusher 由延迟持久化的存储实现，用于在批量索引后或关闭服务时写入磁盘
+type Flusher interface {
+	Flush() error
 }
 
 // EmbeddingService 定义嵌入服务接口
 type EmbeddingService interface {
-	GenerateEmbedding(text string) ([]float32, error)
-	GenerateTableEmbedding(table *models.Table) ([]float32, error)
+	GenerateEmbedding(ctx context.Context, text string) ([]float32, error)
+	GenerateTableEmbedding(ctx context.Context, table *models.Table) ([]float32, error)
 }
@@ -0,0 +1,336 @@
+package rag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"sql_generator/internal/models"
+)
+
+// 支持的相似度度量方式
+const (
+	MetricCosine = "cosine"
+	MetricDot    = "dot"
+)
+
+// snapshotSaveInterval 控制变更后自动写快照的最小间隔，避免每次变更都全量写入快照
+const snapshotSaveInterval = 30 * time.Second
+
+// MemoryVectorStore 实现基于内存的向量存储，支持暴力Top-K检索和本地快照持久化。
+// 变更按snapshotSaveInterval批量写入快照，批量索引后或关闭服务时需调用Flush
+type MemoryVectorStore struct {
+	mu           sync.RWMutex
+	metric       string
+	snapshotPath string
+	dimension    int
+	entries      map[string]*memoryEntry
+	dirty        bool
+	lastSave     time.Time
+}
+
+// memoryEntry 表示一条表结构向量记录
+type memoryEntry struct {
+	Table  *models.Table `json:"table"`
+	Vector []float32     `json:"vector"`
+	norm   float64
+}
+
+// memorySnapshot 表示写入磁盘的快照格式
+type memorySnapshot struct {
+	Metric    string         `json:"metric"`
+	Dimension int            `json:"dimension"`
+	Entries   []*memoryEntry `json:"entries"`
+}
+
+// NewMemoryVectorStore 创建新的内存向量存储实例
+// snapshotPath为空时不做持久化；快照文件存在时会在创建时加载，
+// 快照的度量方式与metric不同时沿用其中的向量，并在下次保存时按metric重写快照
+func NewMemoryVectorStore(metric, snapshotPath string) (*MemoryVectorStore, error) {
+	if metric == "" {
+		metric = MetricCosine
+	}
+	if metric != MetricCosine && metric != MetricDot {
+		return nil, fmt.Errorf("unsupported vector metric: %s", metric)
+	}
+
+	m := &MemoryVectorStore{
+		metric:       metric,
+		snapshotPath: snapshotPath,
+		entries:      make(map[string]*memoryEntry),
+		lastSave:     time.Now(),
+	}
+
+	if snapshotPath != "" {
+		if err := m.load(); err != nil {
+			return nil, fmt.Errorf("failed to load vector snapshot: %w", err)
+		}
+	}
+
+	return m, nil
+}
+
+// IndexTableStructure 将表结构及其向量写入内存索引，同名表会被覆盖
+func (m *MemoryVectorStore) IndexTableStructure(ctx context.Context, table *models.Table, vector []float32) error {
+	if table == nil || table.Name == "" {
+		return fmt.Errorf("table name is required")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkVectorLocked(table.Name, vector); err != nil {
+		return err
+	}
+	m.dimension = len(vector)
+
+	vectorCopy := make([]float32, len(vector))
+	copy(vectorCopy, vector)
+
+	m.entries[table.Name] = &memoryEntry{
+		Table:  table.Clone(),
+		Vector: vectorCopy,
+		norm:   vectorNorm(vectorCopy),
+	}
+	m.dirty = true
+
+	return m.maybeSaveLocked()
+}
+
+// CheckVector 检查向量能否加入索引，供先持久化再写入索引的调用方提前校验
+func (m *MemoryVectorStore) CheckVector(tableName string, vector []float32) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.checkVectorLocked(tableName, vector)
+}
+
+// checkVectorLocked 检查向量非空且与索引维度一致，调用方需持有锁
+func (m *MemoryVectorStore) checkVectorLocked(tableName string, vector []float32) error {
+	if len(vector) == 0 {
+		return fmt.Errorf("empty vector for table %s", tableName)
+	}
+	if m.dimension != 0 && len(vector) != m.dimension {
+		return fmt.Errorf("vector dimension mismatch for table %s: expected %d, got %d", tableName, m.dimension, len(vector))
+	}
+	return nil
+}
+
+// SearchSimilarTables 返回与查询向量最相似的topK张表，按相似度降序排列
+func (m *MemoryVectorStore) SearchSimilarTables(ctx context.Context, queryVector []float32, topK int) ([]*models.Table, error) {
+	if topK <= 0 {
+		return nil, nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.entries) == 0 {
+		return nil, nil
+	}
+	if len(queryVector) != m.dimension {
+		return nil, fmt.Errorf("query vector dimension mismatch: expected %d, got %d", m.dimension, len(queryVector))
+	}
+
+	type scored struct {
+		entry *memoryEntry
+		score float64
+	}
+
+	queryNorm := vectorNorm(queryVector)
+	results := make([]scored, 0, len(m.entries))
+	for _, entry := range m.entries {
+		results = append(results, scored{entry: entry, score: m.score(queryVector, queryNorm, entry)})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].score == results[j].score {
+			return results[i].entry.Table.Name < results[j].entry.Table.Name
+		}
+		return results[i].score > results[j].score
+	})
+
+	if topK > len(results) {
+		topK = len(results)
+	}
+
+	tables := make([]*models.Table, 0, topK)
+	for _, r := range results[:topK] {
+		tables = append(tables, r.entry.Table.Clone())
+	}
+
+	return tables, nil
+}
+
+// DeleteTableVectors 删除表的向量索引
+func (m *MemoryVectorStore) DeleteTableVectors(ctx context.Context, tableName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.entries[tableName]; !ok {
+		return nil
+	}
+	delete(m.entries, tableName)
+	if len(m.entries) == 0 {
+		m.dimension = 0
+	}
+	m.dirty = true
+
+	return m.maybeSaveLocked()
+}
+
+// IsIndexed 判断快照中是否已有与当前表结构一致的向量
+func (m *MemoryVectorStore) IsIndexed(table *models.Table) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entry, ok := m.entries[table.Name]
+	return ok && TableContentHash(entry.Table) == TableContentHash(table)
+}
+
+// Len 返回已索引的表数量
+func (m *MemoryVectorStore) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.entries)
+}
+
+// Flush 将未保存的变更写入快照文件
+func (m *MemoryVectorStore) Flush() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.dirty || m.snapshotPath == "" {
+		return nil
+	}
+	return m.saveLocked()
+}
+
+// maybeSaveLocked 距上次保存超过snapshotSaveInterval时写快照，调用方需持有写锁
+func (m *MemoryVectorStore) maybeSaveLocked() error {
+	if m.snapshotPath == "" || time.Since(m.lastSave) < snapshotSaveInterval {
+		return nil
+	}
+	return m.saveLocked()
+}
+
+// score 按配置的度量方式计算相似度
+func (m *MemoryVectorStore) score(query []float32, queryNorm float64, entry *memoryEntry) float64 {
+	dot := dotProduct(query, entry.Vector)
+	if m.metric == MetricDot {
+		return dot
+	}
+	if queryNorm == 0 || entry.norm == 0 {
+		return 0
+	}
+	return dot / (queryNorm * entry.norm)
+}
+
+// load 从快照文件加载索引，文件不存在时视为空索引。
+// 文件不是JSON（如HNSW存储的gob快照）时同样视为空索引，保存时覆盖该文件
+func (m *MemoryVectorStore) load() error {
+	data, err := os.ReadFile(m.snapshotPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] != '{' {
+		fmt.Printf("Warning: %s is not a vector snapshot in JSON, rebuilding the index\n", m.snapshotPath)
+		return nil
+	}
+
+	var snapshot memorySnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("failed to parse snapshot %s: %w", m.snapshotPath, err)
+	}
+
+	for _, entry := range snapshot.Entries {
+		if entry.Table == nil || len(entry.Vector) != snapshot.Dimension {
+			return fmt.Errorf("corrupted snapshot entry in %s", m.snapshotPath)
+		}
+		entry.norm = vectorNorm(entry.Vector)
+		m.entries[entry.Table.Name] = entry
+	}
+	m.dimension = snapshot.Dimension
+
+	// 向量与度量方式无关，可直接沿用；标记为未保存以便按当前度量方式重写快照
+	if snapshot.Metric != m.metric {
+		fmt.Printf("Warning: vector snapshot %s uses metric %q, rewriting it for %q\n", m.snapshotPath, snapshot.Metric, m.metric)
+		m.dirty = true
+	}
+
+	return nil
+}
+
+// saveLocked 将索引写入快照文件，调用方需持有写锁
+func (m *MemoryVectorStore) saveLocked() error {
+	if m.snapshotPath == "" {
+		return nil
+	}
+
+	snapshot := memorySnapshot{
+		Metric:    m.metric,
+		Dimension: m.dimension,
+		Entries:   make([]*memoryEntry, 0, len(m.entries)),
+	}
+	for _, entry := range m.entries {
+		snapshot.Entries = append(snapshot.Entries, entry)
+	}
+	sort.Slice(snapshot.Entries, func(i, j int) bool {
+		return snapshot.Entries[i].Table.Name < snapshot.Entries[j].Table.Name
+	})
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	// 先写临时文件再重命名，避免进程中断导致快照损坏
+	dir := filepath.Dir(m.snapshotPath)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(m.snapshotPath)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to close snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), m.snapshotPath); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+
+	m.dirty = false
+	m.lastSave = time.Now()
+	return nil
+}
+
+// dotProduct 计算两个等长向量的点积
+func dotProduct(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+// vectorNorm 计算向量的L2范数
+func vectorNorm(v []float32) float64 {
+	return math.Sqrt(dotProduct(v, v))
+}
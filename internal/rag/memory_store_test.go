@@ -0,0 +1,179 @@
+package rag
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sql_generator/internal/models"
+)
+
+func TestMemoryVectorStore_SearchSimilarTables(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewMemoryVectorStore(MetricCosine, "")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	vectors := map[string][]float32{
+		"users":    {1, 0, 0},
+		"orders":   {0, 1, 0},
+		"payments": {0, 0.9, 0.1},
+	}
+	for name, vector := range vectors {
+		if err := store.IndexTableStructure(ctx, &models.Table{Name: name}, vector); err != nil {
+			t.Fatalf("Failed to index table %s: %v", name, err)
+		}
+	}
+
+	tables, err := store.SearchSimilarTables(ctx, []float32{0, 1, 0}, 2)
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(tables) != 2 {
+		t.Fatalf("Expected 2 tables, got %d", len(tables))
+	}
+	if tables[0].Name != "orders" || tables[1].Name != "payments" {
+		t.Errorf("Unexpected ranking: %s, %s", tables[0].Name, tables[1].Name)
+	}
+
+	if _, err := store.SearchSimilarTables(ctx, []float32{1, 0}, 1); err == nil {
+		t.Error("Expected error for mismatched query dimension")
+	}
+	if err := store.IndexTableStructure(ctx, &models.Table{Name: "bad"}, []float32{1}); err == nil {
+		t.Error("Expected error for mismatched vector dimension")
+	}
+}
+
+func TestMemoryVectorStore_CopiesTables(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewMemoryVectorStore(MetricCosine, "")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	table := &models.Table{
+		Name:          "orders",
+		Columns:       []models.Column{{Name: "id", Type: "BIGINT"}},
+		Relationships: []models.Relationship{{Columns: []string{"user_id"}, ToTable: "users", ToColumns: []string{"id"}}},
+	}
+	if err := store.IndexTableStructure(ctx, table, []float32{1, 0}); err != nil {
+		t.Fatalf("Failed to index table: %v", err)
+	}
+
+	// 修改索引时传入的表和检索返回的表都不影响索引中的表
+	table.Columns[0].Name = "changed"
+	found, _ := store.SearchSimilarTables(ctx, []float32{1, 0}, 1)
+	found[0].Columns[0].Type = "changed"
+	found[0].Relationships[0].ToColumns[0] = "changed"
+
+	found, _ = store.SearchSimilarTables(ctx, []float32{1, 0}, 1)
+	if column := found[0].Columns[0]; column.Name != "id" || column.Type != "BIGINT" {
+		t.Errorf("Expected the indexed column to be unchanged, got %+v", column)
+	}
+	if to := found[0].Relationships[0].ToColumns[0]; to != "id" {
+		t.Errorf("Expected the indexed relationship to be unchanged, got %s", to)
+	}
+}
+
+func TestMemoryVectorStore_DotMetric(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewMemoryVectorStore(MetricDot, "")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	// 点积会偏向范数更大的向量，余弦则不会
+	store.IndexTableStructure(ctx, &models.Table{Name: "small"}, []float32{1, 0})
+	store.IndexTableStructure(ctx, &models.Table{Name: "large"}, []float32{3, 3})
+
+	tables, err := store.SearchSimilarTables(ctx, []float32{1, 0}, 1)
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if tables[0].Name != "large" {
+		t.Errorf("Expected large to rank first with dot metric, got %s", tables[0].Name)
+	}
+
+	if _, err := NewMemoryVectorStore("euclidean", ""); err == nil {
+		t.Error("Expected error for unsupported metric")
+	}
+}
+
+func TestMemoryVectorStore_Snapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vectors.json")
+
+	ctx := context.Background()
+	store, err := NewMemoryVectorStore(MetricCosine, path)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	store.IndexTableStructure(ctx, &models.Table{Name: "users", Description: "用户表"}, []float32{1, 0})
+	store.IndexTableStructure(ctx, &models.Table{Name: "orders"}, []float32{0, 1})
+	if err := store.DeleteTableVectors(ctx, "orders"); err != nil {
+		t.Fatalf("Failed to delete vectors: %v", err)
+	}
+
+	// Changes are written in batches until flushed
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("Expected no snapshot before Flush, got %v", err)
+	}
+	if err := store.Flush(); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	reloaded, err := NewMemoryVectorStore(MetricCosine, path)
+	if err != nil {
+		t.Fatalf("Failed to reload store: %v", err)
+	}
+	if reloaded.Len() != 1 {
+		t.Fatalf("Expected 1 table after reload, got %d", reloaded.Len())
+	}
+
+	tables, err := reloaded.SearchSimilarTables(ctx, []float32{1, 0}, 5)
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(tables) != 1 || tables[0].Description != "用户表" {
+		t.Errorf("Unexpected tables after reload: %+v", tables)
+	}
+
+	// A snapshot of another metric keeps its vectors and is rewritten on Flush
+	dot, err := NewMemoryVectorStore(MetricDot, path)
+	if err != nil || dot.Len() != 1 {
+		t.Fatalf("Expected the cosine snapshot to load for the dot metric, got %v", err)
+	}
+	if err := dot.Flush(); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"metric":"dot"`) {
+		t.Errorf("Expected the snapshot to be rewritten for the dot metric, got %s", data)
+	}
+}
+
+func TestMemoryVectorStore_IsIndexed(t *testing.T) {
+	ctx := context.Background()
+	store, _ := NewMemoryVectorStore(MetricCosine, "")
+	table := &models.Table{Name: "users", Description: "用户表"}
+	store.IndexTableStructure(ctx, table, []float32{1, 0})
+
+	if !store.IsIndexed(table) {
+		t.Error("Expected unchanged table to be indexed")
+	}
+
+	changed := *table
+	changed.Description = "用户信息表"
+	if store.IsIndexed(&changed) {
+		t.Error("Expected changed table to require reindexing")
+	}
+
+	if err := store.CheckVector("orders", []float32{0, 1, 0}); err == nil {
+		t.Error("Expected a vector of another dimension to be rejected")
+	}
+	if err := store.CheckVector("orders", []float32{0, 1}); err != nil {
+		t.Errorf("Expected a vector of the index dimension to pass, got %v", err)
+	}
+}
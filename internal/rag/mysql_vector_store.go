@@ -0,0 +1,148 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"sql_generator/internal/models"
+	"sql_generator/internal/storage"
+)
+
+// MySQLVectorStore 实现基于MySQL持久化的向量存储
+// 向量保存在table_vectors表中，启动时加载到内存索引用于检索
+type MySQLVectorStore struct {
+	mu     sync.RWMutex
+	store  *storage.MySQLStore
+	index  *MemoryVectorStore
+	model  string
+	hashes map[string]string
+}
+
+// NewMySQLVectorStore 创建新的MySQL向量存储，并加载当前模型已持久化的向量
+func NewMySQLVectorStore(ctx context.Context, store *storage.MySQLStore, model, metric string) (*MySQLVectorStore, error) {
+	index, err := NewMemoryVectorStore(metric, "")
+	if err != nil {
+		return nil, err
+	}
+
+	m := &MySQLVectorStore{
+		store:  store,
+		index:  index,
+		model:  model,
+		hashes: make(map[string]string),
+	}
+
+	if err := m.load(ctx); err != nil {
+		return nil, fmt.Errorf("failed to load table vectors: %w", err)
+	}
+
+	return m, nil
+}
+
+// IndexTableStructure 持久化表向量并更新内存索引。
+// 向量先经过内存索引的校验再写入，写入索引仍失败时删除已持久化的向量，使两者保持一致
+func (m *MySQLVectorStore) IndexTableStructure(ctx context.Context, table *models.Table, vector []float32) error {
+	if err := m.index.CheckVector(table.Name, vector); err != nil {
+		return err
+	}
+	hash := TableContentHash(table)
+
+	err := m.store.SaveTableVector(ctx, &storage.TableVector{
+		TableName:   table.Name,
+		Model:       m.model,
+		Vector:      vector,
+		ContentHash: hash,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := m.index.IndexTableStructure(ctx, table, vector); err != nil {
+		if deleteErr := m.store.DeleteTableVector(ctx, table.Name); deleteErr != nil {
+			fmt.Printf("Warning: failed to delete unindexed vector of table %s: %v\n", table.Name, deleteErr)
+		}
+		return err
+	}
+
+	m.mu.Lock()
+	m.hashes[table.Name] = hash
+	m.mu.Unlock()
+
+	return nil
+}
+
+// SearchSimilarTables 在内存索引中搜索相似的表结构
+func (m *MySQLVectorStore) SearchSimilarTables(ctx context.Context, queryVector []float32, topK int) ([]*models.Table, error) {
+	return m.index.SearchSimilarTables(ctx, queryVector, topK)
+}
+
+// DeleteTableVectors 同时删除持久化向量和内存索引
+func (m *MySQLVectorStore) DeleteTableVectors(ctx context.Context, tableName string) error {
+	if err := m.store.DeleteTableVector(ctx, tableName); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	delete(m.hashes, tableName)
+	m.mu.Unlock()
+
+	return m.index.DeleteTableVectors(ctx, tableName)
+}
+
+// IsIndexed 判断表是否已有与当前结构一致的向量，可据此跳过重新生成嵌入
+func (m *MySQLVectorStore) IsIndexed(table *models.Table) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.hashes[table.Name] == TableContentHash(table)
+}
+
+// load 读取当前模型的向量，与表定义匹配后加载到内存索引
+// 表结构已变化（哈希不一致）的向量会被忽略，等待重新生成；无法加入索引的向量会被删除，等待重新生成
+func (m *MySQLVectorStore) load(ctx context.Context) error {
+	vectors, err := m.store.ListTableVectors(ctx, m.model)
+	if err != nil {
+		return err
+	}
+	if len(vectors) == 0 {
+		return nil
+	}
+
+	byName := make(map[string]*storage.TableVector, len(vectors))
+	for _, vector := range vectors {
+		byName[vector.TableName] = vector
+	}
+
+	const pageSize = 100
+	for offset := 0; ; offset += pageSize {
+		tables, err := m.store.ListTables(ctx, pageSize, offset)
+		if err != nil {
+			return err
+		}
+
+		for _, table := range tables {
+			vector, ok := byName[table.Name]
+			if !ok {
+				continue
+			}
+			hash := TableContentHash(table)
+			if vector.ContentHash != hash {
+				continue
+			}
+			if err := m.index.IndexTableStructure(ctx, table, vector.Vector); err != nil {
+				fmt.Printf("Warning: dropping persisted vector of table %s: %v\n", table.Name, err)
+				if err := m.store.DeleteTableVector(ctx, table.Name); err != nil {
+					return err
+				}
+				continue
+			}
+			m.hashes[table.Name] = hash
+		}
+
+		if len(tables) < pageSize {
+			break
+		}
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package rag
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -39,7 +40,7 @@ type EmbeddingResponse1 struct {
 }
 
 // GenerateEmbedding 生成文本的向量表示
-func (o *OpenAIEmbeddingService) GenerateEmbedding(text string) ([]float32, error) {
+func (o *OpenAIEmbeddingService) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
 	// 准备请求
 	reqBody := EmbeddingRequest1{
 		Model: o.model,
@@ -51,7 +52,7 @@ func (o *OpenAIEmbeddingService) GenerateEmbedding(text string) ([]float32, erro
 	}
 
 	// 创建HTTP请求
-	req, err := http.NewRequest("POST", "https://api.openai.com/v1/embeddings", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/embeddings", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -91,21 +92,6 @@ func (o *OpenAIEmbeddingService) GenerateEmbedding(text string) ([]float32, erro
 }
 
 // GenerateTableEmbedding 生成表结构的嵌入向量
-func (o *OpenAIEmbeddingService) GenerateTableEmbedding(table *models.Table) ([]float32, error) {
-	// 构造表结构的文本表示
-	tableText := fmt.Sprintf("Table name: %s\nDescription: %s\n", table.Name, table.Description)
-
-	for _, column := range table.Columns {
-		columnText := fmt.Sprintf("Column: %s, Type: %s, Description: %s",
-			column.Name, column.Type, column.Description)
-		if column.IsPrimary {
-			columnText += ", Primary Key"
-		}
-		if column.IsRequired {
-			columnText += ", Required"
-		}
-		tableText += columnText + "\n"
-	}
-
-	return o.GenerateEmbedding(tableText)
+func (o *OpenAIEmbeddingService) GenerateTableEmbedding(ctx context.Context, table *models.Table) ([]float32, error) {
+	return o.GenerateEmbedding(ctx, TableEmbeddingText(table))
 }
@@ -1,6 +1,7 @@
 package rag
 
 import (
+	"context"
 	"fmt"
 
 	"sql_generator/internal/models"
@@ -24,17 +25,17 @@ func NewPineconeVectorStore(apiKey, indexName string, store storage.Store) (Vect
 }
 
 // IndexTableStructure 将表结构索引到向量数据库中
-func (p *PineconeVectorStore) IndexTableStructure(table *models.Table, vector []float32) error {
+func (p *PineconeVectorStore) IndexTableStructure(ctx context.Context, table *models.Table, vector []float32) error {
 	// 简化实现，实际项目中应调用Pinecone API
 	fmt.Printf("Indexing table %s with vector of length %d\n", table.Name, len(vector))
 	return nil
 }
 
 // SearchSimilarTables 搜索相似的表结构
-func (p *PineconeVectorStore) SearchSimilarTables(queryVector []float32, topK int) ([]*models.Table, error) {
+func (p *PineconeVectorStore) SearchSimilarTables(ctx context.Context, queryVector []float32, topK int) ([]*models.Table, error) {
 	// 简化实现，实际项目中应调用Pinecone搜索API
 	// 这里返回所有表作为示例
-	tables, err := p.store.ListTables(topK, 0)
+	tables, err := p.store.ListTables(ctx, topK, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -42,7 +43,7 @@ func (p *PineconeVectorStore) SearchSimilarTables(queryVector []float32, topK in
 }
 
 // DeleteTableVectors 删除表的向量索引
-func (p *PineconeVectorStore) DeleteTableVectors(tableName string) error {
+func (p *PineconeVectorStore) DeleteTableVectors(ctx context.Context, tableName string) error {
 	// 简化实现，实际项目中应调用Pinecone删除API
 	fmt.Printf("Deleting vector for table %s\n", tableName)
 	return nil
@@ -0,0 +1,154 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"sql_generator/internal/models"
+	"sql_generator/internal/storage"
+)
+
+// QueryVectorStore 持久化查询描述的向量，由*storage.MySQLStore实现
+type QueryVectorStore interface {
+	SaveQueryVector(ctx context.Context, vector *storage.QueryVector) error
+	ListQueryVectors(ctx context.Context, model string) ([]*storage.QueryVector, error)
+}
+
+// QueryIndex 是历史查询描述的内存向量索引，按余弦相似度检索相似的问题。
+// store不为nil时向量持久化到query_vectors表，创建时加载当前模型已持久化的向量
+type QueryIndex struct {
+	mu      sync.RWMutex
+	store   QueryVectorStore
+	model   string
+	entries map[string]*queryEntry
+}
+
+// queryEntry 是一条查询在索引中的向量及检索时过滤用的属性
+type queryEntry struct {
+	dialect string
+	correct bool
+	vector  []float32
+	norm    float64
+}
+
+// QueryFilter 限定检索的查询
+type QueryFilter struct {
+	// Dialect 不为空时只返回该方言或未记录方言的查询
+	Dialect string
+	// CorrectOnly 为true时只返回被标记为正确的查询
+	CorrectOnly bool
+}
+
+// QueryMatch 是检索到的查询ID及其与问题的余弦相似度
+type QueryMatch struct {
+	ID    string
+	Score float64
+}
+
+// NewQueryIndex 创建查询向量索引，store为nil时只保存在内存中
+func NewQueryIndex(ctx context.Context, store QueryVectorStore, model string) (*QueryIndex, error) {
+	x := &QueryIndex{
+		store:   store,
+		model:   model,
+		entries: make(map[string]*queryEntry),
+	}
+	if store == nil {
+		return x, nil
+	}
+
+	vectors, err := store.ListQueryVectors(ctx, model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load query vectors: %w", err)
+	}
+	for _, v := range vectors {
+		x.entries[v.QueryID] = newQueryEntry(v.Dialect, v.Correct, v.Vector)
+	}
+	return x, nil
+}
+
+func newQueryEntry(dialect string, correct bool, vector []float32) *queryEntry {
+	return &queryEntry{dialect: dialect, correct: correct, vector: vector, norm: vectorNorm(vector)}
+}
+
+// IndexQuery 持久化查询描述的向量并加入索引
+func (x *QueryIndex) IndexQuery(ctx context.Context, query *models.Query, vector []float32) error {
+	if len(vector) == 0 {
+		return fmt.Errorf("empty vector for query %s", query.ID)
+	}
+
+	if x.store != nil {
+		err := x.store.SaveQueryVector(ctx, &storage.QueryVector{QueryID: query.ID, Model: x.model, Vector: vector})
+		if err != nil {
+			return err
+		}
+	}
+
+	correct := query.Feedback != nil && query.Feedback.Correct
+	x.mu.Lock()
+	x.entries[query.ID] = newQueryEntry(query.Dialect, correct, vector)
+	x.mu.Unlock()
+	return nil
+}
+
+// SetCorrect 记录查询是否被标记为正确，未索引的查询被忽略
+func (x *QueryIndex) SetCorrect(id string, correct bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if entry, ok := x.entries[id]; ok {
+		entry.correct = correct
+	}
+}
+
+// IsIndexed 判断查询是否已有向量，可据此跳过重新生成嵌入
+func (x *QueryIndex) IsIndexed(id string) bool {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	_, ok := x.entries[id]
+	return ok
+}
+
+// Len 返回索引中的查询数量
+func (x *QueryIndex) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.entries)
+}
+
+// Search 返回与vector余弦相似度最高的至多topK条符合filter的查询，按相似度从高到低排列。
+// 维度与vector不同的向量（由其他嵌入模型生成）被忽略
+func (x *QueryIndex) Search(vector []float32, topK int, filter QueryFilter) []QueryMatch {
+	norm := vectorNorm(vector)
+	if topK <= 0 || norm == 0 {
+		return nil
+	}
+
+	x.mu.RLock()
+	var matches []QueryMatch
+	for id, entry := range x.entries {
+		if filter.CorrectOnly && !entry.correct {
+			continue
+		}
+		if filter.Dialect != "" && entry.dialect != "" && entry.dialect != filter.Dialect {
+			continue
+		}
+		if len(entry.vector) != len(vector) || entry.norm == 0 {
+			continue
+		}
+		matches = append(matches, QueryMatch{ID: id, Score: dotProduct(vector, entry.vector) / (norm * entry.norm)})
+	}
+	x.mu.RUnlock()
+
+	// 相似度相同时按ID排序，使结果稳定
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	if len(matches) > topK {
+		matches = matches[:topK]
+	}
+	return matches
+}
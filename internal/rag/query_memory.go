@@ -0,0 +1,51 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"sql_generator/internal/models"
+	"sql_generator/internal/storage"
+)
+
+// QueryMemory 检索描述与问题相似的历史查询，ExampleSelector从中选出被标记为正确的查询作为few-shot示例
+type QueryMemory struct {
+	store        storage.Store
+	embeddingSvc EmbeddingService
+	index        *QueryIndex
+}
+
+// NewQueryMemory 创建查询记忆，从index检索相似查询并从store读取查询内容
+func NewQueryMemory(store storage.Store, embeddingSvc EmbeddingService, index *QueryIndex) *QueryMemory {
+	return &QueryMemory{
+		store:        store,
+		embeddingSvc: embeddingSvc,
+		index:        index,
+	}
+}
+
+// SearchSimilar 返回描述与text最相似的至多k条符合filter的历史查询及相似度，按相似度从高到低排列
+func (m *QueryMemory) SearchSimilar(ctx context.Context, text string, k int, filter QueryFilter) ([]*models.SimilarQuery, error) {
+	if k <= 0 {
+		return nil, nil
+	}
+
+	vector, err := m.embeddingSvc.GenerateEmbedding(ctx, text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate question embedding: %w", err)
+	}
+
+	var similar []*models.SimilarQuery
+	for _, match := range m.index.Search(vector, k, filter) {
+		query, err := m.store.GetQueryByID(ctx, match.ID)
+		if err != nil {
+			if errors.Is(err, storage.ErrQueryNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		similar = append(similar, &models.SimilarQuery{Query: query, Score: match.Score})
+	}
+	return similar, nil
+}
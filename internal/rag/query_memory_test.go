@@ -0,0 +1,129 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"sql_generator/internal/models"
+	"sql_generator/internal/storage"
+)
+
+// queryStore 是仅支持按ID读取查询的storage.Store测试实现
+type queryStore struct {
+	storage.Store
+	queries map[string]*models.Query
+}
+
+func (s *queryStore) GetQueryByID(ctx context.Context, id string) (*models.Query, error) {
+	query, ok := s.queries[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", storage.ErrQueryNotFound, id)
+	}
+	return query, nil
+}
+
+// memoryQueryVectors 是内存中的QueryVectorStore测试实现，正确性取自queries
+type memoryQueryVectors struct {
+	queries map[string]*models.Query
+	vectors []*storage.QueryVector
+}
+
+func (s *memoryQueryVectors) SaveQueryVector(ctx context.Context, vector *storage.QueryVector) error {
+	s.vectors = append(s.vectors, vector)
+	return nil
+}
+
+func (s *memoryQueryVectors) ListQueryVectors(ctx context.Context, model string) ([]*storage.QueryVector, error) {
+	var vectors []*storage.QueryVector
+	for _, v := range s.vectors {
+		if v.Model != model {
+			continue
+		}
+		q := s.queries[v.QueryID]
+		listed := *v
+		listed.Dialect, listed.Correct = q.Dialect, q.Feedback != nil && q.Feedback.Correct
+		vectors = append(vectors, &listed)
+	}
+	return vectors, nil
+}
+
+// keywordEmbedding 按关键词是否出现生成向量
+type keywordEmbedding struct {
+	keywords []string
+}
+
+func (e *keywordEmbedding) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	vector := make([]float32, len(e.keywords))
+	for i, keyword := range e.keywords {
+		if strings.Contains(text, keyword) {
+			vector[i] = 1
+		}
+	}
+	return vector, nil
+}
+
+func (e *keywordEmbedding) GenerateTableEmbedding(ctx context.Context, table *models.Table) ([]float32, error) {
+	return e.GenerateEmbedding(ctx, table.Name)
+}
+
+// newTestQueryMemory indexes five queries, all but one marked correct, with
+// keyword embeddings
+func newTestQueryMemory(t *testing.T) (*QueryMemory, *QueryIndex, map[string]*models.Query, *memoryQueryVectors) {
+	correct := &models.QueryFeedback{Correct: true}
+	queries := map[string]*models.Query{
+		"1": {ID: "1", Description: "统计每个用户的订单数", Dialect: "mysql", Feedback: correct},
+		"2": {ID: "2", Description: "统计每个城市的用户数", Dialect: "mysql", Feedback: correct},
+		"3": {ID: "3", Description: "统计每个用户的订单金额", Dialect: "hive", Feedback: correct},
+		"4": {ID: "4", Description: "统计每个用户的订单数量", Dialect: "mysql"},
+		"5": {ID: "5", Description: "查询商品库存", Dialect: "mysql", Feedback: correct},
+	}
+	embedding := &keywordEmbedding{keywords: []string{"用户", "订单", "城市", "商品"}}
+	vectors := &memoryQueryVectors{queries: queries}
+
+	ctx := context.Background()
+	index, err := NewQueryIndex(ctx, vectors, "keywords")
+	if err != nil {
+		t.Fatalf("NewQueryIndex: %v", err)
+	}
+	for _, id := range []string{"1", "2", "3", "4", "5"} {
+		vector, _ := embedding.GenerateEmbedding(ctx, queries[id].Description)
+		if err := index.IndexQuery(ctx, queries[id], vector); err != nil {
+			t.Fatalf("IndexQuery: %v", err)
+		}
+	}
+	return NewQueryMemory(&queryStore{queries: queries}, embedding, index), index, queries, vectors
+}
+
+func TestQueryMemory(t *testing.T) {
+	memory, _, queries, vectors := newTestQueryMemory(t)
+	ctx := context.Background()
+
+	// Similar queries include those not marked correct, with their score
+	similar, err := memory.SearchSimilar(ctx, "用户订单", 3, QueryFilter{})
+	if err != nil {
+		t.Fatalf("SearchSimilar: %v", err)
+	}
+	if len(similar) != 3 || similar[0].Score < 0.99 || similar[2].Query.ID != "4" {
+		t.Errorf("unexpected similar queries %+v", similar)
+	}
+
+	// Persisted vectors are loaded with the correctness of their queries
+	queries["1"].Feedback = nil
+	queries["4"].Feedback = &models.QueryFeedback{Correct: true}
+	reloaded, err := NewQueryIndex(ctx, vectors, "keywords")
+	if err != nil {
+		t.Fatalf("NewQueryIndex: %v", err)
+	}
+	if reloaded.Len() != 5 || !reloaded.IsIndexed("3") {
+		t.Fatalf("expected 5 persisted vectors, got %d", reloaded.Len())
+	}
+	vector, _ := memory.embeddingSvc.GenerateEmbedding(ctx, "每个用户有多少订单")
+	if matches := reloaded.Search(vector, 1, QueryFilter{Dialect: "mysql", CorrectOnly: true}); len(matches) != 1 || matches[0].ID != "4" {
+		t.Errorf("unexpected matches after reload %+v", matches)
+	}
+	if other, _ := NewQueryIndex(ctx, vectors, "other-model"); other.Len() != 0 {
+		t.Errorf("expected no vectors of another model, got %d", other.Len())
+	}
+}
@@ -30,7 +30,7 @@ func NewQwenEmbeddingService(apiKey, model string) EmbeddingService {
 }
 
 // GenerateEmbedding 生成文本的向量表示
-func (q *QwenEmbeddingService) GenerateEmbedding(text string) ([]float32, error) {
+func (q *QwenEmbeddingService) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
 	// 创建嵌入请求
 	req := openai.EmbeddingRequest{
 		Input: []string{text},
@@ -38,7 +38,7 @@ func (q *QwenEmbeddingService) GenerateEmbedding(text string) ([]float32, error)
 	}
 
 	// 发送请求
-	resp, err := q.client.CreateEmbeddings(context.Background(), req)
+	resp, err := q.client.CreateEmbeddings(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create embeddings: %w", err)
 	}
@@ -51,21 +51,6 @@ func (q *QwenEmbeddingService) GenerateEmbedding(text string) ([]float32, error)
 }
 
 // GenerateTableEmbedding 生成表结构的嵌入向量
-func (q *QwenEmbeddingService) GenerateTableEmbedding(table *models.Table) ([]float32, error) {
-	// 构造表结构的文本表示
-	tableText := fmt.Sprintf("Table name: %s\nDescription: %s\n", table.Name, table.Description)
-
-	for _, column := range table.Columns {
-		columnText := fmt.Sprintf("Column: %s, Type: %s, Description: %s",
-			column.Name, column.Type, column.Description)
-		if column.IsPrimary {
-			columnText += ", Primary Key"
-		}
-		if column.IsRequired {
-			columnText += ", Required"
-		}
-		tableText += columnText + "\n"
-	}
-
-	return q.GenerateEmbedding(tableText)
+func (q *QwenEmbeddingService) GenerateTableEmbedding(ctx context.Context, table *models.Table) ([]float32, error) {
+	return q.GenerateEmbedding(ctx, TableEmbeddingText(table))
 }
@@ -0,0 +1,61 @@
+package rag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"sql_generator/internal/models"
+)
+
+// tableTextLabels 是表结构文本中各部分的标签
+type tableTextLabels struct {
+	table, column, partition, relationship      string
+	typ, description, primaryKey, required, sep string
+}
+
+// tableTextLabelsBilingual 是中英双语的表结构文本标签。
+// 标签同时包含两种语言，中文问题和英文问题都能检索到同一张表，不受表描述语言的影响
+var tableTextLabelsBilingual = tableTextLabels{
+	table: "Table name / 表名", column: "Column / 字段", partition: "Partition column / 分区字段", relationship: "Relationship / 关联关系",
+	typ: "Type / 类型", description: "Description / 描述", primaryKey: "Primary Key / 主键", required: "Required / 必填", sep: ", ",
+}
+
+// TableEmbeddingText 构造表结构的文本表示，供各嵌入服务生成向量使用。
+// 标签使用中英双语，避免单一语言的标签使向量偏向该语言而降低另一种语言问题的检索效果
+func TableEmbeddingText(table *models.Table) string {
+	l := tableTextLabelsBilingual
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("%s: %s\n%s: %s\n", l.table, table.Name, l.description, table.Description))
+
+	for _, column := range table.Columns {
+		columnText := fmt.Sprintf("%s: %s%s%s: %s%s%s: %s",
+			l.column, column.Name, l.sep, l.typ, column.Type, l.sep, l.description, column.Description)
+		if column.IsPrimary {
+			columnText += l.sep + l.primaryKey
+		}
+		if column.IsRequired {
+			columnText += l.sep + l.required
+		}
+		text.WriteString(columnText + "\n")
+	}
+
+	for _, key := range table.PartitionKeys {
+		text.WriteString(fmt.Sprintf("%s: %s%s%s: %s%s%s: %s\n",
+			l.partition, key.Name, l.sep, l.typ, key.Type, l.sep, l.description, key.Description))
+	}
+
+	for _, rel := range table.Relationships {
+		text.WriteString(fmt.Sprintf("%s: %s -> %s.%s\n",
+			l.relationship, strings.Join(rel.Columns, ", "), rel.ToTable, strings.Join(rel.ToColumns, ", ")))
+	}
+
+	return text.String()
+}
+
+// TableContentHash 计算表结构文本的哈希，用于判断已持久化的向量是否过期
+func TableContentHash(table *models.Table) string {
+	sum := sha256.Sum256([]byte(TableEmbeddingText(table)))
+	return hex.EncodeToString(sum[:])
+}
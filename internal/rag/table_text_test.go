@@ -0,0 +1,86 @@
+package rag
+
+import (
+	"context"
+	"testing"
+
+	"sql_generator/internal/models"
+)
+
+func TestTableEmbeddingTextLabels(t *testing.T) {
+	chinese := &models.Table{
+		Name:        "users",
+		Description: "用户表",
+		Columns: []models.Column{
+			{Name: "id", Type: "BIGINT", Description: "用户ID", IsPrimary: true},
+			{Name: "email", Type: "VARCHAR(255)", Description: "邮箱"},
+		},
+	}
+	want := "Table name / 表名: users\nDescription / 描述: 用户表\n" +
+		"Column / 字段: id, Type / 类型: BIGINT, Description / 描述: 用户ID, Primary Key / 主键\n" +
+		"Column / 字段: email, Type / 类型: VARCHAR(255), Description / 描述: 邮箱\n"
+	if got := TableEmbeddingText(chinese); got != want {
+		t.Errorf("TableEmbeddingText = %q, want %q", got, want)
+	}
+
+	english := &models.Table{
+		Name:        "orders",
+		Description: "Orders placed by users",
+		Columns:     []models.Column{{Name: "id", Type: "BIGINT", Description: "Order ID", IsPrimary: true, IsRequired: true}},
+		Relationships: []models.Relationship{
+			{Columns: []string{"user_id"}, ToTable: "users", ToColumns: []string{"id"}},
+		},
+	}
+	want = "Table name / 表名: orders\nDescription / 描述: Orders placed by users\n" +
+		"Column / 字段: id, Type / 类型: BIGINT, Description / 描述: Order ID, Primary Key / 主键, Required / 必填\n" +
+		"Relationship / 关联关系: user_id -> users.id\n"
+	if got := TableEmbeddingText(english); got != want {
+		t.Errorf("TableEmbeddingText = %q, want %q", got, want)
+	}
+}
+
+func TestTableEmbeddingText_RetrievedByBothLanguages(t *testing.T) {
+	ctx := context.Background()
+	// 向量只记录标签和邮箱相关的关键词，单一语言的标签会让另一种语言的问题匹配到描述语言相同的表
+	embedding := &keywordEmbedding{keywords: []string{"Table name", "表名", "Column", "字段", "Description", "描述", "email", "邮箱"}}
+	store, err := NewMemoryVectorStore(MetricCosine, "")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	tables := []*models.Table{
+		{
+			Name:        "users",
+			Description: "用户表",
+			Columns:     []models.Column{{Name: "id", Description: "用户ID"}, {Name: "email", Description: "邮箱"}},
+		},
+		{
+			Name:        "orders",
+			Description: "Orders placed by users",
+			Columns:     []models.Column{{Name: "id", Description: "Order ID"}, {Name: "amount", Description: "Order amount"}},
+		},
+	}
+	for _, table := range tables {
+		vector, err := embedding.GenerateEmbedding(ctx, TableEmbeddingText(table))
+		if err != nil {
+			t.Fatalf("Failed to embed table %s: %v", table.Name, err)
+		}
+		if err := store.IndexTableStructure(ctx, table, vector); err != nil {
+			t.Fatalf("Failed to index table %s: %v", table.Name, err)
+		}
+	}
+
+	for _, question := range []string{"Column holding the email of a user", "哪个字段保存邮箱？"} {
+		vector, _ := embedding.GenerateEmbedding(ctx, question)
+		found, err := store.SearchSimilarTables(ctx, vector, 1)
+		if err != nil {
+			t.Fatalf("Failed to search: %v", err)
+		}
+		if len(found) != 1 {
+			t.Fatalf("Question %q retrieved %d tables, want 1", question, len(found))
+		}
+		if found[0].Name != "users" {
+			t.Errorf("Question %q retrieved %s, want users", question, found[0].Name)
+		}
+	}
+}
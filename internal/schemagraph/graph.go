@@ -0,0 +1,327 @@
+// Package schemagraph models the stored tables as a graph whose edges are
+// join conditions: declared relationships plus foreign keys inferred from the
+// "xxx_id" -> "xxx.id" naming convention. It finds join paths between tables
+// and the minimal set of joins connecting the tables of a question.
+package schemagraph
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"sql_generator/internal/models"
+)
+
+// Edge weights. Inferred edges cost more so that declared keys are preferred.
+const (
+	declaredWeight = 1.0
+	inferredWeight = 1.5
+)
+
+// Edge is a join condition between two tables
+type Edge struct {
+	From        string   `json:"from"`
+	FromColumns []string `json:"from_columns"`
+	To          string   `json:"to"`
+	ToColumns   []string `json:"to_columns"`
+	// Cardinality is seen from From, e.g. many_to_one for orders -> users
+	Cardinality string `json:"cardinality,omitempty"`
+	// Inferred is set for edges derived from column names
+	Inferred bool `json:"inferred"`
+}
+
+// Condition renders the edge as a join condition, e.g. "orders.user_id = users.id"
+func (e Edge) Condition() string {
+	parts := make([]string, len(e.FromColumns))
+	for i := range e.FromColumns {
+		parts[i] = fmt.Sprintf("%s.%s = %s.%s", e.From, e.FromColumns[i], e.To, e.ToColumns[i])
+	}
+	return strings.Join(parts, " AND ")
+}
+
+func (e Edge) weight() float64 {
+	if e.Inferred {
+		return inferredWeight
+	}
+	return declaredWeight
+}
+
+// reverse returns the edge seen from the other table
+func (e Edge) reverse() Edge {
+	return Edge{
+		From:        e.To,
+		FromColumns: e.ToColumns,
+		To:          e.From,
+		ToColumns:   e.FromColumns,
+		Cardinality: reverseCardinality(e.Cardinality),
+		Inferred:    e.Inferred,
+	}
+}
+
+func reverseCardinality(c string) string {
+	switch c {
+	case models.ManyToOne:
+		return models.OneToMany
+	case models.OneToMany:
+		return models.ManyToOne
+	default:
+		return c
+	}
+}
+
+// idRef is a column named "<stem>_id" that may reference the table <stem>
+type idRef struct {
+	table  string
+	column string
+}
+
+// Graph is a thread-safe schema graph keyed by lower-cased table name
+type Graph struct {
+	mu     sync.RWMutex
+	tables map[string]*models.Table
+	// declaredIn lists the declared edges pointing at each table
+	declaredIn map[string][]Edge
+	// idRefs lists the "<stem>_id" columns by stem
+	idRefs map[string][]idRef
+}
+
+// New creates an empty Graph
+func New() *Graph {
+	return &Graph{
+		tables:     make(map[string]*models.Table),
+		declaredIn: make(map[string][]Edge),
+		idRefs:     make(map[string][]idRef),
+	}
+}
+
+// AddTable adds or replaces a table
+func (g *Graph) AddTable(table *models.Table) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := strings.ToLower(table.Name)
+	if _, ok := g.tables[key]; ok {
+		g.removeLocked(key)
+	}
+	g.tables[key] = table
+
+	for _, edge := range declaredEdges(table) {
+		target := strings.ToLower(edge.To)
+		g.declaredIn[target] = append(g.declaredIn[target], edge)
+	}
+	for _, column := range table.AllColumns() {
+		if stem, ok := idStem(column.Name); ok {
+			g.idRefs[stem] = append(g.idRefs[stem], idRef{table: key, column: column.Name})
+		}
+	}
+}
+
+// RemoveTable removes a table and its edges
+func (g *Graph) RemoveTable(name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.removeLocked(strings.ToLower(name))
+}
+
+func (g *Graph) removeLocked(key string) {
+	if _, ok := g.tables[key]; !ok {
+		return
+	}
+	delete(g.tables, key)
+
+	for target, edges := range g.declaredIn {
+		kept := edges[:0]
+		for _, edge := range edges {
+			if strings.ToLower(edge.From) != key {
+				kept = append(kept, edge)
+			}
+		}
+		if len(kept) == 0 {
+			delete(g.declaredIn, target)
+		} else {
+			g.declaredIn[target] = kept
+		}
+	}
+	for stem, refs := range g.idRefs {
+		kept := refs[:0]
+		for _, ref := range refs {
+			if ref.table != key {
+				kept = append(kept, ref)
+			}
+		}
+		if len(kept) == 0 {
+			delete(g.idRefs, stem)
+		} else {
+			g.idRefs[stem] = kept
+		}
+	}
+}
+
+// Table returns the definition of a table in the graph, or nil
+func (g *Graph) Table(name string) *models.Table {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.tables[strings.ToLower(name)]
+}
+
+// Edges returns the join conditions from a table to its neighbours
+func (g *Graph) Edges(name string) []Edge {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.edgesLocked(strings.ToLower(name))
+}
+
+func (g *Graph) edgesLocked(key string) []Edge {
+	table, ok := g.tables[key]
+	if !ok {
+		return nil
+	}
+
+	var edges []Edge
+	declared := make(map[string]bool)
+
+	// Declared relationships in both directions
+	for _, edge := range declaredEdges(table) {
+		for _, column := range edge.FromColumns {
+			declared[strings.ToLower(column)] = true
+		}
+		if target, ok := g.tables[strings.ToLower(edge.To)]; ok {
+			edge.To = target.Name
+			edges = append(edges, edge)
+		}
+	}
+	for _, edge := range g.declaredIn[key] {
+		reversed := edge.reverse()
+		reversed.From = table.Name
+		edges = append(edges, reversed)
+	}
+
+	// Inferred "<stem>_id" -> <stem>.id, unless a relationship covers the column
+	for _, column := range table.AllColumns() {
+		stem, ok := idStem(column.Name)
+		if !ok || declared[strings.ToLower(column.Name)] {
+			continue
+		}
+		for _, name := range tableNamesForStem(stem) {
+			target, ok := g.tables[name]
+			if !ok || name == key {
+				continue
+			}
+			if id := idColumn(target); id != "" {
+				edges = append(edges, Edge{
+					From:        table.Name,
+					FromColumns: []string{column.Name},
+					To:          target.Name,
+					ToColumns:   []string{id},
+					Cardinality: models.ManyToOne,
+					Inferred:    true,
+				})
+			}
+		}
+	}
+
+	// Inferred edges from other tables pointing at this one
+	if id := idColumn(table); id != "" {
+		for _, stem := range stemsForTable(key) {
+			for _, ref := range g.idRefs[stem] {
+				source := g.tables[ref.table]
+				if ref.table == key || source == nil || declaresColumn(source, ref.column) {
+					continue
+				}
+				edges = append(edges, Edge{
+					From:        table.Name,
+					FromColumns: []string{id},
+					To:          source.Name,
+					ToColumns:   []string{ref.column},
+					Cardinality: models.OneToMany,
+					Inferred:    true,
+				})
+			}
+		}
+	}
+
+	return edges
+}
+
+// declaredEdges converts the relationships of a table into edges
+func declaredEdges(table *models.Table) []Edge {
+	edges := make([]Edge, 0, len(table.Relationships))
+	for _, rel := range table.Relationships {
+		if len(rel.Columns) == 0 || len(rel.Columns) != len(rel.ToColumns) {
+			continue
+		}
+		cardinality := rel.Cardinality
+		if cardinality == "" {
+			cardinality = models.ManyToOne
+		}
+		edges = append(edges, Edge{
+			From:        table.Name,
+			FromColumns: rel.Columns,
+			To:          rel.ToTable,
+			ToColumns:   rel.ToColumns,
+			Cardinality: cardinality,
+		})
+	}
+	return edges
+}
+
+// declaresColumn reports whether a relationship of table starts at column
+func declaresColumn(table *models.Table, column string) bool {
+	for _, rel := range table.Relationships {
+		for _, name := range rel.Columns {
+			if strings.EqualFold(name, column) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// idStem returns "user" for a column named "user_id"
+func idStem(column string) (string, bool) {
+	lower := strings.ToLower(column)
+	if len(lower) <= 3 || !strings.HasSuffix(lower, "_id") {
+		return "", false
+	}
+	return strings.TrimSuffix(lower, "_id"), true
+}
+
+// idColumn returns the "id" column of a table, or its single primary key
+func idColumn(table *models.Table) string {
+	var primary []string
+	for _, column := range table.Columns {
+		if strings.EqualFold(column.Name, "id") {
+			return column.Name
+		}
+		if column.IsPrimary {
+			primary = append(primary, column.Name)
+		}
+	}
+	if len(primary) == 1 {
+		return primary[0]
+	}
+	return ""
+}
+
+// tableNamesForStem lists the table names a "<stem>_id" column may refer to
+func tableNamesForStem(stem string) []string {
+	names := []string{stem, stem + "s", stem + "es"}
+	if strings.HasSuffix(stem, "y") {
+		names = append(names, strings.TrimSuffix(stem, "y")+"ies")
+	}
+	return names
+}
+
+// stemsForTable is the inverse of tableNamesForStem
+func stemsForTable(name string) []string {
+	stems := []string{name}
+	switch {
+	case strings.HasSuffix(name, "ies"):
+		stems = append(stems, strings.TrimSuffix(name, "ies")+"y")
+	case strings.HasSuffix(name, "es"):
+		stems = append(stems, strings.TrimSuffix(name, "es"), strings.TrimSuffix(name, "s"))
+	case strings.HasSuffix(name, "s"):
+		stems = append(stems, strings.TrimSuffix(name, "s"))
+	}
+	return stems
+}
@@ -0,0 +1,126 @@
+package schemagraph
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"sql_generator/internal/models"
+)
+
+func table(name string, columns ...string) *models.Table {
+	t := &models.Table{Name: name}
+	for _, column := range columns {
+		t.Columns = append(t.Columns, models.Column{Name: column, Type: "BIGINT"})
+	}
+	return t
+}
+
+// newShopGraph builds users <- orders -> order_items -> products, where
+// orders -> users is declared and the other edges are inferred from names
+func newShopGraph() *Graph {
+	orders := table("orders", "id", "user_id", "amount")
+	orders.Relationships = []models.Relationship{
+		{Columns: []string{"user_id"}, ToTable: "users", ToColumns: []string{"id"}, Cardinality: models.ManyToOne},
+	}
+
+	g := New()
+	g.AddTable(table("users", "id", "name"))
+	g.AddTable(orders)
+	g.AddTable(table("order_items", "id", "order_id", "product_id"))
+	g.AddTable(table("products", "id", "category_id"))
+	g.AddTable(table("categories", "id", "name"))
+	g.AddTable(table("logs", "message"))
+	return g
+}
+
+func conditions(edges []Edge) []string {
+	result := make([]string, len(edges))
+	for i, edge := range edges {
+		result[i] = edge.Condition()
+	}
+	return result
+}
+
+func TestGraph_Edges(t *testing.T) {
+	g := newShopGraph()
+
+	edges := g.Edges("users")
+	if len(edges) != 1 || edges[0].Inferred || edges[0].Cardinality != models.OneToMany {
+		t.Fatalf("Unexpected users edges: %+v", edges)
+	}
+	if got := edges[0].Condition(); got != "users.id = orders.user_id" {
+		t.Errorf("Unexpected condition %q", got)
+	}
+
+	// The declared relationship replaces the inferred one for orders.user_id
+	want := []string{"orders.user_id = users.id", "orders.id = order_items.order_id"}
+	if got := conditions(g.Edges("orders")); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	// category_id resolves to the plural table name
+	edges = g.Edges("products")
+	if got := conditions(edges); !reflect.DeepEqual(got, []string{"products.category_id = categories.id", "products.id = order_items.product_id"}) {
+		t.Errorf("Unexpected products edges: %v", got)
+	}
+	if !edges[0].Inferred || edges[0].Cardinality != models.ManyToOne {
+		t.Errorf("Expected an inferred many_to_one edge: %+v", edges[0])
+	}
+}
+
+func TestGraph_ShortestPath(t *testing.T) {
+	g := newShopGraph()
+
+	path, err := g.ShortestPath("USERS", "products")
+	if err != nil {
+		t.Fatalf("ShortestPath failed: %v", err)
+	}
+	want := []string{"users.id = orders.user_id", "orders.id = order_items.order_id", "order_items.product_id = products.id"}
+	if got := conditions(path); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if _, err := g.ShortestPath("users", "logs"); !errors.Is(err, ErrNoPath) {
+		t.Errorf("Expected ErrNoPath, got %v", err)
+	}
+	if _, err := g.ShortestPath("users", "missing"); !errors.Is(err, ErrUnknownTable) {
+		t.Errorf("Expected ErrUnknownTable, got %v", err)
+	}
+}
+
+func TestGraph_JoinPlan(t *testing.T) {
+	g := newShopGraph()
+
+	plan := g.JoinPlan([]string{"users", "products", "categories", "logs"}, DefaultMaxHops)
+	if !reflect.DeepEqual(plan.Bridges, []string{"orders", "order_items"}) {
+		t.Errorf("Unexpected bridges: %v", plan.Bridges)
+	}
+	if !reflect.DeepEqual(plan.Unconnected, []string{"logs"}) {
+		t.Errorf("Unexpected unconnected tables: %v", plan.Unconnected)
+	}
+	if len(plan.Edges) != 4 {
+		t.Errorf("Expected 4 joins, got %v", conditions(plan.Edges))
+	}
+
+	// Paths longer than the hop limit are not used
+	plan = g.JoinPlan([]string{"users", "products"}, 2)
+	if len(plan.Edges) != 0 || len(plan.Unconnected) != 2 {
+		t.Errorf("Expected no joins within 2 hops, got %+v", plan)
+	}
+}
+
+func TestGraph_RemoveTable(t *testing.T) {
+	g := newShopGraph()
+	g.RemoveTable("orders")
+
+	if g.Table("orders") != nil {
+		t.Error("Expected orders to be removed")
+	}
+	if edges := g.Edges("users"); len(edges) != 0 {
+		t.Errorf("Expected users to have no edges, got %+v", edges)
+	}
+	if _, err := g.ShortestPath("users", "products"); !errors.Is(err, ErrNoPath) {
+		t.Errorf("Expected ErrNoPath, got %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package schemagraph
+
+import (
+	"container/heap"
+	"errors"
+	"strings"
+)
+
+// DefaultMaxHops bounds the number of joins between two tables of a plan
+const DefaultMaxHops = 3
+
+var (
+	// ErrUnknownTable is returned when a table is not in the graph
+	ErrUnknownTable = errors.New("table not found in schema graph")
+	// ErrNoPath is returned when two tables cannot be joined
+	ErrNoPath = errors.New("no join path between tables")
+)
+
+// Plan is the set of joins connecting a group of tables
+type Plan struct {
+	// Edges are the join conditions, each oriented away from the tables already joined
+	Edges []Edge `json:"edges"`
+	// Bridges are tables not in the request that are needed to connect it
+	Bridges []string `json:"bridges,omitempty"`
+	// Unconnected are requested tables that could not be joined to any other
+	Unconnected []string `json:"unconnected,omitempty"`
+}
+
+// ShortestPath returns the cheapest chain of joins from one table to another
+func (g *Graph) ShortestPath(from, to string) ([]Edge, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	source, target := strings.ToLower(from), strings.ToLower(to)
+	for _, key := range []string{source, target} {
+		if _, ok := g.tables[key]; !ok {
+			return nil, ErrUnknownTable
+		}
+	}
+	if source == target {
+		return []Edge{}, nil
+	}
+
+	path, _ := g.search(map[string]bool{source: true}, map[string]bool{target: true}, 0)
+	if path == nil {
+		return nil, ErrNoPath
+	}
+	return path, nil
+}
+
+// JoinPlan connects the given tables with as few joins as possible. It grows
+// a tree from the first table by repeatedly attaching the closest remaining
+// table (the shortest-path heuristic for Steiner trees); tables on the way
+// that were not requested become bridges. Paths longer than maxHops are not
+// used, and tables that cannot be reached start a separate tree.
+func (g *Graph) JoinPlan(tables []string, maxHops int) *Plan {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	requested := make(map[string]bool)
+	var pending []string
+	for _, name := range tables {
+		key := strings.ToLower(name)
+		if _, ok := g.tables[key]; ok && !requested[key] {
+			requested[key] = true
+			pending = append(pending, key)
+		}
+	}
+
+	plan := &Plan{}
+	joined := make(map[string]bool)
+	var tree map[string]bool
+	for len(pending) > 0 {
+		targets := make(map[string]bool, len(pending))
+		for _, key := range pending {
+			targets[key] = true
+		}
+
+		var path []Edge
+		var reached string
+		if tree != nil {
+			path, reached = g.search(tree, targets, maxHops)
+		}
+		if path == nil {
+			// Nothing reachable from the current tree: start a new one
+			tree = map[string]bool{pending[0]: true}
+			pending = pending[1:]
+			continue
+		}
+
+		for _, edge := range path {
+			key := strings.ToLower(edge.To)
+			if !tree[key] && !requested[key] {
+				plan.Bridges = append(plan.Bridges, g.tables[key].Name)
+			}
+			tree[key] = true
+			joined[strings.ToLower(edge.From)] = true
+			joined[key] = true
+		}
+		plan.Edges = append(plan.Edges, path...)
+		pending = removeKey(pending, reached)
+	}
+
+	if len(requested) > 1 {
+		for _, name := range tables {
+			key := strings.ToLower(name)
+			if requested[key] && !joined[key] {
+				plan.Unconnected = append(plan.Unconnected, g.tables[key].Name)
+				joined[key] = true
+			}
+		}
+	}
+	return plan
+}
+
+// search runs Dijkstra from all sources at once and returns the path to the
+// nearest target together with that target. A maxHops of zero means no limit.
+func (g *Graph) search(sources, targets map[string]bool, maxHops int) ([]Edge, string) {
+	dist := make(map[string]float64)
+	hops := make(map[string]int)
+	prev := make(map[string]Edge)
+	queue := &nodeQueue{}
+
+	for key := range sources {
+		dist[key] = 0
+		heap.Push(queue, &queueItem{key: key})
+	}
+
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(*queueItem)
+		if item.dist > dist[item.key] {
+			continue
+		}
+		if targets[item.key] && !sources[item.key] {
+			return buildPath(prev, sources, item.key), item.key
+		}
+		if maxHops > 0 && hops[item.key] >= maxHops {
+			continue
+		}
+		for _, edge := range g.edgesLocked(item.key) {
+			next := strings.ToLower(edge.To)
+			d := item.dist + edge.weight()
+			if current, ok := dist[next]; ok && current <= d {
+				continue
+			}
+			dist[next] = d
+			hops[next] = hops[item.key] + 1
+			prev[next] = edge
+			heap.Push(queue, &queueItem{key: next, dist: d})
+		}
+	}
+	return nil, ""
+}
+
+func buildPath(prev map[string]Edge, sources map[string]bool, target string) []Edge {
+	var path []Edge
+	for key := target; !sources[key]; {
+		edge := prev[key]
+		path = append(path, edge)
+		key = strings.ToLower(edge.From)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+func removeKey(keys []string, key string) []string {
+	for i, k := range keys {
+		if k == key {
+			return append(keys[:i], keys[i+1:]...)
+		}
+	}
+	return keys
+}
+
+type queueItem struct {
+	key  string
+	dist float64
+}
+
+// nodeQueue is a min-heap of nodes by distance
+type nodeQueue []*queueItem
+
+func (q nodeQueue) Len() int            { return len(q) }
+func (q nodeQueue) Less(i, j int) bool  { return q[i].dist < q[j].dist }
+func (q nodeQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *nodeQueue) Push(x interface{}) { *q = append(*q, x.(*queueItem)) }
+func (q *nodeQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,47 @@
+package schemagraph
+
+import (
+	"context"
+
+	"sql_generator/internal/models"
+	"sql_generator/internal/storage"
+)
+
+// trackingStore keeps a Graph in sync with the tables of a Store
+type trackingStore struct {
+	storage.Store
+	graph *Graph
+}
+
+// NewTrackingStore wraps store so that table changes are applied to graph
+func NewTrackingStore(store storage.Store, graph *Graph) storage.Store {
+	return &trackingStore{Store: store, graph: graph}
+}
+
+// CreateTable saves the table and adds it to the graph
+func (s *trackingStore) CreateTable(ctx context.Context, table *models.Table) error {
+	if err := s.Store.CreateTable(ctx, table); err != nil {
+		return err
+	}
+	s.graph.AddTable(table)
+	return nil
+}
+
+// UpdateTable updates the table and replaces it in the graph
+func (s *trackingStore) UpdateTable(ctx context.Context, name string, table *models.Table) error {
+	if err := s.Store.UpdateTable(ctx, name, table); err != nil {
+		return err
+	}
+	s.graph.RemoveTable(name)
+	s.graph.AddTable(table)
+	return nil
+}
+
+// DeleteTable deletes the table and removes it from the graph
+func (s *trackingStore) DeleteTable(ctx context.Context, name string) error {
+	if err := s.Store.DeleteTable(ctx, name); err != nil {
+		return err
+	}
+	s.graph.RemoveTable(name)
+	return nil
+}
@@ -0,0 +1,250 @@
+package sensitivity
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"sql_generator/internal/dialect"
+	"sql_generator/internal/language"
+	"sql_generator/internal/models"
+	"sql_generator/internal/validation"
+)
+
+// Violation codes
+const (
+	CodeExcludedColumn = "excluded_column"
+	CodeRefusedColumn  = "refused_column"
+	// CodeMaskedStar is reported when "*" would return a masked column unmasked
+	CodeMaskedStar = "masked_column_in_star"
+)
+
+// Violation describes a sensitive column the SQL may not use as written
+type Violation struct {
+	Code        string `json:"code"`
+	Message     string `json:"message"`
+	Table       string `json:"table"`
+	Column      string `json:"column"`
+	Sensitivity string `json:"sensitivity"`
+}
+
+// ViolationError is returned by Enforce when the SQL uses sensitive columns
+// the role may not see
+type ViolationError struct {
+	Violations []Violation
+}
+
+func (e *ViolationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+	return "generated SQL uses sensitive columns: " + strings.Join(messages, "; ")
+}
+
+// Restrict returns the tables as the role may see them: excluded columns and
+// the relationships over them are removed, masked and refused columns are
+// annotated for the model in the prompt language lang. Tables without
+// affected columns are returned as is.
+func Restrict(tables []*models.Table, rules Rules, lang language.Language) []*models.Table {
+	excluded := make(map[string]bool)
+	for _, table := range tables {
+		for _, col := range table.AllColumns() {
+			if rules.Action(col.Sensitivity) == Exclude {
+				excluded[columnKey(table.Name, col.Name)] = true
+			}
+		}
+	}
+
+	restricted := make([]*models.Table, len(tables))
+	for i, table := range tables {
+		restricted[i] = restrictTable(table, rules, excluded, lang)
+	}
+	return restricted
+}
+
+// annotations 是各语言中追加在脱敏和受限字段描述后的说明
+var annotations = map[language.Language]map[Action]string{
+	language.Chinese: {
+		Mask:   "（敏感字段，查询结果中的值会被自动脱敏，不要对该表使用SELECT *）",
+		Refuse: "（敏感字段，只能用于过滤和关联条件，不能出现在SELECT列表中，不要对该表使用SELECT *）",
+	},
+	language.English: {
+		Mask:   " (sensitive column, its values are masked in query results; do not use SELECT * on this table)",
+		Refuse: " (sensitive column, only usable in filters and join conditions, never in the SELECT list; do not use SELECT * on this table)",
+	},
+}
+
+func restrictTable(table *models.Table, rules Rules, excluded map[string]bool, lang language.Language) *models.Table {
+	notes, ok := annotations[lang]
+	if !ok {
+		notes = annotations[language.Default]
+	}
+	changed := false
+	restrict := func(columns []models.Column) []models.Column {
+		var kept []models.Column
+		for _, col := range columns {
+			switch rules.Action(col.Sensitivity) {
+			case Exclude:
+				changed = true
+				continue
+			case Mask:
+				changed = true
+				col.Description = annotate(col.Description, notes[Mask])
+			case Refuse:
+				changed = true
+				col.Description = annotate(col.Description, notes[Refuse])
+			}
+			kept = append(kept, col)
+		}
+		return kept
+	}
+
+	columns := restrict(table.Columns)
+	partitionKeys := restrict(table.PartitionKeys)
+
+	var relationships []models.Relationship
+	for _, rel := range table.Relationships {
+		if usesExcluded(excluded, table.Name, rel.Columns) || usesExcluded(excluded, rel.ToTable, rel.ToColumns) {
+			changed = true
+			continue
+		}
+		relationships = append(relationships, rel)
+	}
+
+	if !changed {
+		return table
+	}
+	copied := *table
+	copied.Columns = columns
+	copied.PartitionKeys = partitionKeys
+	copied.Relationships = relationships
+	return &copied
+}
+
+// Excluded reports whether any of the columns of table is excluded for the role
+func Excluded(tables []*models.Table, rules Rules, table string, columns []string) bool {
+	for _, t := range tables {
+		if !strings.EqualFold(t.Name, table) {
+			continue
+		}
+		for _, col := range t.AllColumns() {
+			for _, name := range columns {
+				if strings.EqualFold(col.Name, name) && rules.Action(col.Sensitivity) == Exclude {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func usesExcluded(excluded map[string]bool, table string, columns []string) bool {
+	for _, col := range columns {
+		if excluded[columnKey(table, col)] {
+			return true
+		}
+	}
+	return false
+}
+
+func columnKey(table, column string) string {
+	return strings.ToLower(table) + "." + strings.ToLower(column)
+}
+
+func annotate(description, note string) string {
+	return strings.TrimSpace(description + note)
+}
+
+// Enforce checks sql, written in dialect d, against the role's rules. Tables
+// in known are used without a storage lookup and must carry their full,
+// unrestricted columns. Selected masked columns are wrapped in a hash
+// function and the rewritten SQL is returned; SQL using excluded columns,
+// selecting refused columns or selecting masked columns through "*" is
+// rejected with a ViolationError.
+func Enforce(ctx context.Context, validator *validation.Validator, sql string, d dialect.Dialect, known []*models.Table, rules Rules) (string, error) {
+	report, err := validator.Validate(ctx, sql, d, known)
+	if err != nil {
+		return "", err
+	}
+
+	var violations []Violation
+	violate := func(code string, ref validation.ColumnReference, format string) {
+		violations = append(violations, Violation{
+			Code:        code,
+			Message:     fmt.Sprintf(format, ref.Table+"."+ref.Column, ref.Sensitivity),
+			Table:       ref.Table,
+			Column:      ref.Column,
+			Sensitivity: ref.Sensitivity,
+		})
+	}
+
+	for _, ref := range report.Columns {
+		if rules.Action(ref.Sensitivity) == Exclude {
+			violate(CodeExcludedColumn, ref, "column %s is %s data and not available to this role")
+		}
+	}
+
+	var masked []validation.SelectedColumn
+	for _, sel := range report.Selected {
+		switch rules.Action(sel.Sensitivity) {
+		case Refuse:
+			violate(CodeRefusedColumn, sel.ColumnReference, "column %s is %s data and may not be selected by this role")
+		case Mask:
+			if sel.Ref == nil {
+				violate(CodeMaskedStar, sel.ColumnReference, "column %s is %s data and must be masked; list the selected columns instead of using *")
+				continue
+			}
+			masked = append(masked, sel)
+		}
+	}
+	if len(violations) > 0 {
+		return "", &ViolationError{Violations: dedupe(violations)}
+	}
+
+	// Rewrite from the end so that earlier offsets stay valid
+	sort.Slice(masked, func(i, j int) bool { return masked[i].Ref.Pos > masked[j].Ref.Pos })
+	for _, sel := range masked {
+		text := sql[sel.Ref.Pos:sel.Ref.End]
+		replacement := MaskExpression(d, text)
+		if sel.Bare {
+			replacement += " AS " + text[strings.LastIndex(text, ".")+1:]
+		}
+		sql = sql[:sel.Ref.Pos] + replacement + sql[sel.Ref.End:]
+	}
+	return sql, nil
+}
+
+// dedupe drops repeated violations of the same column, e.g. from a column
+// referenced in several select lists
+func dedupe(violations []Violation) []Violation {
+	seen := make(map[string]bool)
+	var unique []Violation
+	for _, v := range violations {
+		key := v.Code + "\x00" + columnKey(v.Table, v.Column)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, v)
+	}
+	return unique
+}
+
+// MaskExpression wraps expr in the dialect's SHA-256 hash function, so masked
+// values can still be counted, grouped and joined but not read
+func MaskExpression(d dialect.Dialect, expr string) string {
+	switch d {
+	case dialect.MySQL:
+		return fmt.Sprintf("SHA2(%s, 256)", expr)
+	case dialect.Postgres:
+		return fmt.Sprintf("encode(sha256(convert_to(CAST(%s AS TEXT), 'UTF8')), 'hex')", expr)
+	case dialect.Presto:
+		return fmt.Sprintf("to_hex(sha256(to_utf8(CAST(%s AS VARCHAR))))", expr)
+	case dialect.ClickHouse:
+		return fmt.Sprintf("hex(SHA256(toString(%s)))", expr)
+	default:
+		return fmt.Sprintf("sha2(CAST(%s AS STRING), 256)", expr)
+	}
+}
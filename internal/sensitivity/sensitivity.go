@@ -0,0 +1,129 @@
+// Package sensitivity decides how columns tagged as sensitive are treated in
+// generated SQL. Depending on the caller's role a tag is allowed, masked in
+// the result, excluded from the schema the model sees, or refused outright.
+package sensitivity
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"sql_generator/internal/config"
+	"sql_generator/internal/models"
+)
+
+// Action is the treatment of a sensitive column for one role
+type Action string
+
+// Actions
+const (
+	// Allow treats the column like any other
+	Allow Action = "allow"
+	// Mask replaces selected values with a hash of the value
+	Mask Action = "mask"
+	// Exclude hides the column from the prompt and rejects SQL that uses it
+	Exclude Action = "exclude"
+	// Refuse rejects SQL that selects the column; filtering and joining on it is allowed
+	Refuse Action = "refuse"
+)
+
+// ErrUnknownRole is returned for roles without configured rules
+var ErrUnknownRole = errors.New("unknown role")
+
+// Tags returns the supported sensitivity tags
+func Tags() []string {
+	return []string{models.SensitivityPII, models.SensitivityFinancial, models.SensitivitySecret}
+}
+
+// ValidTag reports whether tag is empty or a supported sensitivity tag
+func ValidTag(tag string) bool {
+	if tag == "" {
+		return true
+	}
+	for _, t := range Tags() {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Rules maps sensitivity tags to the action applied for one role
+type Rules map[string]Action
+
+// Action returns the action for a column tag. Untagged columns are allowed;
+// tags the role has no rule for are excluded.
+func (r Rules) Action(tag string) Action {
+	if tag == "" {
+		return Allow
+	}
+	if action, ok := r[tag]; ok {
+		return action
+	}
+	return Exclude
+}
+
+// Policy holds the rules of every role
+type Policy struct {
+	defaultRole     string
+	trustRoleHeader bool
+	roles           map[string]Rules
+}
+
+// New creates a policy from its configuration
+func New(cfg config.SensitivityConfig) (*Policy, error) {
+	p := &Policy{defaultRole: cfg.DefaultRole, trustRoleHeader: cfg.TrustRoleHeader, roles: make(map[string]Rules)}
+	for role, actions := range cfg.Rules {
+		rules := make(Rules)
+		for tag, name := range actions {
+			if tag == "" || !ValidTag(tag) {
+				return nil, fmt.Errorf("unknown sensitivity tag %q for role %s, expected one of %s", tag, role, strings.Join(Tags(), ", "))
+			}
+			action := Action(strings.ToLower(name))
+			switch action {
+			case Allow, Mask, Exclude, Refuse:
+			default:
+				return nil, fmt.Errorf("unknown action %q for %s columns of role %s, expected allow, mask, exclude or refuse", name, tag, role)
+			}
+			rules[tag] = action
+		}
+		p.roles[role] = rules
+	}
+	if p.defaultRole != "" && p.roles[p.defaultRole] == nil {
+		return nil, fmt.Errorf("default role %q has no sensitivity rules", p.defaultRole)
+	}
+	return p, nil
+}
+
+// RequestRole returns the role of a request whose X-User-Role header is
+// header. The header is sent by the client, so it is ignored unless the
+// policy is configured to trust it, and the request gets the default role.
+func (p *Policy) RequestRole(header string) string {
+	if !p.trustRoleHeader {
+		return ""
+	}
+	return strings.TrimSpace(header)
+}
+
+// Rules returns the rules of role, or of the default role when role is empty
+func (p *Policy) Rules(role string) (Rules, error) {
+	if role == "" {
+		role = p.defaultRole
+	}
+	rules, ok := p.roles[role]
+	if !ok {
+		return nil, fmt.Errorf("%w %q, expected one of %s", ErrUnknownRole, role, strings.Join(p.Roles(), ", "))
+	}
+	return rules, nil
+}
+
+// Roles returns the configured role names in sorted order
+func (p *Policy) Roles() []string {
+	roles := make([]string, 0, len(p.roles))
+	for role := range p.roles {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
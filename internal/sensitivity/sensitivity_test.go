@@ -0,0 +1,202 @@
+package sensitivity
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"sql_generator/internal/config"
+	"sql_generator/internal/dialect"
+	"sql_generator/internal/language"
+	"sql_generator/internal/models"
+	"sql_generator/internal/validation"
+)
+
+func testTables() []*models.Table {
+	return []*models.Table{
+		{
+			Name: "users",
+			Columns: []models.Column{
+				{Name: "id", Type: "BIGINT", IsPrimary: true},
+				{Name: "name", Type: "VARCHAR(100)"},
+				{Name: "email", Type: "VARCHAR(200)", Description: "邮箱", Sensitivity: models.SensitivityPII},
+				{Name: "password_hash", Type: "VARCHAR(64)", Sensitivity: models.SensitivitySecret},
+			},
+		},
+		{
+			Name: "accounts",
+			Columns: []models.Column{
+				{Name: "id", Type: "BIGINT", IsPrimary: true},
+				{Name: "user_id", Type: "BIGINT"},
+				{Name: "balance", Type: "DECIMAL(12,2)", Sensitivity: models.SensitivityFinancial},
+			},
+			Relationships: []models.Relationship{
+				{Columns: []string{"user_id"}, ToTable: "users", ToColumns: []string{"id"}},
+			},
+		},
+	}
+}
+
+var analyst = Rules{
+	models.SensitivityPII:       Mask,
+	models.SensitivityFinancial: Refuse,
+	models.SensitivitySecret:    Exclude,
+}
+
+func TestNewPolicy(t *testing.T) {
+	policy, err := New(config.SensitivityConfig{
+		DefaultRole: "analyst",
+		Rules: map[string]map[string]string{
+			"analyst": {"pii": "mask", "financial": "refuse"},
+			"admin":   {"pii": "allow", "financial": "allow", "secret": "allow"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	rules, err := policy.Rules("")
+	if err != nil || rules.Action("pii") != Mask || rules.Action("secret") != Exclude || rules.Action("") != Allow {
+		t.Errorf("Unexpected default role rules %v: %v", rules, err)
+	}
+	if rules, _ := policy.Rules("admin"); rules.Action("secret") != Allow {
+		t.Errorf("Unexpected admin rules %v", rules)
+	}
+	if _, err := policy.Rules("guest"); !errors.Is(err, ErrUnknownRole) {
+		t.Errorf("Expected ErrUnknownRole, got %v", err)
+	}
+
+	// The role header is only used when it is trusted
+	if role := policy.RequestRole("admin"); role != "" {
+		t.Errorf("Expected the untrusted role header to be ignored, got %q", role)
+	}
+	trusted, err := New(config.SensitivityConfig{
+		DefaultRole:     "analyst",
+		TrustRoleHeader: true,
+		Rules:           map[string]map[string]string{"analyst": {"pii": "mask"}},
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if role := trusted.RequestRole(" admin "); role != "admin" {
+		t.Errorf("Expected the trusted role header, got %q", role)
+	}
+
+	invalid := []config.SensitivityConfig{
+		{Rules: map[string]map[string]string{"analyst": {"pii": "hide"}}},
+		{Rules: map[string]map[string]string{"analyst": {"health": "mask"}}},
+		{DefaultRole: "guest", Rules: map[string]map[string]string{"analyst": {"pii": "mask"}}},
+	}
+	for _, cfg := range invalid {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("Expected an error for %+v", cfg)
+		}
+	}
+}
+
+func TestRestrict(t *testing.T) {
+	tables := testTables()
+	restricted := Restrict(tables, analyst, language.Chinese)
+
+	users := restricted[0]
+	if users == tables[0] {
+		t.Fatal("Expected a copy of the users table")
+	}
+	var names []string
+	for _, col := range users.Columns {
+		names = append(names, col.Name)
+	}
+	if strings.Join(names, ",") != "id,name,email" {
+		t.Errorf("Unexpected visible columns %v", names)
+	}
+	if !strings.Contains(users.Columns[2].Description, "脱敏") {
+		t.Errorf("Masked column is not annotated: %q", users.Columns[2].Description)
+	}
+	if english := Restrict(tables, analyst, language.English); english[0].Columns[2].Description != "邮箱 (sensitive column, its values are masked in query results; do not use SELECT * on this table)" {
+		t.Errorf("Unexpected English annotation: %q", english[0].Columns[2].Description)
+	}
+	if len(tables[0].Columns) != 4 || tables[0].Columns[2].Description != "邮箱" {
+		t.Error("Restrict modified the original table")
+	}
+
+	if unrestricted := Restrict(tables, Rules{"pii": Allow, "financial": Allow, "secret": Allow}, language.Chinese); unrestricted[0] != tables[0] {
+		t.Error("Expected tables without restricted columns to be returned as is")
+	}
+
+	// Relationships over excluded columns are dropped; tags without a rule are excluded
+	tables[0].Columns[0].Sensitivity = models.SensitivitySecret
+	if restricted := Restrict(tables, Rules{"pii": Allow, "financial": Allow}, language.Chinese); len(restricted[1].Relationships) != 0 {
+		t.Errorf("Expected the relationship to users.id to be dropped, got %+v", restricted[1].Relationships)
+	}
+}
+
+func TestEnforceMasksSelectedColumns(t *testing.T) {
+	tests := []struct {
+		dialect dialect.Dialect
+		sql     string
+		want    string
+	}{
+		{
+			dialect.MySQL,
+			"SELECT u.name, u.email FROM users u WHERE u.email LIKE '%@example.com'",
+			"SELECT u.name, SHA2(u.email, 256) AS email FROM users u WHERE u.email LIKE '%@example.com'",
+		},
+		{
+			dialect.Hive,
+			"SELECT count(DISTINCT email) AS emails, `email` AS e FROM users",
+			"SELECT count(DISTINCT sha2(CAST(email AS STRING), 256)) AS emails, sha2(CAST(`email` AS STRING), 256) AS e FROM users",
+		},
+		{
+			dialect.Postgres,
+			`SELECT t.email FROM (SELECT email FROM users) t`,
+			`SELECT t.email FROM (SELECT encode(sha256(convert_to(CAST(email AS TEXT), 'UTF8')), 'hex') AS email FROM users) t`,
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := Enforce(context.Background(), validation.NewValidator(nil), tt.sql, tt.dialect, testTables(), analyst)
+		if err != nil {
+			t.Errorf("Enforce(%q) failed: %v", tt.sql, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Enforce(%q)\n got %q\nwant %q", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestEnforceRejectsViolations(t *testing.T) {
+	tests := []struct {
+		sql  string
+		code string
+	}{
+		{"SELECT name FROM users WHERE password_hash = 'x'", CodeExcludedColumn},
+		{"SELECT u.name, a.balance FROM users u JOIN accounts a ON a.user_id = u.id", CodeRefusedColumn},
+		{"SELECT sum(balance) FROM accounts", CodeRefusedColumn},
+		{"SELECT id, name, email FROM (SELECT * FROM users) t", CodeMaskedStar},
+	}
+
+	for _, tt := range tests {
+		_, err := Enforce(context.Background(), validation.NewValidator(nil), tt.sql, dialect.MySQL, testTables(), analyst)
+		var violation *ViolationError
+		if !errors.As(err, &violation) {
+			t.Errorf("Enforce(%q): expected a ViolationError, got %v", tt.sql, err)
+			continue
+		}
+		found := false
+		for _, v := range violation.Violations {
+			found = found || v.Code == tt.code
+		}
+		if !found {
+			t.Errorf("Enforce(%q): expected %s, got %+v", tt.sql, tt.code, violation.Violations)
+		}
+	}
+
+	// Filtering and joining on refused columns is allowed
+	sql := "SELECT u.name FROM users u JOIN accounts a ON a.user_id = u.id WHERE a.balance > 1000"
+	got, err := Enforce(context.Background(), validation.NewValidator(nil), sql, dialect.MySQL, testTables(), analyst)
+	if err != nil || got != sql {
+		t.Errorf("Expected %q to pass unchanged, got %q: %v", sql, got, err)
+	}
+}
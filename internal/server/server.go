@@ -1,20 +1,60 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"sql_generator/internal/config"
+	"sql_generator/internal/datasource"
+	"sql_generator/internal/dialect"
 	"sql_generator/internal/handlers"
 	"sql_generator/internal/llm"
+	"sql_generator/internal/policy"
+	"sql_generator/internal/prompt"
 	"sql_generator/internal/rag"
+	"sql_generator/internal/schemagraph"
+	"sql_generator/internal/sensitivity"
 	"sql_generator/internal/storage"
+	"sql_generator/internal/validation"
 )
 
+// Server is the HTTP server together with the resources it releases on Close
+type Server struct {
+	*http.Server
+	// cancel cancels the contexts of requests still running
+	cancel context.CancelFunc
+	// vectorStore is flushed once no request can change it anymore
+	vectorStore rag.VectorStore
+	// datasources are closed once no request can execute queries anymore
+	datasources *datasource.Registry
+}
+
+// Close is called after Shutdown has returned, whether the requests drained
+// or the shutdown timed out. It closes the remaining connections, cancels
+// the requests that are still running, flushes pending vector index
+// changes and closes the sandbox datasources.
+func (s *Server) Close() error {
+	err := s.Server.Close()
+	s.cancel()
+
+	if flusher, ok := s.vectorStore.(rag.Flusher); ok {
+		if flushErr := flusher.Flush(); flushErr != nil && err == nil {
+			err = fmt.Errorf("failed to flush vector store: %w", flushErr)
+		}
+	}
+
+	if closeErr := s.datasources.Close(); closeErr != nil && err == nil {
+		err = fmt.Errorf("failed to close sandbox datasources: %w", closeErr)
+	}
+	return err
+}
+
 // New creates a new HTTP server with configured routes
-func New(cfg *config.Config) (*http.Server, error) {
+func New(cfg *config.Config) (*Server, error) {
 	// Set Gin to release mode
 	gin.SetMode(gin.ReleaseMode)
 
@@ -39,20 +79,34 @@ func New(cfg *config.Config) (*http.Server, error) {
 	}
 
 	// Create vector store
-	vectorStore, err := rag.NewPineconeVectorStore(cfg.VectorDB.APIKey, cfg.VectorDB.IndexName, mysqlStore)
+	fmt.Printf("Creating vector store with provider: %s\n", cfg.VectorDB.Provider)
+	vectorStore, err := rag.NewVectorStore(context.Background(), cfg.VectorDB, cfg.Embedding, mysqlStore)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create vector store: %w", err)
 	}
 
-	// Create RAG enhanced storage
-	store := storage.NewRAGEnhancedStore(mysqlStore, embeddingSvc, vectorStore)
+	// Index the descriptions of saved queries to find similar past questions
+	queryIndex, err := rag.NewQueryIndex(context.Background(), mysqlStore, rag.EmbeddingModelName(cfg.Embedding))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create query index: %w", err)
+	}
 
-	// Load existing tables from MySQL and index them for RAG
-	err = loadAndIndexTables(mysqlStore, embeddingSvc, vectorStore)
+	// Create RAG enhanced storage whose table changes also update the schema graph
+	graph := schemagraph.New()
+	store := schemagraph.NewTrackingStore(storage.NewRAGEnhancedStore(mysqlStore, embeddingSvc, vectorStore, queryIndex), graph)
+
+	// Load existing tables from MySQL, index them for RAG and build the schema graph
+	err = loadAndIndexTables(context.Background(), mysqlStore, embeddingSvc, vectorStore, graph)
 	if err != nil {
 		fmt.Printf("Warning: failed to load and index tables: %v\n", err)
 	}
 
+	// Index queries saved before query indexing or with another embedding model
+	err = indexQueries(context.Background(), mysqlStore, embeddingSvc, queryIndex)
+	if err != nil {
+		fmt.Printf("Warning: failed to index queries: %v\n", err)
+	}
+
 	// Create LLM client with RAG enhancement
 	var baseLLMClient llm.Client
 	// 根据配置的模型名称来选择合适的LLM客户端
@@ -65,57 +119,166 @@ func New(cfg *config.Config) (*http.Server, error) {
 		baseLLMClient = llm.NewOpenAIClient(cfg.LLM)
 	}
 
-	llmClient := llm.NewRAGEnhancedClient(cfg.LLM, baseLLMClient, embeddingSvc, vectorStore)
+	sensitivityPolicy, err := sensitivity.New(cfg.Sensitivity)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SENSITIVITY_RULES: %w", err)
+	}
+
+	// Validate generated SQL against the stored table schemas and let the model
+	// repair it; the RAG client retrieves tables once and reuses them for every round.
+	// The sensitivity client hides, masks or refuses sensitive columns per caller role.
+	// The example client adds the correct past queries most similar to the question as few-shot examples.
+	validator := validation.NewValidator(store)
+	validatingClient := llm.NewValidatingClient(baseLLMClient, validator, cfg.LLM.MaxRepairAttempts)
+	sensitivityClient := llm.NewSensitivityClient(validatingClient, sensitivityPolicy, validator)
+	ragClient := llm.NewRAGEnhancedClient(cfg.LLM, sensitivityClient, embeddingSvc, vectorStore, graph)
+	memory := rag.NewQueryMemory(mysqlStore, embeddingSvc, queryIndex)
+	llmClient := llm.NewExampleClient(ragClient, rag.NewExampleSelector(memory), cfg.LLM.FewShotExamples)
+
+	defaultDialect, err := dialect.Parse(cfg.LLM.Dialect)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LLM_SQL_DIALECT: %w", err)
+	}
+
+	sqlPolicy, err := policy.New(cfg.Policy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SQL_POLICY_ALLOWED_STATEMENTS: %w", err)
+	}
+
+	// Connect to the sandbox datasources generated queries are executed against
+	// The metadata database is not one of them: it holds the service's own tables
+	// rather than the business data queries are generated for
+	datasources, err := datasource.OpenRegistry(cfg.Sandbox)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sandbox datasources: %w", err)
+	}
+
+	// Prompt templates come from the prompt_templates table, PROMPT_TEMPLATE_DIR and the built-in default
+	templates, err := prompt.NewTemplates(mysqlStore, cfg.Prompt.TemplateDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load prompt templates: %w", err)
+	}
 
 	// Create handlers
-	handler := handlers.NewHandler(store, llmClient)
+	handler := handlers.NewHandler(store, llmClient, defaultDialect, graph, datasources, sqlPolicy, sensitivityPolicy, templates, prompt.NewBuilder(cfg.LLM), memory)
 
 	// Register routes
 	handler.RegisterRoutes(router)
 
+	// Request contexts derive from baseCtx so that Close cancels the LLM calls
+	// of requests that did not finish during a graceful shutdown
+	baseCtx, cancel := context.WithCancel(context.Background())
+
 	// Create HTTP server
 	srv := &http.Server{
 		Addr:         ":" + cfg.Server.Port,
 		Handler:      router,
 		ReadTimeout:  time.Duration(cfg.Server.ReadTimeout) * time.Second,
 		WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
+		BaseContext: func(net.Listener) context.Context {
+			return baseCtx
+		},
 	}
 
-	return srv, nil
+	return &Server{Server: srv, cancel: cancel, vectorStore: vectorStore, datasources: datasources}, nil
 }
 
-// loadAndIndexTables loads existing tables from storage and indexes them for RAG
-func loadAndIndexTables(store storage.Store, embeddingSvc rag.EmbeddingService, vectorStore rag.VectorStore) error {
+// loadAndIndexTables loads existing tables from storage, indexes them for RAG
+// and adds them to the schema graph
+func loadAndIndexTables(ctx context.Context, store storage.Store, embeddingSvc rag.EmbeddingService, vectorStore rag.VectorStore, graph *schemagraph.Graph) error {
 	fmt.Println("Loading existing tables from MySQL...")
 
-	// Get all tables
-	tables, err := store.ListTables(1000, 0) // Load up to 1000 tables
-	if err != nil {
-		return fmt.Errorf("failed to list tables: %w", err)
+	checker, _ := vectorStore.(rag.IndexedChecker)
+
+	const pageSize = 100
+	loaded, skipped := 0, 0
+	for offset := 0; ; offset += pageSize {
+		tables, err := store.ListTables(ctx, pageSize, offset)
+		if err != nil {
+			return fmt.Errorf("failed to list tables: %w", err)
+		}
+		loaded += len(tables)
+
+		// Index each table for RAG
+		for _, table := range tables {
+			graph.AddTable(table)
+
+			// Skip tables whose persisted vector is still up to date
+			if checker != nil && checker.IsIndexed(table) {
+				skipped++
+				continue
+			}
+
+			fmt.Printf("Indexing table: %s (%s)\n", table.Name, table.Description)
+
+			// Generate embedding for the table
+			vector, err := embeddingSvc.GenerateTableEmbedding(ctx, table)
+			if err != nil {
+				fmt.Printf("Warning: failed to generate embedding for table %s: %v\n", table.Name, err)
+				continue
+			}
+
+			// Index the table structure in vector store
+			err = vectorStore.IndexTableStructure(ctx, table, vector)
+			if err != nil {
+				fmt.Printf("Warning: failed to index table %s: %v\n", table.Name, err)
+				continue
+			}
+
+			fmt.Printf("Successfully indexed table: %s\n", table.Name)
+		}
+
+		if len(tables) < pageSize {
+			break
+		}
 	}
 
-	fmt.Printf("Loaded %d tables from MySQL\n", len(tables))
+	fmt.Printf("Loaded %d tables from MySQL, %d already indexed\n", loaded, skipped)
 
-	// Index each table for RAG
-	for _, table := range tables {
-		fmt.Printf("Indexing table: %s (%s)\n", table.Name, table.Description)
+	// Persist the index built above for stores that save lazily
+	if flusher, ok := vectorStore.(rag.Flusher); ok {
+		if err := flusher.Flush(); err != nil {
+			return fmt.Errorf("failed to flush vector store: %w", err)
+		}
+	}
 
-		// Generate embedding for the table
-		vector, err := embeddingSvc.GenerateTableEmbedding(table)
+	return nil
+}
+
+// indexQueries embeds the descriptions of the stored queries missing from
+// the query index
+func indexQueries(ctx context.Context, store storage.Store, embeddingSvc rag.EmbeddingService, index *rag.QueryIndex) error {
+	const pageSize = 100
+	indexed := 0
+	for offset := 0; ; offset += pageSize {
+		queries, err := store.ListQueries(ctx, pageSize, offset)
 		if err != nil {
-			fmt.Printf("Warning: failed to generate embedding for table %s: %v\n", table.Name, err)
-			continue
+			return fmt.Errorf("failed to list queries: %w", err)
 		}
 
-		// Index the table structure in vector store
-		err = vectorStore.IndexTableStructure(table, vector)
-		if err != nil {
-			fmt.Printf("Warning: failed to index table %s: %v\n", table.Name, err)
-			continue
+		for _, query := range queries {
+			if index.IsIndexed(query.ID) {
+				continue
+			}
+
+			vector, err := embeddingSvc.GenerateEmbedding(ctx, query.Description)
+			if err != nil {
+				fmt.Printf("Warning: failed to generate embedding for query %s: %v\n", query.ID, err)
+				continue
+			}
+
+			if err := index.IndexQuery(ctx, query, vector); err != nil {
+				fmt.Printf("Warning: failed to index query %s: %v\n", query.ID, err)
+				continue
+			}
+			indexed++
 		}
 
-		fmt.Printf("Successfully indexed table: %s\n", table.Name)
+		if len(queries) < pageSize {
+			break
+		}
 	}
 
+	fmt.Printf("Indexed %d queries, %d in the query index\n", indexed, index.Len())
 	return nil
 }
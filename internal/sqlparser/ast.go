@@ -0,0 +1,305 @@
+package sqlparser
+
+import "strings"
+
+// Statement is a parsed SQL statement
+type Statement interface {
+	statement()
+}
+
+// QueryExpr is a query body: *Select, *SetOperation or *ParenQuery
+type QueryExpr interface {
+	queryExpr()
+}
+
+// TableExpr is an item of a FROM clause
+type TableExpr interface {
+	tableExpr()
+}
+
+// Expr is a scalar expression
+type Expr interface {
+	expr()
+}
+
+// SelectStatement is a complete query with optional CTEs and trailing clauses
+type SelectStatement struct {
+	With    []*CTE
+	Body    QueryExpr
+	OrderBy []*OrderItem
+	Limit   Expr
+	Offset  Expr
+	// Hive/Spark DISTRIBUTE BY, SORT BY and CLUSTER BY clauses
+	DistributeBy []Expr
+	SortBy       []*OrderItem
+	ClusterBy    []Expr
+}
+
+// OtherStatement is a statement that is not parsed beyond its leading keyword
+type OtherStatement struct {
+	// Verb is the upper-cased leading keyword, e.g. INSERT or DROP
+	Verb string
+	Text string
+}
+
+// CTE is a common table expression of a WITH clause
+type CTE struct {
+	Name      string
+	Columns   []string
+	Query     *SelectStatement
+	Recursive bool
+}
+
+// Select is a single SELECT block
+type Select struct {
+	Distinct bool
+	Columns  []*SelectItem
+	From     []TableExpr
+	Where    Expr
+	GroupBy  []Expr
+	Having   Expr
+	Qualify  Expr
+}
+
+// SelectItem is an output column. Star is set for "*" and "t.*".
+type SelectItem struct {
+	Expr  Expr
+	Alias string
+	Star  bool
+	// StarTable qualifies a "t.*" item
+	StarTable string
+}
+
+// SetOperation combines two queries with UNION, INTERSECT or EXCEPT
+type SetOperation struct {
+	Op    string
+	All   bool
+	Left  QueryExpr
+	Right QueryExpr
+}
+
+// ParenQuery is a parenthesised query used as a set operand
+type ParenQuery struct {
+	Query *SelectStatement
+}
+
+// OrderItem is an ORDER BY or SORT BY item
+type OrderItem struct {
+	Expr Expr
+	Desc bool
+}
+
+// TableName references a stored table, optionally qualified by schema
+type TableName struct {
+	Schema string
+	Name   string
+	Alias  string
+}
+
+// DerivedTable is a subquery in a FROM clause
+type DerivedTable struct {
+	Query   *SelectStatement
+	Alias   string
+	Columns []string
+}
+
+// TableFunction is a table-valued function in a FROM clause, e.g. UNNEST(x)
+type TableFunction struct {
+	Func    *FuncCall
+	Alias   string
+	Columns []string
+}
+
+// Join combines two table expressions
+type Join struct {
+	// Type is INNER, LEFT, RIGHT, FULL, CROSS, LEFT SEMI or LEFT ANTI
+	Type    string
+	Natural bool
+	Left    TableExpr
+	Right   TableExpr
+	On      Expr
+	Using   []string
+}
+
+// LateralView is a Hive/Spark "LATERAL VIEW [OUTER] udtf(...) alias AS col, ..."
+// that joins the rows generated by Func to Source
+type LateralView struct {
+	Source  TableExpr
+	Outer   bool
+	Func    *FuncCall
+	Alias   string
+	Columns []string
+}
+
+// ParenTableExpr is a parenthesised join tree
+type ParenTableExpr struct {
+	Exprs []TableExpr
+}
+
+// ColumnRef is a dotted identifier path in expression context. Depending on
+// the tables in scope it is column, table.column, schema.table.column or a
+// struct field access such as t.address.city, so resolution is left to the
+// caller.
+type ColumnRef struct {
+	Parts []string
+	Pos   int
+	// End is the byte offset just past the last part
+	End int
+}
+
+// Name returns the dotted path as written
+func (c *ColumnRef) Name() string {
+	return strings.Join(c.Parts, ".")
+}
+
+// LiteralKind classifies literals
+type LiteralKind int
+
+// Literal kinds
+const (
+	NumberLiteral LiteralKind = iota
+	StringLiteral
+	NullLiteral
+	BoolLiteral
+	// KeywordLiteral is a bare keyword argument such as a time unit in
+	// TIMESTAMPDIFF(DAY, a, b)
+	KeywordLiteral
+	// TypedLiteral is DATE '...', TIMESTAMP '...' or INTERVAL ...
+	TypedLiteral
+	ParamLiteral
+)
+
+// Literal is a constant value
+type Literal struct {
+	Kind  LiteralKind
+	Value string
+	// Type is set for typed literals, e.g. DATE
+	Type string
+}
+
+// BinaryExpr applies an infix operator. Op is upper-cased for keyword
+// operators (AND, OR, LIKE, ...), with NOT folded in (e.g. "NOT LIKE").
+type BinaryExpr struct {
+	Op    string
+	Left  Expr
+	Right Expr
+}
+
+// UnaryExpr applies a prefix operator such as NOT or -
+type UnaryExpr struct {
+	Op   string
+	Expr Expr
+}
+
+// FuncCall is a function or aggregate call
+type FuncCall struct {
+	Name     string
+	Args     []Expr
+	Distinct bool
+	Star     bool
+	// NoParens marks niladic keywords such as CURRENT_DATE
+	NoParens bool
+	OrderBy  []*OrderItem
+	Filter   Expr
+	Over     *WindowSpec
+}
+
+// WindowSpec is an OVER clause
+type WindowSpec struct {
+	Name        string
+	PartitionBy []Expr
+	OrderBy     []*OrderItem
+}
+
+// CaseExpr is a CASE expression
+type CaseExpr struct {
+	Operand Expr
+	Whens   []*When
+	Else    Expr
+}
+
+// When is a WHEN ... THEN ... branch
+type When struct {
+	Cond   Expr
+	Result Expr
+}
+
+// CastExpr converts an expression to a type
+type CastExpr struct {
+	Expr Expr
+	Type string
+}
+
+// InExpr is "x [NOT] IN (list)" or "x [NOT] IN (subquery)"
+type InExpr struct {
+	Expr     Expr
+	Not      bool
+	List     []Expr
+	Subquery *SelectStatement
+}
+
+// BetweenExpr is "x [NOT] BETWEEN low AND high"
+type BetweenExpr struct {
+	Expr Expr
+	Not  bool
+	Low  Expr
+	High Expr
+}
+
+// IsExpr is "x IS [NOT] NULL|TRUE|FALSE|UNKNOWN"
+type IsExpr struct {
+	Expr  Expr
+	Not   bool
+	Value string
+}
+
+// ExistsExpr is "EXISTS (subquery)"
+type ExistsExpr struct {
+	Subquery *SelectStatement
+}
+
+// SubqueryExpr is a scalar subquery, or the operand of ANY/ALL/SOME
+type SubqueryExpr struct {
+	Quantifier string
+	Query      *SelectStatement
+}
+
+// TupleExpr is a parenthesised expression list
+type TupleExpr struct {
+	Exprs []Expr
+}
+
+// IndexExpr is an array or map subscript, e.g. tags[0]
+type IndexExpr struct {
+	Expr  Expr
+	Index Expr
+}
+
+func (*SelectStatement) statement() {}
+func (*OtherStatement) statement()  {}
+
+func (*Select) queryExpr()       {}
+func (*SetOperation) queryExpr() {}
+func (*ParenQuery) queryExpr()   {}
+
+func (*TableName) tableExpr()      {}
+func (*DerivedTable) tableExpr()   {}
+func (*TableFunction) tableExpr()  {}
+func (*Join) tableExpr()           {}
+func (*LateralView) tableExpr()    {}
+func (*ParenTableExpr) tableExpr() {}
+
+func (*ColumnRef) expr()    {}
+func (*Literal) expr()      {}
+func (*BinaryExpr) expr()   {}
+func (*UnaryExpr) expr()    {}
+func (*FuncCall) expr()     {}
+func (*CaseExpr) expr()     {}
+func (*CastExpr) expr()     {}
+func (*InExpr) expr()       {}
+func (*BetweenExpr) expr()  {}
+func (*IsExpr) expr()       {}
+func (*ExistsExpr) expr()   {}
+func (*SubqueryExpr) expr() {}
+func (*TupleExpr) expr()    {}
+func (*IndexExpr) expr()    {}
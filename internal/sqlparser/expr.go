@@ -0,0 +1,698 @@
+package sqlparser
+
+import (
+	"strings"
+)
+
+// comparisonOps are the infix comparison operators
+var comparisonOps = map[string]bool{
+	"=": true, "==": true, "<>": true, "!=": true, "<": true, ">": true,
+	"<=": true, ">=": true, "<=>": true,
+}
+
+func (p *parser) parseExpr() (Expr, error) {
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseXor()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		right, err := p.parseXor()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: "OR", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseXor() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("XOR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: "XOR", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") || p.acceptOp("&&") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: "AND", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (Expr, error) {
+	if p.acceptKeyword("NOT") {
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryExpr{Op: "NOT", Expr: expr}, nil
+	}
+	return p.parsePredicate()
+}
+
+// parsePredicate parses comparisons, IS, IN, BETWEEN and LIKE-style operators
+func (p *parser) parsePredicate() (Expr, error) {
+	left, err := p.parseBitOr()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := p.peek()
+		switch {
+		case tok.Kind == Operator && comparisonOps[tok.Value]:
+			p.next()
+			op := tok.Value
+			if p.isKeyword("ANY", "ALL", "SOME") && p.peekN(1).Kind == Operator && p.peekN(1).Value == "(" {
+				quantifier := p.next().Upper
+				p.next()
+				q, err := p.parseQuery()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expectOp(")"); err != nil {
+					return nil, err
+				}
+				left = &BinaryExpr{Op: op, Left: left, Right: &SubqueryExpr{Quantifier: quantifier, Query: q}}
+				continue
+			}
+			right, err := p.parseBitOr()
+			if err != nil {
+				return nil, err
+			}
+			left = &BinaryExpr{Op: op, Left: left, Right: right}
+
+		case p.acceptKeyword("IS"):
+			not := p.acceptKeyword("NOT")
+			if p.acceptKeyword("DISTINCT") {
+				if err := p.expectKeyword("FROM"); err != nil {
+					return nil, err
+				}
+				right, err := p.parseBitOr()
+				if err != nil {
+					return nil, err
+				}
+				op := "IS DISTINCT FROM"
+				if not {
+					op = "IS NOT DISTINCT FROM"
+				}
+				left = &BinaryExpr{Op: op, Left: left, Right: right}
+				continue
+			}
+			if !p.isKeyword("NULL", "TRUE", "FALSE", "UNKNOWN") {
+				return nil, p.errorf("expected NULL, TRUE, FALSE or UNKNOWN after IS, found %s", describe(p.peek()))
+			}
+			left = &IsExpr{Expr: left, Not: not, Value: p.next().Upper}
+
+		case p.isKeyword("NOT", "IN", "BETWEEN", "LIKE", "ILIKE", "RLIKE", "REGEXP", "SIMILAR"):
+			not := false
+			if p.isKeyword("NOT") {
+				if !tokenIs(p.peekN(1), "IN", "BETWEEN", "LIKE", "ILIKE", "RLIKE", "REGEXP", "SIMILAR") {
+					return left, nil
+				}
+				p.next()
+				not = true
+			}
+			if left, err = p.parsePredicateTail(left, not); err != nil {
+				return nil, err
+			}
+
+		default:
+			return left, nil
+		}
+	}
+}
+
+// parsePredicateTail parses the keyword predicate following left
+func (p *parser) parsePredicateTail(left Expr, not bool) (Expr, error) {
+	op := p.next().Upper
+	switch op {
+	case "IN":
+		in := &InExpr{Expr: left, Not: not}
+		if err := p.expectOp("("); err != nil {
+			return nil, err
+		}
+		var err error
+		if p.isQueryStart() {
+			in.Subquery, err = p.parseQuery()
+		} else {
+			in.List, err = p.parseExprList()
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectOp(")"); err != nil {
+			return nil, err
+		}
+		return in, nil
+
+	case "BETWEEN":
+		low, err := p.parseBitOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("AND"); err != nil {
+			return nil, err
+		}
+		high, err := p.parseBitOr()
+		if err != nil {
+			return nil, err
+		}
+		return &BetweenExpr{Expr: left, Not: not, Low: low, High: high}, nil
+
+	case "SIMILAR":
+		if err := p.expectKeyword("TO"); err != nil {
+			return nil, err
+		}
+		op = "SIMILAR TO"
+	}
+
+	right, err := p.parseBitOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.acceptKeyword("ESCAPE") {
+		if _, err := p.parsePrimary(); err != nil {
+			return nil, err
+		}
+	}
+	if not {
+		op = "NOT " + op
+	}
+	return &BinaryExpr{Op: op, Left: left, Right: right}, nil
+}
+
+// binaryLevel parses a left-associative level of symbolic or keyword operators
+func (p *parser) binaryLevel(next func() (Expr, error), ops ...string) (Expr, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		op := ""
+		for _, candidate := range ops {
+			if (tok.Kind == Operator && tok.Value == candidate) || (tok.Kind == Ident && tok.Upper == candidate) {
+				op = candidate
+				break
+			}
+		}
+		if op == "" {
+			return left, nil
+		}
+		p.next()
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: op, Left: left, Right: right}
+	}
+}
+
+func (p *parser) parseBitOr() (Expr, error) {
+	return p.binaryLevel(p.parseBitAnd, "|")
+}
+
+func (p *parser) parseBitAnd() (Expr, error) {
+	return p.binaryLevel(p.parseShift, "&")
+}
+
+func (p *parser) parseShift() (Expr, error) {
+	return p.binaryLevel(p.parseAdditive, "<<", ">>")
+}
+
+func (p *parser) parseAdditive() (Expr, error) {
+	return p.binaryLevel(p.parseMultiplicative, "+", "-", "||")
+}
+
+func (p *parser) parseMultiplicative() (Expr, error) {
+	return p.binaryLevel(p.parseBitXor, "*", "/", "%", "DIV", "MOD")
+}
+
+func (p *parser) parseBitXor() (Expr, error) {
+	return p.binaryLevel(p.parseUnary, "^")
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	tok := p.peek()
+	if tok.Kind == Operator && (tok.Value == "-" || tok.Value == "+" || tok.Value == "~" || tok.Value == "!") {
+		p.next()
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		// Fold signs into numeric literals so -1 stays a number
+		if lit, ok := expr.(*Literal); ok && lit.Kind == NumberLiteral && (tok.Value == "-" || tok.Value == "+") {
+			if tok.Value == "-" {
+				lit.Value = "-" + lit.Value
+			}
+			return lit, nil
+		}
+		return &UnaryExpr{Op: tok.Value, Expr: expr}, nil
+	}
+	if p.isKeyword("BINARY") && p.peekN(1).Kind != Operator {
+		p.next()
+	}
+	return p.parsePostfix()
+}
+
+func (p *parser) parsePostfix() (Expr, error) {
+	expr, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.acceptOp("::"):
+			typ, err := p.parseType()
+			if err != nil {
+				return nil, err
+			}
+			expr = &CastExpr{Expr: expr, Type: typ}
+		case p.acceptOp("["):
+			index, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectOp("]"); err != nil {
+				return nil, err
+			}
+			expr = &IndexExpr{Expr: expr, Index: index}
+		case p.acceptKeyword("COLLATE"):
+			if _, err := p.parseTypeWord(); err != nil {
+				return nil, err
+			}
+		default:
+			return expr, nil
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	tok := p.peek()
+	switch tok.Kind {
+	case Number:
+		p.next()
+		return &Literal{Kind: NumberLiteral, Value: tok.Value}, nil
+	case String:
+		p.next()
+		return &Literal{Kind: StringLiteral, Value: tok.Value}, nil
+	case Param:
+		p.next()
+		return &Literal{Kind: ParamLiteral, Value: tok.Value}, nil
+	case Operator:
+		return p.parseOperatorPrimary()
+	case Ident, QuotedIdent:
+		if tok.Kind == Ident {
+			if expr, ok, err := p.parseKeywordPrimary(); ok || err != nil {
+				return expr, err
+			}
+		}
+		return p.parseIdentPrimary()
+	}
+	return nil, p.errorf("unexpected %s", describe(tok))
+}
+
+func (p *parser) parseOperatorPrimary() (Expr, error) {
+	tok := p.peek()
+	switch tok.Value {
+	case "(":
+		p.next()
+		if p.isQueryStart() {
+			q, err := p.parseQuery()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectOp(")"); err != nil {
+				return nil, err
+			}
+			return &SubqueryExpr{Query: q}, nil
+		}
+		exprs, err := p.parseExprList()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectOp(")"); err != nil {
+			return nil, err
+		}
+		if len(exprs) == 1 {
+			return exprs[0], nil
+		}
+		return &TupleExpr{Exprs: exprs}, nil
+	case "@":
+		// MySQL user and system variables
+		p.next()
+		p.acceptOp("@")
+		name := p.next()
+		if name.Kind != Ident && name.Kind != QuotedIdent && name.Kind != String {
+			return nil, p.errorf("expected variable name")
+		}
+		return &Literal{Kind: ParamLiteral, Value: "@" + name.Value}, nil
+	case ":":
+		// Named bind parameters, e.g. :start_date
+		p.next()
+		name := p.next()
+		if name.Kind != Ident {
+			return nil, p.errorf("expected parameter name")
+		}
+		return &Literal{Kind: ParamLiteral, Value: ":" + name.Value}, nil
+	}
+	return nil, p.errorf("unexpected %s", describe(tok))
+}
+
+// parseKeywordPrimary handles expressions introduced by a keyword. ok is false
+// when the current identifier is not such a keyword.
+func (p *parser) parseKeywordPrimary() (Expr, bool, error) {
+	tok := p.peek()
+	nextIsParen := p.peekN(1).Kind == Operator && p.peekN(1).Value == "("
+
+	switch tok.Upper {
+	case "NULL":
+		p.next()
+		return &Literal{Kind: NullLiteral, Value: "NULL"}, true, nil
+	case "TRUE", "FALSE":
+		p.next()
+		return &Literal{Kind: BoolLiteral, Value: tok.Upper}, true, nil
+	case "CASE":
+		expr, err := p.parseCase()
+		return expr, true, err
+	case "EXISTS":
+		p.next()
+		if err := p.expectOp("("); err != nil {
+			return nil, true, err
+		}
+		q, err := p.parseQuery()
+		if err != nil {
+			return nil, true, err
+		}
+		if err := p.expectOp(")"); err != nil {
+			return nil, true, err
+		}
+		return &ExistsExpr{Subquery: q}, true, nil
+	case "CAST", "TRY_CAST", "SAFE_CAST":
+		if !nextIsParen {
+			break
+		}
+		p.pos += 2
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, true, err
+		}
+		if err := p.expectKeyword("AS"); err != nil {
+			return nil, true, err
+		}
+		typ, err := p.parseType()
+		if err != nil {
+			return nil, true, err
+		}
+		if err := p.expectOp(")"); err != nil {
+			return nil, true, err
+		}
+		return &CastExpr{Expr: expr, Type: typ}, true, nil
+	case "CONVERT":
+		if !nextIsParen {
+			break
+		}
+		p.pos += 2
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, true, err
+		}
+		var result Expr = expr
+		if p.acceptOp(",") {
+			typ, err := p.parseType()
+			if err != nil {
+				return nil, true, err
+			}
+			result = &CastExpr{Expr: expr, Type: typ}
+		} else if p.acceptKeyword("USING") {
+			if _, err := p.parseTypeWord(); err != nil {
+				return nil, true, err
+			}
+		}
+		if err := p.expectOp(")"); err != nil {
+			return nil, true, err
+		}
+		return result, true, nil
+	case "EXTRACT":
+		if !nextIsParen {
+			break
+		}
+		p.pos += 2
+		unit := p.next()
+		if unit.Kind != Ident && unit.Kind != String {
+			return nil, true, p.errorf("expected unit in EXTRACT")
+		}
+		if err := p.expectKeyword("FROM"); err != nil {
+			return nil, true, err
+		}
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, true, err
+		}
+		if err := p.expectOp(")"); err != nil {
+			return nil, true, err
+		}
+		return &FuncCall{Name: tok.Value, Args: []Expr{&Literal{Kind: KeywordLiteral, Value: strings.ToUpper(unit.Value)}, expr}}, true, nil
+	case "INTERVAL":
+		p.next()
+		value, err := p.parseBitXor()
+		if err != nil {
+			return nil, true, err
+		}
+		lit := &Literal{Kind: TypedLiteral, Type: "INTERVAL"}
+		if v, ok := value.(*Literal); ok {
+			lit.Value = v.Value
+		}
+		if t := p.peek(); t.Kind == Ident && timeUnits[t.Upper] {
+			p.next()
+			lit.Value += " " + t.Upper
+		}
+		return lit, true, nil
+	case "DATE", "TIME", "TIMESTAMP", "DATETIME":
+		if next := p.peekN(1); next.Kind == String {
+			p.pos += 2
+			return &Literal{Kind: TypedLiteral, Type: tok.Upper, Value: next.Value}, true, nil
+		}
+	}
+
+	if niladic[tok.Upper] && !nextIsParen {
+		p.next()
+		return &FuncCall{Name: tok.Value, NoParens: true}, true, nil
+	}
+	if reserved[tok.Upper] && !nextIsParen {
+		return nil, true, p.errorf("unexpected keyword %s", tok.Value)
+	}
+	return nil, false, nil
+}
+
+// parseIdentPrimary parses a column path or a function call
+func (p *parser) parseIdentPrimary() (Expr, error) {
+	first := p.next()
+	last := first
+	parts := []string{first.Value}
+	for p.isOp(".") {
+		next := p.peekN(1)
+		if next.Kind != Ident && next.Kind != QuotedIdent {
+			break
+		}
+		p.pos += 2
+		parts = append(parts, next.Value)
+		last = next
+	}
+
+	if p.isOp("(") && (first.Kind == Ident || len(parts) > 1) {
+		return p.parseFuncCall(strings.Join(parts, "."))
+	}
+	return &ColumnRef{Parts: parts, Pos: first.Pos, End: p.tokenEnd(last)}, nil
+}
+
+// tokenEnd returns the byte offset just past an identifier token
+func (p *parser) tokenEnd(tok Token) int {
+	return tok.End(p.src)
+}
+
+// parseFuncCall parses the argument list and trailing clauses of a call
+func (p *parser) parseFuncCall(name string) (*FuncCall, error) {
+	if err := p.expectOp("("); err != nil {
+		return nil, err
+	}
+	fn := &FuncCall{Name: name}
+	upper := strings.ToUpper(name)
+
+	if p.acceptOp("*") {
+		fn.Star = true
+	} else if !p.isOp(")") {
+		if p.acceptKeyword("DISTINCT") {
+			fn.Distinct = true
+		} else {
+			p.acceptKeyword("ALL")
+		}
+		if err := p.parseFuncArgs(fn, upper); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expectOp(")"); err != nil {
+		return nil, err
+	}
+
+	if p.isKeyword("WITHIN") && tokenIs(p.peekN(1), "GROUP") {
+		p.pos += 2
+		if err := p.expectOp("("); err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("ORDER"); err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		var err error
+		if fn.OrderBy, err = p.parseOrderItems(); err != nil {
+			return nil, err
+		}
+		if err := p.expectOp(")"); err != nil {
+			return nil, err
+		}
+	}
+	if p.isKeyword("FILTER") && p.peekN(1).Kind == Operator && p.peekN(1).Value == "(" {
+		p.pos += 2
+		if err := p.expectKeyword("WHERE"); err != nil {
+			return nil, err
+		}
+		var err error
+		if fn.Filter, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+		if err := p.expectOp(")"); err != nil {
+			return nil, err
+		}
+	}
+	if p.isKeyword("IGNORE", "RESPECT") && tokenIs(p.peekN(1), "NULLS") {
+		p.pos += 2
+	}
+	if p.acceptKeyword("OVER") {
+		var err error
+		if fn.Over, err = p.parseWindowSpec(); err != nil {
+			return nil, err
+		}
+	}
+
+	return fn, nil
+}
+
+// parseFuncArgs parses call arguments, including the keyword-separated forms
+// of SUBSTRING, TRIM, POSITION and GROUP_CONCAT
+func (p *parser) parseFuncArgs(fn *FuncCall, upper string) error {
+	if upper == "TRIM" && p.isKeyword("LEADING", "TRAILING", "BOTH") {
+		fn.Args = append(fn.Args, &Literal{Kind: KeywordLiteral, Value: p.next().Upper})
+		// TRIM(BOTH FROM s)
+		p.acceptKeyword("FROM")
+	}
+
+	for {
+		if p.isOp(")") {
+			return nil
+		}
+
+		var arg Expr
+		var err error
+		switch {
+		case len(fn.Args) == 0 && unitFunctions[upper] && p.peek().Kind == Ident && timeUnits[p.peek().Upper] &&
+			p.peekN(1).Kind == Operator && p.peekN(1).Value == ",":
+			arg = &Literal{Kind: KeywordLiteral, Value: p.next().Upper}
+		case upper == "POSITION":
+			// POSITION(substr IN str): parse below the IN predicate level
+			arg, err = p.parseBitOr()
+		default:
+			arg, err = p.parseExpr()
+		}
+		if err != nil {
+			return err
+		}
+		fn.Args = append(fn.Args, arg)
+
+		switch {
+		case p.acceptOp(","):
+		case p.isKeyword("FROM", "FOR", "IN", "SEPARATOR"):
+			p.next()
+		case p.acceptKeyword("AS"):
+			typ, err := p.parseType()
+			if err != nil {
+				return err
+			}
+			fn.Args = append(fn.Args, &Literal{Kind: KeywordLiteral, Value: typ})
+		case p.isKeyword("ORDER") && tokenIs(p.peekN(1), "BY"):
+			p.pos += 2
+			if fn.OrderBy, err = p.parseOrderItems(); err != nil {
+				return err
+			}
+			p.acceptKeyword("SEPARATOR")
+		case p.acceptKeyword("USING"):
+			if _, err := p.parseTypeWord(); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+func (p *parser) parseCase() (Expr, error) {
+	if err := p.expectKeyword("CASE"); err != nil {
+		return nil, err
+	}
+	c := &CaseExpr{}
+
+	var err error
+	if !p.isKeyword("WHEN") {
+		if c.Operand, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	for p.acceptKeyword("WHEN") {
+		when := &When{}
+		if when.Cond, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("THEN"); err != nil {
+			return nil, err
+		}
+		if when.Result, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+		c.Whens = append(c.Whens, when)
+	}
+	if len(c.Whens) == 0 {
+		return nil, p.errorf("expected WHEN, found %s", describe(p.peek()))
+	}
+	if p.acceptKeyword("ELSE") {
+		if c.Else, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expectKeyword("END"); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,266 @@
+// Package sqlparser implements a lightweight SQL lexer and parser covering the
+// query subset produced by the generator (SELECT/WITH with joins, subqueries,
+// set operations and window functions). Other statements are classified by
+// their leading keyword but not parsed further.
+package sqlparser
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TokenKind identifies the lexical class of a token
+type TokenKind int
+
+// Token kinds
+const (
+	EOF TokenKind = iota
+	Ident
+	QuotedIdent
+	Number
+	String
+	Operator
+	Param
+)
+
+func (k TokenKind) String() string {
+	switch k {
+	case EOF:
+		return "end of input"
+	case Ident:
+		return "identifier"
+	case QuotedIdent:
+		return "quoted identifier"
+	case Number:
+		return "number"
+	case String:
+		return "string"
+	case Operator:
+		return "operator"
+	case Param:
+		return "parameter"
+	}
+	return "unknown"
+}
+
+// Token is a lexical token. For identifiers Upper holds the upper-cased
+// text used for keyword matching; for quoted tokens Value is unescaped.
+type Token struct {
+	Kind  TokenKind
+	Value string
+	Upper string
+	Pos   int
+}
+
+// Options controls dialect-specific lexing
+type Options struct {
+	// DoubleQuotedIdentifiers treats "x" as an identifier (ANSI, Postgres,
+	// Presto) instead of a string literal (MySQL, Hive, Spark)
+	DoubleQuotedIdentifiers bool
+}
+
+// SyntaxError describes a lexing or parsing failure
+type SyntaxError struct {
+	Message string
+	Line    int
+	Column  int
+	Near    string
+}
+
+func (e *SyntaxError) Error() string {
+	if e.Near == "" {
+		return fmt.Sprintf("syntax error at line %d, column %d: %s", e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("syntax error at line %d, column %d near %q: %s", e.Line, e.Column, e.Near, e.Message)
+}
+
+// newSyntaxError builds a SyntaxError for the byte offset pos of src
+func newSyntaxError(src string, pos int, near, format string, args ...interface{}) *SyntaxError {
+	if pos > len(src) {
+		pos = len(src)
+	}
+	line := strings.Count(src[:pos], "\n") + 1
+	column := pos - strings.LastIndex(src[:pos], "\n")
+	return &SyntaxError{Message: fmt.Sprintf(format, args...), Line: line, Column: column, Near: near}
+}
+
+// multiCharOperators are matched longest first
+var multiCharOperators = []string{"<=>", "->>", "<=", ">=", "<>", "!=", "==", "||", "&&", "::", "<<", ">>", "->", ":="}
+
+// Tokenize splits src into tokens, dropping whitespace and comments
+func Tokenize(src string, opts Options) ([]Token, error) {
+	var tokens []Token
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+		case c == '-' && strings.HasPrefix(src[i:], "--"), c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '/' && strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, newSyntaxError(src, i, "", "unterminated comment")
+			}
+			i += end + 4
+		case c == '\'' || (c == '"' && !opts.DoubleQuotedIdentifiers):
+			value, next, err := scanQuoted(src, i, c, true)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, Token{Kind: String, Value: value, Pos: i})
+			i = next
+		case c == '`' || c == '"':
+			value, next, err := scanQuoted(src, i, c, false)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, Token{Kind: QuotedIdent, Value: value, Upper: strings.ToUpper(value), Pos: i})
+			i = next
+		case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+			start := i
+			for i < len(src) && (isDigit(src[i]) || src[i] == '.') {
+				i++
+			}
+			if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+				j := i + 1
+				if j < len(src) && (src[j] == '+' || src[j] == '-') {
+					j++
+				}
+				if j < len(src) && isDigit(src[j]) {
+					i = j
+					for i < len(src) && isDigit(src[i]) {
+						i++
+					}
+				}
+			}
+			// Identifiers may start with digits in MySQL/Hive, e.g. 1st_day
+			if i < len(src) && isIdentByte(src, i) {
+				for i < len(src) && (isIdentByte(src, i) || isDigit(src[i])) {
+					i += runeLen(src, i)
+				}
+				text := src[start:i]
+				tokens = append(tokens, Token{Kind: Ident, Value: text, Upper: strings.ToUpper(text), Pos: start})
+				continue
+			}
+			tokens = append(tokens, Token{Kind: Number, Value: src[start:i], Pos: start})
+		case c == '$' && strings.HasPrefix(src[i:], "${"):
+			end := strings.IndexByte(src[i:], '}')
+			if end < 0 {
+				return nil, newSyntaxError(src, i, "", "unterminated variable reference")
+			}
+			tokens = append(tokens, Token{Kind: Param, Value: src[i : i+end+1], Pos: i})
+			i += end + 1
+		case c == '?':
+			tokens = append(tokens, Token{Kind: Param, Value: "?", Pos: i})
+			i++
+		case isIdentByte(src, i):
+			start := i
+			for i < len(src) && (isIdentByte(src, i) || isDigit(src[i])) {
+				i += runeLen(src, i)
+			}
+			text := src[start:i]
+			tokens = append(tokens, Token{Kind: Ident, Value: text, Upper: strings.ToUpper(text), Pos: start})
+		default:
+			matched := false
+			for _, op := range multiCharOperators {
+				if strings.HasPrefix(src[i:], op) {
+					tokens = append(tokens, Token{Kind: Operator, Value: op, Pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if matched {
+				continue
+			}
+			if strings.IndexByte("=<>+-*/%(),.;:[]&|^~!@{}", c) < 0 {
+				return nil, newSyntaxError(src, i, string(c), "unexpected character")
+			}
+			tokens = append(tokens, Token{Kind: Operator, Value: string(c), Pos: i})
+			i++
+		}
+	}
+
+	tokens = append(tokens, Token{Kind: EOF, Pos: len(src)})
+	return tokens, nil
+}
+
+// End returns the byte offset just past tok in src, the source it was read from
+func (t Token) End(src string) int {
+	switch t.Kind {
+	case EOF:
+		return t.Pos
+	case String, QuotedIdent:
+		if _, next, err := scanQuoted(src, t.Pos, src[t.Pos], t.Kind == String); err == nil {
+			return next
+		}
+	}
+	return t.Pos + len(t.Value)
+}
+
+// scanQuoted reads a quoted literal starting at src[start], honouring doubled
+// quotes and, for strings, backslash escapes
+func scanQuoted(src string, start int, quote byte, backslash bool) (string, int, error) {
+	var value strings.Builder
+	i := start + 1
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case backslash && c == '\\' && i+1 < len(src):
+			value.WriteByte(unescape(src[i+1]))
+			i += 2
+		case c == quote:
+			if i+1 < len(src) && src[i+1] == quote {
+				value.WriteByte(quote)
+				i += 2
+				continue
+			}
+			return value.String(), i + 1, nil
+		default:
+			value.WriteByte(c)
+			i++
+		}
+	}
+	return "", 0, newSyntaxError(src, start, "", "unterminated quoted literal")
+}
+
+func unescape(c byte) byte {
+	switch c {
+	case 'n':
+		return '\n'
+	case 't':
+		return '\t'
+	case 'r':
+		return '\r'
+	case '0':
+		return 0
+	}
+	return c
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isIdentByte reports whether the rune starting at src[i] may appear in an
+// unquoted identifier; non-ASCII letters such as Chinese are allowed
+func isIdentByte(src string, i int) bool {
+	c := src[i]
+	if c < utf8.RuneSelf {
+		return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+	}
+	r, _ := utf8.DecodeRuneInString(src[i:])
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// runeLen returns the byte length of the rune starting at src[i]
+func runeLen(src string, i int) int {
+	_, size := utf8.DecodeRuneInString(src[i:])
+	return size
+}
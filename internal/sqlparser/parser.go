@@ -0,0 +1,1019 @@
+package sqlparser
+
+import (
+	"strings"
+)
+
+// reserved words cannot be used as bare aliases or column names
+var reserved = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "GROUP": true, "ORDER": true,
+	"HAVING": true, "LIMIT": true, "OFFSET": true, "FETCH": true, "JOIN": true,
+	"INNER": true, "LEFT": true, "RIGHT": true, "FULL": true, "OUTER": true,
+	"CROSS": true, "NATURAL": true, "ON": true, "USING": true, "UNION": true,
+	"INTERSECT": true, "EXCEPT": true, "MINUS": true, "AS": true, "AND": true,
+	"OR": true, "XOR": true, "NOT": true, "IN": true, "IS": true, "LIKE": true,
+	"ILIKE": true, "RLIKE": true, "REGEXP": true, "BETWEEN": true, "CASE": true,
+	"WHEN": true, "THEN": true, "ELSE": true, "END": true, "WITH": true,
+	"WINDOW": true, "QUALIFY": true, "LATERAL": true, "DISTRIBUTE": true,
+	"SORT": true, "CLUSTER": true, "SEMI": true, "ANTI": true, "INTO": true,
+	"VALUES": true, "SET": true, "STRAIGHT_JOIN": true, "TABLESAMPLE": true,
+	"FOR": true, "DISTINCT": true, "ALL": true, "EXISTS": true, "BY": true,
+	"ASC": true, "DESC": true, "DIV": true, "MOD": true, "ESCAPE": true,
+}
+
+// niladic are keywords that act as functions without parentheses
+var niladic = map[string]bool{
+	"CURRENT_DATE": true, "CURRENT_TIME": true, "CURRENT_TIMESTAMP": true,
+	"LOCALTIME": true, "LOCALTIMESTAMP": true, "CURRENT_USER": true,
+	"SESSION_USER": true, "CURRENT_SCHEMA": true, "CURRENT_CATALOG": true,
+}
+
+// timeUnits may appear as bare keywords in date functions and INTERVAL
+var timeUnits = map[string]bool{
+	"MICROSECOND": true, "MILLISECOND": true, "SECOND": true, "MINUTE": true,
+	"HOUR": true, "DAY": true, "WEEK": true, "MONTH": true, "QUARTER": true,
+	"YEAR": true, "DOW": true, "DOY": true, "EPOCH": true, "DAYOFWEEK": true,
+	"DAYOFYEAR": true, "YEAR_MONTH": true, "DAY_HOUR": true, "DAY_MINUTE": true,
+	"DAY_SECOND": true, "HOUR_MINUTE": true, "HOUR_SECOND": true,
+	"MINUTE_SECOND": true, "SECONDS": true, "MINUTES": true, "HOURS": true,
+	"DAYS": true, "WEEKS": true, "MONTHS": true, "YEARS": true,
+}
+
+// unitFunctions take a bare time unit as their first argument
+var unitFunctions = map[string]bool{
+	"TIMESTAMPDIFF": true, "TIMESTAMPADD": true, "DATEDIFF": true,
+	"DATEADD": true, "DATE_PART": true, "DATEPART": true, "DATE_TRUNC": true,
+	"DATETRUNC": true, "LAST_DAY": true,
+}
+
+// Parse parses one or more semicolon-separated statements
+func Parse(sql string, opts Options) ([]Statement, error) {
+	tokens, err := Tokenize(sql, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{src: sql, tokens: tokens}
+	var statements []Statement
+	for {
+		for p.acceptOp(";") {
+		}
+		if p.peek().Kind == EOF {
+			break
+		}
+
+		stmt, err := p.parseStatement()
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, stmt)
+
+		if !p.isOp(";") && p.peek().Kind != EOF {
+			return nil, p.errorf("unexpected %s", describe(p.peek()))
+		}
+	}
+
+	return statements, nil
+}
+
+// ParseOne parses exactly one statement
+func ParseOne(sql string, opts Options) (Statement, error) {
+	statements, err := Parse(sql, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(statements) != 1 {
+		return nil, newSyntaxError(sql, 0, "", "expected exactly one statement, found %d", len(statements))
+	}
+	return statements[0], nil
+}
+
+// parser is a recursive-descent parser over a token slice. Backtracking is
+// done by saving and restoring pos.
+type parser struct {
+	src    string
+	tokens []Token
+	pos    int
+}
+
+func (p *parser) peek() Token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekN(n int) Token {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+func (p *parser) next() Token {
+	tok := p.tokens[p.pos]
+	if tok.Kind != EOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) isKeyword(words ...string) bool {
+	return tokenIs(p.peek(), words...)
+}
+
+func tokenIs(tok Token, words ...string) bool {
+	if tok.Kind != Ident {
+		return false
+	}
+	for _, w := range words {
+		if tok.Upper == w {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) acceptKeyword(word string) bool {
+	if p.isKeyword(word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectKeyword(word string) error {
+	if !p.acceptKeyword(word) {
+		return p.errorf("expected %s, found %s", word, describe(p.peek()))
+	}
+	return nil
+}
+
+func (p *parser) isOp(op string) bool {
+	tok := p.peek()
+	return tok.Kind == Operator && tok.Value == op
+}
+
+func (p *parser) acceptOp(op string) bool {
+	if p.isOp(op) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectOp(op string) error {
+	if !p.acceptOp(op) {
+		return p.errorf("expected %q, found %s", op, describe(p.peek()))
+	}
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	tok := p.peek()
+	return newSyntaxError(p.src, tok.Pos, tokenText(tok), format, args...)
+}
+
+// tokenText returns the source form of a token for error messages
+func tokenText(tok Token) string {
+	switch tok.Kind {
+	case EOF:
+		return ""
+	case String:
+		return "'" + tok.Value + "'"
+	}
+	return tok.Value
+}
+
+func describe(tok Token) string {
+	if tok.Kind == EOF {
+		return "end of input"
+	}
+	return tok.Kind.String() + " " + tokenText(tok)
+}
+
+// isQueryStart reports whether the current token starts a query
+func (p *parser) isQueryStart() bool {
+	return p.isKeyword("SELECT", "WITH")
+}
+
+func (p *parser) parseStatement() (Statement, error) {
+	if p.isQueryStart() || p.isOp("(") {
+		return p.parseQuery()
+	}
+
+	tok := p.peek()
+	if tok.Kind != Ident {
+		return nil, p.errorf("expected statement, found %s", describe(tok))
+	}
+
+	// Skip to the end of the statement, keeping its text
+	depth := 0
+	for {
+		t := p.peek()
+		if t.Kind == EOF || (depth == 0 && t.Kind == Operator && t.Value == ";") {
+			break
+		}
+		if t.Kind == Operator && t.Value == "(" {
+			depth++
+		} else if t.Kind == Operator && t.Value == ")" && depth > 0 {
+			depth--
+		}
+		p.next()
+	}
+
+	return &OtherStatement{
+		Verb: tok.Upper,
+		Text: strings.TrimSpace(p.src[tok.Pos:p.peek().Pos]),
+	}, nil
+}
+
+// parseQuery parses [WITH ...] body [ORDER BY ...] [LIMIT ...]
+func (p *parser) parseQuery() (*SelectStatement, error) {
+	q := &SelectStatement{}
+
+	if p.acceptKeyword("WITH") {
+		recursive := p.acceptKeyword("RECURSIVE")
+		for {
+			cte, err := p.parseCTE()
+			if err != nil {
+				return nil, err
+			}
+			cte.Recursive = recursive
+			q.With = append(q.With, cte)
+			if !p.acceptOp(",") {
+				break
+			}
+		}
+	}
+
+	body, err := p.parseSetExpr()
+	if err != nil {
+		return nil, err
+	}
+	q.Body = body
+
+	if err := p.parseQueryTail(q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func (p *parser) parseCTE() (*CTE, error) {
+	name, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	cte := &CTE{Name: name}
+
+	if p.isOp("(") {
+		if cte.Columns, err = p.parseIdentList(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expectKeyword("AS"); err != nil {
+		return nil, err
+	}
+	if err := p.expectOp("("); err != nil {
+		return nil, err
+	}
+	if cte.Query, err = p.parseQuery(); err != nil {
+		return nil, err
+	}
+	if err := p.expectOp(")"); err != nil {
+		return nil, err
+	}
+	return cte, nil
+}
+
+// parseQueryTail parses the clauses that follow a query body
+func (p *parser) parseQueryTail(q *SelectStatement) error {
+	var err error
+	for {
+		switch {
+		case p.isKeyword("ORDER") && tokenIs(p.peekN(1), "BY"):
+			p.pos += 2
+			if q.OrderBy, err = p.parseOrderItems(); err != nil {
+				return err
+			}
+		case p.isKeyword("SORT") && tokenIs(p.peekN(1), "BY"):
+			p.pos += 2
+			if q.SortBy, err = p.parseOrderItems(); err != nil {
+				return err
+			}
+		case p.isKeyword("DISTRIBUTE") && tokenIs(p.peekN(1), "BY"):
+			p.pos += 2
+			if q.DistributeBy, err = p.parseExprList(); err != nil {
+				return err
+			}
+		case p.isKeyword("CLUSTER") && tokenIs(p.peekN(1), "BY"):
+			p.pos += 2
+			if q.ClusterBy, err = p.parseExprList(); err != nil {
+				return err
+			}
+		case p.acceptKeyword("LIMIT"):
+			if p.acceptKeyword("ALL") {
+				continue
+			}
+			first, err := p.parseExpr()
+			if err != nil {
+				return err
+			}
+			q.Limit = first
+			// MySQL LIMIT offset, count
+			if p.acceptOp(",") {
+				q.Offset = first
+				if q.Limit, err = p.parseExpr(); err != nil {
+					return err
+				}
+			}
+		case p.acceptKeyword("OFFSET"):
+			if q.Offset, err = p.parseExpr(); err != nil {
+				return err
+			}
+			if !p.acceptKeyword("ROWS") {
+				p.acceptKeyword("ROW")
+			}
+		case p.acceptKeyword("FETCH"):
+			if !p.acceptKeyword("FIRST") {
+				if err := p.expectKeyword("NEXT"); err != nil {
+					return err
+				}
+			}
+			if q.Limit, err = p.parseExpr(); err != nil {
+				return err
+			}
+			if !p.acceptKeyword("ROWS") {
+				if err := p.expectKeyword("ROW"); err != nil {
+					return err
+				}
+			}
+			if !p.acceptKeyword("ONLY") {
+				if err := p.expectKeyword("WITH"); err != nil {
+					return err
+				}
+				if err := p.expectKeyword("TIES"); err != nil {
+					return err
+				}
+			}
+		case p.isKeyword("FOR") && tokenIs(p.peekN(1), "UPDATE", "SHARE"),
+			p.isKeyword("LOCK") && tokenIs(p.peekN(1), "IN"):
+			// Locking clauses do not affect validation; skip to the end
+			for !p.isOp(";") && !p.isOp(")") && p.peek().Kind != EOF {
+				p.next()
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+func (p *parser) parseSetExpr() (QueryExpr, error) {
+	left, err := p.parseQueryPrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isKeyword("UNION", "INTERSECT", "EXCEPT", "MINUS") {
+		op := p.next().Upper
+		if op == "MINUS" {
+			op = "EXCEPT"
+		}
+		all := p.acceptKeyword("ALL")
+		if !all {
+			p.acceptKeyword("DISTINCT")
+		}
+		right, err := p.parseQueryPrimary()
+		if err != nil {
+			return nil, err
+		}
+		left = &SetOperation{Op: op, All: all, Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseQueryPrimary() (QueryExpr, error) {
+	if p.acceptOp("(") {
+		q, err := p.parseQuery()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectOp(")"); err != nil {
+			return nil, err
+		}
+		return &ParenQuery{Query: q}, nil
+	}
+	if !p.isKeyword("SELECT") {
+		return nil, p.errorf("expected SELECT, found %s", describe(p.peek()))
+	}
+	return p.parseSelect()
+}
+
+func (p *parser) parseSelect() (*Select, error) {
+	if err := p.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+	s := &Select{}
+
+	if p.acceptKeyword("DISTINCT") || p.acceptKeyword("DISTINCTROW") {
+		s.Distinct = true
+	} else {
+		p.acceptKeyword("ALL")
+	}
+	for p.isKeyword("SQL_CALC_FOUND_ROWS", "SQL_NO_CACHE", "SQL_CACHE", "HIGH_PRIORITY", "STRAIGHT_JOIN", "SQL_SMALL_RESULT", "SQL_BIG_RESULT") {
+		p.next()
+	}
+
+	for {
+		item, err := p.parseSelectItem()
+		if err != nil {
+			return nil, err
+		}
+		s.Columns = append(s.Columns, item)
+		if !p.acceptOp(",") {
+			break
+		}
+	}
+
+	var err error
+	if p.acceptKeyword("FROM") {
+		if s.From, err = p.parseTableRefs(); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("WHERE") {
+		if s.Where, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.isKeyword("GROUP") && tokenIs(p.peekN(1), "BY") {
+		p.pos += 2
+		if s.GroupBy, err = p.parseGroupBy(); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("HAVING") {
+		if s.Having, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("WINDOW") {
+		// Named windows are parsed for syntax only
+		for {
+			if _, err := p.parseIdent(); err != nil {
+				return nil, err
+			}
+			if err := p.expectKeyword("AS"); err != nil {
+				return nil, err
+			}
+			if _, err := p.parseWindowSpec(); err != nil {
+				return nil, err
+			}
+			if !p.acceptOp(",") {
+				break
+			}
+		}
+	}
+	if p.acceptKeyword("QUALIFY") {
+		if s.Qualify, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func (p *parser) parseGroupBy() ([]Expr, error) {
+	var exprs []Expr
+	for {
+		if p.isKeyword("GROUPING") && tokenIs(p.peekN(1), "SETS") {
+			p.pos += 2
+			set, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			exprs = append(exprs, set)
+		} else {
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			exprs = append(exprs, expr)
+		}
+		if !p.acceptOp(",") {
+			break
+		}
+	}
+	if p.isKeyword("WITH") && tokenIs(p.peekN(1), "ROLLUP", "CUBE") {
+		p.pos += 2
+	}
+	return exprs, nil
+}
+
+func (p *parser) parseSelectItem() (*SelectItem, error) {
+	if p.acceptOp("*") {
+		return &SelectItem{Star: true}, nil
+	}
+
+	// t.* and schema.t.*
+	if t := p.peek(); t.Kind == Ident || t.Kind == QuotedIdent {
+		for n := 1; ; n += 2 {
+			dot, next := p.peekN(n), p.peekN(n+1)
+			if dot.Kind != Operator || dot.Value != "." {
+				break
+			}
+			if next.Kind == Operator && next.Value == "*" {
+				table := p.peekN(n - 1).Value
+				p.pos += n + 2
+				return &SelectItem{Star: true, StarTable: table}, nil
+			}
+			if next.Kind != Ident && next.Kind != QuotedIdent {
+				break
+			}
+		}
+	}
+
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	item := &SelectItem{Expr: expr}
+	if item.Alias, err = p.parseAlias(true); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// parseAlias parses an optional [AS] alias. String aliases are accepted in
+// select lists, as MySQL does.
+func (p *parser) parseAlias(allowString bool) (string, error) {
+	if p.acceptKeyword("AS") {
+		tok := p.peek()
+		if tok.Kind == String && allowString {
+			p.next()
+			return tok.Value, nil
+		}
+		return p.parseIdent()
+	}
+
+	tok := p.peek()
+	switch {
+	case tok.Kind == QuotedIdent:
+		p.next()
+		return tok.Value, nil
+	case tok.Kind == String && allowString:
+		p.next()
+		return tok.Value, nil
+	case tok.Kind == Ident && !reserved[tok.Upper]:
+		p.next()
+		return tok.Value, nil
+	}
+	return "", nil
+}
+
+// parseIdent parses a bare or quoted identifier
+func (p *parser) parseIdent() (string, error) {
+	tok := p.peek()
+	if tok.Kind == QuotedIdent || (tok.Kind == Ident && !reserved[tok.Upper]) {
+		p.next()
+		return tok.Value, nil
+	}
+	return "", p.errorf("expected identifier, found %s", describe(tok))
+}
+
+// parseIdentList parses "(a, b, c)"
+func (p *parser) parseIdentList() ([]string, error) {
+	if err := p.expectOp("("); err != nil {
+		return nil, err
+	}
+	var names []string
+	for {
+		name, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		if !p.acceptOp(",") {
+			break
+		}
+	}
+	if err := p.expectOp(")"); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+func (p *parser) parseTableRefs() ([]TableExpr, error) {
+	var refs []TableExpr
+	for {
+		ref, err := p.parseTableRef()
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+		if !p.acceptOp(",") {
+			break
+		}
+	}
+	return refs, nil
+}
+
+func (p *parser) parseTableRef() (TableExpr, error) {
+	left, err := p.parseTableFactor()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if p.isKeyword("LATERAL") && tokenIs(p.peekN(1), "VIEW") {
+			if left, err = p.parseLateralView(left); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		joinType, natural, ok := p.parseJoinKeyword()
+		if !ok {
+			return left, nil
+		}
+
+		right, err := p.parseTableFactor()
+		if err != nil {
+			return nil, err
+		}
+		join := &Join{Type: joinType, Natural: natural, Left: left, Right: right}
+
+		if p.acceptKeyword("ON") {
+			if join.On, err = p.parseExpr(); err != nil {
+				return nil, err
+			}
+		} else if p.acceptKeyword("USING") {
+			if join.Using, err = p.parseIdentList(); err != nil {
+				return nil, err
+			}
+		}
+		left = join
+	}
+}
+
+// parseLateralView parses "LATERAL VIEW [OUTER] udtf(...) [alias] AS col, ..."
+func (p *parser) parseLateralView(source TableExpr) (TableExpr, error) {
+	p.pos += 2
+	view := &LateralView{Source: source, Outer: p.acceptKeyword("OUTER")}
+
+	var parts []string
+	for {
+		name, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, name)
+		if !p.acceptOp(".") {
+			break
+		}
+	}
+	if !p.isOp("(") {
+		return nil, p.errorf("expected table generating function after LATERAL VIEW, found %s", describe(p.peek()))
+	}
+	fn, err := p.parseFuncCall(strings.Join(parts, "."))
+	if err != nil {
+		return nil, err
+	}
+	view.Func = fn
+
+	if !p.isKeyword("AS") {
+		if view.Alias, err = p.parseIdent(); err != nil {
+			return nil, err
+		}
+	}
+	if !p.acceptKeyword("AS") {
+		return view, nil
+	}
+
+	if p.isOp("(") {
+		view.Columns, err = p.parseIdentList()
+		return view, err
+	}
+	for {
+		name, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+		view.Columns = append(view.Columns, name)
+		if !p.isOp(",") || p.startsTableRef(1) {
+			break
+		}
+		p.next()
+	}
+	return view, nil
+}
+
+// startsTableRef reports whether the tokens from offset n look like an
+// aliased or qualified table reference rather than a single column alias
+func (p *parser) startsTableRef(n int) bool {
+	tok := p.peekN(n)
+	if tok.Kind != Ident && tok.Kind != QuotedIdent {
+		return true
+	}
+	after := p.peekN(n + 1)
+	return (after.Kind == Ident && !reserved[after.Upper]) || after.Kind == QuotedIdent ||
+		(after.Kind == Operator && (after.Value == "." || after.Value == "("))
+}
+
+// parseJoinKeyword consumes a join operator and returns its normalised type
+func (p *parser) parseJoinKeyword() (string, bool, bool) {
+	start := p.pos
+	natural := p.acceptKeyword("NATURAL")
+
+	joinType := "INNER"
+	switch {
+	case p.acceptKeyword("JOIN"), p.acceptKeyword("STRAIGHT_JOIN"):
+		return joinType, natural, true
+	case p.acceptKeyword("INNER"):
+	case p.acceptKeyword("CROSS"):
+		joinType = "CROSS"
+	case p.isKeyword("LEFT", "RIGHT", "FULL"):
+		joinType = p.next().Upper
+		if !p.acceptKeyword("OUTER") && joinType == "LEFT" {
+			if p.acceptKeyword("SEMI") {
+				joinType = "LEFT SEMI"
+			} else if p.acceptKeyword("ANTI") {
+				joinType = "LEFT ANTI"
+			}
+		}
+	default:
+		p.pos = start
+		return "", false, false
+	}
+
+	if !p.acceptKeyword("JOIN") {
+		p.pos = start
+		return "", false, false
+	}
+	return joinType, natural, true
+}
+
+func (p *parser) parseTableFactor() (TableExpr, error) {
+	lateral := p.acceptKeyword("LATERAL")
+
+	if p.isOp("(") {
+		// A parenthesised query is tried first, then a parenthesised join
+		start := p.pos
+		p.next()
+		if p.isQueryStart() || p.isOp("(") {
+			q, err := p.parseQuery()
+			if err == nil && p.acceptOp(")") {
+				derived := &DerivedTable{Query: q}
+				if err := p.parseTableAlias(&derived.Alias, &derived.Columns); err != nil {
+					return nil, err
+				}
+				return derived, nil
+			}
+			if lateral {
+				if err != nil {
+					return nil, err
+				}
+				return nil, p.errorf("expected \")\", found %s", describe(p.peek()))
+			}
+			p.pos = start + 1
+		}
+
+		refs, err := p.parseTableRefs()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectOp(")"); err != nil {
+			return nil, err
+		}
+		return &ParenTableExpr{Exprs: refs}, nil
+	}
+
+	var parts []string
+	for {
+		name, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, name)
+		if !p.acceptOp(".") {
+			break
+		}
+	}
+
+	if p.isOp("(") {
+		fn, err := p.parseFuncCall(strings.Join(parts, "."))
+		if err != nil {
+			return nil, err
+		}
+		tf := &TableFunction{Func: fn}
+		if err := p.parseTableAlias(&tf.Alias, &tf.Columns); err != nil {
+			return nil, err
+		}
+		return tf, nil
+	}
+
+	table := &TableName{Name: parts[len(parts)-1]}
+	if len(parts) > 1 {
+		table.Schema = parts[len(parts)-2]
+	}
+
+	if err := p.skipTableHints(); err != nil {
+		return nil, err
+	}
+	var columns []string
+	if err := p.parseTableAlias(&table.Alias, &columns); err != nil {
+		return nil, err
+	}
+	if err := p.skipTableHints(); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// parseTableAlias parses [AS] alias [(col, ...)]
+func (p *parser) parseTableAlias(alias *string, columns *[]string) error {
+	name, err := p.parseAlias(false)
+	if err != nil {
+		return err
+	}
+	*alias = name
+	if name != "" && p.isOp("(") {
+		if *columns, err = p.parseIdentList(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skipTableHints skips MySQL index hints and TABLESAMPLE clauses
+func (p *parser) skipTableHints() error {
+	for {
+		switch {
+		case p.isKeyword("USE", "FORCE", "IGNORE") && tokenIs(p.peekN(1), "INDEX", "KEY"):
+			p.pos += 2
+			if p.acceptKeyword("FOR") {
+				p.next()
+				p.acceptKeyword("BY")
+			}
+		case p.acceptKeyword("TABLESAMPLE"):
+		default:
+			return nil
+		}
+		if err := p.skipParens(); err != nil {
+			return err
+		}
+	}
+}
+
+// skipParens skips a balanced parenthesised token sequence
+func (p *parser) skipParens() error {
+	if err := p.expectOp("("); err != nil {
+		return err
+	}
+	for depth := 1; depth > 0; {
+		tok := p.next()
+		switch {
+		case tok.Kind == EOF:
+			return p.errorf("unbalanced parentheses")
+		case tok.Kind == Operator && tok.Value == "(":
+			depth++
+		case tok.Kind == Operator && tok.Value == ")":
+			depth--
+		}
+	}
+	return nil
+}
+
+func (p *parser) parseExprList() ([]Expr, error) {
+	var exprs []Expr
+	for {
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+		if !p.acceptOp(",") {
+			return exprs, nil
+		}
+	}
+}
+
+func (p *parser) parseOrderItems() ([]*OrderItem, error) {
+	var items []*OrderItem
+	for {
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		item := &OrderItem{Expr: expr}
+		if p.acceptKeyword("DESC") {
+			item.Desc = true
+		} else {
+			p.acceptKeyword("ASC")
+		}
+		if p.isKeyword("NULLS") && tokenIs(p.peekN(1), "FIRST", "LAST") {
+			p.pos += 2
+		}
+		items = append(items, item)
+		if !p.acceptOp(",") {
+			return items, nil
+		}
+	}
+}
+
+// parseWindowSpec parses an OVER clause body; frame clauses are skipped
+func (p *parser) parseWindowSpec() (*WindowSpec, error) {
+	spec := &WindowSpec{}
+	if !p.isOp("(") {
+		name, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+		spec.Name = name
+		return spec, nil
+	}
+	p.next()
+
+	if tok := p.peek(); (tok.Kind == Ident && !reserved[tok.Upper] && !tokenIs(tok, "PARTITION", "ROWS", "RANGE", "GROUPS")) || tok.Kind == QuotedIdent {
+		spec.Name = p.next().Value
+	}
+
+	var err error
+	if p.isKeyword("PARTITION") && tokenIs(p.peekN(1), "BY") {
+		p.pos += 2
+		if spec.PartitionBy, err = p.parseExprList(); err != nil {
+			return nil, err
+		}
+	}
+	if p.isKeyword("ORDER") && tokenIs(p.peekN(1), "BY") {
+		p.pos += 2
+		if spec.OrderBy, err = p.parseOrderItems(); err != nil {
+			return nil, err
+		}
+	}
+	if p.isKeyword("ROWS", "RANGE", "GROUPS") {
+		for depth := 0; ; {
+			if p.peek().Kind == EOF {
+				return nil, p.errorf("unterminated window frame")
+			}
+			if p.isOp(")") && depth == 0 {
+				break
+			}
+			tok := p.next()
+			if tok.Kind == Operator && tok.Value == "(" {
+				depth++
+			} else if tok.Kind == Operator && tok.Value == ")" {
+				depth--
+			}
+		}
+	}
+
+	if err := p.expectOp(")"); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// parseType parses a type name as written, e.g. DECIMAL(10, 2) or
+// ARRAY<STRUCT<a:INT>>
+func (p *parser) parseType() (string, error) {
+	start := p.peek().Pos
+	if _, err := p.parseTypeWord(); err != nil {
+		return "", err
+	}
+	for p.isKeyword("PRECISION", "VARYING", "UNSIGNED", "SIGNED", "INTEGER", "INT") {
+		p.next()
+	}
+
+	if p.isOp("(") {
+		if err := p.skipParens(); err != nil {
+			return "", err
+		}
+	}
+	if p.isOp("<") {
+		for depth := 0; ; {
+			tok := p.next()
+			if tok.Kind == EOF {
+				return "", p.errorf("unterminated type parameters")
+			}
+			if tok.Kind == Operator {
+				depth += strings.Count(tok.Value, "<") - strings.Count(tok.Value, ">")
+			}
+			if depth <= 0 {
+				break
+			}
+		}
+	}
+	for p.isOp("[") && p.peekN(1).Kind == Operator && p.peekN(1).Value == "]" {
+		p.pos += 2
+	}
+
+	return strings.TrimSpace(p.src[start:p.peek().Pos]), nil
+}
+
+func (p *parser) parseTypeWord() (string, error) {
+	tok := p.peek()
+	if tok.Kind == Ident || tok.Kind == QuotedIdent {
+		p.next()
+		return tok.Value, nil
+	}
+	return "", p.errorf("expected type, found %s", describe(tok))
+}
@@ -0,0 +1,211 @@
+package sqlparser
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseAcceptsQueries(t *testing.T) {
+	queries := []string{
+		"SELECT 1",
+		"SELECT id, item FROM orders LATERAL VIEW explode(items) x AS item WHERE dt = '2024-01-01'",
+		"SELECT a, b FROM t LATERAL VIEW posexplode(arr) AS a, b JOIN s ON s.id = t.id",
+		"SELECT * FROM users",
+		"SELECT u.*, o.id FROM users u JOIN orders o ON o.user_id = u.id",
+		"SELECT a, b AS bee, c 'cee' FROM db.t1 AS x WHERE x.a > 1 AND NOT b IS NULL",
+		"SELECT DISTINCT name FROM users ORDER BY name DESC NULLS LAST LIMIT 10 OFFSET 5",
+		"SELECT id FROM users LIMIT 5, 10",
+		"SELECT COUNT(*), COUNT(DISTINCT city), SUM(amount) FROM orders GROUP BY city WITH ROLLUP HAVING SUM(amount) > 100",
+		"WITH recent AS (SELECT * FROM orders WHERE created_at >= CURRENT_DATE - INTERVAL 7 DAY) SELECT user_id FROM recent",
+		"WITH RECURSIVE t(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM t WHERE n < 10) SELECT n FROM t",
+		"SELECT id FROM a UNION SELECT id FROM b EXCEPT SELECT id FROM c",
+		"(SELECT id FROM a) UNION ALL (SELECT id FROM b) ORDER BY id",
+		"SELECT * FROM (SELECT id, name FROM users) AS u WHERE u.id IN (SELECT user_id FROM orders)",
+		"SELECT * FROM users WHERE EXISTS (SELECT 1 FROM orders WHERE orders.user_id = users.id)",
+		"SELECT * FROM users WHERE id NOT IN (1, 2, 3) AND name NOT LIKE 'a%' AND age NOT BETWEEN 1 AND 10",
+		"SELECT CASE WHEN age > 18 THEN 'adult' ELSE 'minor' END AS grp FROM users",
+		"SELECT CASE status WHEN 1 THEN 'on' WHEN 0 THEN 'off' END FROM devices",
+		"SELECT CAST(amount AS DECIMAL(10, 2)), amount::numeric, CONVERT(name, CHAR(20)) FROM orders",
+		"SELECT EXTRACT(YEAR FROM created_at), TIMESTAMPDIFF(DAY, a, b), DATE_ADD(d, INTERVAL 1 MONTH) FROM t",
+		"SELECT SUBSTRING(name FROM 1 FOR 3), TRIM(LEADING 'x' FROM name), POSITION('a' IN name) FROM users",
+		"SELECT GROUP_CONCAT(DISTINCT name ORDER BY name SEPARATOR ',') FROM users",
+		"SELECT name, ROW_NUMBER() OVER (PARTITION BY dept ORDER BY salary DESC ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) FROM emp",
+		"SELECT SUM(x) FILTER (WHERE x > 0) OVER w FROM t WINDOW w AS (ORDER BY id)",
+		"SELECT * FROM a LEFT OUTER JOIN b USING (id) RIGHT JOIN c ON c.id = b.id CROSS JOIN d NATURAL JOIN e",
+		"SELECT * FROM a LEFT SEMI JOIN b ON a.id = b.id",
+		"SELECT * FROM a, b WHERE a.id = b.id",
+		"SELECT * FROM (a JOIN b ON a.id = b.id) JOIN c ON c.id = a.id",
+		"SELECT * FROM users USE INDEX (idx_name) WHERE name = 'x'",
+		"SELECT `order`.`id`, \"name\" FROM `order`",
+		"SELECT 用户名 FROM 用户表 WHERE 年龄 > 18",
+		"SELECT tags[0], info.address.city FROM profiles info",
+		"SELECT * FROM logs WHERE dt = '${bizdate}' AND id = ? AND uid = @uid",
+		"SELECT a FROM t WHERE b = ANY (SELECT b FROM u)",
+		"SELECT DATE '2024-01-01', TIMESTAMP '2024-01-01 00:00:00', NULL, TRUE FROM t",
+		"SELECT x FROM t DISTRIBUTE BY x SORT BY x",
+		"SELECT -1, +2, ~3, 4 DIV 2, 5 MOD 2, 'a' || 'b' FROM t -- trailing comment",
+		"SELECT /* hint */ id FROM t; SELECT id FROM u;",
+		"SELECT * FROM t FETCH FIRST 10 ROWS ONLY",
+		"SELECT * FROM t1 WHERE (a, b) IN ((1, 2), (3, 4))",
+		"SELECT LEFT(name, 3), IF(a > 1, 'y', 'n'), REPLACE(name, 'a', 'b') FROM t",
+	}
+
+	for _, sql := range queries {
+		if _, err := Parse(sql, Options{}); err != nil {
+			t.Errorf("Parse(%q) failed: %v", sql, err)
+		}
+	}
+}
+
+func TestParseRejectsInvalidSQL(t *testing.T) {
+	queries := []string{
+		"SELECT FROM users",
+		"SELECT * FROM",
+		"SELECT * FROM users WHERE",
+		"SELECT (a FROM t",
+		"SELECT * FROM users WHERE name = 'unterminated",
+		"SELECT a b c FROM t",
+		"SELECT CASE END FROM t",
+		"SELECT * FROM a JOIN b ON",
+	}
+
+	for _, sql := range queries {
+		_, err := Parse(sql, Options{})
+		if err == nil {
+			t.Errorf("Parse(%q) succeeded, want syntax error", sql)
+			continue
+		}
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("Parse(%q) returned %T, want *SyntaxError", sql, err)
+		}
+	}
+}
+
+func TestParseSelectStructure(t *testing.T) {
+	stmt, err := ParseOne("SELECT u.name AS n, COUNT(*) FROM shop.users u LEFT JOIN orders o ON o.user_id = u.id WHERE u.age >= 18 GROUP BY u.name", Options{})
+	if err != nil {
+		t.Fatalf("ParseOne failed: %v", err)
+	}
+
+	query, ok := stmt.(*SelectStatement)
+	if !ok {
+		t.Fatalf("expected *SelectStatement, got %T", stmt)
+	}
+	sel, ok := query.Body.(*Select)
+	if !ok {
+		t.Fatalf("expected *Select body, got %T", query.Body)
+	}
+
+	if len(sel.Columns) != 2 || sel.Columns[0].Alias != "n" {
+		t.Fatalf("unexpected select list: %+v", sel.Columns)
+	}
+	ref, ok := sel.Columns[0].Expr.(*ColumnRef)
+	if !ok || ref.Name() != "u.name" {
+		t.Errorf("expected column u.name, got %#v", sel.Columns[0].Expr)
+	}
+	if fn, ok := sel.Columns[1].Expr.(*FuncCall); !ok || !fn.Star {
+		t.Errorf("expected COUNT(*), got %#v", sel.Columns[1].Expr)
+	}
+
+	join, ok := sel.From[0].(*Join)
+	if !ok || join.Type != "LEFT" {
+		t.Fatalf("expected LEFT join, got %#v", sel.From[0])
+	}
+	left := join.Left.(*TableName)
+	if left.Schema != "shop" || left.Name != "users" || left.Alias != "u" {
+		t.Errorf("unexpected left table: %+v", left)
+	}
+	if right := join.Right.(*TableName); right.Name != "orders" || right.Alias != "o" {
+		t.Errorf("unexpected right table: %+v", right)
+	}
+
+	where, ok := sel.Where.(*BinaryExpr)
+	if !ok || where.Op != ">=" {
+		t.Fatalf("unexpected WHERE: %#v", sel.Where)
+	}
+	if lit, ok := where.Right.(*Literal); !ok || lit.Kind != NumberLiteral || lit.Value != "18" {
+		t.Errorf("unexpected WHERE literal: %#v", where.Right)
+	}
+	if len(sel.GroupBy) != 1 {
+		t.Errorf("expected one GROUP BY expression, got %d", len(sel.GroupBy))
+	}
+}
+
+func TestParseLateralView(t *testing.T) {
+	sql := "SELECT t.id, tag, k, v FROM events t LATERAL VIEW explode(t.tags) tg AS tag LATERAL VIEW OUTER explode(t.props) p AS k, v, users u WHERE t.dt = '2024-01-01'"
+	stmt, err := ParseOne(sql, Options{})
+	if err != nil {
+		t.Fatalf("ParseOne failed: %v", err)
+	}
+	sel := stmt.(*SelectStatement).Body.(*Select)
+	if len(sel.From) != 2 {
+		t.Fatalf("expected 2 FROM items, got %d", len(sel.From))
+	}
+
+	outer, ok := sel.From[0].(*LateralView)
+	if !ok || !outer.Outer || outer.Alias != "p" || len(outer.Columns) != 2 || outer.Columns[1] != "v" {
+		t.Fatalf("unexpected outer lateral view: %#v", sel.From[0])
+	}
+	inner, ok := outer.Source.(*LateralView)
+	if !ok || inner.Outer || inner.Func.Name != "explode" || inner.Alias != "tg" || len(inner.Columns) != 1 {
+		t.Fatalf("unexpected inner lateral view: %#v", outer.Source)
+	}
+	if table, ok := inner.Source.(*TableName); !ok || table.Name != "events" {
+		t.Errorf("unexpected lateral view source: %#v", inner.Source)
+	}
+	if table, ok := sel.From[1].(*TableName); !ok || table.Alias != "u" {
+		t.Errorf("unexpected second FROM item: %#v", sel.From[1])
+	}
+}
+
+func TestParseOtherStatements(t *testing.T) {
+	statements, err := Parse("INSERT INTO t (a) VALUES (1); DROP TABLE t", Options{})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(statements) != 2 {
+		t.Fatalf("expected 2 statements, got %d", len(statements))
+	}
+
+	for i, verb := range []string{"INSERT", "DROP"} {
+		other, ok := statements[i].(*OtherStatement)
+		if !ok || other.Verb != verb {
+			t.Errorf("statement %d: expected %s, got %#v", i, verb, statements[i])
+		}
+	}
+	if text := statements[0].(*OtherStatement).Text; text != "INSERT INTO t (a) VALUES (1)" {
+		t.Errorf("unexpected statement text %q", text)
+	}
+}
+
+func TestDoubleQuotedIdentifiers(t *testing.T) {
+	stmt, err := ParseOne(`SELECT "user id" FROM t`, Options{DoubleQuotedIdentifiers: true})
+	if err != nil {
+		t.Fatalf("ParseOne failed: %v", err)
+	}
+	item := stmt.(*SelectStatement).Body.(*Select).Columns[0]
+	if ref, ok := item.Expr.(*ColumnRef); !ok || ref.Name() != "user id" {
+		t.Errorf("expected column reference, got %#v", item.Expr)
+	}
+
+	stmt, err = ParseOne(`SELECT "user id" FROM t`, Options{})
+	if err != nil {
+		t.Fatalf("ParseOne failed: %v", err)
+	}
+	item = stmt.(*SelectStatement).Body.(*Select).Columns[0]
+	if lit, ok := item.Expr.(*Literal); !ok || lit.Kind != StringLiteral {
+		t.Errorf("expected string literal, got %#v", item.Expr)
+	}
+}
+
+func TestSyntaxErrorPosition(t *testing.T) {
+	_, err := Parse("SELECT a\nFROM t\nWHERE", Options{})
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("expected *SyntaxError, got %v", err)
+	}
+	if syntaxErr.Line != 3 {
+		t.Errorf("expected error on line 3, got %d (%v)", syntaxErr.Line, err)
+	}
+}
@@ -0,0 +1,14 @@
+package storage
+
+import "errors"
+
+// Sentinel errors wrapped by Store implementations so callers can tell a
+// missing record apart from a storage failure
+var (
+	// ErrTableNotFound is returned when no table with the given name exists
+	ErrTableNotFound = errors.New("table not found")
+	// ErrQueryNotFound is returned when no query with the given ID exists
+	ErrQueryNotFound = errors.New("query not found")
+	// ErrPromptTemplateNotFound is returned when no prompt template with the given name and version exists
+	ErrPromptTemplateNotFound = errors.New("prompt template not found")
+)
@@ -14,17 +14,20 @@ import (
 // Store defines the interface for data storage
 type Store interface {
 	// Table operations
-	CreateTable(table *models.Table) error
-	GetTableByName(name string) (*models.Table, error)
-	SearchTables(keyword string, limit, offset int) ([]*models.Table, error)
-	ListTables(limit, offset int) ([]*models.Table, error)
-	UpdateTable(name string, table *models.Table) error
-	DeleteTable(name string) error
+	CreateTable(ctx context.Context, table *models.Table) error
+	GetTableByName(ctx context.Context, name string) (*models.Table, error)
+	SearchTables(ctx context.Context, keyword string, limit, offset int) ([]*models.Table, error)
+	ListTables(ctx context.Context, limit, offset int) ([]*models.Table, error)
+	UpdateTable(ctx context.Context, name string, table *models.Table) error
+	DeleteTable(ctx context.Context, name string) error
 
 	// Query operations
-	CreateQuery(query *models.Query) error
-	GetQueryByID(id string) (*models.Query, error)
-	ListQueries(limit, offset int) ([]*models.Query, error)
+	CreateQuery(ctx context.Context, query *models.Query) error
+	GetQueryByID(ctx context.Context, id string) (*models.Query, error)
+	ListQueries(ctx context.Context, limit, offset int) ([]*models.Query, error)
+	UpdateQueryExecution(ctx context.Context, id string, execution *models.QueryExecution) error
+	UpdateQueryPlan(ctx context.Context, id string, plan *models.QueryPlan) error
+	UpdateQueryFeedback(ctx context.Context, id string, feedback *models.QueryFeedback) error
 }
 
 // MongoStore implements Store interface with MongoDB
@@ -33,7 +36,6 @@ type MongoStore struct {
 	db      *mongo.Database
 	tables  *mongo.Collection
 	queries *mongo.Collection
-	context context.Context
 }
 
 // NewMongoStore creates a new MongoDB storage
@@ -57,13 +59,13 @@ func NewMongoStore(mongoURI, dbName string) (*MongoStore, error) {
 	// Create indexes
 	_, err = tables.Indexes().CreateMany(ctx, []mongo.IndexModel{
 		{
-			Keys: bson.D{{"name", 1}},
+			Keys: bson.D{{Key: "name", Value: 1}},
 			Options: options.Index().
 				SetUnique(true).
 				SetName("name_unique_index"),
 		},
 		{
-			Keys: bson.D{{"description", "text"}, {"columns.description", "text"}, {"columns.name", "text"}},
+			Keys: bson.D{{Key: "description", Value: "text"}, {Key: "columns.description", Value: "text"}, {Key: "columns.name", Value: "text"}},
 			Options: options.Index().
 				SetName("text_search_index"),
 		},
@@ -77,17 +79,16 @@ func NewMongoStore(mongoURI, dbName string) (*MongoStore, error) {
 		db:      database,
 		tables:  tables,
 		queries: queries,
-		context: ctx,
 	}, nil
 }
 
 // CreateTable saves a table definition
-func (s *MongoStore) CreateTable(table *models.Table) error {
+func (s *MongoStore) CreateTable(ctx context.Context, table *models.Table) error {
 	now := time.Now()
 	table.CreatedAt = now
 	table.UpdatedAt = now
 
-	_, err := s.tables.InsertOne(s.context, table)
+	_, err := s.tables.InsertOne(ctx, table)
 	if err != nil {
 		return fmt.Errorf("failed to insert table: %w", err)
 	}
@@ -95,12 +96,12 @@ func (s *MongoStore) CreateTable(table *models.Table) error {
 }
 
 // GetTableByName retrieves a table by name
-func (s *MongoStore) GetTableByName(name string) (*models.Table, error) {
+func (s *MongoStore) GetTableByName(ctx context.Context, name string) (*models.Table, error) {
 	var table models.Table
-	err := s.tables.FindOne(s.context, bson.M{"name": name}).Decode(&table)
+	err := s.tables.FindOne(ctx, bson.M{"name": name}).Decode(&table)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("table not found: %s", name)
+			return nil, fmt.Errorf("%w: %s", ErrTableNotFound, name)
 		}
 		return nil, fmt.Errorf("failed to find table: %w", err)
 	}
@@ -108,7 +109,7 @@ func (s *MongoStore) GetTableByName(name string) (*models.Table, error) {
 }
 
 // SearchTables searches tables by keywords
-func (s *MongoStore) SearchTables(keyword string, limit, offset int) ([]*models.Table, error) {
+func (s *MongoStore) SearchTables(ctx context.Context, keyword string, limit, offset int) ([]*models.Table, error) {
 	if limit <= 0 {
 		limit = 10
 	}
@@ -124,14 +125,14 @@ func (s *MongoStore) SearchTables(keyword string, limit, offset int) ([]*models.
 	opts.SetSkip(int64(offset))
 	opts.SetSort(bson.M{"score": bson.M{"$meta": "textScore"}})
 
-	cursor, err := s.tables.Find(s.context, filter, opts)
+	cursor, err := s.tables.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search tables: %w", err)
 	}
-	defer cursor.Close(s.context)
+	defer cursor.Close(ctx)
 
 	var tables []*models.Table
-	if err = cursor.All(s.context, &tables); err != nil {
+	if err = cursor.All(ctx, &tables); err != nil {
 		return nil, fmt.Errorf("failed to decode tables: %w", err)
 	}
 
@@ -139,7 +140,7 @@ func (s *MongoStore) SearchTables(keyword string, limit, offset int) ([]*models.
 }
 
 // ListTables returns tables with pagination
-func (s *MongoStore) ListTables(limit, offset int) ([]*models.Table, error) {
+func (s *MongoStore) ListTables(ctx context.Context, limit, offset int) ([]*models.Table, error) {
 	if limit <= 0 {
 		limit = 10
 	}
@@ -153,14 +154,14 @@ func (s *MongoStore) ListTables(limit, offset int) ([]*models.Table, error) {
 	opts.SetSkip(int64(offset))
 	opts.SetSort(bson.M{"created_at": -1})
 
-	cursor, err := s.tables.Find(s.context, bson.M{}, opts)
+	cursor, err := s.tables.Find(ctx, bson.M{}, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list tables: %w", err)
 	}
-	defer cursor.Close(s.context)
+	defer cursor.Close(ctx)
 
 	var tables []*models.Table
-	if err = cursor.All(s.context, &tables); err != nil {
+	if err = cursor.All(ctx, &tables); err != nil {
 		return nil, fmt.Errorf("failed to decode tables: %w", err)
 	}
 
@@ -168,11 +169,11 @@ func (s *MongoStore) ListTables(limit, offset int) ([]*models.Table, error) {
 }
 
 // UpdateTable updates a table by name
-func (s *MongoStore) UpdateTable(name string, table *models.Table) error {
+func (s *MongoStore) UpdateTable(ctx context.Context, name string, table *models.Table) error {
 	table.UpdatedAt = time.Now()
 
 	result, err := s.tables.UpdateOne(
-		s.context,
+		ctx,
 		bson.M{"name": name},
 		bson.M{"$set": table},
 	)
@@ -181,31 +182,31 @@ func (s *MongoStore) UpdateTable(name string, table *models.Table) error {
 	}
 
 	if result.MatchedCount == 0 {
-		return fmt.Errorf("table not found: %s", name)
+		return fmt.Errorf("%w: %s", ErrTableNotFound, name)
 	}
 
 	return nil
 }
 
 // DeleteTable removes a table by name
-func (s *MongoStore) DeleteTable(name string) error {
-	result, err := s.tables.DeleteOne(s.context, bson.M{"name": name})
+func (s *MongoStore) DeleteTable(ctx context.Context, name string) error {
+	result, err := s.tables.DeleteOne(ctx, bson.M{"name": name})
 	if err != nil {
 		return fmt.Errorf("failed to delete table: %w", err)
 	}
 
 	if result.DeletedCount == 0 {
-		return fmt.Errorf("table not found: %s", name)
+		return fmt.Errorf("%w: %s", ErrTableNotFound, name)
 	}
 
 	return nil
 }
 
 // CreateQuery saves a generated query
-func (s *MongoStore) CreateQuery(query *models.Query) error {
+func (s *MongoStore) CreateQuery(ctx context.Context, query *models.Query) error {
 	query.CreatedAt = time.Now()
 
-	_, err := s.queries.InsertOne(s.context, query)
+	_, err := s.queries.InsertOne(ctx, query)
 	if err != nil {
 		return fmt.Errorf("failed to insert query: %w", err)
 	}
@@ -213,12 +214,12 @@ func (s *MongoStore) CreateQuery(query *models.Query) error {
 }
 
 // GetQueryByID retrieves a query by ID
-func (s *MongoStore) GetQueryByID(id string) (*models.Query, error) {
+func (s *MongoStore) GetQueryByID(ctx context.Context, id string) (*models.Query, error) {
 	var query models.Query
-	err := s.queries.FindOne(s.context, bson.M{"_id": id}).Decode(&query)
+	err := s.queries.FindOne(ctx, bson.M{"_id": id}).Decode(&query)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("query not found: %s", id)
+			return nil, fmt.Errorf("%w: %s", ErrQueryNotFound, id)
 		}
 		return nil, fmt.Errorf("failed to find query: %w", err)
 	}
@@ -226,7 +227,7 @@ func (s *MongoStore) GetQueryByID(id string) (*models.Query, error) {
 }
 
 // ListQueries returns all queries with pagination
-func (s *MongoStore) ListQueries(limit, offset int) ([]*models.Query, error) {
+func (s *MongoStore) ListQueries(ctx context.Context, limit, offset int) ([]*models.Query, error) {
 	if limit <= 0 {
 		limit = 10
 	}
@@ -240,16 +241,58 @@ func (s *MongoStore) ListQueries(limit, offset int) ([]*models.Query, error) {
 	opts.SetSkip(int64(offset))
 	opts.SetSort(bson.M{"created_at": -1})
 
-	cursor, err := s.queries.Find(s.context, bson.M{}, opts)
+	cursor, err := s.queries.Find(ctx, bson.M{}, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list queries: %w", err)
 	}
-	defer cursor.Close(s.context)
+	defer cursor.Close(ctx)
 
 	var queries []*models.Query
-	if err = cursor.All(s.context, &queries); err != nil {
+	if err = cursor.All(ctx, &queries); err != nil {
 		return nil, fmt.Errorf("failed to decode queries: %w", err)
 	}
 
 	return queries, nil
 }
+
+// UpdateQueryExecution records the latest execution of a query
+func (s *MongoStore) UpdateQueryExecution(ctx context.Context, id string, execution *models.QueryExecution) error {
+	result, err := s.queries.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"execution": execution}})
+	if err != nil {
+		return fmt.Errorf("failed to update query execution: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", ErrQueryNotFound, id)
+	}
+
+	return nil
+}
+
+// UpdateQueryPlan records the latest EXPLAIN plan of a query
+func (s *MongoStore) UpdateQueryPlan(ctx context.Context, id string, plan *models.QueryPlan) error {
+	result, err := s.queries.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"plan": plan}})
+	if err != nil {
+		return fmt.Errorf("failed to update query plan: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", ErrQueryNotFound, id)
+	}
+
+	return nil
+}
+
+// UpdateQueryFeedback records the latest feedback on a query
+func (s *MongoStore) UpdateQueryFeedback(ctx context.Context, id string, feedback *models.QueryFeedback) error {
+	result, err := s.queries.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"feedback": feedback}})
+	if err != nil {
+		return fmt.Errorf("failed to update query feedback: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", ErrQueryNotFound, id)
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -34,6 +35,12 @@ func NewMySQLStore(dsn string) (*MySQLStore, error) {
 		return nil, fmt.Errorf("failed to create tables: %w", err)
 	}
 
+	// Add columns introduced after the initial schema
+	err = migrateTables(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate tables: %w", err)
+	}
+
 	// Create indexes
 	err = createIndexes(db)
 	if err != nil {
@@ -53,6 +60,12 @@ func createTables(db *sql.DB) error {
 		name VARCHAR(255) NOT NULL UNIQUE,
 		description TEXT,
 		columns JSON,
+		partition_keys JSON,
+		bucketing JSON,
+		storage_format VARCHAR(32),
+		location TEXT,
+		table_type VARCHAR(16),
+		relationships JSON,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
 	)`
@@ -62,9 +75,57 @@ func createTables(db *sql.DB) error {
 		id VARCHAR(36) PRIMARY KEY,
 		description TEXT,
 		sql_text TEXT,
+		dialect VARCHAR(32),
+		language VARCHAR(8),
+		explanation TEXT,
+		tables_used JSON,
+		assumptions JSON,
+		confidence DOUBLE,
+		validation JSON,
+		attempts JSON,
+		prompt_truncation JSON,
+		prompt_template VARCHAR(255),
+		prompt_template_version INT,
+		execution JSON,
+		explain_plan JSON,
+		feedback JSON,
+		correct BOOLEAN NOT NULL DEFAULT FALSE,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	)`
 
+	tableVectorsSQL := `
+	CREATE TABLE IF NOT EXISTS table_vectors (
+		table_name VARCHAR(255) PRIMARY KEY,
+		model VARCHAR(255) NOT NULL,
+		dimension INT NOT NULL,
+		vector MEDIUMBLOB NOT NULL,
+		content_hash CHAR(64) NOT NULL,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+	)`
+
+	queryVectorsSQL := `
+	CREATE TABLE IF NOT EXISTS query_vectors (
+		query_id VARCHAR(36) PRIMARY KEY,
+		model VARCHAR(255) NOT NULL,
+		dimension INT NOT NULL,
+		vector MEDIUMBLOB NOT NULL,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+	)`
+
+	promptTemplatesSQL := `
+	CREATE TABLE IF NOT EXISTS prompt_templates (
+		name VARCHAR(255) NOT NULL,
+		version INT NOT NULL,
+		dialect VARCHAR(32) NOT NULL DEFAULT '',
+		tenant VARCHAR(255) NOT NULL DEFAULT '',
+		language VARCHAR(8) NOT NULL DEFAULT '',
+		description TEXT,
+		body MEDIUMTEXT NOT NULL,
+		active BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (name, version)
+	)`
+
 	_, err := db.Exec(tablesSQL)
 	if err != nil {
 		return fmt.Errorf("failed to create tables table: %w", err)
@@ -75,6 +136,74 @@ func createTables(db *sql.DB) error {
 		return fmt.Errorf("failed to create queries table: %w", err)
 	}
 
+	_, err = db.Exec(tableVectorsSQL)
+	if err != nil {
+		return fmt.Errorf("failed to create table_vectors table: %w", err)
+	}
+
+	_, err = db.Exec(queryVectorsSQL)
+	if err != nil {
+		return fmt.Errorf("failed to create query_vectors table: %w", err)
+	}
+
+	_, err = db.Exec(promptTemplatesSQL)
+	if err != nil {
+		return fmt.Errorf("failed to create prompt_templates table: %w", err)
+	}
+
+	return nil
+}
+
+// migrateTables adds columns that are missing from tables created by older
+// versions. MySQL has no ADD COLUMN IF NOT EXISTS, so each column is looked
+// up in information_schema first.
+func migrateTables(db *sql.DB) error {
+	migrations := []struct {
+		table, column, definition string
+	}{
+		{"queries", "explanation", "TEXT"},
+		{"queries", "tables_used", "JSON"},
+		{"queries", "assumptions", "JSON"},
+		{"queries", "confidence", "DOUBLE"},
+		{"queries", "validation", "JSON"},
+		{"queries", "attempts", "JSON"},
+		{"queries", "dialect", "VARCHAR(32)"},
+		{"queries", "execution", "JSON"},
+		{"queries", "explain_plan", "JSON"},
+		{"queries", "prompt_truncation", "JSON"},
+		{"queries", "prompt_template", "VARCHAR(255)"},
+		{"queries", "prompt_template_version", "INT"},
+		{"queries", "language", "VARCHAR(8)"},
+		{"queries", "feedback", "JSON"},
+		{"queries", "correct", "BOOLEAN NOT NULL DEFAULT FALSE"},
+		{"prompt_templates", "language", "VARCHAR(8) NOT NULL DEFAULT ''"},
+		{"tables", "partition_keys", "JSON"},
+		{"tables", "bucketing", "JSON"},
+		{"tables", "storage_format", "VARCHAR(32)"},
+		{"tables", "location", "TEXT"},
+		{"tables", "table_type", "VARCHAR(16)"},
+		{"tables", "relationships", "JSON"},
+	}
+
+	for _, m := range migrations {
+		var count int
+		err := db.QueryRow(`
+			SELECT COUNT(*) FROM information_schema.COLUMNS
+			WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?
+		`, m.table, m.column).Scan(&count)
+		if err != nil {
+			return fmt.Errorf("failed to look up column %s.%s: %w", m.table, m.column, err)
+		}
+		if count > 0 {
+			continue
+		}
+
+		migrationSQL := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.definition)
+		if _, err := db.Exec(migrationSQL); err != nil {
+			return fmt.Errorf("failed to run migration %q: %w", migrationSQL, err)
+		}
+	}
+
 	return nil
 }
 
@@ -96,21 +225,25 @@ func createIndexes(db *sql.DB) error {
 	return nil
 }
 
+// tableColumns lists the columns selected for a models.Table, in scan order
+const tableColumns = "id, name, description, columns, partition_keys, bucketing, storage_format, location, table_type, relationships, created_at, updated_at"
+
 // CreateTable saves a table definition
-func (s *MySQLStore) CreateTable(table *models.Table) error {
+func (s *MySQLStore) CreateTable(ctx context.Context, table *models.Table) error {
 	now := time.Now()
 	table.CreatedAt = now
 	table.UpdatedAt = now
 
-	columnsJSON, err := json.Marshal(table.Columns)
+	details, err := marshalTableDetails(table)
 	if err != nil {
-		return fmt.Errorf("failed to marshal columns: %w", err)
+		return err
 	}
 
-	_, err = s.DB.Exec(`
-		INSERT INTO tables (id, name, description, columns, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`, table.ID, table.Name, table.Description, columnsJSON, table.CreatedAt, table.UpdatedAt)
+	_, err = s.DB.ExecContext(ctx, `
+		INSERT INTO tables (id, name, description, columns, partition_keys, bucketing, storage_format, location, table_type, relationships, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, table.ID, table.Name, table.Description, details.columns, details.partitionKeys, details.bucketing,
+		table.StorageFormat, table.Location, table.TableType, details.relationships, table.CreatedAt, table.UpdatedAt)
 
 	if err != nil {
 		return fmt.Errorf("failed to insert table: %w", err)
@@ -120,37 +253,26 @@ func (s *MySQLStore) CreateTable(table *models.Table) error {
 }
 
 // GetTableByName retrieves a table by name
-func (s *MySQLStore) GetTableByName(name string) (*models.Table, error) {
-	var table models.Table
-	var columnsJSON []byte
-	var createdAt, updatedAt time.Time
-
-	err := s.DB.QueryRow(`
-		SELECT id, name, description, columns, created_at, updated_at
+func (s *MySQLStore) GetTableByName(ctx context.Context, name string) (*models.Table, error) {
+	row := s.DB.QueryRowContext(ctx, `
+		SELECT `+tableColumns+`
 		FROM tables
 		WHERE name = ?
-	`, name).Scan(&table.ID, &table.Name, &table.Description, &columnsJSON, &createdAt, &updatedAt)
+	`, name)
 
+	table, err := scanTable(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("table not found: %s", name)
+			return nil, fmt.Errorf("%w: %s", ErrTableNotFound, name)
 		}
-		return nil, fmt.Errorf("failed to find table: %w", err)
-	}
-
-	err = json.Unmarshal(columnsJSON, &table.Columns)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal columns: %w", err)
+		return nil, err
 	}
 
-	table.CreatedAt = createdAt
-	table.UpdatedAt = updatedAt
-
-	return &table, nil
+	return table, nil
 }
 
 // SearchTables searches tables by keywords
-func (s *MySQLStore) SearchTables(keyword string, limit, offset int) ([]*models.Table, error) {
+func (s *MySQLStore) SearchTables(ctx context.Context, keyword string, limit, offset int) ([]*models.Table, error) {
 	if limit <= 0 {
 		limit = 10
 	}
@@ -159,8 +281,8 @@ func (s *MySQLStore) SearchTables(keyword string, limit, offset int) ([]*models.
 		limit = 100 // Cap at 100 results
 	}
 
-	rows, err := s.DB.Query(`
-		SELECT id, name, description, columns, created_at, updated_at
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT `+tableColumns+`
 		FROM tables
 		WHERE MATCH(description) AGAINST(? IN NATURAL LANGUAGE MODE)
 		ORDER BY MATCH(description) AGAINST(? IN NATURAL LANGUAGE MODE) DESC
@@ -172,37 +294,11 @@ func (s *MySQLStore) SearchTables(keyword string, limit, offset int) ([]*models.
 	}
 	defer rows.Close()
 
-	var tables []*models.Table
-	for rows.Next() {
-		var table models.Table
-		var columnsJSON []byte
-		var createdAt, updatedAt time.Time
-
-		err := rows.Scan(&table.ID, &table.Name, &table.Description, &columnsJSON, &createdAt, &updatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan table: %w", err)
-		}
-
-		err = json.Unmarshal(columnsJSON, &table.Columns)
-		if err != nil {
-			return nil, fmt.Errorf("failed to unmarshal columns: %w", err)
-		}
-
-		table.CreatedAt = createdAt
-		table.UpdatedAt = updatedAt
-
-		tables = append(tables, &table)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to iterate rows: %w", err)
-	}
-
-	return tables, nil
+	return scanTables(rows)
 }
 
 // ListTables returns tables with pagination
-func (s *MySQLStore) ListTables(limit, offset int) ([]*models.Table, error) {
+func (s *MySQLStore) ListTables(ctx context.Context, limit, offset int) ([]*models.Table, error) {
 	if limit <= 0 {
 		limit = 10
 	}
@@ -211,8 +307,8 @@ func (s *MySQLStore) ListTables(limit, offset int) ([]*models.Table, error) {
 		limit = 100 // Cap at 100 results
 	}
 
-	rows, err := s.DB.Query(`
-		SELECT id, name, description, columns, created_at, updated_at
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT `+tableColumns+`
 		FROM tables
 		ORDER BY created_at DESC
 		LIMIT ? OFFSET ?
@@ -223,49 +319,24 @@ func (s *MySQLStore) ListTables(limit, offset int) ([]*models.Table, error) {
 	}
 	defer rows.Close()
 
-	var tables []*models.Table
-	for rows.Next() {
-		var table models.Table
-		var columnsJSON []byte
-		var createdAt, updatedAt time.Time
-
-		err := rows.Scan(&table.ID, &table.Name, &table.Description, &columnsJSON, &createdAt, &updatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan table: %w", err)
-		}
-
-		err = json.Unmarshal(columnsJSON, &table.Columns)
-		if err != nil {
-			return nil, fmt.Errorf("failed to unmarshal columns: %w", err)
-		}
-
-		table.CreatedAt = createdAt
-		table.UpdatedAt = updatedAt
-
-		tables = append(tables, &table)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to iterate rows: %w", err)
-	}
-
-	return tables, nil
+	return scanTables(rows)
 }
 
 // UpdateTable updates a table by name
-func (s *MySQLStore) UpdateTable(name string, table *models.Table) error {
+func (s *MySQLStore) UpdateTable(ctx context.Context, name string, table *models.Table) error {
 	table.UpdatedAt = time.Now()
 
-	columnsJSON, err := json.Marshal(table.Columns)
+	details, err := marshalTableDetails(table)
 	if err != nil {
-		return fmt.Errorf("failed to marshal columns: %w", err)
+		return err
 	}
 
-	result, err := s.DB.Exec(`
+	result, err := s.DB.ExecContext(ctx, `
 		UPDATE tables
-		SET description = ?, columns = ?, updated_at = ?
+		SET description = ?, columns = ?, partition_keys = ?, bucketing = ?, storage_format = ?, location = ?, table_type = ?, relationships = ?, updated_at = ?
 		WHERE name = ?
-	`, table.Description, columnsJSON, table.UpdatedAt, name)
+	`, table.Description, details.columns, details.partitionKeys, details.bucketing,
+		table.StorageFormat, table.Location, table.TableType, details.relationships, table.UpdatedAt, name)
 
 	if err != nil {
 		return fmt.Errorf("failed to update table: %w", err)
@@ -277,15 +348,15 @@ func (s *MySQLStore) UpdateTable(name string, table *models.Table) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("table not found: %s", name)
+		return fmt.Errorf("%w: %s", ErrTableNotFound, name)
 	}
 
 	return nil
 }
 
 // DeleteTable removes a table by name
-func (s *MySQLStore) DeleteTable(name string) error {
-	result, err := s.DB.Exec(`DELETE FROM tables WHERE name = ?`, name)
+func (s *MySQLStore) DeleteTable(ctx context.Context, name string) error {
+	result, err := s.DB.ExecContext(ctx, `DELETE FROM tables WHERE name = ?`, name)
 	if err != nil {
 		return fmt.Errorf("failed to delete table: %w", err)
 	}
@@ -296,20 +367,78 @@ func (s *MySQLStore) DeleteTable(name string) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("table not found: %s", name)
+		return fmt.Errorf("%w: %s", ErrTableNotFound, name)
 	}
 
 	return nil
 }
 
 // CreateQuery saves a generated query
-func (s *MySQLStore) CreateQuery(query *models.Query) error {
+func (s *MySQLStore) CreateQuery(ctx context.Context, query *models.Query) error {
 	query.CreatedAt = time.Now()
 
-	_, err := s.DB.Exec(`
-		INSERT INTO queries (id, description, sql_text, created_at)
-		VALUES (?, ?, ?, ?)
-	`, query.ID, query.Description, query.SQL, query.CreatedAt)
+	tablesUsedJSON, err := json.Marshal(query.TablesUsed)
+	if err != nil {
+		return fmt.Errorf("failed to marshal tables used: %w", err)
+	}
+
+	assumptionsJSON, err := json.Marshal(query.Assumptions)
+	if err != nil {
+		return fmt.Errorf("failed to marshal assumptions: %w", err)
+	}
+
+	var validationJSON []byte
+	if query.Validation != nil {
+		validationJSON, err = json.Marshal(query.Validation)
+		if err != nil {
+			return fmt.Errorf("failed to marshal validation: %w", err)
+		}
+	}
+
+	var attemptsJSON []byte
+	if len(query.Attempts) > 0 {
+		attemptsJSON, err = json.Marshal(query.Attempts)
+		if err != nil {
+			return fmt.Errorf("failed to marshal attempts: %w", err)
+		}
+	}
+
+	var truncationJSON []byte
+	if query.PromptTruncation != nil {
+		truncationJSON, err = json.Marshal(query.PromptTruncation)
+		if err != nil {
+			return fmt.Errorf("failed to marshal prompt truncation: %w", err)
+		}
+	}
+
+	var executionJSON []byte
+	if query.Execution != nil {
+		executionJSON, err = json.Marshal(query.Execution)
+		if err != nil {
+			return fmt.Errorf("failed to marshal execution: %w", err)
+		}
+	}
+
+	var planJSON []byte
+	if query.Plan != nil {
+		planJSON, err = json.Marshal(query.Plan)
+		if err != nil {
+			return fmt.Errorf("failed to marshal plan: %w", err)
+		}
+	}
+
+	var feedbackJSON []byte
+	if query.Feedback != nil {
+		feedbackJSON, err = json.Marshal(query.Feedback)
+		if err != nil {
+			return fmt.Errorf("failed to marshal feedback: %w", err)
+		}
+	}
+
+	_, err = s.DB.ExecContext(ctx, `
+		INSERT INTO queries (id, description, sql_text, dialect, language, explanation, tables_used, assumptions, confidence, validation, attempts, prompt_truncation, prompt_template, prompt_template_version, execution, explain_plan, feedback, correct, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, query.ID, query.Description, query.SQL, query.Dialect, query.Language, query.Explanation, tablesUsedJSON, assumptionsJSON, query.Confidence, validationJSON, attemptsJSON, truncationJSON, query.PromptTemplate, query.PromptTemplateVersion, executionJSON, planJSON, feedbackJSON, query.Feedback != nil && query.Feedback.Correct, query.CreatedAt)
 
 	if err != nil {
 		return fmt.Errorf("failed to insert query: %w", err)
@@ -318,31 +447,24 @@ func (s *MySQLStore) CreateQuery(query *models.Query) error {
 	return nil
 }
 
-// GetQueryByID retrieves a query by ID
-func (s *MySQLStore) GetQueryByID(id string) (*models.Query, error) {
-	var query models.Query
-	var createdAt time.Time
-
-	err := s.DB.QueryRow(`
-		SELECT id, description, sql_text, created_at
-		FROM queries
-		WHERE id = ?
-	`, id).Scan(&query.ID, &query.Description, &query.SQL, &createdAt)
+// queryColumns lists the columns selected for a models.Query, in scan order
+const queryColumns = "id, description, sql_text, dialect, language, explanation, tables_used, assumptions, confidence, validation, attempts, prompt_truncation, prompt_template, prompt_template_version, execution, explain_plan, feedback, created_at"
 
+// GetQueryByID retrieves a query by ID
+func (s *MySQLStore) GetQueryByID(ctx context.Context, id string) (*models.Query, error) {
+	query, err := scanQuery(s.DB.QueryRowContext(ctx, "SELECT "+queryColumns+" FROM queries WHERE id = ?", id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("query not found: %s", id)
+			return nil, fmt.Errorf("%w: %s", ErrQueryNotFound, id)
 		}
-		return nil, fmt.Errorf("failed to find query: %w", err)
+		return nil, err
 	}
 
-	query.CreatedAt = createdAt
-
-	return &query, nil
+	return query, nil
 }
 
 // ListQueries returns all queries with pagination
-func (s *MySQLStore) ListQueries(limit, offset int) ([]*models.Query, error) {
+func (s *MySQLStore) ListQueries(ctx context.Context, limit, offset int) ([]*models.Query, error) {
 	if limit <= 0 {
 		limit = 10
 	}
@@ -351,8 +473,8 @@ func (s *MySQLStore) ListQueries(limit, offset int) ([]*models.Query, error) {
 		limit = 100 // Cap at 100 results
 	}
 
-	rows, err := s.DB.Query(`
-		SELECT id, description, sql_text, created_at
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT `+queryColumns+`
 		FROM queries
 		ORDER BY created_at DESC
 		LIMIT ? OFFSET ?
@@ -363,23 +485,302 @@ func (s *MySQLStore) ListQueries(limit, offset int) ([]*models.Query, error) {
 	}
 	defer rows.Close()
 
+	return scanQueries(rows)
+}
+
+// scanQuery scans a row selected with queryColumns. sql.ErrNoRows is
+// returned unwrapped so that callers can map it to ErrQueryNotFound.
+func scanQuery(row rowScanner) (*models.Query, error) {
+	var query models.Query
+	var details queryDetails
+
+	err := row.Scan(&query.ID, &query.Description, &query.SQL, &details.dialect, &details.language, &details.explanation, &details.tablesUsed, &details.assumptions, &details.confidence, &details.validation, &details.attempts, &details.truncation, &details.template, &details.templateVersion, &details.execution, &details.plan, &details.feedback, &query.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan query: %w", err)
+	}
+
+	if err := details.apply(&query); err != nil {
+		return nil, err
+	}
+
+	return &query, nil
+}
+
+// scanQueries scans all rows selected with queryColumns
+func scanQueries(rows *sql.Rows) ([]*models.Query, error) {
 	var queries []*models.Query
 	for rows.Next() {
-		var query models.Query
-		var createdAt time.Time
-
-		err := rows.Scan(&query.ID, &query.Description, &query.SQL, &createdAt)
+		query, err := scanQuery(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan query: %w", err)
+			return nil, err
 		}
-
-		query.CreatedAt = createdAt
-		queries = append(queries, &query)
+		queries = append(queries, query)
 	}
 
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("failed to iterate rows: %w", err)
 	}
 
 	return queries, nil
 }
+
+// tableDetails holds the JSON and nullable columns of a tables row
+type tableDetails struct {
+	columns       []byte
+	partitionKeys []byte
+	bucketing     []byte
+	storageFormat sql.NullString
+	location      sql.NullString
+	tableType     sql.NullString
+	relationships []byte
+}
+
+// marshalTableDetails encodes the JSON columns of table
+func marshalTableDetails(table *models.Table) (*tableDetails, error) {
+	var d tableDetails
+	var err error
+
+	if d.columns, err = json.Marshal(table.Columns); err != nil {
+		return nil, fmt.Errorf("failed to marshal columns: %w", err)
+	}
+
+	if len(table.PartitionKeys) > 0 {
+		if d.partitionKeys, err = json.Marshal(table.PartitionKeys); err != nil {
+			return nil, fmt.Errorf("failed to marshal partition keys: %w", err)
+		}
+	}
+
+	if table.Bucketing != nil {
+		if d.bucketing, err = json.Marshal(table.Bucketing); err != nil {
+			return nil, fmt.Errorf("failed to marshal bucketing: %w", err)
+		}
+	}
+
+	if len(table.Relationships) > 0 {
+		if d.relationships, err = json.Marshal(table.Relationships); err != nil {
+			return nil, fmt.Errorf("failed to marshal relationships: %w", err)
+		}
+	}
+
+	return &d, nil
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTable scans a row selected with tableColumns. sql.ErrNoRows is
+// returned unwrapped so that callers can map it to ErrTableNotFound.
+func scanTable(row rowScanner) (*models.Table, error) {
+	var table models.Table
+	var d tableDetails
+
+	err := row.Scan(&table.ID, &table.Name, &table.Description, &d.columns, &d.partitionKeys, &d.bucketing,
+		&d.storageFormat, &d.location, &d.tableType, &d.relationships, &table.CreatedAt, &table.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan table: %w", err)
+	}
+
+	if err := json.Unmarshal(d.columns, &table.Columns); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal columns: %w", err)
+	}
+
+	if len(d.partitionKeys) > 0 {
+		if err := json.Unmarshal(d.partitionKeys, &table.PartitionKeys); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal partition keys: %w", err)
+		}
+	}
+
+	if len(d.bucketing) > 0 {
+		if err := json.Unmarshal(d.bucketing, &table.Bucketing); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal bucketing: %w", err)
+		}
+	}
+
+	if len(d.relationships) > 0 {
+		if err := json.Unmarshal(d.relationships, &table.Relationships); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal relationships: %w", err)
+		}
+	}
+
+	table.StorageFormat = d.storageFormat.String
+	table.Location = d.location.String
+	table.TableType = d.tableType.String
+
+	return &table, nil
+}
+
+// scanTables scans all rows selected with tableColumns
+func scanTables(rows *sql.Rows) ([]*models.Table, error) {
+	var tables []*models.Table
+	for rows.Next() {
+		table, err := scanTable(rows)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	return tables, nil
+}
+
+// UpdateQueryExecution records the latest execution of a query
+func (s *MySQLStore) UpdateQueryExecution(ctx context.Context, id string, execution *models.QueryExecution) error {
+	executionJSON, err := json.Marshal(execution)
+	if err != nil {
+		return fmt.Errorf("failed to marshal execution: %w", err)
+	}
+
+	result, err := s.DB.ExecContext(ctx, "UPDATE queries SET execution = ? WHERE id = ?", executionJSON, id)
+	if err != nil {
+		return fmt.Errorf("failed to update query execution: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s", ErrQueryNotFound, id)
+	}
+
+	return nil
+}
+
+// UpdateQueryPlan records the latest EXPLAIN plan of a query
+func (s *MySQLStore) UpdateQueryPlan(ctx context.Context, id string, plan *models.QueryPlan) error {
+	planJSON, err := json.Marshal(plan)
+	if err != nil {
+		return fmt.Errorf("failed to marshal plan: %w", err)
+	}
+
+	result, err := s.DB.ExecContext(ctx, "UPDATE queries SET explain_plan = ? WHERE id = ?", planJSON, id)
+	if err != nil {
+		return fmt.Errorf("failed to update query plan: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s", ErrQueryNotFound, id)
+	}
+
+	return nil
+}
+
+// UpdateQueryFeedback records the latest feedback on a query
+func (s *MySQLStore) UpdateQueryFeedback(ctx context.Context, id string, feedback *models.QueryFeedback) error {
+	feedbackJSON, err := json.Marshal(feedback)
+	if err != nil {
+		return fmt.Errorf("failed to marshal feedback: %w", err)
+	}
+
+	result, err := s.DB.ExecContext(ctx, "UPDATE queries SET feedback = ?, correct = ? WHERE id = ?", feedbackJSON, feedback.Correct, id)
+	if err != nil {
+		return fmt.Errorf("failed to update query feedback: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s", ErrQueryNotFound, id)
+	}
+
+	return nil
+}
+
+// queryDetails holds the nullable structured columns of a queries row
+type queryDetails struct {
+	dialect         sql.NullString
+	language        sql.NullString
+	explanation     sql.NullString
+	tablesUsed      []byte
+	assumptions     []byte
+	confidence      sql.NullFloat64
+	validation      []byte
+	attempts        []byte
+	truncation      []byte
+	template        sql.NullString
+	templateVersion sql.NullInt64
+	execution       []byte
+	plan            []byte
+	feedback        []byte
+}
+
+// apply copies the scanned details into query
+func (d *queryDetails) apply(query *models.Query) error {
+	query.Dialect = d.dialect.String
+	query.Language = d.language.String
+	query.Explanation = d.explanation.String
+	query.Confidence = d.confidence.Float64
+	query.PromptTemplate = d.template.String
+	query.PromptTemplateVersion = int(d.templateVersion.Int64)
+
+	if len(d.tablesUsed) > 0 {
+		if err := json.Unmarshal(d.tablesUsed, &query.TablesUsed); err != nil {
+			return fmt.Errorf("failed to unmarshal tables used: %w", err)
+		}
+	}
+
+	if len(d.assumptions) > 0 {
+		if err := json.Unmarshal(d.assumptions, &query.Assumptions); err != nil {
+			return fmt.Errorf("failed to unmarshal assumptions: %w", err)
+		}
+	}
+
+	if len(d.validation) > 0 {
+		if err := json.Unmarshal(d.validation, &query.Validation); err != nil {
+			return fmt.Errorf("failed to unmarshal validation: %w", err)
+		}
+	}
+
+	if len(d.attempts) > 0 {
+		if err := json.Unmarshal(d.attempts, &query.Attempts); err != nil {
+			return fmt.Errorf("failed to unmarshal attempts: %w", err)
+		}
+	}
+
+	if len(d.truncation) > 0 {
+		if err := json.Unmarshal(d.truncation, &query.PromptTruncation); err != nil {
+			return fmt.Errorf("failed to unmarshal prompt truncation: %w", err)
+		}
+	}
+
+	if len(d.execution) > 0 {
+		if err := json.Unmarshal(d.execution, &query.Execution); err != nil {
+			return fmt.Errorf("failed to unmarshal execution: %w", err)
+		}
+	}
+
+	if len(d.plan) > 0 {
+		if err := json.Unmarshal(d.plan, &query.Plan); err != nil {
+			return fmt.Errorf("failed to unmarshal plan: %w", err)
+		}
+	}
+
+	if len(d.feedback) > 0 {
+		if err := json.Unmarshal(d.feedback, &query.Feedback); err != nil {
+			return fmt.Errorf("failed to unmarshal feedback: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -1,13 +1,15 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"testing"
 	"time"
 
-	"awesomeProject2/internal/models"
+	"sql_generator/internal/models"
 	"github.com/google/uuid"
 	_ "github.com/go-sql-driver/mysql"
 )
@@ -35,6 +37,8 @@ func getTestMySQLStore(t *testing.T) *MySQLStore {
 func cleanupTestData(db *sql.DB) {
 	db.Exec("DELETE FROM queries")
 	db.Exec("DELETE FROM tables")
+	db.Exec("DELETE FROM prompt_templates")
+	db.Exec("DELETE FROM query_vectors")
 }
 
 // createTestTable creates a sample table for testing
@@ -83,17 +87,18 @@ func createTestQuery() *models.Query {
 
 func TestMySQLStore_CreateTable(t *testing.T) {
 	store := getTestMySQLStore(t)
+	ctx := context.Background()
 	defer store.DB.Close()
 	
 	table := createTestTable()
 	
-	err := store.CreateTable(table)
+	err := store.CreateTable(ctx, table)
 	if err != nil {
 		t.Fatalf("Failed to create table: %v", err)
 	}
 	
 	// Verify the table was created
-	retrieved, err := store.GetTableByName(table.Name)
+	retrieved, err := store.GetTableByName(ctx, table.Name)
 	if err != nil {
 		t.Fatalf("Failed to get table: %v", err)
 	}
@@ -122,24 +127,25 @@ func TestMySQLStore_CreateTable(t *testing.T) {
 
 func TestMySQLStore_GetTableByName(t *testing.T) {
 	store := getTestMySQLStore(t)
+	ctx := context.Background()
 	defer store.DB.Close()
 	
 	table := createTestTable()
 	
 	// Table should not exist initially
-	_, err := store.GetTableByName(table.Name)
+	_, err := store.GetTableByName(ctx, table.Name)
 	if err == nil {
 		t.Fatalf("Expected error when getting non-existent table, got nil")
 	}
 	
 	// Create the table
-	err = store.CreateTable(table)
+	err = store.CreateTable(ctx, table)
 	if err != nil {
 		t.Fatalf("Failed to create table: %v", err)
 	}
 	
 	// Now it should exist
-	retrieved, err := store.GetTableByName(table.Name)
+	retrieved, err := store.GetTableByName(ctx, table.Name)
 	if err != nil {
 		t.Fatalf("Failed to get table: %v", err)
 	}
@@ -149,20 +155,75 @@ func TestMySQLStore_GetTableByName(t *testing.T) {
 	}
 	
 	// 测试获取空表名
-	_, err = store.GetTableByName("")
+	_, err = store.GetTableByName(ctx, "")
 	if err == nil {
 		t.Error("Expected error when getting table with empty name, got nil")
 	}
 }
 
+func TestMySQLStore_TableStorageMetadata(t *testing.T) {
+	store := getTestMySQLStore(t)
+	ctx := context.Background()
+	defer store.DB.Close()
+
+	table := createTestTable()
+	table.PartitionKeys = []models.Column{{Name: "dt", Type: "STRING", Description: "Date partition"}}
+	table.Bucketing = &models.Bucketing{Columns: []string{"id"}, SortedBy: []string{"name"}, Buckets: 32}
+	table.StorageFormat = "ORC"
+	table.Location = "hdfs:///warehouse/test"
+	table.TableType = models.TableTypeExternal
+	table.Relationships = []models.Relationship{{Columns: []string{"id"}, ToTable: "users", ToColumns: []string{"id"}, Cardinality: models.OneToOne}}
+	table.Columns[1].Sensitivity = models.SensitivityPII
+
+	if err := store.CreateTable(ctx, table); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	retrieved, err := store.GetTableByName(ctx, table.Name)
+	if err != nil {
+		t.Fatalf("Failed to get table: %v", err)
+	}
+	if len(retrieved.PartitionKeys) != 1 || retrieved.PartitionKeys[0].Name != "dt" {
+		t.Errorf("Unexpected partition keys: %+v", retrieved.PartitionKeys)
+	}
+	if retrieved.Bucketing == nil || retrieved.Bucketing.Buckets != 32 || retrieved.Bucketing.Columns[0] != "id" {
+		t.Errorf("Unexpected bucketing: %+v", retrieved.Bucketing)
+	}
+	if retrieved.StorageFormat != "ORC" || retrieved.Location != table.Location || retrieved.TableType != models.TableTypeExternal {
+		t.Errorf("Unexpected storage metadata: %s %s %s", retrieved.StorageFormat, retrieved.Location, retrieved.TableType)
+	}
+	if len(retrieved.Relationships) != 1 || retrieved.Relationships[0].ToTable != "users" || retrieved.Relationships[0].Cardinality != models.OneToOne {
+		t.Errorf("Unexpected relationships: %+v", retrieved.Relationships)
+	}
+	if retrieved.Columns[1].Sensitivity != models.SensitivityPII || retrieved.Columns[0].Sensitivity != "" {
+		t.Errorf("Unexpected column sensitivity: %+v", retrieved.Columns)
+	}
+
+	// Clearing the metadata on update removes it
+	table.PartitionKeys = nil
+	table.Bucketing = nil
+	table.StorageFormat = ""
+	if err := store.UpdateTable(ctx, table.Name, table); err != nil {
+		t.Fatalf("Failed to update table: %v", err)
+	}
+	retrieved, err = store.GetTableByName(ctx, table.Name)
+	if err != nil {
+		t.Fatalf("Failed to get table: %v", err)
+	}
+	if len(retrieved.PartitionKeys) != 0 || retrieved.Bucketing != nil || retrieved.StorageFormat != "" {
+		t.Errorf("Expected cleared metadata, got %+v", retrieved)
+	}
+}
+
 func TestMySQLStore_UpdateTable(t *testing.T) {
 	store := getTestMySQLStore(t)
+	ctx := context.Background()
 	defer store.DB.Close()
 	
 	table := createTestTable()
 	
 	// Create the table
-	err := store.CreateTable(table)
+	err := store.CreateTable(ctx, table)
 	if err != nil {
 		t.Fatalf("Failed to create table: %v", err)
 	}
@@ -180,13 +241,13 @@ func TestMySQLStore_UpdateTable(t *testing.T) {
 		IsRequired:  false,
 	})
 	
-	err = store.UpdateTable(table.Name, table)
+	err = store.UpdateTable(ctx, table.Name, table)
 	if err != nil {
 		t.Fatalf("Failed to update table: %v", err)
 	}
 	
 	// Verify the update
-	updated, err := store.GetTableByName(table.Name)
+	updated, err := store.GetTableByName(ctx, table.Name)
 	if err != nil {
 		t.Fatalf("Failed to get updated table: %v", err)
 	}
@@ -206,7 +267,7 @@ func TestMySQLStore_UpdateTable(t *testing.T) {
 	
 	// 测试更新不存在的表
 	table.Name = "non_existent_table"
-	err = store.UpdateTable(table.Name, table)
+	err = store.UpdateTable(ctx, table.Name, table)
 	if err == nil {
 		t.Error("Expected error when updating non-existent table, got nil")
 	}
@@ -214,30 +275,31 @@ func TestMySQLStore_UpdateTable(t *testing.T) {
 
 func TestMySQLStore_DeleteTable(t *testing.T) {
 	store := getTestMySQLStore(t)
+	ctx := context.Background()
 	defer store.DB.Close()
 	
 	table := createTestTable()
 	
 	// Create the table
-	err := store.CreateTable(table)
+	err := store.CreateTable(ctx, table)
 	if err != nil {
 		t.Fatalf("Failed to create table: %v", err)
 	}
 	
 	// Delete the table
-	err = store.DeleteTable(table.Name)
+	err = store.DeleteTable(ctx, table.Name)
 	if err != nil {
 		t.Fatalf("Failed to delete table: %v", err)
 	}
 	
 	// Table should no longer exist
-	_, err = store.GetTableByName(table.Name)
+	_, err = store.GetTableByName(ctx, table.Name)
 	if err == nil {
 		t.Fatalf("Expected error when getting deleted table, got nil")
 	}
 	
 	// 测试删除不存在的表
-	err = store.DeleteTable("non_existent_table")
+	err = store.DeleteTable(ctx, "non_existent_table")
 	if err == nil {
 		t.Error("Expected error when deleting non-existent table, got nil")
 	}
@@ -245,13 +307,14 @@ func TestMySQLStore_DeleteTable(t *testing.T) {
 
 func TestMySQLStore_SearchTables(t *testing.T) {
 	store := getTestMySQLStore(t)
+	ctx := context.Background()
 	defer store.DB.Close()
 	
 	// Create test tables
 	table1 := createTestTable()
 	table1.Name = "users_table"
 	table1.Description = "Table containing user information"
-	err := store.CreateTable(table1)
+	err := store.CreateTable(ctx, table1)
 	if err != nil {
 		t.Fatalf("Failed to create table1: %v", err)
 	}
@@ -259,13 +322,13 @@ func TestMySQLStore_SearchTables(t *testing.T) {
 	table2 := createTestTable()
 	table2.Name = "orders_table"
 	table2.Description = "Table containing order information"
-	err = store.CreateTable(table2)
+	err = store.CreateTable(ctx, table2)
 	if err != nil {
 		t.Fatalf("Failed to create table2: %v", err)
 	}
 	
 	// Search for tables
-	results, err := store.SearchTables("user", 10, 0)
+	results, err := store.SearchTables(ctx, "user", 10, 0)
 	if err != nil {
 		t.Fatalf("Failed to search tables: %v", err)
 	}
@@ -284,7 +347,7 @@ func TestMySQLStore_SearchTables(t *testing.T) {
 	}
 	
 	// 测试搜索限制
-	results, err = store.SearchTables("table", 1, 0)
+	results, err = store.SearchTables(ctx, "table", 1, 0)
 	if err != nil {
 		t.Fatalf("Failed to search tables with limit: %v", err)
 	}
@@ -294,7 +357,7 @@ func TestMySQLStore_SearchTables(t *testing.T) {
 	}
 	
 	// 测试搜索偏移
-	results, err = store.SearchTables("table", 10, 1)
+	results, err = store.SearchTables(ctx, "table", 10, 1)
 	if err != nil {
 		t.Fatalf("Failed to search tables with offset: %v", err)
 	}
@@ -307,12 +370,13 @@ func TestMySQLStore_SearchTables(t *testing.T) {
 
 func TestMySQLStore_ListTables(t *testing.T) {
 	store := getTestMySQLStore(t)
+	ctx := context.Background()
 	defer store.DB.Close()
 	
 	// Create test tables
 	table1 := createTestTable()
 	table1.Name = "first_table"
-	err := store.CreateTable(table1)
+	err := store.CreateTable(ctx, table1)
 	if err != nil {
 		t.Fatalf("Failed to create table1: %v", err)
 	}
@@ -321,13 +385,13 @@ func TestMySQLStore_ListTables(t *testing.T) {
 	
 	table2 := createTestTable()
 	table2.Name = "second_table"
-	err = store.CreateTable(table2)
+	err = store.CreateTable(ctx, table2)
 	if err != nil {
 		t.Fatalf("Failed to create table2: %v", err)
 	}
 	
 	// List tables
-	results, err := store.ListTables(10, 0)
+	results, err := store.ListTables(ctx, 10, 0)
 	if err != nil {
 		t.Fatalf("Failed to list tables: %v", err)
 	}
@@ -337,7 +401,7 @@ func TestMySQLStore_ListTables(t *testing.T) {
 	}
 	
 	// 测试限制
-	results, err = store.ListTables(1, 0)
+	results, err = store.ListTables(ctx, 1, 0)
 	if err != nil {
 		t.Fatalf("Failed to list tables with limit: %v", err)
 	}
@@ -347,7 +411,7 @@ func TestMySQLStore_ListTables(t *testing.T) {
 	}
 	
 	// 测试偏移
-	results, err = store.ListTables(10, 1)
+	results, err = store.ListTables(ctx, 10, 1)
 	if err != nil {
 		t.Fatalf("Failed to list tables with offset: %v", err)
 	}
@@ -360,17 +424,18 @@ func TestMySQLStore_ListTables(t *testing.T) {
 
 func TestMySQLStore_CreateQuery(t *testing.T) {
 	store := getTestMySQLStore(t)
+	ctx := context.Background()
 	defer store.DB.Close()
 	
 	query := createTestQuery()
 	
-	err := store.CreateQuery(query)
+	err := store.CreateQuery(ctx, query)
 	if err != nil {
 		t.Fatalf("Failed to create query: %v", err)
 	}
 	
 	// Verify the query was created
-	retrieved, err := store.GetQueryByID(query.ID)
+	retrieved, err := store.GetQueryByID(ctx, query.ID)
 	if err != nil {
 		t.Fatalf("Failed to get query: %v", err)
 	}
@@ -391,24 +456,25 @@ func TestMySQLStore_CreateQuery(t *testing.T) {
 
 func TestMySQLStore_GetQueryByID(t *testing.T) {
 	store := getTestMySQLStore(t)
+	ctx := context.Background()
 	defer store.DB.Close()
 	
 	query := createTestQuery()
 	
 	// Query should not exist initially
-	_, err := store.GetQueryByID(query.ID)
+	_, err := store.GetQueryByID(ctx, query.ID)
 	if err == nil {
 		t.Fatalf("Expected error when getting non-existent query, got nil")
 	}
 	
 	// Create the query
-	err = store.CreateQuery(query)
+	err = store.CreateQuery(ctx, query)
 	if err != nil {
 		t.Fatalf("Failed to create query: %v", err)
 	}
 	
 	// Now it should exist
-	retrieved, err := store.GetQueryByID(query.ID)
+	retrieved, err := store.GetQueryByID(ctx, query.ID)
 	if err != nil {
 		t.Fatalf("Failed to get query: %v", err)
 	}
@@ -418,7 +484,7 @@ func TestMySQLStore_GetQueryByID(t *testing.T) {
 	}
 	
 	// 测试获取空ID
-	_, err = store.GetQueryByID("")
+	_, err = store.GetQueryByID(ctx, "")
 	if err == nil {
 		t.Error("Expected error when getting query with empty ID, got nil")
 	}
@@ -426,12 +492,13 @@ func TestMySQLStore_GetQueryByID(t *testing.T) {
 
 func TestMySQLStore_ListQueries(t *testing.T) {
 	store := getTestMySQLStore(t)
+	ctx := context.Background()
 	defer store.DB.Close()
 	
 	// Create test queries
 	query1 := createTestQuery()
 	query1.Description = "First test query"
-	err := store.CreateQuery(query1)
+	err := store.CreateQuery(ctx, query1)
 	if err != nil {
 		t.Fatalf("Failed to create query1: %v", err)
 	}
@@ -440,13 +507,13 @@ func TestMySQLStore_ListQueries(t *testing.T) {
 	
 	query2 := createTestQuery()
 	query2.Description = "Second test query"
-	err = store.CreateQuery(query2)
+	err = store.CreateQuery(ctx, query2)
 	if err != nil {
 		t.Fatalf("Failed to create query2: %v", err)
 	}
 	
 	// List queries
-	results, err := store.ListQueries(10, 0)
+	results, err := store.ListQueries(ctx, 10, 0)
 	if err != nil {
 		t.Fatalf("Failed to list queries: %v", err)
 	}
@@ -456,7 +523,7 @@ func TestMySQLStore_ListQueries(t *testing.T) {
 	}
 	
 	// 测试限制
-	results, err = store.ListQueries(1, 0)
+	results, err = store.ListQueries(ctx, 1, 0)
 	if err != nil {
 		t.Fatalf("Failed to list queries with limit: %v", err)
 	}
@@ -466,7 +533,7 @@ func TestMySQLStore_ListQueries(t *testing.T) {
 	}
 	
 	// 测试偏移
-	results, err = store.ListQueries(10, 1)
+	results, err = store.ListQueries(ctx, 10, 1)
 	if err != nil {
 		t.Fatalf("Failed to list queries with offset: %v", err)
 	}
@@ -478,26 +545,210 @@ func TestMySQLStore_ListQueries(t *testing.T) {
 }
 
 // TestEdgeCases 测试边界情况和错误处理
+func TestMySQLStore_UpdateQueryExecution(t *testing.T) {
+	store := getTestMySQLStore(t)
+	ctx := context.Background()
+	defer store.DB.Close()
+
+	execution := &models.QueryExecution{Datasource: "local", Status: models.ExecutionSucceeded, DurationMs: 12, RowCount: 3}
+	if err := store.UpdateQueryExecution(ctx, "missing", execution); !errors.Is(err, ErrQueryNotFound) {
+		t.Errorf("Expected ErrQueryNotFound, got %v", err)
+	}
+
+	query := createTestQuery()
+	if err := store.CreateQuery(ctx, query); err != nil {
+		t.Fatalf("Failed to create query: %v", err)
+	}
+	if err := store.UpdateQueryExecution(ctx, query.ID, execution); err != nil {
+		t.Fatalf("Failed to update execution: %v", err)
+	}
+
+	retrieved, err := store.GetQueryByID(ctx, query.ID)
+	if err != nil {
+		t.Fatalf("Failed to get query: %v", err)
+	}
+	if retrieved.Execution == nil || retrieved.Execution.Status != models.ExecutionSucceeded || retrieved.Execution.RowCount != 3 {
+		t.Errorf("Unexpected execution: %+v", retrieved.Execution)
+	}
+}
+
+func TestMySQLStore_UpdateQueryPlan(t *testing.T) {
+	store := getTestMySQLStore(t)
+	ctx := context.Background()
+	defer store.DB.Close()
+
+	query := createTestQuery()
+	if err := store.CreateQuery(ctx, query); err != nil {
+		t.Fatalf("Failed to create query: %v", err)
+	}
+
+	plan := &models.QueryPlan{
+		Datasource: "local",
+		Steps:      []models.PlanStep{{ID: 1, Table: "users", Access: models.AccessFullScan, EstimatedRows: 1000}},
+		Warnings:   []models.PlanWarning{{Code: models.PlanWarningFullScan, Table: "users", Message: "full scan of users"}},
+	}
+	if err := store.UpdateQueryPlan(ctx, query.ID, plan); err != nil {
+		t.Fatalf("Failed to update plan: %v", err)
+	}
+
+	retrieved, err := store.GetQueryByID(ctx, query.ID)
+	if err != nil {
+		t.Fatalf("Failed to get query: %v", err)
+	}
+	if retrieved.Plan == nil || len(retrieved.Plan.Steps) != 1 || len(retrieved.Plan.Warnings) != 1 {
+		t.Errorf("Unexpected plan: %+v", retrieved.Plan)
+	}
+}
+
+func TestMySQLStore_QueryFeedback(t *testing.T) {
+	store := getTestMySQLStore(t)
+	ctx := context.Background()
+	defer store.DB.Close()
+
+	feedback := &models.QueryFeedback{Correct: true, Comment: "matches the report", UpdatedAt: time.Now()}
+	if err := store.UpdateQueryFeedback(ctx, "missing", feedback); !errors.Is(err, ErrQueryNotFound) {
+		t.Errorf("Expected ErrQueryNotFound, got %v", err)
+	}
+
+	correct, wrong := createTestQuery(), createTestQuery()
+	for _, query := range []*models.Query{correct, wrong} {
+		if err := store.CreateQuery(ctx, query); err != nil {
+			t.Fatalf("Failed to create query: %v", err)
+		}
+	}
+	if err := store.UpdateQueryFeedback(ctx, correct.ID, feedback); err != nil {
+		t.Fatalf("Failed to update feedback: %v", err)
+	}
+	if err := store.UpdateQueryFeedback(ctx, wrong.ID, &models.QueryFeedback{Correct: false, UpdatedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to update feedback: %v", err)
+	}
+
+	retrieved, err := store.GetQueryByID(ctx, correct.ID)
+	if err != nil {
+		t.Fatalf("Failed to get query: %v", err)
+	}
+	if retrieved.Feedback == nil || !retrieved.Feedback.Correct || retrieved.Feedback.Comment != feedback.Comment {
+		t.Errorf("Unexpected feedback: %+v", retrieved.Feedback)
+	}
+
+	// Query vectors are listed with the correctness of their queries
+	for _, query := range []*models.Query{correct, wrong} {
+		if err := store.SaveQueryVector(ctx, &QueryVector{QueryID: query.ID, Model: "test", Vector: []float32{1, 0}}); err != nil {
+			t.Fatalf("Failed to save query vector: %v", err)
+		}
+	}
+	vectors, err := store.ListQueryVectors(ctx, "test")
+	if err != nil {
+		t.Fatalf("Failed to list query vectors: %v", err)
+	}
+	if len(vectors) != 2 {
+		t.Fatalf("Expected 2 query vectors, got %d", len(vectors))
+	}
+	for _, vector := range vectors {
+		if vector.Correct != (vector.QueryID == correct.ID) || len(vector.Vector) != 2 {
+			t.Errorf("Unexpected query vector %+v", vector)
+		}
+	}
+}
+
+func TestMySQLStore_PromptTemplates(t *testing.T) {
+	store := getTestMySQLStore(t)
+	ctx := context.Background()
+	defer store.DB.Close()
+
+	first := &models.PromptTemplate{Name: "hive_short", Dialect: "hive", Body: "{{.Description}}"}
+	second := &models.PromptTemplate{Name: "hive_short", Dialect: "hive", Body: "{{.Description}} {{.Schema}}"}
+	other := &models.PromptTemplate{Name: "hive_long", Dialect: "hive", Body: "{{.Schema}}"}
+	for _, template := range []*models.PromptTemplate{first, second, other} {
+		if err := store.CreatePromptTemplate(ctx, template); err != nil {
+			t.Fatalf("Failed to create prompt template: %v", err)
+		}
+	}
+	if first.Version != 1 || second.Version != 2 || other.Version != 1 {
+		t.Fatalf("Unexpected versions %d, %d, %d", first.Version, second.Version, other.Version)
+	}
+
+	if err := store.ActivatePromptTemplate(ctx, "hive_long", 1); err != nil {
+		t.Fatalf("Failed to activate prompt template: %v", err)
+	}
+	if err := store.ActivatePromptTemplate(ctx, "hive_short", 2); err != nil {
+		t.Fatalf("Failed to activate prompt template: %v", err)
+	}
+
+	templates, err := store.ListPromptTemplates(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list prompt templates: %v", err)
+	}
+	var active []string
+	for _, template := range templates {
+		if template.Active {
+			active = append(active, fmt.Sprintf("%s v%d", template.Name, template.Version))
+		}
+	}
+	if len(templates) != 3 || len(active) != 1 || active[0] != "hive_short v2" {
+		t.Errorf("Unexpected templates %v, active %v", len(templates), active)
+	}
+
+	retrieved, err := store.GetPromptTemplate(ctx, "hive_short", 2)
+	if err != nil {
+		t.Fatalf("Failed to get prompt template: %v", err)
+	}
+	if retrieved.Body != second.Body || retrieved.Source != models.TemplateSourceStore {
+		t.Errorf("Unexpected prompt template %+v", retrieved)
+	}
+
+	versions, err := store.ListPromptTemplateVersions(ctx, "hive_short")
+	if err != nil {
+		t.Fatalf("Failed to list prompt template versions: %v", err)
+	}
+	if len(versions) != 2 || versions[0].Version != 1 || versions[1].Version != 2 {
+		t.Errorf("Unexpected prompt template versions %+v", versions)
+	}
+
+	activeTemplates, err := store.ListActivePromptTemplates(ctx, "acme", "hive", "en")
+	if err != nil {
+		t.Fatalf("Failed to list active prompt templates: %v", err)
+	}
+	if len(activeTemplates) != 1 || activeTemplates[0].Name != "hive_short" {
+		t.Errorf("Unexpected active prompt templates %+v", activeTemplates)
+	}
+	activeTemplates, err = store.ListActivePromptTemplates(ctx, "acme", "mysql", "en")
+	if err != nil {
+		t.Fatalf("Failed to list active prompt templates: %v", err)
+	}
+	if len(activeTemplates) != 0 {
+		t.Errorf("Expected no active prompt templates for mysql, got %+v", activeTemplates)
+	}
+
+	if _, err := store.GetPromptTemplate(ctx, "hive_short", 3); !errors.Is(err, ErrPromptTemplateNotFound) {
+		t.Errorf("Expected ErrPromptTemplateNotFound, got %v", err)
+	}
+	if err := store.ActivatePromptTemplate(ctx, "missing", 1); !errors.Is(err, ErrPromptTemplateNotFound) {
+		t.Errorf("Expected ErrPromptTemplateNotFound, got %v", err)
+	}
+}
+
 func TestEdgeCases(t *testing.T) {
 	store := getTestMySQLStore(t)
+	ctx := context.Background()
 	defer store.DB.Close()
 	
 	// 测试带有特殊字符的表名
 	table := createTestTable()
 	table.Name = "test-table_with.special/chars"
-	err := store.CreateTable(table)
+	err := store.CreateTable(ctx, table)
 	if err != nil {
 		t.Fatalf("Failed to create table with special chars: %v", err)
 	}
 	
 	// 验证可以检索它
-	_, err = store.GetTableByName(table.Name)
+	_, err = store.GetTableByName(ctx, table.Name)
 	if err != nil {
 		t.Fatalf("Failed to get table with special chars: %v", err)
 	}
 	
 	// 测试大偏移量（应该返回空结果而不是错误）
-	results, err := store.ListTables(10, 1000)
+	results, err := store.ListTables(ctx, 10, 1000)
 	if err != nil {
 		t.Fatalf("Failed to list tables with large offset: %v", err)
 	}
@@ -507,7 +758,7 @@ func TestEdgeCases(t *testing.T) {
 	}
 	
 	// 测试负限制（应该使用默认值）
-	results, err = store.ListTables(-1, 0)
+	results, err = store.ListTables(ctx, -1, 0)
 	if err != nil {
 		t.Fatalf("Failed to list tables with negative limit: %v", err)
 	}
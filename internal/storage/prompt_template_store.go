@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"sql_generator/internal/models"
+)
+
+// promptTemplateColumns lists the columns selected for a models.PromptTemplate, in scan order
+const promptTemplateColumns = "name, version, dialect, tenant, language, description, body, active, created_at"
+
+// CreatePromptTemplate saves template as the next version of its name. The
+// new version is inactive; template.Version and CreatedAt are set.
+func (s *MySQLStore) CreatePromptTemplate(ctx context.Context, template *models.PromptTemplate) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var latest int
+	err = tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM prompt_templates WHERE name = ? FOR UPDATE`, template.Name).Scan(&latest)
+	if err != nil {
+		return fmt.Errorf("failed to find latest prompt template version: %w", err)
+	}
+
+	template.Version = latest + 1
+	template.Active = false
+	template.CreatedAt = time.Now()
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO prompt_templates (name, version, dialect, tenant, language, description, body, active, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, template.Name, template.Version, template.Dialect, template.Tenant, template.Language, template.Description, template.Body, template.Active, template.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert prompt template: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit prompt template: %w", err)
+	}
+	return nil
+}
+
+// GetPromptTemplate retrieves a version of a prompt template
+func (s *MySQLStore) GetPromptTemplate(ctx context.Context, name string, version int) (*models.PromptTemplate, error) {
+	row := s.DB.QueryRowContext(ctx, `SELECT `+promptTemplateColumns+` FROM prompt_templates WHERE name = ? AND version = ?`, name, version)
+	template, err := scanPromptTemplate(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: %s version %d", ErrPromptTemplateNotFound, name, version)
+		}
+		return nil, fmt.Errorf("failed to find prompt template: %w", err)
+	}
+	return template, nil
+}
+
+// ListPromptTemplates returns all versions of all prompt templates, ordered
+// by name and version
+func (s *MySQLStore) ListPromptTemplates(ctx context.Context) ([]*models.PromptTemplate, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT `+promptTemplateColumns+` FROM prompt_templates ORDER BY name, version`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompt templates: %w", err)
+	}
+	return scanPromptTemplates(rows)
+}
+
+// ListActivePromptTemplates returns the active templates that apply to a
+// tenant, dialect and language: those whose tenant, dialect and language
+// are each the given one or empty
+func (s *MySQLStore) ListActivePromptTemplates(ctx context.Context, tenant, dialect, language string) ([]*models.PromptTemplate, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT `+promptTemplateColumns+` FROM prompt_templates
+		WHERE active AND tenant IN (?, '') AND dialect IN (?, '') AND language IN (?, '')
+	`, tenant, dialect, language)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active prompt templates: %w", err)
+	}
+	return scanPromptTemplates(rows)
+}
+
+// ListPromptTemplateVersions returns all versions of the named template,
+// ordered by version
+func (s *MySQLStore) ListPromptTemplateVersions(ctx context.Context, name string) ([]*models.PromptTemplate, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT `+promptTemplateColumns+` FROM prompt_templates WHERE name = ? ORDER BY version`, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompt template versions: %w", err)
+	}
+	return scanPromptTemplates(rows)
+}
+
+// ActivatePromptTemplate makes a version the active template of its tenant,
+// dialect and language. The templates previously active for them and the
+// other versions of the name are deactivated.
+func (s *MySQLStore) ActivatePromptTemplate(ctx context.Context, name string, version int) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var dialect, tenant, language string
+	err = tx.QueryRowContext(ctx, `SELECT dialect, tenant, language FROM prompt_templates WHERE name = ? AND version = ? FOR UPDATE`, name, version).Scan(&dialect, &tenant, &language)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%w: %s version %d", ErrPromptTemplateNotFound, name, version)
+		}
+		return fmt.Errorf("failed to find prompt template: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE prompt_templates SET active = (name = ? AND version = ?)
+		WHERE name = ? OR (dialect = ? AND tenant = ? AND language = ?)
+	`, name, version, name, dialect, tenant, language)
+	if err != nil {
+		return fmt.Errorf("failed to activate prompt template: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit prompt template activation: %w", err)
+	}
+	return nil
+}
+
+// scanPromptTemplate scans a row selected with promptTemplateColumns
+func scanPromptTemplate(row rowScanner) (*models.PromptTemplate, error) {
+	var template models.PromptTemplate
+	var description sql.NullString
+	err := row.Scan(&template.Name, &template.Version, &template.Dialect, &template.Tenant, &template.Language, &description, &template.Body, &template.Active, &template.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	template.Description = description.String
+	template.Source = models.TemplateSourceStore
+	return &template, nil
+}
+
+// scanPromptTemplates scans and closes rows selected with promptTemplateColumns
+func scanPromptTemplates(rows *sql.Rows) ([]*models.PromptTemplate, error) {
+	defer rows.Close()
+
+	var templates []*models.PromptTemplate
+	for rows.Next() {
+		template, err := scanPromptTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan prompt template: %w", err)
+		}
+		templates = append(templates, template)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	return templates, nil
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"fmt"
 
 	"sql_generator/internal/models"
@@ -8,15 +9,21 @@ import (
 
 // VectorStore 定义向量存储接口
 type VectorStore interface {
-	IndexTableStructure(table *models.Table, vector []float32) error
-	SearchSimilarTables(queryVector []float32, topK int) ([]*models.Table, error)
-	DeleteTableVectors(tableName string) error
+	IndexTableStructure(ctx context.Context, table *models.Table, vector []float32) error
+	SearchSimilarTables(ctx context.Context, queryVector []float32, topK int) ([]*models.Table, error)
+	DeleteTableVectors(ctx context.Context, tableName string) error
 }
 
 // EmbeddingService 定义嵌入服务接口
 type EmbeddingService interface {
-	GenerateEmbedding(text string) ([]float32, error)
-	GenerateTableEmbedding(table *models.Table) ([]float32, error)
+	GenerateEmbedding(ctx context.Context, text string) ([]float32, error)
+	GenerateTableEmbedding(ctx context.Context, table *models.Table) ([]float32, error)
+}
+
+// QueryIndex 定义历史查询描述的向量索引接口
+type QueryIndex interface {
+	IndexQuery(ctx context.Context, query *models.Query, vector []float32) error
+	SetCorrect(id string, correct bool)
 }
 
 // RAGEnhancedStore 结合RAG功能的存储实现
@@ -24,27 +31,30 @@ type RAGEnhancedStore struct {
 	Store        // 修改这里，使用通用的Store接口而不是*MongoStore
 	embeddingSvc EmbeddingService
 	vectorStore  VectorStore
+	// queryIndex 索引保存的查询描述，为nil时不索引查询
+	queryIndex QueryIndex
 }
 
-// NewRAGEnhancedStore 创建支持RAG的存储实例
-func NewRAGEnhancedStore(store Store, embeddingSvc EmbeddingService, vectorStore VectorStore) Store {
+// NewRAGEnhancedStore 创建支持RAG的存储实例，queryIndex为nil时不索引查询
+func NewRAGEnhancedStore(store Store, embeddingSvc EmbeddingService, vectorStore VectorStore, queryIndex QueryIndex) Store {
 	return &RAGEnhancedStore{
 		Store:        store,
 		embeddingSvc: embeddingSvc,
 		vectorStore:  vectorStore,
+		queryIndex:   queryIndex,
 	}
 }
 
 // CreateTable 保存表定义并创建向量索引
-func (r *RAGEnhancedStore) CreateTable(table *models.Table) error {
+func (r *RAGEnhancedStore) CreateTable(ctx context.Context, table *models.Table) error {
 	// 首先保存到存储中
-	err := r.Store.CreateTable(table)
+	err := r.Store.CreateTable(ctx, table)
 	if err != nil {
 		return fmt.Errorf("failed to create table in storage: %w", err)
 	}
 
 	// 生成并向量数据库中索引表结构
-	err = r.indexTableForRAG(table)
+	err = r.indexTableForRAG(ctx, table)
 	if err != nil {
 		// 如果向量索引失败，记录日志但不中断操作
 		fmt.Printf("Warning: Failed to index table for RAG: %v\n", err)
@@ -54,21 +64,21 @@ func (r *RAGEnhancedStore) CreateTable(table *models.Table) error {
 }
 
 // UpdateTable 更新表并更新向量索引
-func (r *RAGEnhancedStore) UpdateTable(name string, table *models.Table) error {
+func (r *RAGEnhancedStore) UpdateTable(ctx context.Context, name string, table *models.Table) error {
 	// 更新存储中的表
-	err := r.Store.UpdateTable(name, table)
+	err := r.Store.UpdateTable(ctx, name, table)
 	if err != nil {
 		return fmt.Errorf("failed to update table in storage: %w", err)
 	}
 
 	// 删除旧的向量索引
-	err = r.vectorStore.DeleteTableVectors(name)
+	err = r.vectorStore.DeleteTableVectors(ctx, name)
 	if err != nil {
 		fmt.Printf("Warning: Failed to delete old vector index: %v\n", err)
 	}
 
 	// 创建新的向量索引
-	err = r.indexTableForRAG(table)
+	err = r.indexTableForRAG(ctx, table)
 	if err != nil {
 		fmt.Printf("Warning: Failed to reindex table for RAG: %v\n", err)
 	}
@@ -77,15 +87,15 @@ func (r *RAGEnhancedStore) UpdateTable(name string, table *models.Table) error {
 }
 
 // DeleteTable 删除表并删除向量索引
-func (r *RAGEnhancedStore) DeleteTable(name string) error {
+func (r *RAGEnhancedStore) DeleteTable(ctx context.Context, name string) error {
 	// 从存储中删除表
-	err := r.Store.DeleteTable(name)
+	err := r.Store.DeleteTable(ctx, name)
 	if err != nil {
 		return fmt.Errorf("failed to delete table from storage: %w", err)
 	}
 
 	// 删除向量索引
-	err = r.vectorStore.DeleteTableVectors(name)
+	err = r.vectorStore.DeleteTableVectors(ctx, name)
 	if err != nil {
 		fmt.Printf("Warning: Failed to delete vector index: %v\n", err)
 	}
@@ -94,15 +104,15 @@ func (r *RAGEnhancedStore) DeleteTable(name string) error {
 }
 
 // indexTableForRAG 为表创建向量索引
-func (r *RAGEnhancedStore) indexTableForRAG(table *models.Table) error {
+func (r *RAGEnhancedStore) indexTableForRAG(ctx context.Context, table *models.Table) error {
 	// 生成表结构的向量表示
-	vector, err := r.embeddingSvc.GenerateTableEmbedding(table)
+	vector, err := r.embeddingSvc.GenerateTableEmbedding(ctx, table)
 	if err != nil {
 		return fmt.Errorf("failed to generate table embedding: %w", err)
 	}
 
 	// 索引到向量数据库
-	err = r.vectorStore.IndexTableStructure(table, vector)
+	err = r.vectorStore.IndexTableStructure(ctx, table, vector)
 	if err != nil {
 		return fmt.Errorf("failed to index table structure: %w", err)
 	}
@@ -111,30 +121,68 @@ func (r *RAGEnhancedStore) indexTableForRAG(table *models.Table) error {
 }
 
 // SearchTables 搜索表（结合传统搜索和RAG向量搜索）
-func (r *RAGEnhancedStore) SearchTables(keyword string, limit, offset int) ([]*models.Table, error) {
+func (r *RAGEnhancedStore) SearchTables(ctx context.Context, keyword string, limit, offset int) ([]*models.Table, error) {
 	// 优先尝试使用RAG向量搜索
-	tables, err := r.searchTablesByEmbedding(keyword, limit)
+	tables, err := r.searchTablesByEmbedding(ctx, keyword, limit)
 	if err == nil && len(tables) > 0 {
 		return tables, nil
 	}
 
 	// 如果RAG搜索失败或未找到结果，回退到传统的文本搜索
-	return r.Store.SearchTables(keyword, limit, offset)
+	return r.Store.SearchTables(ctx, keyword, limit, offset)
 }
 
 // searchTablesByEmbedding 使用嵌入向量搜索表
-func (r *RAGEnhancedStore) searchTablesByEmbedding(keyword string, limit int) ([]*models.Table, error) {
+func (r *RAGEnhancedStore) searchTablesByEmbedding(ctx context.Context, keyword string, limit int) ([]*models.Table, error) {
 	// 生成查询关键词的向量表示
-	queryVector, err := r.embeddingSvc.GenerateEmbedding(keyword)
+	queryVector, err := r.embeddingSvc.GenerateEmbedding(ctx, keyword)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
 	}
 
 	// 在向量数据库中搜索
-	tables, err := r.vectorStore.SearchSimilarTables(queryVector, limit)
+	tables, err := r.vectorStore.SearchSimilarTables(ctx, queryVector, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search by embedding: %w", err)
 	}
 
 	return tables, nil
 }
+
+// CreateQuery 保存查询并为其描述创建向量索引
+func (r *RAGEnhancedStore) CreateQuery(ctx context.Context, query *models.Query) error {
+	err := r.Store.CreateQuery(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to create query in storage: %w", err)
+	}
+
+	if r.queryIndex == nil {
+		return nil
+	}
+
+	// 查询已保存，索引失败只影响相似问题的检索
+	vector, err := r.embeddingSvc.GenerateEmbedding(ctx, query.Description)
+	if err != nil {
+		fmt.Printf("Warning: Failed to generate query embedding: %v\n", err)
+		return nil
+	}
+	if err := r.queryIndex.IndexQuery(ctx, query, vector); err != nil {
+		fmt.Printf("Warning: Failed to index query: %v\n", err)
+	}
+
+	return nil
+}
+
+// UpdateQueryFeedback 记录查询的反馈，并在索引中更新查询是否正确
+func (r *RAGEnhancedStore) UpdateQueryFeedback(ctx context.Context, id string, feedback *models.QueryFeedback) error {
+	err := r.Store.UpdateQueryFeedback(ctx, id, feedback)
+	if err != nil {
+		return err
+	}
+
+	if r.queryIndex != nil {
+		r.queryIndex.SetCorrect(id, feedback.Correct)
+	}
+
+	return nil
+}
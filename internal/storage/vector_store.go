@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// TableVector represents a persisted embedding of a table definition
+type TableVector struct {
+	TableName   string
+	Model       string
+	Dimension   int
+	Vector      []float32
+	ContentHash string
+	UpdatedAt   time.Time
+}
+
+// SaveTableVector inserts or replaces the embedding of a table
+func (s *MySQLStore) SaveTableVector(ctx context.Context, vector *TableVector) error {
+	vector.UpdatedAt = time.Now()
+
+	_, err := s.DB.ExecContext(ctx, `
+		INSERT INTO table_vectors (table_name, model, dimension, vector, content_hash, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			model = VALUES(model),
+			dimension = VALUES(dimension),
+			vector = VALUES(vector),
+			content_hash = VALUES(content_hash),
+			updated_at = VALUES(updated_at)
+	`, vector.TableName, vector.Model, len(vector.Vector), encodeVector(vector.Vector), vector.ContentHash, vector.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to save table vector: %w", err)
+	}
+
+	return nil
+}
+
+// ListTableVectors returns all embeddings generated by the given model
+func (s *MySQLStore) ListTableVectors(ctx context.Context, model string) ([]*TableVector, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT table_name, model, dimension, vector, content_hash, updated_at
+		FROM table_vectors
+		WHERE model = ?
+	`, model)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list table vectors: %w", err)
+	}
+	defer rows.Close()
+
+	var vectors []*TableVector
+	for rows.Next() {
+		var vector TableVector
+		var blob []byte
+
+		err := rows.Scan(&vector.TableName, &vector.Model, &vector.Dimension, &blob, &vector.ContentHash, &vector.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan table vector: %w", err)
+		}
+
+		vector.Vector, err = decodeVector(blob, vector.Dimension)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode vector of table %s: %w", vector.TableName, err)
+		}
+
+		vectors = append(vectors, &vector)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	return vectors, nil
+}
+
+// DeleteTableVector removes the embedding of a table
+func (s *MySQLStore) DeleteTableVector(ctx context.Context, tableName string) error {
+	_, err := s.DB.ExecContext(ctx, `DELETE FROM table_vectors WHERE table_name = ?`, tableName)
+	if err != nil {
+		return fmt.Errorf("failed to delete table vector: %w", err)
+	}
+
+	return nil
+}
+
+// QueryVector represents a persisted embedding of a query description.
+// Dialect and Correct are read from the query when vectors are listed.
+type QueryVector struct {
+	QueryID   string
+	Model     string
+	Dimension int
+	Vector    []float32
+	Dialect   string
+	Correct   bool
+	UpdatedAt time.Time
+}
+
+// SaveQueryVector inserts or replaces the embedding of a query description
+func (s *MySQLStore) SaveQueryVector(ctx context.Context, vector *QueryVector) error {
+	vector.UpdatedAt = time.Now()
+
+	_, err := s.DB.ExecContext(ctx, `
+		INSERT INTO query_vectors (query_id, model, dimension, vector, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			model = VALUES(model),
+			dimension = VALUES(dimension),
+			vector = VALUES(vector),
+			updated_at = VALUES(updated_at)
+	`, vector.QueryID, vector.Model, len(vector.Vector), encodeVector(vector.Vector), vector.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to save query vector: %w", err)
+	}
+
+	return nil
+}
+
+// ListQueryVectors returns all query embeddings generated by the given
+// model with the dialect and correctness of their queries
+func (s *MySQLStore) ListQueryVectors(ctx context.Context, model string) ([]*QueryVector, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT v.query_id, v.model, v.dimension, v.vector, q.dialect, q.correct, v.updated_at
+		FROM query_vectors v
+		JOIN queries q ON q.id = v.query_id
+		WHERE v.model = ?
+	`, model)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list query vectors: %w", err)
+	}
+	defer rows.Close()
+
+	var vectors []*QueryVector
+	for rows.Next() {
+		var vector QueryVector
+		var blob []byte
+		var dialect sql.NullString
+
+		err := rows.Scan(&vector.QueryID, &vector.Model, &vector.Dimension, &blob, &dialect, &vector.Correct, &vector.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan query vector: %w", err)
+		}
+		vector.Dialect = dialect.String
+
+		vector.Vector, err = decodeVector(blob, vector.Dimension)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode vector of query %s: %w", vector.QueryID, err)
+		}
+
+		vectors = append(vectors, &vector)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	return vectors, nil
+}
+
+// encodeVector serializes a vector as little-endian float32 values
+func encodeVector(vector []float32) []byte {
+	buf := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return buf
+}
+
+// decodeVector deserializes a vector written by encodeVector
+func decodeVector(buf []byte, dimension int) ([]float32, error) {
+	if len(buf) != 4*dimension {
+		return nil, fmt.Errorf("expected %d bytes, got %d", 4*dimension, len(buf))
+	}
+
+	vector := make([]float32, dimension)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return vector, nil
+}
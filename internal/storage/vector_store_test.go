@@ -0,0 +1,22 @@
+package storage
+
+import "testing"
+
+func TestEncodeDecodeVector(t *testing.T) {
+	vector := []float32{0, 1.5, -2.25, 3e-8}
+
+	decoded, err := decodeVector(encodeVector(vector), len(vector))
+	if err != nil {
+		t.Fatalf("Failed to decode vector: %v", err)
+	}
+
+	for i := range vector {
+		if decoded[i] != vector[i] {
+			t.Errorf("Element %d: expected %v, got %v", i, vector[i], decoded[i])
+		}
+	}
+
+	if _, err := decodeVector(encodeVector(vector), len(vector)+1); err == nil {
+		t.Error("Expected error for mismatched dimension")
+	}
+}
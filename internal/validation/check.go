@@ -0,0 +1,481 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	"sql_generator/internal/dialect"
+	"sql_generator/internal/models"
+	"sql_generator/internal/sqlparser"
+)
+
+// checkQuery validates a query and returns its output relation
+func (c *checker) checkQuery(q *sqlparser.SelectStatement, parent *scope) *relation {
+	sc := newScope(parent)
+
+	for _, cte := range q.With {
+		key := strings.ToLower(cte.Name)
+		if cte.Recursive {
+			// A recursive CTE references itself before its columns are known
+			sc.ctes[key] = &relation{open: true}
+		}
+		rel := c.checkQuery(cte.Query, sc)
+		sc.ctes[key] = renameColumns(rel, cte.Columns)
+	}
+
+	rel, body := c.checkQueryExpr(q.Body, sc)
+
+	// ORDER BY sees the select block of a simple query, or only the output
+	// columns of a set operation
+	order := body
+	if order == nil {
+		order = newScope(sc)
+		order.sources = []*source{{rel: rel}}
+	}
+	for _, item := range q.OrderBy {
+		c.checkExpr(item.Expr, order)
+	}
+	for _, item := range q.SortBy {
+		c.checkExpr(item.Expr, order)
+	}
+	for _, expr := range q.DistributeBy {
+		c.checkExpr(expr, order)
+	}
+	for _, expr := range q.ClusterBy {
+		c.checkExpr(expr, order)
+	}
+	if len(q.SortBy) > 0 || len(q.DistributeBy) > 0 || len(q.ClusterBy) > 0 {
+		c.requireFeature(dialect.DistributeBy)
+	}
+
+	return rel
+}
+
+// checkQueryExpr validates a query body. The scope of the select block is
+// returned for simple queries so that ORDER BY can resolve against it.
+func (c *checker) checkQueryExpr(body sqlparser.QueryExpr, sc *scope) (*relation, *scope) {
+	switch b := body.(type) {
+	case *sqlparser.Select:
+		return c.checkSelect(b, sc)
+	case *sqlparser.ParenQuery:
+		return c.checkQuery(b.Query, sc), nil
+	case *sqlparser.SetOperation:
+		left, _ := c.checkQueryExpr(b.Left, sc)
+		right, _ := c.checkQueryExpr(b.Right, sc)
+		if !left.open && !right.open && len(left.columns) != len(right.columns) {
+			c.addIssue(models.ValidationIssue{
+				Code:     CodeColumnCountMismatch,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("%s operands return %d and %d columns", b.Op, len(left.columns), len(right.columns)),
+			})
+		}
+		return left, nil
+	}
+	return &relation{open: true}, nil
+}
+
+func (c *checker) checkSelect(s *sqlparser.Select, parent *scope) (*relation, *scope) {
+	// Only the select list of this block counts as selected; restore the
+	// state of an enclosing select list when this block is a subquery in it
+	selecting, bareRef := c.selecting, c.bareRef
+	c.selecting, c.bareRef = false, nil
+	defer func() { c.selecting, c.bareRef = selecting, bareRef }()
+
+	sc := newScope(parent)
+	for _, te := range s.From {
+		c.addTableExpr(te, sc)
+	}
+
+	c.checkExpr(s.Where, sc)
+
+	out := &relation{}
+	aliases := make(map[string]bool)
+	for _, item := range s.Columns {
+		if item.Star {
+			c.expandStar(item.StarTable, sc, out)
+			continue
+		}
+
+		c.selecting = true
+		c.bareRef = nil
+		if ref, ok := item.Expr.(*sqlparser.ColumnRef); ok && item.Alias == "" {
+			c.bareRef = ref
+		}
+		category := c.checkExpr(item.Expr, sc)
+		c.selecting, c.bareRef = false, nil
+		name := item.Alias
+		if name == "" {
+			if ref, ok := item.Expr.(*sqlparser.ColumnRef); ok {
+				name = ref.Parts[len(ref.Parts)-1]
+			}
+		} else {
+			aliases[strings.ToLower(name)] = true
+		}
+		out.columns = append(out.columns, column{name: name, category: category})
+	}
+	sc.aliases = aliases
+
+	for _, expr := range s.GroupBy {
+		c.checkExpr(expr, sc)
+	}
+	c.checkExpr(s.Having, sc)
+	c.checkExpr(s.Qualify, sc)
+	c.checkPartitionFilters(s, sc)
+
+	return out, sc
+}
+
+// expandStar appends the columns matched by "*" or "table.*" to out
+func (c *checker) expandStar(table string, sc *scope, out *relation) {
+	var sources []*source
+	if table == "" {
+		sources = sc.sources
+	} else if src := sc.source(table); src != nil {
+		sources = []*source{src}
+	} else {
+		c.addIssue(models.ValidationIssue{
+			Code:     CodeUnknownTable,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("unknown table or alias %q in %s.*", table, table),
+			Table:    table,
+		})
+		out.open = true
+		return
+	}
+
+	for _, src := range sources {
+		for _, col := range src.rel.columns {
+			if col.table != "" {
+				c.recordColumn(&col)
+				c.report.Selected = append(c.report.Selected, SelectedColumn{ColumnReference: col.reference()})
+			}
+			out.columns = append(out.columns, column{name: col.name, category: col.category})
+		}
+		if src.rel.open {
+			out.open = true
+		}
+	}
+}
+
+// addTableExpr adds the sources of a FROM item to sc
+func (c *checker) addTableExpr(te sqlparser.TableExpr, sc *scope) {
+	switch t := te.(type) {
+	case *sqlparser.TableName:
+		name := t.Alias
+		if name == "" {
+			name = t.Name
+		}
+		if t.Schema == "" {
+			if rel := sc.cte(t.Name); rel != nil {
+				sc.sources = append(sc.sources, &source{name: name, rel: rel})
+				return
+			}
+		}
+
+		table := c.table(t.Name)
+		if table == nil {
+			if c.err == nil {
+				c.addIssue(models.ValidationIssue{
+					Code:     CodeUnknownTable,
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("table %q does not exist", t.Name),
+					Table:    t.Name,
+				})
+			}
+			// Further references to the missing table are not reported again
+			sc.sources = append(sc.sources, &source{name: name, table: t.Name, rel: &relation{open: true}})
+			return
+		}
+		c.recordTable(table.Name)
+		sc.sources = append(sc.sources, &source{name: name, table: table.Name, rel: tableRelation(table)})
+
+	case *sqlparser.DerivedTable:
+		// Derived tables cannot see sibling FROM items
+		rel := c.checkQuery(t.Query, sc.parent)
+		sc.sources = append(sc.sources, &source{name: t.Alias, rel: renameColumns(rel, t.Columns)})
+
+	case *sqlparser.TableFunction:
+		c.checkExpr(t.Func, sc)
+		rel := &relation{open: len(t.Columns) == 0}
+		for _, name := range t.Columns {
+			rel.columns = append(rel.columns, column{name: name})
+		}
+		name := t.Alias
+		if name == "" {
+			name = t.Func.Name
+		}
+		sc.sources = append(sc.sources, &source{name: name, rel: rel})
+
+	case *sqlparser.LateralView:
+		c.requireFeature(dialect.LateralView)
+		c.addTableExpr(t.Source, sc)
+		c.checkExpr(t.Func, sc)
+		rel := &relation{open: len(t.Columns) == 0}
+		for _, name := range t.Columns {
+			rel.columns = append(rel.columns, column{name: name})
+		}
+		sc.sources = append(sc.sources, &source{name: t.Alias, rel: rel})
+
+	case *sqlparser.Join:
+		switch t.Type {
+		case "FULL":
+			c.requireFeature(dialect.FullJoin)
+		case "LEFT SEMI", "LEFT ANTI":
+			c.requireFeature(dialect.SemiJoin)
+		}
+		c.addTableExpr(t.Left, sc)
+		mid := len(sc.sources)
+		c.addTableExpr(t.Right, sc)
+
+		for _, name := range t.Using {
+			sc.using[strings.ToLower(name)] = true
+			c.resolveUnqualified(name, name, sc)
+		}
+		if t.Natural {
+			for _, right := range sc.sources[mid:] {
+				for _, col := range right.rel.columns {
+					for _, left := range sc.sources[:mid] {
+						if left.rel.find(col.name) != nil {
+							sc.using[strings.ToLower(col.name)] = true
+						}
+					}
+				}
+			}
+		}
+		c.checkExpr(t.On, sc)
+
+	case *sqlparser.ParenTableExpr:
+		for _, expr := range t.Exprs {
+			c.addTableExpr(expr, sc)
+		}
+	}
+}
+
+// renameColumns applies a column alias list such as "t(a, b)" to rel
+func renameColumns(rel *relation, names []string) *relation {
+	if len(names) == 0 {
+		return rel
+	}
+	renamed := &relation{}
+	for i, name := range names {
+		col := column{name: name}
+		if i < len(rel.columns) {
+			col.category = rel.columns[i].category
+		}
+		renamed.columns = append(renamed.columns, col)
+	}
+	return renamed
+}
+
+// checkExpr validates an expression and returns its type category
+func (c *checker) checkExpr(e sqlparser.Expr, sc *scope) string {
+	switch x := e.(type) {
+	case nil:
+		return categoryUnknown
+
+	case *sqlparser.ColumnRef:
+		col := c.resolve(x, sc)
+		if col != nil && col.table != "" && c.selecting {
+			c.report.Selected = append(c.report.Selected, SelectedColumn{
+				ColumnReference: col.reference(),
+				Ref:             x,
+				Bare:            x == c.bareRef,
+			})
+		}
+		if col != nil && len(x.Parts) <= 2 {
+			return col.category
+		}
+		return categoryUnknown
+
+	case *sqlparser.Literal:
+		return literalCategory(x)
+
+	case *sqlparser.BinaryExpr:
+		left := c.checkExpr(x.Left, sc)
+		right := c.checkExpr(x.Right, sc)
+		switch x.Op {
+		case "=", "==", "<>", "!=", "<", ">", "<=", ">=", "<=>":
+			c.checkComparison(x.Left, left, x.Right, right)
+			return categoryBoolean
+		case "+", "-":
+			if left == categoryTemporal || right == categoryTemporal {
+				return categoryTemporal
+			}
+			return categoryNumeric
+		case "*", "/", "%", "DIV", "MOD", "|", "&", "^", "<<", ">>":
+			return categoryNumeric
+		case "||":
+			return categoryUnknown
+		}
+		return categoryBoolean
+
+	case *sqlparser.UnaryExpr:
+		inner := c.checkExpr(x.Expr, sc)
+		if x.Op == "NOT" || x.Op == "!" {
+			return categoryBoolean
+		}
+		return inner
+
+	case *sqlparser.FuncCall:
+		args := make([]string, len(x.Args))
+		for i, arg := range x.Args {
+			args[i] = c.checkExpr(arg, sc)
+		}
+		for _, item := range x.OrderBy {
+			c.checkExpr(item.Expr, sc)
+		}
+		c.checkExpr(x.Filter, sc)
+		if x.Over != nil {
+			for _, expr := range x.Over.PartitionBy {
+				c.checkExpr(expr, sc)
+			}
+			for _, item := range x.Over.OrderBy {
+				c.checkExpr(item.Expr, sc)
+			}
+		}
+		c.checkAggregate(x, args)
+		return functionCategory(x.Name, args)
+
+	case *sqlparser.CaseExpr:
+		operand := c.checkExpr(x.Operand, sc)
+		result := categoryUnknown
+		for _, when := range x.Whens {
+			cond := c.checkExpr(when.Cond, sc)
+			if x.Operand != nil {
+				c.checkComparison(x.Operand, operand, when.Cond, cond)
+			}
+			if category := c.checkExpr(when.Result, sc); result == categoryUnknown {
+				result = category
+			}
+		}
+		if category := c.checkExpr(x.Else, sc); result == categoryUnknown {
+			result = category
+		}
+		return result
+
+	case *sqlparser.CastExpr:
+		c.checkExpr(x.Expr, sc)
+		return typeCategory(x.Type)
+
+	case *sqlparser.InExpr:
+		left := c.checkExpr(x.Expr, sc)
+		for _, item := range x.List {
+			c.checkComparison(x.Expr, left, item, c.checkExpr(item, sc))
+		}
+		if x.Subquery != nil {
+			c.checkQuery(x.Subquery, sc)
+		}
+		return categoryBoolean
+
+	case *sqlparser.BetweenExpr:
+		value := c.checkExpr(x.Expr, sc)
+		c.checkComparison(x.Expr, value, x.Low, c.checkExpr(x.Low, sc))
+		c.checkComparison(x.Expr, value, x.High, c.checkExpr(x.High, sc))
+		return categoryBoolean
+
+	case *sqlparser.IsExpr:
+		c.checkExpr(x.Expr, sc)
+		return categoryBoolean
+
+	case *sqlparser.ExistsExpr:
+		c.checkQuery(x.Subquery, sc)
+		return categoryBoolean
+
+	case *sqlparser.SubqueryExpr:
+		rel := c.checkQuery(x.Query, sc)
+		if x.Quantifier == "" && len(rel.columns) == 1 {
+			return rel.columns[0].category
+		}
+		return categoryUnknown
+
+	case *sqlparser.TupleExpr:
+		for _, expr := range x.Exprs {
+			c.checkExpr(expr, sc)
+		}
+		return categoryUnknown
+
+	case *sqlparser.IndexExpr:
+		c.checkExpr(x.Expr, sc)
+		c.checkExpr(x.Index, sc)
+		return categoryUnknown
+	}
+
+	return categoryUnknown
+}
+
+// checkComparison reports comparisons between incompatible types
+func (c *checker) checkComparison(left sqlparser.Expr, leftCategory string, right sqlparser.Expr, rightCategory string) {
+	if lit, ok := right.(*sqlparser.Literal); ok {
+		c.checkLiteral(left, leftCategory, lit)
+		return
+	}
+	if lit, ok := left.(*sqlparser.Literal); ok {
+		c.checkLiteral(right, rightCategory, lit)
+		return
+	}
+
+	if (leftCategory == categoryNumeric && rightCategory == categoryString) ||
+		(leftCategory == categoryString && rightCategory == categoryNumeric) {
+		c.addIssue(models.ValidationIssue{
+			Code:     CodeTypeMismatch,
+			Severity: SeverityWarning,
+			Message: fmt.Sprintf("%s (%s) is compared with %s (%s)",
+				describeExpr(left), leftCategory, describeExpr(right), rightCategory),
+			Column: columnName(left),
+		})
+	}
+}
+
+// checkLiteral reports literals that cannot be converted to the type of expr
+func (c *checker) checkLiteral(expr sqlparser.Expr, category string, lit *sqlparser.Literal) {
+	if lit.Kind != sqlparser.StringLiteral {
+		return
+	}
+
+	switch {
+	case category == categoryNumeric && !isNumeric(lit.Value):
+		c.addIssue(models.ValidationIssue{
+			Code:     CodeTypeMismatch,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("%s is numeric but is compared with non-numeric string '%s'", describeExpr(expr), lit.Value),
+			Column:   columnName(expr),
+		})
+	case category == categoryTemporal && !looksLikeDate(lit.Value):
+		c.addIssue(models.ValidationIssue{
+			Code:     CodeTypeMismatch,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("%s is a date/time value but is compared with '%s', which is not a date literal", describeExpr(expr), lit.Value),
+			Column:   columnName(expr),
+		})
+	}
+}
+
+// checkAggregate reports numeric aggregates applied to non-numeric columns
+func (c *checker) checkAggregate(fn *sqlparser.FuncCall, args []string) {
+	name := strings.ToUpper(fn.Name)
+	if (name != "SUM" && name != "AVG") || len(args) != 1 || args[0] != categoryString {
+		return
+	}
+	c.addIssue(models.ValidationIssue{
+		Code:     CodeTypeMismatch,
+		Severity: SeverityWarning,
+		Message:  fmt.Sprintf("%s is applied to %s, which is not numeric", name, describeExpr(fn.Args[0])),
+		Column:   columnName(fn.Args[0]),
+	})
+}
+
+// describeExpr names an expression in issue messages
+func describeExpr(e sqlparser.Expr) string {
+	if ref, ok := e.(*sqlparser.ColumnRef); ok {
+		return fmt.Sprintf("column %q", ref.Name())
+	}
+	return "expression"
+}
+
+// columnName returns the column name of a column reference, or ""
+func columnName(e sqlparser.Expr) string {
+	if ref, ok := e.(*sqlparser.ColumnRef); ok {
+		return ref.Parts[len(ref.Parts)-1]
+	}
+	return ""
+}
@@ -0,0 +1,43 @@
+package validation
+
+import (
+	"fmt"
+
+	"sql_generator/internal/dialect"
+	"sql_generator/internal/models"
+	"sql_generator/internal/sqlparser"
+)
+
+// requireFeature reports feature when the target dialect does not support it
+func (c *checker) requireFeature(feature dialect.Feature) {
+	if c.dialect.Supports(feature) {
+		return
+	}
+	message := fmt.Sprintf("%s is not supported in %s", feature, c.dialect.DisplayName())
+	if alt := c.dialect.Alternative(feature); alt != "" {
+		message += "; " + alt
+	}
+	c.addIssue(models.ValidationIssue{
+		Code:     CodeDialectMismatch,
+		Severity: SeverityError,
+		Message:  message,
+	})
+}
+
+// checkQuoting reports backtick-quoted identifiers in dialects that quote
+// identifiers with double quotes
+func (c *checker) checkQuoting(sql string) {
+	if c.dialect.Supports(dialect.BacktickQuotes) {
+		return
+	}
+	tokens, err := sqlparser.Tokenize(sql, c.dialect.ParserOptions())
+	if err != nil {
+		return
+	}
+	for _, tok := range tokens {
+		if tok.Kind == sqlparser.QuotedIdent && sql[tok.Pos] == '`' {
+			c.requireFeature(dialect.BacktickQuotes)
+			return
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	"sql_generator/internal/dialect"
+	"sql_generator/internal/models"
+	"sql_generator/internal/sqlparser"
+)
+
+// checkPartitionFilters reports partitioned tables of a select block whose
+// partition keys are not referenced by the WHERE clause or a join condition.
+// Hive and Spark scan every partition of such a query (and Hive's strict
+// mode rejects it), so it is an error there and a warning elsewhere.
+func (c *checker) checkPartitionFilters(s *sqlparser.Select, sc *scope) {
+	var refs []*sqlparser.ColumnRef
+	collect := func(ref *sqlparser.ColumnRef) { refs = append(refs, ref) }
+	walkColumnRefs(s.Where, collect)
+	for _, te := range s.From {
+		walkJoinConditions(te, collect)
+	}
+
+	severity := SeverityWarning
+	if c.dialect == dialect.Hive || c.dialect == dialect.Spark {
+		severity = SeverityError
+	}
+
+	for _, src := range sc.sources {
+		if src.table == "" {
+			continue
+		}
+		table := c.table(src.table)
+		if table == nil || len(table.PartitionKeys) == 0 || filtersPartition(refs, src, table) {
+			continue
+		}
+
+		keys := make([]string, len(table.PartitionKeys))
+		for i, key := range table.PartitionKeys {
+			keys[i] = key.Name
+		}
+		c.addIssue(models.ValidationIssue{
+			Code:     CodePartitionFilterMissing,
+			Severity: severity,
+			Message: fmt.Sprintf("table %q is partitioned by %s but the query does not filter on a partition key; add a WHERE condition on %s to prune partitions",
+				table.Name, strings.Join(keys, ", "), keys[0]),
+			Table: table.Name,
+		})
+	}
+}
+
+// filtersPartition reports whether one of refs is a partition key of src
+func filtersPartition(refs []*sqlparser.ColumnRef, src *source, table *models.Table) bool {
+	for _, ref := range refs {
+		name := ref.Parts[len(ref.Parts)-1]
+		if len(ref.Parts) >= 2 {
+			qualifier := ref.Parts[len(ref.Parts)-2]
+			if !strings.EqualFold(qualifier, src.name) && !strings.EqualFold(qualifier, src.table) {
+				continue
+			}
+		}
+		for _, key := range table.PartitionKeys {
+			if strings.EqualFold(key.Name, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// walkJoinConditions calls fn for the column references in the ON
+// conditions of a FROM item
+func walkJoinConditions(te sqlparser.TableExpr, fn func(*sqlparser.ColumnRef)) {
+	switch t := te.(type) {
+	case *sqlparser.Join:
+		walkJoinConditions(t.Left, fn)
+		walkJoinConditions(t.Right, fn)
+		walkColumnRefs(t.On, fn)
+	case *sqlparser.LateralView:
+		walkJoinConditions(t.Source, fn)
+	case *sqlparser.ParenTableExpr:
+		for _, expr := range t.Exprs {
+			walkJoinConditions(expr, fn)
+		}
+	}
+}
+
+// walkColumnRefs calls fn for every column reference in e, without
+// descending into subqueries
+func walkColumnRefs(e sqlparser.Expr, fn func(*sqlparser.ColumnRef)) {
+	switch x := e.(type) {
+	case *sqlparser.ColumnRef:
+		fn(x)
+	case *sqlparser.BinaryExpr:
+		walkColumnRefs(x.Left, fn)
+		walkColumnRefs(x.Right, fn)
+	case *sqlparser.UnaryExpr:
+		walkColumnRefs(x.Expr, fn)
+	case *sqlparser.FuncCall:
+		for _, arg := range x.Args {
+			walkColumnRefs(arg, fn)
+		}
+	case *sqlparser.CaseExpr:
+		walkColumnRefs(x.Operand, fn)
+		for _, when := range x.Whens {
+			walkColumnRefs(when.Cond, fn)
+			walkColumnRefs(when.Result, fn)
+		}
+		walkColumnRefs(x.Else, fn)
+	case *sqlparser.CastExpr:
+		walkColumnRefs(x.Expr, fn)
+	case *sqlparser.InExpr:
+		walkColumnRefs(x.Expr, fn)
+		for _, item := range x.List {
+			walkColumnRefs(item, fn)
+		}
+	case *sqlparser.BetweenExpr:
+		walkColumnRefs(x.Expr, fn)
+		walkColumnRefs(x.Low, fn)
+		walkColumnRefs(x.High, fn)
+	case *sqlparser.IsExpr:
+		walkColumnRefs(x.Expr, fn)
+	case *sqlparser.TupleExpr:
+		for _, item := range x.Exprs {
+			walkColumnRefs(item, fn)
+		}
+	case *sqlparser.IndexExpr:
+		walkColumnRefs(x.Expr, fn)
+		walkColumnRefs(x.Index, fn)
+	}
+}
@@ -0,0 +1,255 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	"sql_generator/internal/models"
+	"sql_generator/internal/sqlparser"
+)
+
+// column is an output or table column with its type category
+type column struct {
+	name     string
+	category string
+	// table is the stored table the column belongs to, if any
+	table string
+	// sensitivity is the tag of a stored column
+	sensitivity string
+}
+
+// reference describes a stored column for the report
+func (c *column) reference() ColumnReference {
+	return ColumnReference{Table: c.table, Column: c.name, Sensitivity: c.sensitivity}
+}
+
+// relation is a set of columns. Open relations (unknown tables, table
+// functions, SELECT * over them) accept any column name.
+type relation struct {
+	columns []column
+	open    bool
+}
+
+func (r *relation) find(name string) *column {
+	for i := range r.columns {
+		if strings.EqualFold(r.columns[i].name, name) {
+			return &r.columns[i]
+		}
+	}
+	return nil
+}
+
+// source is a FROM item visible under name
+type source struct {
+	name string
+	// table is the stored table name for base tables
+	table string
+	rel   *relation
+}
+
+// scope holds the names visible while checking one SELECT block
+type scope struct {
+	parent  *scope
+	sources []*source
+	ctes    map[string]*relation
+	// aliases are the select-list aliases usable in GROUP BY, HAVING and ORDER BY
+	aliases map[string]bool
+	// using lists columns merged by USING or NATURAL joins, which are not ambiguous
+	using map[string]bool
+}
+
+func newScope(parent *scope) *scope {
+	return &scope{
+		parent: parent,
+		ctes:   make(map[string]*relation),
+		using:  make(map[string]bool),
+	}
+}
+
+// cte finds a common table expression visible from s
+func (s *scope) cte(name string) *relation {
+	for sc := s; sc != nil; sc = sc.parent {
+		if rel, ok := sc.ctes[strings.ToLower(name)]; ok {
+			return rel
+		}
+	}
+	return nil
+}
+
+// source finds the FROM item named name in s only
+func (s *scope) source(name string) *source {
+	for _, src := range s.sources {
+		if strings.EqualFold(src.name, name) {
+			return src
+		}
+	}
+	return nil
+}
+
+// sourceByTable finds a FROM item over the stored table name in s only
+func (s *scope) sourceByTable(name string) *source {
+	for _, src := range s.sources {
+		if src.table != "" && strings.EqualFold(src.table, name) {
+			return src
+		}
+	}
+	return nil
+}
+
+// resolve resolves a column reference and returns the column it denotes, or
+// nil when the column is unknown or cannot be typed
+func (c *checker) resolve(ref *sqlparser.ColumnRef, sc *scope) *column {
+	parts := ref.Parts
+	if len(parts) == 1 {
+		return c.resolveUnqualified(ref.Name(), parts[0], sc)
+	}
+
+	// table.column[.field...] and schema.table.column[.field...]
+	for s := sc; s != nil; s = s.parent {
+		if src := s.source(parts[0]); src != nil {
+			return c.resolveIn(src, parts[0], parts[1])
+		}
+		if len(parts) >= 3 {
+			if src := s.sourceByTable(parts[1]); src != nil {
+				return c.resolveIn(src, parts[1], parts[2])
+			}
+		}
+	}
+
+	// struct_column.field
+	if c.lookupUnqualified(parts[0], sc) != nil {
+		return nil
+	}
+
+	message := fmt.Sprintf("unknown table or alias %q in column reference %q", parts[0], ref.Name())
+	for s := sc; s != nil; s = s.parent {
+		if src := s.sourceByTable(parts[0]); src != nil {
+			message = fmt.Sprintf("table %q is aliased as %q; reference the column as %s.%s", src.table, src.name, src.name, parts[1])
+			break
+		}
+	}
+	c.addIssue(models.ValidationIssue{
+		Code:     CodeUnknownTable,
+		Severity: SeverityError,
+		Message:  message,
+		Table:    parts[0],
+		Column:   parts[1],
+	})
+	return nil
+}
+
+// resolveIn resolves name as a column of src, reporting unknown columns
+func (c *checker) resolveIn(src *source, qualifier, name string) *column {
+	if col := src.rel.find(name); col != nil {
+		if col.table != "" {
+			c.recordColumn(col)
+		}
+		return col
+	}
+	if src.rel.open {
+		return nil
+	}
+
+	table := src.table
+	if table == "" {
+		table = src.name
+	}
+	c.addIssue(models.ValidationIssue{
+		Code:     CodeUnknownColumn,
+		Severity: SeverityError,
+		Message:  fmt.Sprintf("column %q does not exist in %s", name, describeSource(src, qualifier)),
+		Table:    table,
+		Column:   name,
+	})
+	return nil
+}
+
+// resolveUnqualified resolves a bare column name, innermost scope first
+func (c *checker) resolveUnqualified(display, name string, sc *scope) *column {
+	for s := sc; s != nil; s = s.parent {
+		var matches []*source
+		var found *column
+		open := false
+		for _, src := range s.sources {
+			if col := src.rel.find(name); col != nil {
+				matches = append(matches, src)
+				found = col
+			} else if src.rel.open {
+				open = true
+			}
+		}
+
+		switch {
+		case len(matches) > 1 && !s.using[strings.ToLower(name)]:
+			names := make([]string, len(matches))
+			for i, src := range matches {
+				names[i] = src.name
+			}
+			c.addIssue(models.ValidationIssue{
+				Code:     CodeAmbiguousColumn,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("column %q is ambiguous; it exists in %s", display, strings.Join(names, ", ")),
+				Column:   name,
+			})
+			return nil
+		case len(matches) > 0:
+			if found.table != "" {
+				c.recordColumn(found)
+			}
+			return found
+		case s.aliases[strings.ToLower(name)]:
+			return nil
+		case open:
+			// The column may belong to a source whose columns are unknown
+			return nil
+		}
+	}
+
+	issue := models.ValidationIssue{
+		Code:     CodeUnknownColumn,
+		Severity: SeverityError,
+		Message:  fmt.Sprintf("column %q does not exist in any table in scope", display),
+		Column:   name,
+	}
+	if sc != nil && len(sc.sources) == 1 {
+		src := sc.sources[0]
+		issue.Table = src.table
+		issue.Message = fmt.Sprintf("column %q does not exist in %s", display, describeSource(src, src.name))
+	}
+	c.addIssue(issue)
+	return nil
+}
+
+// lookupUnqualified finds a bare column without reporting issues
+func (c *checker) lookupUnqualified(name string, sc *scope) *column {
+	for s := sc; s != nil; s = s.parent {
+		for _, src := range s.sources {
+			if col := src.rel.find(name); col != nil {
+				return col
+			}
+		}
+	}
+	return nil
+}
+
+// describeSource names a source for issue messages
+func describeSource(src *source, qualifier string) string {
+	switch {
+	case src.table == "":
+		return fmt.Sprintf("derived table %q", qualifier)
+	case strings.EqualFold(src.table, qualifier):
+		return fmt.Sprintf("table %q", src.table)
+	default:
+		return fmt.Sprintf("table %q (alias %q)", src.table, qualifier)
+	}
+}
+
+// tableRelation builds the relation of a stored table, including its partition keys
+func tableRelation(table *models.Table) *relation {
+	columns := table.AllColumns()
+	rel := &relation{columns: make([]column, len(columns))}
+	for i, col := range columns {
+		rel.columns[i] = column{name: col.Name, category: typeCategory(col.Type), table: table.Name, sensitivity: col.Sensitivity}
+	}
+	return rel
+}
@@ -0,0 +1,142 @@
+package validation
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"sql_generator/internal/sqlparser"
+)
+
+// Type categories used for mismatch detection. Only comparisons between
+// clearly incompatible categories are reported.
+const (
+	categoryUnknown  = ""
+	categoryNumeric  = "numeric"
+	categoryString   = "string"
+	categoryTemporal = "temporal"
+	categoryBoolean  = "boolean"
+)
+
+// typePrefixes maps declared type names to categories; the longest matching
+// prefix wins so that e.g. DATETIME is not read as DATE
+var typePrefixes = []struct {
+	prefix   string
+	category string
+}{
+	{"TINYINT", categoryNumeric}, {"SMALLINT", categoryNumeric}, {"MEDIUMINT", categoryNumeric},
+	{"BIGINT", categoryNumeric}, {"INTEGER", categoryNumeric}, {"INT", categoryNumeric},
+	{"DECIMAL", categoryNumeric}, {"NUMERIC", categoryNumeric}, {"NUMBER", categoryNumeric},
+	{"FLOAT", categoryNumeric}, {"DOUBLE", categoryNumeric}, {"REAL", categoryNumeric},
+	{"SERIAL", categoryNumeric}, {"BIGSERIAL", categoryNumeric}, {"SMALLSERIAL", categoryNumeric},
+	{"MONEY", categoryNumeric}, {"SIGNED", categoryNumeric}, {"UNSIGNED", categoryNumeric},
+	{"CHAR", categoryString}, {"VARCHAR", categoryString}, {"NCHAR", categoryString},
+	{"NVARCHAR", categoryString}, {"CHARACTER", categoryString}, {"TEXT", categoryString},
+	{"TINYTEXT", categoryString}, {"MEDIUMTEXT", categoryString}, {"LONGTEXT", categoryString},
+	{"STRING", categoryString}, {"ENUM", categoryString}, {"CLOB", categoryString},
+	{"DATETIME", categoryTemporal}, {"TIMESTAMP", categoryTemporal}, {"DATE", categoryTemporal},
+	{"TIME", categoryTemporal}, {"YEAR", categoryTemporal},
+	{"BOOLEAN", categoryBoolean}, {"BOOL", categoryBoolean},
+}
+
+// typeCategory classifies a declared column type such as "VARCHAR(255)"
+func typeCategory(typ string) string {
+	upper := strings.ToUpper(strings.TrimSpace(typ))
+	best, category := 0, categoryUnknown
+	for _, p := range typePrefixes {
+		if len(p.prefix) <= best || !strings.HasPrefix(upper, p.prefix) {
+			continue
+		}
+		// The prefix must end at a word boundary: INTERVAL is not INT
+		if rest := upper[len(p.prefix):]; rest != "" && isWordChar(rest[0]) {
+			continue
+		}
+		best, category = len(p.prefix), p.category
+	}
+	return category
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// literalCategory classifies a literal
+func literalCategory(lit *sqlparser.Literal) string {
+	switch lit.Kind {
+	case sqlparser.NumberLiteral:
+		return categoryNumeric
+	case sqlparser.StringLiteral:
+		return categoryString
+	case sqlparser.BoolLiteral:
+		return categoryBoolean
+	case sqlparser.TypedLiteral:
+		if lit.Type != "INTERVAL" {
+			return categoryTemporal
+		}
+	}
+	return categoryUnknown
+}
+
+// numericFunctions, stringFunctions and temporalFunctions give the result
+// category of common functions; others are unknown unless they pass
+// through the category of their first argument
+var (
+	numericFunctions = setOf("COUNT", "SUM", "AVG", "ROUND", "ABS", "FLOOR", "CEIL", "CEILING",
+		"LENGTH", "CHAR_LENGTH", "CHARACTER_LENGTH", "ROW_NUMBER", "RANK", "DENSE_RANK",
+		"NTILE", "PERCENT_RANK", "CUME_DIST", "DATEDIFF", "TIMESTAMPDIFF", "YEAR", "MONTH",
+		"DAY", "HOUR", "MINUTE", "SECOND", "WEEK", "QUARTER", "DAYOFWEEK", "DAYOFMONTH",
+		"DAYOFYEAR", "EXTRACT", "MOD", "POWER", "POW", "SQRT", "LN", "LOG", "EXP",
+		"STDDEV", "STDDEV_POP", "STDDEV_SAMP", "VARIANCE", "VAR_POP", "VAR_SAMP",
+		"UNIX_TIMESTAMP", "LOCATE", "INSTR", "POSITION", "SIGN", "TRUNCATE")
+	stringFunctions = setOf("CONCAT", "CONCAT_WS", "UPPER", "LOWER", "UCASE", "LCASE",
+		"SUBSTRING", "SUBSTR", "TRIM", "LTRIM", "RTRIM", "REPLACE", "GROUP_CONCAT",
+		"STRING_AGG", "DATE_FORMAT", "LEFT", "RIGHT", "LPAD", "RPAD", "REVERSE",
+		"REPEAT", "FORMAT", "HEX", "MD5", "SHA1", "SHA2", "TO_CHAR")
+	temporalFunctions = setOf("NOW", "CURRENT_DATE", "CURRENT_TIME", "CURRENT_TIMESTAMP",
+		"LOCALTIME", "LOCALTIMESTAMP", "SYSDATE", "CURDATE", "CURTIME", "DATE", "DATE_ADD",
+		"DATE_SUB", "ADDDATE", "SUBDATE", "STR_TO_DATE", "TO_DATE", "TO_TIMESTAMP",
+		"DATE_TRUNC", "LAST_DAY", "TIMESTAMPADD", "UTC_DATE", "UTC_TIMESTAMP")
+	passThroughFunctions = setOf("MIN", "MAX", "COALESCE", "IFNULL", "NVL", "NULLIF",
+		"ANY_VALUE", "FIRST_VALUE", "LAST_VALUE", "LAG", "LEAD", "NTH_VALUE")
+)
+
+func setOf(names ...string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+// functionCategory returns the result category of a function call
+func functionCategory(name string, args []string) string {
+	upper := strings.ToUpper(name)
+	switch {
+	case numericFunctions[upper]:
+		return categoryNumeric
+	case stringFunctions[upper]:
+		return categoryString
+	case temporalFunctions[upper]:
+		return categoryTemporal
+	case passThroughFunctions[upper]:
+		for _, arg := range args {
+			if arg != categoryUnknown {
+				return arg
+			}
+		}
+	}
+	return categoryUnknown
+}
+
+func isNumeric(s string) bool {
+	_, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return err == nil
+}
+
+// datePattern matches the date and timestamp formats databases accept in
+// comparisons, e.g. 2024-01-31, 2024/01/31 08:00 or 20240131
+var datePattern = regexp.MustCompile(`^\d{4}([-/.]?\d{1,2}([-/.]?\d{1,2})?)?([ T]\d{1,2}(:\d{1,2}(:\d{1,2}(\.\d+)?)?)?)?([+-]\d{2}:?\d{2}|Z)?$|^\d{1,2}:\d{2}(:\d{2})?$`)
+
+func looksLikeDate(s string) bool {
+	return datePattern.MatchString(strings.TrimSpace(s))
+}
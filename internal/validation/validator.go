@@ -0,0 +1,227 @@
+// Package validation checks generated SQL against the stored table schemas.
+// Every table, alias and column reference is resolved against models.Table
+// definitions so that hallucinated or ambiguous identifiers and obvious type
+// mismatches are reported before the query reaches a database.
+package validation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"sql_generator/internal/dialect"
+	"sql_generator/internal/models"
+	"sql_generator/internal/sqlparser"
+	"sql_generator/internal/storage"
+)
+
+// Issue codes
+const (
+	CodeSyntaxError         = "syntax_error"
+	CodeUnknownTable        = "unknown_table"
+	CodeUnknownColumn       = "unknown_column"
+	CodeAmbiguousColumn     = "ambiguous_column"
+	CodeTypeMismatch        = "type_mismatch"
+	CodeColumnCountMismatch = "column_count_mismatch"
+	CodeNotValidated        = "not_validated"
+	CodeDialectMismatch     = "dialect_mismatch"
+	// CodePartitionFilterMissing is an error for Hive and Spark, a warning otherwise
+	CodePartitionFilterMissing = "partition_filter_missing"
+)
+
+// Issue severities. Only errors make a query invalid.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// TableLookup resolves stored table definitions by name; storage.Store satisfies it
+type TableLookup interface {
+	GetTableByName(ctx context.Context, name string) (*models.Table, error)
+}
+
+// ColumnReference is a column of a stored table referenced by a query
+type ColumnReference struct {
+	Table  string
+	Column string
+	// Sensitivity is the column's sensitivity tag, if any
+	Sensitivity string
+}
+
+// SelectedColumn is a stored column returned by a select list, at any
+// nesting level
+type SelectedColumn struct {
+	ColumnReference
+	// Ref is the reference in the SQL text; nil when selected through "*"
+	Ref *sqlparser.ColumnRef
+	// Bare is set when the select item is the reference itself, without an alias
+	Bare bool
+}
+
+// Report is the outcome of validating one SQL text
+type Report struct {
+	Issues []models.ValidationIssue
+	// Tables lists the stored tables referenced by the query
+	Tables []string
+	// Columns lists the stored columns the query resolved to
+	Columns []ColumnReference
+	// Selected lists the stored columns appearing in select lists
+	Selected []SelectedColumn
+}
+
+// Valid reports whether the query has no error-level issues
+func (r *Report) Valid() bool {
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			return false
+		}
+	}
+	return true
+}
+
+// Errors returns the messages of error-level issues
+func (r *Report) Errors() []string {
+	var messages []string
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			messages = append(messages, issue.Message)
+		}
+	}
+	return messages
+}
+
+// Validation converts the report into its API representation
+func (r *Report) Validation() *models.Validation {
+	issues := r.Issues
+	if issues == nil {
+		issues = []models.ValidationIssue{}
+	}
+	return &models.Validation{Valid: r.Valid(), Issues: issues}
+}
+
+// Validator validates SQL against table definitions from a TableLookup
+type Validator struct {
+	lookup TableLookup
+}
+
+// NewValidator creates a new Validator
+func NewValidator(lookup TableLookup) *Validator {
+	return &Validator{lookup: lookup}
+}
+
+// Validate parses sql as written in dialect d and resolves its identifiers.
+// Tables in known are used without a storage lookup. Syntax errors, schema
+// problems and constructs d does not support are returned as issues in the
+// report; the error is only set when storage fails. An empty dialect skips
+// the dialect checks.
+func (v *Validator) Validate(ctx context.Context, sql string, d dialect.Dialect, known []*models.Table) (*Report, error) {
+	c := &checker{
+		ctx:     ctx,
+		lookup:  v.lookup,
+		dialect: d,
+		report:  &Report{},
+		tables:  make(map[string]*models.Table),
+		missing: make(map[string]bool),
+		seen:    make(map[string]bool),
+	}
+	for _, table := range known {
+		c.tables[strings.ToLower(table.Name)] = table
+	}
+
+	statements, err := sqlparser.Parse(sql, d.ParserOptions())
+	if err != nil {
+		var syntaxErr *sqlparser.SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			return nil, err
+		}
+		c.addIssue(models.ValidationIssue{Code: CodeSyntaxError, Severity: SeverityError, Message: syntaxErr.Error()})
+		return c.report, nil
+	}
+	c.checkQuoting(sql)
+
+	for _, stmt := range statements {
+		switch s := stmt.(type) {
+		case *sqlparser.SelectStatement:
+			c.checkQuery(s, nil)
+		case *sqlparser.OtherStatement:
+			c.addIssue(models.ValidationIssue{
+				Code:     CodeNotValidated,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("%s statements are not validated against the schema", s.Verb),
+			})
+		}
+		if c.err != nil {
+			return nil, c.err
+		}
+	}
+
+	return c.report, nil
+}
+
+// checker holds the state of a single validation run
+type checker struct {
+	ctx     context.Context
+	lookup  TableLookup
+	dialect dialect.Dialect
+	report  *Report
+	tables  map[string]*models.Table
+	missing map[string]bool
+	seen    map[string]bool
+	err     error
+	// selecting is set while checking a select item; bareRef is the item
+	// itself when it is a plain column reference
+	selecting bool
+	bareRef   *sqlparser.ColumnRef
+}
+
+func (c *checker) addIssue(issue models.ValidationIssue) {
+	key := issue.Code + "\x00" + issue.Message
+	if c.seen[key] {
+		return
+	}
+	c.seen[key] = true
+	c.report.Issues = append(c.report.Issues, issue)
+}
+
+// table returns the stored definition of name, or nil if it does not exist
+func (c *checker) table(name string) *models.Table {
+	key := strings.ToLower(name)
+	if table, ok := c.tables[key]; ok {
+		return table
+	}
+	if c.missing[key] || c.err != nil || c.lookup == nil {
+		return nil
+	}
+
+	table, err := c.lookup.GetTableByName(c.ctx, name)
+	if err != nil {
+		if !errors.Is(err, storage.ErrTableNotFound) {
+			c.err = fmt.Errorf("failed to load table %s: %w", name, err)
+		}
+		c.missing[key] = true
+		return nil
+	}
+	c.tables[key] = table
+	return table
+}
+
+// recordTable adds a stored table to the report
+func (c *checker) recordTable(name string) {
+	for _, t := range c.report.Tables {
+		if t == name {
+			return
+		}
+	}
+	c.report.Tables = append(c.report.Tables, name)
+}
+
+// recordColumn adds a stored column to the report
+func (c *checker) recordColumn(col *column) {
+	for _, ref := range c.report.Columns {
+		if ref.Table == col.table && ref.Column == col.name {
+			return
+		}
+	}
+	c.report.Columns = append(c.report.Columns, col.reference())
+}
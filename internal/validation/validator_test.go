@@ -0,0 +1,320 @@
+package validation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"sql_generator/internal/dialect"
+	"sql_generator/internal/models"
+	"sql_generator/internal/storage"
+)
+
+// mapLookup is a TableLookup backed by a map
+type mapLookup map[string]*models.Table
+
+func (m mapLookup) GetTableByName(ctx context.Context, name string) (*models.Table, error) {
+	if table, ok := m[name]; ok {
+		return table, nil
+	}
+	return nil, fmt.Errorf("%w: %s", storage.ErrTableNotFound, name)
+}
+
+func testTables() mapLookup {
+	return mapLookup{
+		"users": {
+			Name: "users",
+			Columns: []models.Column{
+				{Name: "id", Type: "BIGINT", IsPrimary: true},
+				{Name: "name", Type: "VARCHAR(100)"},
+				{Name: "age", Type: "INT"},
+				{Name: "created_at", Type: "DATETIME"},
+			},
+		},
+		"orders": {
+			Name: "orders",
+			Columns: []models.Column{
+				{Name: "id", Type: "BIGINT", IsPrimary: true},
+				{Name: "user_id", Type: "BIGINT"},
+				{Name: "amount", Type: "DECIMAL(10,2)"},
+				{Name: "status", Type: "VARCHAR(20)"},
+				{Name: "created_at", Type: "DATETIME"},
+			},
+		},
+		"page_views": {
+			Name: "page_views",
+			Columns: []models.Column{
+				{Name: "user_id", Type: "BIGINT"},
+				{Name: "url", Type: "STRING"},
+			},
+			PartitionKeys: []models.Column{{Name: "dt", Type: "STRING"}, {Name: "hour", Type: "STRING"}},
+		},
+	}
+}
+
+func validate(t *testing.T, sql string) *Report {
+	t.Helper()
+	report, err := NewValidator(testTables()).Validate(context.Background(), sql, "", nil)
+	if err != nil {
+		t.Fatalf("Validate(%q) failed: %v", sql, err)
+	}
+	return report
+}
+
+func issueCodes(report *Report) []string {
+	codes := make([]string, len(report.Issues))
+	for i, issue := range report.Issues {
+		codes[i] = issue.Code
+	}
+	return codes
+}
+
+func TestValidateAcceptsValidQueries(t *testing.T) {
+	queries := []string{
+		"SELECT id, name FROM users WHERE age > 18",
+		"SELECT u.name, SUM(o.amount) AS total FROM users u JOIN orders o ON o.user_id = u.id GROUP BY u.name ORDER BY total DESC",
+		"SELECT name FROM users WHERE id IN (SELECT user_id FROM orders WHERE status = 'paid')",
+		"SELECT * FROM users WHERE EXISTS (SELECT 1 FROM orders WHERE orders.user_id = users.id)",
+		"WITH big AS (SELECT user_id, SUM(amount) AS total FROM orders GROUP BY user_id) SELECT u.name, b.total FROM users u JOIN big b ON b.user_id = u.id",
+		"SELECT t.uid FROM (SELECT user_id AS uid FROM orders) t",
+		"SELECT x.a FROM (SELECT id, name FROM users) AS x(a, b)",
+		"SELECT id FROM users UNION SELECT user_id FROM orders ORDER BY id",
+		"SELECT id FROM users JOIN orders USING (id)",
+		"SELECT created_at FROM users WHERE created_at >= '2024-01-01' AND age BETWEEN '18' AND 30",
+		"SELECT u.name, ROW_NUMBER() OVER (PARTITION BY u.age ORDER BY u.created_at) rn FROM users u",
+		"WITH RECURSIVE n(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM n WHERE x < 5) SELECT x FROM n",
+		"SELECT COUNT(*) cnt FROM users HAVING cnt > 1",
+		"SELECT u.* FROM users u",
+	}
+
+	for _, sql := range queries {
+		report := validate(t, sql)
+		if len(report.Issues) != 0 {
+			t.Errorf("Validate(%q) reported issues: %+v", sql, report.Issues)
+		}
+	}
+}
+
+func TestValidateReportsIssues(t *testing.T) {
+	tests := []struct {
+		sql     string
+		code    string
+		message string
+	}{
+		{"SELECT id FROM customers", CodeUnknownTable, `table "customers" does not exist`},
+		{"SELECT email FROM users", CodeUnknownColumn, `column "email" does not exist in table "users"`},
+		{"SELECT u.email FROM users u", CodeUnknownColumn, `column "email" does not exist in table "users" (alias "u")`},
+		{"SELECT users.name FROM users u", CodeUnknownTable, `table "users" is aliased as "u"`},
+		{"SELECT x.name FROM users u", CodeUnknownTable, `unknown table or alias "x"`},
+		{"SELECT id FROM users u JOIN orders o ON o.user_id = u.id", CodeAmbiguousColumn, `column "id" is ambiguous`},
+		{"SELECT name FROM users WHERE age = 'eighteen'", CodeTypeMismatch, `is numeric but is compared with non-numeric string 'eighteen'`},
+		{"SELECT t.missing FROM (SELECT id FROM users) t", CodeUnknownColumn, `column "missing" does not exist in derived table "t"`},
+		{"SELECT id, name FROM users UNION SELECT id FROM orders", CodeColumnCountMismatch, "UNION operands return 2 and 1 columns"},
+		{"SELECT name FROM users WHERE", CodeSyntaxError, "syntax error"},
+		{"SELECT name FROM users WHERE id IN (SELECT missing FROM orders)", CodeUnknownColumn, `column "missing" does not exist`},
+	}
+
+	for _, tt := range tests {
+		report := validate(t, tt.sql)
+		if report.Valid() {
+			t.Errorf("Validate(%q) reported valid, want %s", tt.sql, tt.code)
+			continue
+		}
+
+		found := false
+		for _, issue := range report.Issues {
+			if issue.Code == tt.code && strings.Contains(issue.Message, tt.message) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Validate(%q) issues %+v, want %s containing %q", tt.sql, report.Issues, tt.code, tt.message)
+		}
+	}
+}
+
+func TestValidateWarnings(t *testing.T) {
+	tests := []string{
+		"SELECT u.name FROM users u JOIN orders o ON o.status = u.id",
+		"SELECT SUM(name) FROM users",
+		"SELECT id FROM users WHERE created_at > 'last week'",
+		"DELETE FROM users",
+	}
+
+	for _, sql := range tests {
+		report := validate(t, sql)
+		if !report.Valid() {
+			t.Errorf("Validate(%q) reported errors %v, want only warnings", sql, report.Errors())
+		}
+		if len(report.Issues) == 0 || report.Issues[0].Severity != SeverityWarning {
+			t.Errorf("Validate(%q) issues %+v, want a warning", sql, report.Issues)
+		}
+	}
+}
+
+func TestValidateDialects(t *testing.T) {
+	tests := []struct {
+		dialect dialect.Dialect
+		sql     string
+		message string
+	}{
+		{dialect.Hive, "SELECT o.id, item FROM orders o LATERAL VIEW explode(o.status) x AS item WHERE item = 'a'", ""},
+		{dialect.Hive, "SELECT u.name FROM users u LEFT SEMI JOIN orders o ON o.user_id = u.id", ""},
+		{dialect.Spark, "SELECT id FROM orders DISTRIBUTE BY user_id SORT BY amount", ""},
+		{dialect.MySQL, "SELECT `id` FROM users", ""},
+		{dialect.Postgres, `SELECT "id" FROM users`, ""},
+		{dialect.Presto, "SELECT id, item FROM orders LATERAL VIEW explode(status) x AS item", "LATERAL VIEW is not supported in Presto/Trino; use CROSS JOIN UNNEST"},
+		{dialect.MySQL, "SELECT u.id FROM users u FULL JOIN orders o ON o.user_id = u.id", "FULL OUTER JOIN is not supported in MySQL"},
+		{dialect.Postgres, "SELECT `id` FROM users", "backtick quoting is not supported in PostgreSQL"},
+		{dialect.Postgres, "SELECT u.name FROM users u LEFT ANTI JOIN orders o ON o.user_id = u.id", "LEFT SEMI/ANTI JOIN is not supported"},
+		{dialect.MySQL, "SELECT id FROM orders CLUSTER BY user_id", "DISTRIBUTE/SORT/CLUSTER BY is not supported in MySQL"},
+	}
+
+	for _, tt := range tests {
+		report, err := NewValidator(testTables()).Validate(context.Background(), tt.sql, tt.dialect, nil)
+		if err != nil {
+			t.Fatalf("Validate(%q) failed: %v", tt.sql, err)
+		}
+		if tt.message == "" {
+			if len(report.Issues) != 0 {
+				t.Errorf("Validate(%q, %s) reported issues: %+v", tt.sql, tt.dialect, report.Issues)
+			}
+			continue
+		}
+		if len(report.Issues) != 1 || report.Issues[0].Code != CodeDialectMismatch || !strings.Contains(report.Issues[0].Message, tt.message) {
+			t.Errorf("Validate(%q, %s) issues %+v, want dialect_mismatch containing %q", tt.sql, tt.dialect, report.Issues, tt.message)
+		}
+	}
+}
+
+func TestValidatePartitionFilters(t *testing.T) {
+	tests := []struct {
+		dialect  dialect.Dialect
+		sql      string
+		severity string
+	}{
+		{dialect.Hive, "SELECT url FROM page_views WHERE dt = '2024-01-01'", ""},
+		{dialect.Hive, "SELECT p.url FROM page_views p WHERE p.hour BETWEEN '00' AND '06'", ""},
+		{dialect.Hive, "SELECT u.name FROM users u JOIN page_views p ON p.user_id = u.id AND p.dt = '2024-01-01'", ""},
+		{dialect.Hive, "SELECT url FROM page_views WHERE user_id = 1", SeverityError},
+		{dialect.Spark, "SELECT u.name FROM users u JOIN page_views p ON p.user_id = u.id WHERE u.dt = '2024-01-01'", SeverityError},
+		{dialect.MySQL, "SELECT COUNT(*) FROM page_views", SeverityWarning},
+	}
+
+	for _, tt := range tests {
+		report, err := NewValidator(testTables()).Validate(context.Background(), tt.sql, tt.dialect, nil)
+		if err != nil {
+			t.Fatalf("Validate(%q) failed: %v", tt.sql, err)
+		}
+		var found *models.ValidationIssue
+		for i, issue := range report.Issues {
+			if issue.Code == CodePartitionFilterMissing {
+				found = &report.Issues[i]
+			}
+		}
+		switch {
+		case tt.severity == "" && found != nil:
+			t.Errorf("Validate(%q) reported %+v", tt.sql, found)
+		case tt.severity != "" && (found == nil || found.Severity != tt.severity || found.Table != "page_views"):
+			t.Errorf("Validate(%q, %s) issues %+v, want partition_filter_missing %s", tt.sql, tt.dialect, report.Issues, tt.severity)
+		}
+	}
+}
+
+func TestValidateUnknownTableReportedOnce(t *testing.T) {
+	report := validate(t, "SELECT c.a, c.b, d FROM customers c")
+	if codes := issueCodes(report); len(codes) != 1 || codes[0] != CodeUnknownTable {
+		t.Errorf("expected a single unknown_table issue, got %+v", report.Issues)
+	}
+}
+
+func TestValidateRecordsReferences(t *testing.T) {
+	report := validate(t, "SELECT u.name, o.amount FROM users u JOIN orders o ON o.user_id = u.id")
+
+	if strings.Join(report.Tables, ",") != "users,orders" {
+		t.Errorf("unexpected tables %v", report.Tables)
+	}
+	want := map[ColumnReference]bool{
+		{Table: "users", Column: "name"}:     true,
+		{Table: "users", Column: "id"}:       true,
+		{Table: "orders", Column: "amount"}:  true,
+		{Table: "orders", Column: "user_id"}: true,
+	}
+	if len(report.Columns) != len(want) {
+		t.Fatalf("unexpected columns %+v", report.Columns)
+	}
+	for _, ref := range report.Columns {
+		if !want[ref] {
+			t.Errorf("unexpected column reference %+v", ref)
+		}
+	}
+}
+
+func TestValidateRecordsSelectedColumns(t *testing.T) {
+	sql := "SELECT u.name, upper(u.name) AS n, (SELECT max(amount) FROM orders WHERE user_id = u.id) AS m FROM users u WHERE u.age > 18"
+	report := validate(t, sql)
+
+	var selected []string
+	for _, sel := range report.Selected {
+		text := sql[sel.Ref.Pos:sel.Ref.End]
+		selected = append(selected, fmt.Sprintf("%s.%s:%s:%v", sel.Table, sel.Column, text, sel.Bare))
+	}
+	want := "users.name:u.name:true,users.name:u.name:false,orders.amount:amount:false"
+	if strings.Join(selected, ",") != want {
+		t.Errorf("unexpected selected columns %v", selected)
+	}
+
+	report = validate(t, "SELECT o.* FROM orders o JOIN users u ON u.id = o.user_id")
+	if len(report.Selected) != 5 || report.Selected[0].Ref != nil || report.Selected[0].Table != "orders" {
+		t.Errorf("unexpected star selection %+v", report.Selected)
+	}
+}
+
+func TestValidateUsesKnownTablesFirst(t *testing.T) {
+	known := []*models.Table{{Name: "events", Columns: []models.Column{{Name: "kind", Type: "STRING"}}}}
+	report, err := NewValidator(mapLookup{}).Validate(context.Background(), "SELECT kind FROM events", "", known)
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if len(report.Issues) != 0 {
+		t.Errorf("unexpected issues %+v", report.Issues)
+	}
+}
+
+// failingLookup simulates a storage outage
+type failingLookup struct{}
+
+func (failingLookup) GetTableByName(ctx context.Context, name string) (*models.Table, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestValidateReturnsStorageErrors(t *testing.T) {
+	_, err := NewValidator(failingLookup{}).Validate(context.Background(), "SELECT id FROM users", "", nil)
+	if err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("expected storage error, got %v", err)
+	}
+}
+
+func TestTypeCategory(t *testing.T) {
+	tests := map[string]string{
+		"INT":           categoryNumeric,
+		"bigint(20)":    categoryNumeric,
+		"DECIMAL(10,2)": categoryNumeric,
+		"VARCHAR(255)":  categoryString,
+		"string":        categoryString,
+		"DATETIME":      categoryTemporal,
+		"date":          categoryTemporal,
+		"TIMESTAMP(3)":  categoryTemporal,
+		"INTERVAL":      categoryUnknown,
+		"JSON":          categoryUnknown,
+		"array<int>":    categoryUnknown,
+		"boolean":       categoryBoolean,
+	}
+	for typ, want := range tests {
+		if got := typeCategory(typ); got != want {
+			t.Errorf("typeCategory(%q) = %q, want %q", typ, got, want)
+		}
+	}
+}
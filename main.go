@@ -48,7 +48,12 @@ func main() {
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Could not gracefully shutdown the server: %v\n", err)
+		log.Printf("Could not gracefully shutdown the server: %v\n", err)
+	}
+
+	// Release the server after the in-flight requests drained or timed out
+	if err := srv.Close(); err != nil {
+		log.Printf("Could not close the server: %v\n", err)
 	}
 
 	log.Println("Server stopped")
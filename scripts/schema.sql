@@ -3,6 +3,12 @@ CREATE TABLE IF NOT EXISTS tables (
     name VARCHAR(255) NOT NULL UNIQUE,
     description TEXT,
     columns JSON,
+    partition_keys JSON,
+    bucketing JSON,
+    storage_format VARCHAR(32),
+    location TEXT,
+    table_type VARCHAR(16),
+    relationships JSON,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
 );
@@ -12,5 +18,53 @@ CREATE TABLE IF NOT EXISTS queries (
     id VARCHAR(36) PRIMARY KEY,
     description TEXT,
     sql_text TEXT,
+    dialect VARCHAR(32),
+    language VARCHAR(8),
+    explanation TEXT,
+    tables_used JSON,
+    assumptions JSON,
+    confidence DOUBLE,
+    validation JSON,
+    attempts JSON,
+    prompt_truncation JSON,
+    prompt_template VARCHAR(255),
+    prompt_template_version INT,
+    execution JSON,
+    explain_plan JSON,
+    feedback JSON,
+    correct BOOLEAN NOT NULL DEFAULT FALSE,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
+
+
+CREATE TABLE IF NOT EXISTS table_vectors (
+    table_name VARCHAR(255) PRIMARY KEY,
+    model VARCHAR(255) NOT NULL,
+    dimension INT NOT NULL,
+    vector MEDIUMBLOB NOT NULL,
+    content_hash CHAR(64) NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+
+CREATE TABLE IF NOT EXISTS query_vectors (
+    query_id VARCHAR(36) PRIMARY KEY,
+    model VARCHAR(255) NOT NULL,
+    dimension INT NOT NULL,
+    vector MEDIUMBLOB NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+
+CREATE TABLE IF NOT EXISTS prompt_templates (
+    name VARCHAR(255) NOT NULL,
+    version INT NOT NULL,
+    dialect VARCHAR(32) NOT NULL DEFAULT '',
+    tenant VARCHAR(255) NOT NULL DEFAULT '',
+    language VARCHAR(8) NOT NULL DEFAULT '',
+    description TEXT,
+    body MEDIUMTEXT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (name, version)
+);
@@ -1,13 +1,14 @@
 package test
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"testing"
 	"time"
 
-	"awesomeProject2/internal/models"
-	"awesomeProject2/internal/storage"
+	"sql_generator/internal/models"
+	"sql_generator/internal/storage"
 	_ "github.com/go-sql-driver/mysql"
 )
 
@@ -170,6 +171,7 @@ func generateSampleTables() []*models.Table {
 // TestCompleteScenario tests the complete scenario with interconnected tables
 func TestCompleteScenario(t *testing.T) {
 	store := getTestMySQLStore(t)
+	ctx := context.Background()
 	defer func() {
 		// Clean up
 		cleanupTestData(t, store)
@@ -180,7 +182,7 @@ func TestCompleteScenario(t *testing.T) {
 
 	// Insert all tables
 	for _, table := range tables {
-		err := store.CreateTable(table)
+		err := store.CreateTable(ctx, table)
 		if err != nil {
 			t.Fatalf("Failed to create table %s: %v", table.Name, err)
 		}
@@ -188,7 +190,7 @@ func TestCompleteScenario(t *testing.T) {
 
 	// Verify all tables were inserted
 	for _, table := range tables {
-		retrieved, err := store.GetTableByName(table.Name)
+		retrieved, err := store.GetTableByName(ctx, table.Name)
 		if err != nil {
 			t.Fatalf("Failed to retrieve table %s: %v", table.Name, err)
 		}
@@ -207,7 +209,7 @@ func TestCompleteScenario(t *testing.T) {
 	}
 
 	// Test listing tables
-	allTables, err := store.ListTables(20, 0)
+	allTables, err := store.ListTables(ctx, 20, 0)
 	if err != nil {
 		t.Fatalf("Failed to list tables: %v", err)
 	}
@@ -217,7 +219,7 @@ func TestCompleteScenario(t *testing.T) {
 	}
 
 	// Test searching tables
-	searchResults, err := store.SearchTables("用户", 10, 0)
+	searchResults, err := store.SearchTables(ctx, "用户", 10, 0)
 	if err != nil {
 		t.Fatalf("Failed to search tables: %v", err)
 	}
@@ -236,7 +238,7 @@ func TestCompleteScenario(t *testing.T) {
 	}
 
 	// Test updating a table
-	usersTable, err := store.GetTableByName("users")
+	usersTable, err := store.GetTableByName(ctx, "users")
 	if err != nil {
 		t.Fatalf("Failed to get users table: %v", err)
 	}
@@ -252,13 +254,13 @@ func TestCompleteScenario(t *testing.T) {
 	usersTable.Columns = append(usersTable.Columns, newColumn)
 	usersTable.UpdatedAt = time.Now()
 
-	err = store.UpdateTable("users", usersTable)
+	err = store.UpdateTable(ctx, "users", usersTable)
 	if err != nil {
 		t.Fatalf("Failed to update users table: %v", err)
 	}
 
 	// Verify the update
-	updatedUsersTable, err := store.GetTableByName("users")
+	updatedUsersTable, err := store.GetTableByName(ctx, "users")
 	if err != nil {
 		t.Fatalf("Failed to get updated users table: %v", err)
 	}
@@ -276,19 +278,19 @@ func TestCompleteScenario(t *testing.T) {
 	}
 
 	// Test deleting a table
-	err = store.DeleteTable("tasks")
+	err = store.DeleteTable(ctx, "tasks")
 	if err != nil {
 		t.Fatalf("Failed to delete tasks table: %v", err)
 	}
 
 	// Verify deletion
-	_, err = store.GetTableByName("tasks")
+	_, err = store.GetTableByName(ctx, "tasks")
 	if err == nil {
 		t.Error("Expected error when getting deleted tasks table, got nil")
 	}
 
 	// Verify we now have 2 tables
-	allTables, err = store.ListTables(20, 0)
+	allTables, err = store.ListTables(ctx, 20, 0)
 	if err != nil {
 		t.Fatalf("Failed to list tables after deletion: %v", err)
 	}
@@ -301,6 +303,7 @@ func TestCompleteScenario(t *testing.T) {
 // TestQueryOperations tests query operations with the sample data
 func TestQueryOperations(t *testing.T) {
 	store := getTestMySQLStore(t)
+	ctx := context.Background()
 	defer func() {
 		// Clean up
 		cleanupTestData(t, store)
@@ -309,7 +312,7 @@ func TestQueryOperations(t *testing.T) {
 	// Generate and insert sample tables
 	tables := generateSampleTables()
 	for _, table := range tables {
-		err := store.CreateTable(table)
+		err := store.CreateTable(ctx, table)
 		if err != nil {
 			t.Fatalf("Failed to create table %s: %v", table.Name, err)
 		}
@@ -339,7 +342,7 @@ func TestQueryOperations(t *testing.T) {
 
 	// Insert queries
 	for _, query := range queries {
-		err := store.CreateQuery(query)
+		err := store.CreateQuery(ctx, query)
 		if err != nil {
 			t.Fatalf("Failed to create query %s: %v", query.Description, err)
 		}
@@ -347,7 +350,7 @@ func TestQueryOperations(t *testing.T) {
 
 	// Retrieve and verify queries
 	for _, expectedQuery := range queries {
-		actualQuery, err := store.GetQueryByID(expectedQuery.ID)
+		actualQuery, err := store.GetQueryByID(ctx, expectedQuery.ID)
 		if err != nil {
 			t.Fatalf("Failed to get query %s: %v", expectedQuery.ID, err)
 		}
@@ -362,7 +365,7 @@ func TestQueryOperations(t *testing.T) {
 	}
 
 	// List queries
-	allQueries, err := store.ListQueries(10, 0)
+	allQueries, err := store.ListQueries(ctx, 10, 0)
 	if err != nil {
 		t.Fatalf("Failed to list queries: %v", err)
 	}
@@ -375,31 +378,32 @@ func TestQueryOperations(t *testing.T) {
 // TestEdgeCases 测试边界情况
 func TestEdgeCases(t *testing.T) {
 	store := getTestMySQLStore(t)
+	ctx := context.Background()
 	defer func() {
 		// Clean up
 		cleanupTestData(t, store)
 	}()
 
 	// 测试空表名
-	_, err := store.GetTableByName("")
+	_, err := store.GetTableByName(ctx, "")
 	if err == nil {
 		t.Error("Expected error for empty table name, got nil")
 	}
 
 	// 测试不存在的表
-	_, err = store.GetTableByName("non_existent_table")
+	_, err = store.GetTableByName(ctx, "non_existent_table")
 	if err == nil {
 		t.Error("Expected error for non-existent table, got nil")
 	}
 
 	// 测试空查询ID
-	_, err = store.GetQueryByID("")
+	_, err = store.GetQueryByID(ctx, "")
 	if err == nil {
 		t.Error("Expected error for empty query ID, got nil")
 	}
 
 	// 测试不存在的查询
-	_, err = store.GetQueryByID("non_existent_query")
+	_, err = store.GetQueryByID(ctx, "non_existent_query")
 	if err == nil {
 		t.Error("Expected error for non-existent query, got nil")
 	}
@@ -408,6 +412,7 @@ func TestEdgeCases(t *testing.T) {
 // TestPagination 测试分页功能
 func TestPagination(t *testing.T) {
 	store := getTestMySQLStore(t)
+	ctx := context.Background()
 	defer func() {
 		// Clean up
 		cleanupTestData(t, store)
@@ -416,14 +421,14 @@ func TestPagination(t *testing.T) {
 	// Generate and insert sample tables
 	tables := generateSampleTables()
 	for _, table := range tables {
-		err := store.CreateTable(table)
+		err := store.CreateTable(ctx, table)
 		if err != nil {
 			t.Fatalf("Failed to create table %s: %v", table.Name, err)
 		}
 	}
 
 	// 测试表分页
-	page1, err := store.ListTables(2, 0)
+	page1, err := store.ListTables(ctx, 2, 0)
 	if err != nil {
 		t.Fatalf("Failed to list tables page 1: %v", err)
 	}
@@ -432,7 +437,7 @@ func TestPagination(t *testing.T) {
 		t.Errorf("Expected 2 tables in page 1, got %d", len(page1))
 	}
 
-	page2, err := store.ListTables(2, 2)
+	page2, err := store.ListTables(ctx, 2, 2)
 	if err != nil {
 		t.Fatalf("Failed to list tables page 2: %v", err)
 	}
@@ -449,14 +454,14 @@ func TestPagination(t *testing.T) {
 			SQL:         "SELECT * FROM users",
 			CreatedAt:   time.Now(),
 		}
-		err := store.CreateQuery(query)
+		err := store.CreateQuery(ctx, query)
 		if err != nil {
 			t.Fatalf("Failed to create query %d: %v", i, err)
 		}
 	}
 
 	// 测试查询分页
-	qPage1, err := store.ListQueries(3, 0)
+	qPage1, err := store.ListQueries(ctx, 3, 0)
 	if err != nil {
 		t.Fatalf("Failed to list queries page 1: %v", err)
 	}
@@ -465,7 +470,7 @@ func TestPagination(t *testing.T) {
 		t.Errorf("Expected 3 queries in page 1, got %d", len(qPage1))
 	}
 
-	qPage2, err := store.ListQueries(3, 3)
+	qPage2, err := store.ListQueries(ctx, 3, 3)
 	if err != nil {
 		t.Fatalf("Failed to list queries page 2: %v", err)
 	}
@@ -4,7 +4,7 @@ import (
 	"testing"
 	"time"
 
-	"awesomeProject2/internal/models"
+	"sql_generator/internal/models"
 	"github.com/google/uuid"
 )
 